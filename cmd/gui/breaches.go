@@ -0,0 +1,52 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/hibp"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lxn/walk"
+)
+
+// breachDumpNames are looked up in the app directory; either a sorted HIBP
+// hash file (with its optional .idx) or the per-prefix directory works.
+var breachDumpNames = []string{"pwned-passwords", "pwned-passwords.txt"}
+
+var (
+	breachOnce sync.Once
+	breachDB   *hibp.DB
+)
+
+// breachChecker returns the local HIBP dump, or nil when none is installed.
+func breachChecker() *hibp.DB {
+	breachOnce.Do(func() {
+		dir, err := defaultVaultDir()
+		if err != nil {
+			return
+		}
+		for _, name := range breachDumpNames {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if db, err := hibp.Open(path); err == nil {
+				breachDB = db
+				return
+			}
+		}
+	})
+	return breachDB
+}
+
+// watchBreaches warns when an entry is saved with a breached password.
+func (mw *PasswordManagerWindow) watchBreaches() {
+	db := breachChecker()
+	if db == nil || mw.vault == nil {
+		return
+	}
+	mw.vault.Breaches = db
+	mw.vault.Warn = func(msg string) {
+		walk.MsgBox(mw, "Breached Password", msg, walk.MsgBoxIconWarning)
+	}
+}
@@ -1,230 +1,445 @@
-package main
-
-import (
-	"appliedcryptography-starter-kit/internal/pwmanager"
-	"fmt"
-
-	"github.com/lxn/walk"
-	. "github.com/lxn/walk/declarative"
-)
-
-type GeneratorDialog struct {
-	*walk.Dialog
-	lengthSpinner     *walk.NumberEdit
-	upperCheck        *walk.CheckBox
-	lowerCheck        *walk.CheckBox
-	numbersCheck      *walk.CheckBox
-	symbolsCheck      *walk.CheckBox
-	similarCheck      *walk.CheckBox
-	ambiguousCheck    *walk.CheckBox
-	passwordField     *walk.LineEdit
-	strengthProgress  *walk.ProgressBar
-	strengthLabel     *walk.TextLabel
-	feedbackLabel     *walk.TextLabel
-	generatedPassword string
-}
-
-func ShowPasswordGenerator(owner walk.Form) (string, error) {
-	dlg := &GeneratorDialog{}
-
-	var acceptPB, cancelPB *walk.PushButton
-
-	if _, err := (Dialog{
-		AssignTo:      &dlg.Dialog,
-		Title:         "Password Generator",
-		DefaultButton: &acceptPB,
-		CancelButton:  &cancelPB,
-		MinSize:       Size{Width: 400, Height: 500},
-		Layout:        VBox{},
-		Children: []Widget{
-			GroupBox{
-				Title:  "Options",
-				Layout: Grid{Columns: 2},
-				Children: []Widget{
-					Label{Text: "Length:"},
-					NumberEdit{
-						AssignTo: &dlg.lengthSpinner,
-						Value:    12,
-						MinValue: 4,
-						MaxValue: 64,
-						OnValueChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-					CheckBox{
-						AssignTo: &dlg.upperCheck,
-						Text:     "Uppercase (A-Z)",
-						Checked:  true,
-						OnCheckedChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-					CheckBox{
-						AssignTo: &dlg.lowerCheck,
-						Text:     "Lowercase (a-z)",
-						Checked:  true,
-						OnCheckedChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-					CheckBox{
-						AssignTo: &dlg.numbersCheck,
-						Text:     "Numbers (0-9)",
-						Checked:  true,
-						OnCheckedChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-					CheckBox{
-						AssignTo: &dlg.symbolsCheck,
-						Text:     "Symbols (!@#$...)",
-						Checked:  true,
-						OnCheckedChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-					CheckBox{
-						AssignTo: &dlg.similarCheck,
-						Text:     "Exclude Similar (l,1,I,o,0,O)",
-						OnCheckedChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-					CheckBox{
-						AssignTo: &dlg.ambiguousCheck,
-						Text:     "Exclude Ambiguous ({,[,/,...)",
-						OnCheckedChanged: func() {
-							dlg.generateAndUpdate()
-						},
-					},
-				},
-			},
-			GroupBox{
-				Title:  "Generated Password",
-				Layout: VBox{},
-				Children: []Widget{
-					LineEdit{
-						AssignTo:  &dlg.passwordField,
-						ReadOnly:  true,
-						MinSize:   Size{Width: 300},
-						TextColor: walk.RGB(0, 0, 0),
-					},
-					Composite{
-						Layout: HBox{},
-						Children: []Widget{
-							PushButton{
-								Text: "🔄 Generate New",
-								OnClicked: func() {
-									dlg.generateAndUpdate()
-								},
-							},
-							PushButton{
-								Text: "📋 Copy",
-								OnClicked: func() {
-									if dlg.generatedPassword != "" {
-										walk.Clipboard().SetText(dlg.generatedPassword)
-									}
-								},
-							},
-						},
-					},
-				},
-			},
-			GroupBox{
-				Title:  "Password Strength",
-				Layout: VBox{},
-				Children: []Widget{
-					ProgressBar{
-						AssignTo: &dlg.strengthProgress,
-						MinSize:  Size{Height: 20},
-					},
-					TextLabel{
-						AssignTo:      &dlg.strengthLabel,
-						TextAlignment: AlignHCenterVCenter,
-					},
-					TextLabel{
-						AssignTo:      &dlg.feedbackLabel,
-						TextAlignment: AlignHCenterVCenter,
-						TextColor:     walk.RGB(100, 100, 100),
-					},
-				},
-			},
-			Composite{
-				Layout: HBox{},
-				Children: []Widget{
-					HSpacer{},
-					PushButton{
-						AssignTo: &acceptPB,
-						Text:     "Use Password",
-						OnClicked: func() {
-							dlg.Accept()
-						},
-					},
-					PushButton{
-						AssignTo:  &cancelPB,
-						Text:      "Cancel",
-						OnClicked: func() { dlg.Cancel() },
-					},
-				},
-			},
-		},
-	}.Run(owner)); err != nil {
-		return "", err
-	}
-
-	// Generate initial password
-	dlg.generateAndUpdate()
-
-	if dlg.Dialog.Result() == walk.DlgCmdOK {
-		return dlg.generatedPassword, nil
-	}
-
-	return "", nil
-}
-
-func (dlg *GeneratorDialog) generateAndUpdate() {
-	opts := pwmanager.PasswordOptions{
-		Length:           int(dlg.lengthSpinner.Value()),
-		IncludeUpper:     dlg.upperCheck.Checked(),
-		IncludeLower:     dlg.lowerCheck.Checked(),
-		IncludeNumbers:   dlg.numbersCheck.Checked(),
-		IncludeSymbols:   dlg.symbolsCheck.Checked(),
-		ExcludeSimilar:   dlg.similarCheck.Checked(),
-		ExcludeAmbiguous: dlg.ambiguousCheck.Checked(),
-	}
-
-	// Generate new password
-	password, err := pwmanager.GeneratePassword(opts)
-	if err != nil || password == "" {
-		dlg.passwordField.SetText("Invalid options selected")
-		dlg.strengthProgress.SetValue(0)
-		dlg.strengthLabel.SetText("N/A")
-		dlg.feedbackLabel.SetText("Select at least one character type")
-		return
-	}
-
-	// Store and display the password
-	dlg.generatedPassword = password
-	dlg.passwordField.SetText(password)
-
-	// Update strength meter
-	score, feedback := pwmanager.AnalyzePasswordStrength(password)
-	dlg.strengthProgress.SetValue(score)
-
-	// Set progress bar color based on score
-	color := walk.RGB(200, 0, 0) // Default red
-	switch {
-	case score >= 80:
-		color = walk.RGB(0, 160, 0) // Green
-	case score >= 60:
-		color = walk.RGB(200, 160, 0) // Yellow
-	}
-
-	brush, _ := walk.NewSolidColorBrush(color)
-	dlg.strengthProgress.SetBackground(brush)
-
-	dlg.strengthLabel.SetText(fmt.Sprintf("Strength: %d%%", score))
-	if len(feedback) > 0 {
-		dlg.feedbackLabel.SetText(feedback[len(feedback)-1])
-	}
-}
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/passwordrules"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/strength"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+)
+
+type GeneratorDialog struct {
+	*walk.Dialog
+	lengthSpinner     *walk.NumberEdit
+	upperCheck        *walk.CheckBox
+	lowerCheck        *walk.CheckBox
+	numbersCheck      *walk.CheckBox
+	symbolsCheck      *walk.CheckBox
+	similarCheck      *walk.CheckBox
+	ambiguousCheck    *walk.CheckBox
+	passphraseCheck   *walk.CheckBox
+	wordsSpinner      *walk.NumberEdit
+	wordListCombo     *walk.ComboBox
+	separatorField    *walk.LineEdit
+	capitalizeCombo   *walk.ComboBox
+	digitCheck        *walk.CheckBox
+	symbolCheck       *walk.CheckBox
+	rulesCheck        *walk.CheckBox
+	rulesField        *walk.LineEdit
+	customWordList    string // file chosen under "Custom file..."
+	passwordField     *walk.LineEdit
+	entropyLabel      *walk.TextLabel
+	strengthProgress  *walk.ProgressBar
+	strengthLabel     *walk.TextLabel
+	feedbackLabel     *walk.TextLabel
+	generatedPassword string
+	userInputs        []string // entry title, username and URL, penalized by the strength estimate
+}
+
+// ShowPasswordGenerator runs the generator dialog. userInputs are the fields of
+// the entry being edited, so the strength meter can flag passwords built from
+// them; if one is a URL with known password rules, those rules are applied.
+func ShowPasswordGenerator(owner walk.Form, userInputs ...string) (string, error) {
+	dlg := &GeneratorDialog{userInputs: userInputs}
+
+	length, rulesText, rulesTitle := 12, "", "Site Rules"
+	for _, in := range userInputs {
+		if site, ok := siteRulesDB().Lookup(in); ok {
+			rulesText, rulesTitle = site.Rules, "Site Rules (known for "+site.Domain+")"
+			if r, err := passwordrules.Parse(site.Rules); err == nil {
+				if n, err := r.Length(0); err == nil {
+					length = max(4, n)
+				}
+			}
+			break
+		}
+	}
+
+	var acceptPB, cancelPB *walk.PushButton
+
+	if _, err := (Dialog{
+		AssignTo:      &dlg.Dialog,
+		Title:         "Password Generator",
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		MinSize:       Size{Width: 400, Height: 760},
+		Layout:        VBox{},
+		Children: []Widget{
+			GroupBox{
+				Title:  "Options",
+				Layout: Grid{Columns: 2},
+				Children: []Widget{
+					Label{Text: "Length:"},
+					NumberEdit{
+						AssignTo: &dlg.lengthSpinner,
+						Value:    float64(length),
+						MinValue: 4,
+						MaxValue: 64,
+						OnValueChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.upperCheck,
+						Text:     "Uppercase (A-Z)",
+						Checked:  true,
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.lowerCheck,
+						Text:     "Lowercase (a-z)",
+						Checked:  true,
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.numbersCheck,
+						Text:     "Numbers (0-9)",
+						Checked:  true,
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.symbolsCheck,
+						Text:     "Symbols (!@#$...)",
+						Checked:  true,
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.similarCheck,
+						Text:     "Exclude Similar (l,1,I,o,0,O)",
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.ambiguousCheck,
+						Text:     "Exclude Ambiguous ({,[,/,...)",
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+				},
+			},
+			GroupBox{
+				Title:  "Passphrase",
+				Layout: Grid{Columns: 2},
+				Children: []Widget{
+					CheckBox{
+						AssignTo:   &dlg.passphraseCheck,
+						Text:       "Use words instead of characters",
+						ColumnSpan: 2,
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					Label{Text: "Words:"},
+					NumberEdit{
+						AssignTo: &dlg.wordsSpinner,
+						Value:    6,
+						MinValue: 3,
+						MaxValue: 20,
+						OnValueChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					Label{Text: "Word list:"},
+					ComboBox{
+						AssignTo:     &dlg.wordListCombo,
+						Model:        generatorWordLists,
+						CurrentIndex: 0,
+						OnCurrentIndexChanged: func() {
+							dlg.onWordListChanged()
+						},
+					},
+					Label{Text: "Separator:"},
+					LineEdit{
+						AssignTo: &dlg.separatorField,
+						Text:     "-",
+						OnTextChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					Label{Text: "Capitalize:"},
+					ComboBox{
+						AssignTo:     &dlg.capitalizeCombo,
+						Model:        generatorCapitalizations,
+						CurrentIndex: 0,
+						OnCurrentIndexChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.digitCheck,
+						Text:     "Add a digit",
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					CheckBox{
+						AssignTo: &dlg.symbolCheck,
+						Text:     "Add a symbol",
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+				},
+			},
+			GroupBox{
+				Title:  rulesTitle,
+				Layout: VBox{},
+				Children: []Widget{
+					CheckBox{
+						AssignTo: &dlg.rulesCheck,
+						Text:     "Follow the site's password rules (replaces the options above)",
+						Checked:  rulesText != "",
+						OnCheckedChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+					LineEdit{
+						AssignTo:  &dlg.rulesField,
+						Text:      rulesText,
+						CueBanner: "minlength: 8; maxlength: 16; required: digit;",
+						OnTextChanged: func() {
+							dlg.generateAndUpdate()
+						},
+					},
+				},
+			},
+			GroupBox{
+				Title:  "Generated Password",
+				Layout: VBox{},
+				Children: []Widget{
+					LineEdit{
+						AssignTo:  &dlg.passwordField,
+						ReadOnly:  true,
+						MinSize:   Size{Width: 300},
+						TextColor: walk.RGB(0, 0, 0),
+					},
+					TextLabel{
+						AssignTo:  &dlg.entropyLabel,
+						TextColor: walk.RGB(100, 100, 100),
+					},
+					Composite{
+						Layout: HBox{},
+						Children: []Widget{
+							PushButton{
+								Text: "🔄 Generate New",
+								OnClicked: func() {
+									dlg.generateAndUpdate()
+								},
+							},
+							PushButton{
+								Text: "📋 Copy",
+								OnClicked: func() {
+									if dlg.generatedPassword != "" {
+										walk.Clipboard().SetText(dlg.generatedPassword)
+									}
+								},
+							},
+						},
+					},
+				},
+			},
+			GroupBox{
+				Title:  "Password Strength",
+				Layout: VBox{},
+				Children: []Widget{
+					ProgressBar{
+						AssignTo: &dlg.strengthProgress,
+						MinSize:  Size{Height: 20},
+					},
+					TextLabel{
+						AssignTo:      &dlg.strengthLabel,
+						TextAlignment: AlignHCenterVCenter,
+					},
+					TextLabel{
+						AssignTo:      &dlg.feedbackLabel,
+						TextAlignment: AlignHCenterVCenter,
+						TextColor:     walk.RGB(100, 100, 100),
+					},
+				},
+			},
+			Composite{
+				Layout: HBox{},
+				Children: []Widget{
+					HSpacer{},
+					PushButton{
+						AssignTo: &acceptPB,
+						Text:     "Use Password",
+						OnClicked: func() {
+							dlg.Accept()
+						},
+					},
+					PushButton{
+						AssignTo:  &cancelPB,
+						Text:      "Cancel",
+						OnClicked: func() { dlg.Cancel() },
+					},
+				},
+			},
+		},
+	}.Run(owner)); err != nil {
+		return "", err
+	}
+
+	// Generate initial password
+	dlg.generateAndUpdate()
+
+	if dlg.Dialog.Result() == walk.DlgCmdOK {
+		return dlg.generatedPassword, nil
+	}
+
+	return "", nil
+}
+
+// Word list and capitalization choices, index-aligned with their option values.
+var (
+	generatorWordLists       = []string{"EFF large (7776 words)", "EFF short (1296 words)", "Custom file..."}
+	generatorWordListValues  = []string{pwmanager.WordListEFFLarge, pwmanager.WordListEFFShort, ""}
+	generatorCapitalizations = []string{"None", "First letter", "All caps", "Random words"}
+	generatorCapValues       = []string{pwmanager.CapNone, pwmanager.CapFirst, pwmanager.CapAll, pwmanager.CapRandom}
+)
+
+// siteRulesFile, if present in the app directory, updates the built-in site
+// password rules; it uses the format of Apple's password-rules.json.
+const siteRulesFile = "password-rules.json"
+
+var siteRulesOnce sync.Once
+
+// siteRulesDB returns the site password rules, merged with siteRulesFile once.
+func siteRulesDB() *passwordrules.DB {
+	siteRulesOnce.Do(func() {
+		dir, err := defaultVaultDir()
+		if err != nil {
+			return
+		}
+		path := filepath.Join(dir, siteRulesFile)
+		if _, err := os.Stat(path); err == nil {
+			passwordrules.LoadFile(path)
+		}
+	})
+	return passwordrules.Default()
+}
+
+// onWordListChanged asks for a file when "Custom file..." is picked and falls
+// back to the large EFF list if none is chosen.
+func (dlg *GeneratorDialog) onWordListChanged() {
+	if dlg.wordListCombo.CurrentIndex() == len(generatorWordLists)-1 {
+		fd := walk.FileDialog{Title: "Choose a word list", Filter: "Word lists (*.txt)|*.txt|All files (*.*)|*.*"}
+		if ok, err := fd.ShowOpen(dlg); err != nil || !ok {
+			dlg.wordListCombo.SetCurrentIndex(0)
+			return
+		}
+		dlg.customWordList = fd.FilePath
+	}
+	dlg.generateAndUpdate()
+}
+
+func (dlg *GeneratorDialog) options() pwmanager.PasswordOptions {
+	opts := pwmanager.PasswordOptions{
+		Length:           int(dlg.lengthSpinner.Value()),
+		IncludeUpper:     dlg.upperCheck.Checked(),
+		IncludeLower:     dlg.lowerCheck.Checked(),
+		IncludeNumbers:   dlg.numbersCheck.Checked(),
+		IncludeSymbols:   dlg.symbolsCheck.Checked(),
+		ExcludeSimilar:   dlg.similarCheck.Checked(),
+		ExcludeAmbiguous: dlg.ambiguousCheck.Checked(),
+	}
+	if dlg.rulesCheck.Checked() {
+		opts.Rules = dlg.rulesField.Text()
+	}
+	if dlg.passphraseCheck.Checked() {
+		opts.Words = int(dlg.wordsSpinner.Value())
+		if i := dlg.wordListCombo.CurrentIndex(); i >= 0 && i < len(generatorWordListValues) {
+			opts.WordList = generatorWordListValues[i]
+		}
+		if opts.WordList == "" {
+			opts.WordList = dlg.customWordList
+		}
+		opts.Separator = dlg.separatorField.Text()
+		if i := dlg.capitalizeCombo.CurrentIndex(); i >= 0 && i < len(generatorCapValues) {
+			opts.Capitalize = generatorCapValues[i]
+		}
+		opts.InjectDigit = dlg.digitCheck.Checked()
+		opts.InjectSymbol = dlg.symbolCheck.Checked()
+	}
+	return opts
+}
+
+func (dlg *GeneratorDialog) generateAndUpdate() {
+	if dlg.feedbackLabel == nil {
+		return // still building the dialog
+	}
+	opts := dlg.options()
+
+	// Generate new password
+	password, err := pwmanager.GeneratePassword(opts)
+	if err != nil || password == "" {
+		dlg.passwordField.SetText("Invalid options selected")
+		dlg.entropyLabel.SetText("")
+		dlg.strengthProgress.SetValue(0)
+		dlg.strengthLabel.SetText("N/A")
+		if err != nil {
+			dlg.feedbackLabel.SetText(err.Error())
+		} else {
+			dlg.feedbackLabel.SetText("Select at least one character type")
+		}
+		return
+	}
+
+	// Store and display the password
+	dlg.generatedPassword = password
+	dlg.passwordField.SetText(password)
+	if bits, err := pwmanager.EntropyBits(opts); err == nil {
+		dlg.entropyLabel.SetText(fmt.Sprintf("Entropy: %.1f bits", bits))
+	}
+
+	// Update strength meter
+	est := strength.Estimate(password, dlg.userInputs...)
+	score := est.Percent()
+	dlg.strengthProgress.SetValue(score)
+
+	// Set progress bar color based on score
+	color := walk.RGB(200, 0, 0) // Default red
+	switch {
+	case score >= 80:
+		color = walk.RGB(0, 160, 0) // Green
+	case score >= 60:
+		color = walk.RGB(200, 160, 0) // Yellow
+	}
+
+	brush, _ := walk.NewSolidColorBrush(color)
+	dlg.strengthProgress.SetBackground(brush)
+
+	dlg.strengthLabel.SetText(fmt.Sprintf("Strength: %d%% (%s), offline crack time: %s",
+		score, strength.ScoreLabel(est.Score), est.CrackTimes.OfflineSlowHash.Display))
+	switch {
+	case est.Feedback.Warning != "":
+		dlg.feedbackLabel.SetText(est.Feedback.Warning)
+	case len(est.Feedback.Suggestions) > 0:
+		dlg.feedbackLabel.SetText(est.Feedback.Suggestions[0])
+	default:
+		dlg.feedbackLabel.SetText(fmt.Sprintf("About 10^%.0f guesses needed", est.GuessesLog10))
+	}
+
+	// Check the local HIBP dump, if one is installed
+	if db := breachChecker(); db != nil {
+		if n, err := db.Count(password); err == nil && n > 0 {
+			dlg.strengthProgress.SetValue(0)
+			dlg.feedbackLabel.SetText(fmt.Sprintf("⚠ Found %d times in known data breaches", n))
+		}
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !unix
+
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/agent"
+	"errors"
+	"os"
+	"os/signal"
+)
+
+func detach(sock string) (int, error) {
+	return 0, errors.New("cannot run in the background on this platform; use -D")
+}
+
+func stop(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Kill()
+}
+
+// handleSignals locks and shuts down on an interrupt.
+func handleSignals(s *agent.Server, shutdown func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	go func() {
+		<-ch
+		s.Lock()
+		shutdown()
+	}()
+}
@@ -0,0 +1,66 @@
+//go:build unix
+
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/agent"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// detach starts the agent again in the foreground, in a new session without
+// a terminal, and waits until its socket answers.
+func detach(sock string) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	cmd := exec.Command(exe, append([]string{"-D"}, os.Args[1:]...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		select {
+		case err := <-exited:
+			if err == nil {
+				err = errors.New("exited")
+			}
+			return 0, errors.New("agent failed to start: " + err.Error() + " (run with -D to see why)")
+		case <-time.After(50 * time.Millisecond):
+		}
+		if c, err := agent.Dial(sock); err == nil {
+			c.Close()
+			return cmd.Process.Pid, nil
+		}
+	}
+	cmd.Process.Kill()
+	return 0, errors.New("agent did not start listening in time")
+}
+
+// stop asks the agent to lock and exit.
+func stop(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// handleSignals locks on SIGUSR1, and locks and shuts down on SIGINT,
+// SIGTERM and SIGHUP.
+func handleSignals(s *agent.Server, shutdown func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range ch {
+			s.Lock()
+			if sig != syscall.SIGUSR1 {
+				shutdown()
+				return
+			}
+		}
+	}()
+}
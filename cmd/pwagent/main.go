@@ -0,0 +1,77 @@
+// pwagent keeps an unlocked vault key in memory so starterkit commands can
+// run without the master password, in the manner of ssh-agent:
+//
+//	eval "$(pwagent)"                  # start it and export PWAGENT_SOCK
+//	starterkit agent unlock --file vault.json   # prompts for the master password
+//	starterkit show --file vault.json --title GitHub
+//	starterkit agent lock              # or kill -USR1 $PWAGENT_PID
+//	eval "$(pwagent -k)"               # stop it
+//
+// In scripts, unlock takes the password from a descriptor or the environment
+// instead, as every command does: --master-fd 3 3<"$PWFILE", or --master-env
+// with STARTERKIT_MASTER set. Never use --master, which shows it to ps.
+//
+// See internal/agent for the protocol.
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/agent"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+func main() {
+	foreground := flag.Bool("D", false, "stay in the foreground and log to stderr")
+	kill := flag.Bool("k", false, "stop the agent named by PWAGENT_PID")
+	sock := flag.String("socket", agent.SocketPath(), "socket path")
+	idle := flag.Duration("idle", 15*time.Minute, "lock after this long without use (0: never)")
+	maxLife := flag.Duration("max", 8*time.Hour, "lock this long after unlocking, however busy (0: never)")
+	flag.Parse()
+
+	if *kill {
+		pid, err := strconv.Atoi(os.Getenv("PWAGENT_PID"))
+		if err != nil {
+			fail("PWAGENT_PID not set")
+		}
+		if err := stop(pid); err != nil {
+			fail(err.Error())
+		}
+		fmt.Println("unset PWAGENT_SOCK;\nunset PWAGENT_PID;")
+		fmt.Printf("echo Agent pid %d killed;\n", pid)
+		return
+	}
+
+	if !*foreground {
+		pid, err := detach(*sock)
+		if err != nil {
+			fail(err.Error())
+		}
+		fmt.Printf("PWAGENT_SOCK=%s; export PWAGENT_SOCK;\n", *sock)
+		fmt.Printf("PWAGENT_PID=%d; export PWAGENT_PID;\n", pid)
+		fmt.Printf("echo Agent pid %d;\n", pid)
+		return
+	}
+
+	l, err := agent.Listen(*sock)
+	if err != nil {
+		fail(err.Error())
+	}
+	s := agent.NewServer(*idle, *maxLife)
+	handleSignals(s, func() {
+		l.Close()
+		os.Remove(*sock)
+	})
+	log.Printf("listening on %s (pid %d)", *sock, os.Getpid())
+	if err := s.Serve(l); err != nil {
+		fail(err.Error())
+	}
+}
+
+func fail(msg string) {
+	fmt.Fprintln(os.Stderr, "pwagent:", msg)
+	os.Exit(1)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/agent"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// agentDerivKey is the derivation key handed out by the agent along with the
+// master key, for commands run without a master password.
+var agentDerivKey *secret.Buffer
+
+// openVault loads the vault at file and unlocks it. Without a master-password
+// flag it uses the key of a running, unlocked pwagent if there is one, and
+// prompts otherwise.
+func openVault(file string, master *masterInput) (*pwmanager.Vault, *secret.Buffer) {
+	v, err := pwmanager.Load(file)
+	check(err, "load")
+	if !master.given() {
+		if key, dk, err := agentKeys(file); err == nil {
+			agentDerivKey.Destroy()
+			agentDerivKey = dk
+			return v, key
+		}
+	}
+	key, err := v.Unlock(master.password(false))
+	check(err, "unlock (check master password)")
+	return v, key
+}
+
+// agentKeys fetches the keys of the vault at file from the agent.
+func agentKeys(file string) (key, dk *secret.Buffer, err error) {
+	c, err := agent.Dial(agent.SocketPath())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer c.Close()
+	return c.Keys(file)
+}
+
+// lockAgentFor locks a running agent if it holds the keys of the vault at
+// file, and reports whether it did.
+func lockAgentFor(file string) bool {
+	c, err := agent.Dial(agent.SocketPath())
+	if err != nil {
+		return false
+	}
+	defer c.Close()
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	st, err := c.Status()
+	if err != nil || !st.Unlocked || st.File != abs {
+		return false
+	}
+	return c.Lock() == nil
+}
+
+// derivationKey returns the root key of derived passwords: a copy of the
+// agent's after openVault used the agent, else stretched from the master password.
+func derivationKey(master *masterInput) (*secret.Buffer, error) {
+	if !master.given() && agentDerivKey.Alive() {
+		return agentDerivKey.Clone()
+	}
+	return pwmanager.DerivationKey(master.password(false))
+}
+
+// cmdAgent drives a running pwagent: unlock, lock or status. Each prints
+// the agent's status afterwards.
+func cmdAgent(args []string) {
+	if len(args) == 0 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	fs := flag.NewFlagSet("agent "+args[0], flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file (unlock)")
+	master := masterFlags(fs)
+	fs.Parse(args[1:])
+
+	c, err := agent.Dial(agent.SocketPath())
+	check(err, "connect to agent")
+	defer c.Close()
+
+	var done string
+	switch args[0] {
+	case "unlock":
+		check(c.Unlock(*file, master.password(false)), "unlock")
+		done = "agent unlocked " + *file
+	case "lock":
+		check(c.Lock(), "lock")
+		done = "agent locked"
+	case "status":
+	default:
+		usage()
+		os.Exit(exitUsage)
+	}
+	st, err := c.Status()
+	check(err, "status")
+	emit(st, func() {
+		if done != "" {
+			fmt.Println(done)
+			return
+		}
+		printAgentStatus(st)
+	})
+}
+
+func printAgentStatus(st *agent.Status) {
+	if !st.Unlocked {
+		fmt.Println("locked")
+		return
+	}
+	fmt.Println("unlocked:", st.File)
+	if !st.IdleLock.IsZero() {
+		fmt.Println("idle lock:", st.IdleLock.Format(time.DateTime))
+	}
+	if !st.HardLock.IsZero() {
+		fmt.Println("hard lock:", st.HardLock.Format(time.DateTime))
+	}
+	if !st.Locked {
+		fmt.Println("warning: the key is not locked in RAM and may be swapped out")
+	}
+}
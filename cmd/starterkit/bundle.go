@@ -0,0 +1,144 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/bundle"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"appliedcryptography-starter-kit/internal/sign"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func exportBundle(key *secret.Buffer, recs []pwmanager.Record, out, password, recipient, comment string) {
+	if len(recs) == 0 {
+		failf(exitNotFound, "no entries matched; nothing exported")
+	}
+	signer, err := pwmanager.OwnerSigningKey(key)
+	check(err, "signing key")
+
+	var data []byte
+	switch {
+	case recipient != "" && password != "":
+		failf(exitUsage, "use either --password or --recipient, not both")
+	case recipient != "":
+		pub, err := base64.StdEncoding.DecodeString(recipient)
+		check(err, "decode --recipient")
+		data, err = bundle.SealForRecipient(recs, pub, signer, comment)
+		check(err, "seal")
+	case password != "":
+		data, err = bundle.SealWithPassword(recs, password, signer, comment)
+		check(err, "seal")
+	default:
+		failf(exitUsage, "bundles must be encrypted: pass --password or --recipient")
+	}
+	check(os.WriteFile(out, data, 0600), "write")
+	fp := sign.Fingerprint(signer.PublicKey)
+	emit(exportResult{File: out, Format: "bundle", Entries: len(recs), Signer: fp}, func() {
+		fmt.Printf("exported %d entries to %s\n", len(recs), out)
+		fmt.Println("signed by", fp)
+	})
+}
+
+func importBundle(file string, master *masterInput, data []byte, password, expectSigner string) {
+	b, err := bundle.Parse(data)
+	check(err, "verify bundle")
+	fp := b.SignerFingerprint()
+	if !machine() {
+		// before the master password is asked for
+		fmt.Printf("bundle created %s with %d entries\n", b.Manifest.CreatedAt.Format("2006-01-02 15:04"), b.Manifest.Entries)
+		if b.Manifest.Comment != "" {
+			fmt.Println("comment:", b.Manifest.Comment)
+		}
+		fmt.Println("signature OK, signer fingerprint:", fp)
+	}
+	if expectSigner != "" && !strings.EqualFold(expectSigner, fp) {
+		failf(exitRefused, "signer %s does not match --expect-signer; refusing to import", fp)
+	}
+
+	v, key := openVault(file, master)
+	defer key.Destroy()
+
+	var recs []pwmanager.Record
+	switch b.Manifest.Mode {
+	case bundle.ModeX25519:
+		kp, err := pwmanager.OwnerExchangeKey(key)
+		check(err, "exchange key")
+		recs, err = b.OpenWithKey(kp)
+		check(err, "decrypt")
+	default:
+		require(password != "", "password")
+		recs, err = b.OpenWithPassword(password)
+		check(err, "decrypt")
+	}
+
+	// Merge: entries that already exist with the same credentials are not duplicated
+	existing, err := v.Records(key)
+	check(err, "decrypt vault")
+	seen := make(map[string]bool, len(existing))
+	for _, r := range existing {
+		seen[mergeKey(r)] = true
+	}
+	var fresh []pwmanager.Record
+	var skipped []string
+	for _, r := range recs {
+		if seen[mergeKey(r)] {
+			skipped = append(skipped, fmt.Sprintf("%q: already in vault", r.Title))
+			continue
+		}
+		fresh = append(fresh, r)
+	}
+	_, err = v.ImportRecords(key, fresh)
+	check(err, "import")
+	check(v.Save(file), "save")
+	sum := pwmanager.Summarize("bundle", fresh, skipped)
+	res := newImportResult(sum)
+	res.Bundle = &bundleInfo{Created: b.Manifest.CreatedAt, Comment: b.Manifest.Comment, Signer: fp}
+	emit(res, func() { fmt.Print(sum) })
+}
+
+func mergeKey(r pwmanager.Record) string {
+	return strings.Join([]string{r.Title, r.Username, r.Password, r.URL}, "\x00")
+}
+
+// cmdIdentity prints the vault owner's public keys: the signing fingerprint that
+// appears on exported bundles and the X25519 key others encrypt bundles to.
+func cmdIdentity(args []string) {
+	fs := flag.NewFlagSet("identity", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	fs.Parse(args)
+
+	_, key := openVault(*file, master)
+	defer key.Destroy()
+	signer, err := pwmanager.OwnerSigningKey(key)
+	check(err, "signing key")
+	kx, err := pwmanager.OwnerExchangeKey(key)
+	check(err, "exchange key")
+	res := identityResult{
+		Fingerprint:  sign.Fingerprint(signer.PublicKey),
+		RecipientKey: base64.StdEncoding.EncodeToString(kx.PublicKey),
+	}
+	emit(res, func() {
+		fmt.Println("signing fingerprint:", res.Fingerprint)
+		fmt.Println("bundle recipient key:", res.RecipientKey)
+	})
+}
+
+// identityResult reports identity.
+type identityResult struct {
+	Fingerprint  string `json:"fingerprint"`  // of the signing key on exported bundles
+	RecipientKey string `json:"recipientKey"` // base64 X25519 key for export --recipient
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
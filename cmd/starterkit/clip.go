@@ -0,0 +1,168 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/clipboard"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// clipFlags are --clip, which sends a secret to the clipboard instead of
+// stdout, and --clip-clear.
+type clipFlags struct {
+	on         *bool
+	clearAfter *time.Duration
+}
+
+// addClipFlags registers --clip-clear on fs, and --clip unless the command
+// always copies.
+func addClipFlags(fs *flag.FlagSet, what string, always bool) *clipFlags {
+	c := &clipFlags{
+		on:         new(bool),
+		clearAfter: fs.Duration("clip-clear", 30*time.Second, "clear the clipboard this long after copying, unless something else was copied since (0: never)"),
+	}
+	if always {
+		*c.on = true
+	} else {
+		c.on = fs.Bool("clip", false, "copy the "+what+" to the clipboard instead of printing it")
+	}
+	return c
+}
+
+// clipResult reports a copy to the clipboard; the value copied is never printed.
+type clipResult struct {
+	ID      string    `json:"id,omitempty"`
+	Title   string    `json:"title,omitempty"`
+	Field   string    `json:"field"`
+	Backend string    `json:"backend"`          // see clipboard.Open
+	ClearAt time.Time `json:"clearAt,omitzero"` // when the clipboard is cleared, if it still holds the copy
+}
+
+// copy puts text on the clipboard and leaves a background process behind to
+// clear it after --clip-clear.
+func (c *clipFlags) copy(field, text string) clipResult {
+	b, err := clipboard.Detect()
+	check(err, "clipboard")
+	check(b.Copy(text), "clipboard")
+	res := clipResult{Field: field, Backend: b.Name()}
+	if *c.clearAfter > 0 {
+		check(scheduleClear(b, clipboard.DigestOf(text), *c.clearAfter), "clipboard")
+		res.ClearAt = time.Now().Add(*c.clearAfter).UTC().Truncate(time.Second)
+	}
+	return res
+}
+
+// printCopied tells people what went where.
+func printCopied(r clipResult) {
+	what := r.Field
+	if r.Title != "" {
+		what = fmt.Sprintf("%s of %q", r.Field, r.Title)
+	}
+	if r.ClearAt.IsZero() {
+		fmt.Printf("Copied the %s to the clipboard (%s).\n", what, r.Backend)
+		return
+	}
+	fmt.Printf("Copied the %s to the clipboard (%s); it is cleared at %s.\n", what, r.Backend, r.ClearAt.Local().Format("15:04:05"))
+}
+
+// scheduleClear starts "starterkit clip-clear" in the background, in a
+// session of its own so it outlives the shell. It gets the digest of the
+// copy on a pipe, never the copy itself, and no stdout or stderr that a
+// caller might be waiting on; only a terminal, which the OSC 52 backend writes to.
+func scheduleClear(b clipboard.Backend, d clipboard.Digest, after time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	fmt.Fprintln(w, hex.EncodeToString(d[:]))
+	w.Close()
+
+	cmd := exec.Command(exe, "clip-clear", "--backend", b.Name(), "--after", after.String())
+	cmd.Stdin = r
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer tty.Close()
+		cmd.Stderr = tty
+	} else if term.IsTerminal(int(os.Stderr.Fd())) {
+		cmd.Stderr = os.Stderr
+	}
+	detachProcess(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// cmdClipClear is the background half of --clip-clear: it waits, then
+// clears the clipboard if it still holds the copy whose digest is on stdin.
+// It is not listed in the usage text and reports nothing.
+func cmdClipClear(args []string) {
+	fs := flag.NewFlagSet("clip-clear", flag.ExitOnError)
+	backend := fs.String("backend", "", "clipboard backend, as from clipboard.Open")
+	after := fs.Duration("after", 30*time.Second, "delay before clearing")
+	fs.Parse(args)
+
+	line, err := readLine(os.Stdin)
+	if err != nil {
+		os.Exit(exitError)
+	}
+	var d clipboard.Digest
+	if n, err := hex.Decode(d[:], []byte(strings.TrimSpace(line))); err != nil || n != len(d) {
+		os.Exit(exitUsage)
+	}
+	time.Sleep(*after)
+	b, err := clipboard.Open(*backend)
+	if err != nil {
+		os.Exit(exitError)
+	}
+	if _, err := clipboard.ClearIf(b, d); err != nil {
+		os.Exit(exitError)
+	}
+}
+
+// cmdCopy copies a field of an entry to the clipboard. For totp that is the
+// current code, as otp --clip copies it, never the secret.
+func cmdCopy(args []string) {
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title (case-insensitive)")
+	field := fs.String("field", "password", "password, username, url, notes, totp (the current code) or the name of a custom field")
+	clip := addClipFlags(fs, "", true)
+	fs.Parse(args)
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	target := entryID(v, *id, *title)
+	e, err := openEntry(v, key, master, target)
+	check(err, "copy")
+	if strings.EqualFold(*field, "totp") {
+		code, _, err := otpCode(e, time.Now())
+		check(err, "copy")
+		res := clip.copy("totp code", code)
+		res.ID, res.Title = e.ID, e.Title
+		emit(res, func() { printCopied(res) })
+		return
+	}
+	value, ok := entryField(e, *field)
+	if !ok {
+		failf(exitNotFound, "%q has no field %q", e.Title, *field)
+	}
+	if value == "" {
+		failf(exitNotFound, "the %s of %q is empty", *field, e.Title)
+	}
+	res := clip.copy(*field, value)
+	res.ID, res.Title = e.ID, e.Title
+	emit(res, func() { printCopied(res) })
+}
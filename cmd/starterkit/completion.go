@@ -0,0 +1,345 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/output"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// commands lists the commands for completion, with the description shells
+// that support one show next to them.
+var commands = [][2]string{
+	{"init", "create a vault"},
+	{"add", "add an entry"},
+	{"list", "list entries"},
+	{"show", "print an entry"},
+	{"copy", "copy a field of an entry to the clipboard"},
+	{"otp", "print or copy the current TOTP code"},
+	{"search", "search all fields"},
+	{"edit", "change fields of an entry"},
+	{"mv", "rename an entry"},
+	{"rm", "move an entry to the trash"},
+	{"trash", "list, restore or empty the trash"},
+	{"passwd", "change the master password"},
+	{"ui", "full-screen terminal ui"},
+	{"run", "run a command with secrets in its environment"},
+	{"inject", "render a template with secrets in it"},
+	{"git-credential", "git credential helper"},
+	{"agent", "unlock, lock or query pwagent"},
+	{"match", "find entries for a URL"},
+	{"generate", "generate passwords"},
+	{"derive", "derive a site password"},
+	{"expiry", "set rotation rules"},
+	{"due", "list rotations due"},
+	{"rotate", "start, confirm or cancel a rotation"},
+	{"import", "import from another password manager"},
+	{"export", "export or bundle entries"},
+	{"report", "security report"},
+	{"hibp-index", "index a HIBP dump"},
+	{"identity", "signing fingerprint and recipient key"},
+	{"schema", "JSON Schema of a command's result"},
+	{"completion", "shell completion script"},
+}
+
+// subcommands are the words that must follow a command.
+var subcommands = map[string][]string{
+	"trash":          {"list", "restore", "empty"},
+	"agent":          {"unlock", "lock", "status"},
+	"completion":     {"bash", "zsh", "fish"},
+	"git-credential": {"get", "store", "erase"},
+}
+
+// Flags whose value is a path, and flags with a fixed set of values.
+var (
+	pathFlags = map[string]bool{
+		"file": true, "i": true, "o": true, "in": true, "out": true, "dump": true, "hibp": true, "keyfile": true, "psl": true,
+		"rules-db": true, "wordlist": true, "env-file": true, "master-file": true, "new-master-file": true,
+	}
+	valueFlags = map[string][]string{
+		"output":     output.Formats,
+		"field":      {"password", "username", "url", "notes", "totp"},
+		"set":        {"domain", "host", "startswith", "exact", "regex", "never"},
+		"capitalize": {"none", "first", "all", "random"},
+		"kdf":        {"pbkdf2", "argon2id", "argon2d", "aes"},
+	}
+)
+
+// cmdCompletion prints the completion script for a shell. The scripts call
+// back into the binary (see cmdComplete) for everything but the basics, so
+// flags, titles and ids are always those of this version and this vault.
+func cmdCompletion(args []string) {
+	if len(args) != 1 {
+		failf(exitUsage, "usage: completion bash|zsh|fish")
+	}
+	script, ok := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}[args[0]]
+	if !ok {
+		failf(exitUsage, "no completion for %q; use bash, zsh or fish", args[0])
+	}
+	fmt.Print(script)
+}
+
+const bashCompletion = `# bash completion for starterkit; load with: source <(starterkit completion bash)
+_starterkit() {
+	local cur=${COMP_WORDS[COMP_CWORD]} IFS=$'\n' line
+	COMPREPLY=()
+	for line in $("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null); do
+		line=${line%%$'\t'*}
+		if [[ $cur == [\"\']* ]]; then
+			COMPREPLY+=("${cur:0:1}$line")
+		else
+			line=$(printf '%q' "$line")
+			COMPREPLY+=("${line/#\\~/\~}")
+		fi
+	done
+	if [[ ${#COMPREPLY[@]} -eq 1 && ${COMPREPLY[0]} == */ ]]; then
+		compopt -o nospace
+	fi
+}
+complete -F _starterkit starterkit
+`
+
+const zshCompletion = `#compdef starterkit
+# zsh completion for starterkit; load with: source <(starterkit completion zsh)
+_starterkit() {
+	local line
+	local -a lines
+	lines=("${(@f)$("${words[1]}" __complete "${(@Q)words[2,CURRENT]}" 2>/dev/null)}")
+	for line in $lines; do
+		[[ -z $line ]] && continue
+		line=${line%%$'\t'*}
+		if [[ $line == */ ]]; then
+			compadd -S '' -- "$line"
+		else
+			compadd -- "$line"
+		fi
+	done
+}
+compdef _starterkit starterkit
+`
+
+const fishCompletion = `# fish completion for starterkit; load with: starterkit completion fish | source
+function __starterkit_complete
+	set -l tokens (commandline -opc) (commandline -ct)
+	$tokens[1] __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c starterkit -f -a '(__starterkit_complete)'
+`
+
+// cmdComplete prints the completions of the last of args, the words typed
+// after the program name, one per line with an optional tab and description.
+// Prefix matching is done here, so the scripts stay small.
+func cmdComplete(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	for i, a := range args {
+		args[i] = unquoteWord(a)
+	}
+	cur := args[len(args)-1]
+	prev := ""
+	if len(args) > 1 {
+		prev = args[len(args)-2]
+	}
+	var out []string
+	switch cmd := args[0]; {
+	case len(args) == 1:
+		for _, c := range commands {
+			out = append(out, c[0]+"\t"+c[1])
+		}
+	case len(args) == 2 && subcommands[cmd] != nil:
+		out = subcommands[cmd]
+	case cmd == "schema":
+		for name := range resultTypes {
+			out = append(out, strings.Fields(name)[0])
+		}
+	case strings.HasPrefix(prev, "-") && !strings.Contains(prev, "="):
+		name := strings.TrimLeft(prev, "-")
+		flags := commandFlags(args[:len(args)-1])
+		if takesValue, known := flags[name]; known && !takesValue {
+			out = flagNames(flags)
+			break
+		}
+		out = flagValues(cmd, name, cur, args)
+		if pathFlags[name] {
+			for _, p := range out {
+				fmt.Println(p) // matched already, against the expanded path
+			}
+			return
+		}
+	case strings.HasPrefix(cur, "-"):
+		out = flagNames(commandFlags(args[:len(args)-1]))
+	}
+	prefix := strings.TrimLeft(cur, "-")
+	dashes := cur[:len(cur)-len(prefix)]
+	seen := map[string]bool{}
+	for _, c := range out {
+		word := strings.SplitN(c, "\t", 2)[0]
+		if dashes == "-" && prefix != "" && strings.HasPrefix(word, "--") {
+			c = c[1:] // -fi completes to -file
+			word = word[1:]
+		}
+		if strings.HasPrefix(word, cur) && !seen[word] {
+			seen[word] = true
+			fmt.Println(c)
+		}
+	}
+}
+
+// unquoteWord drops the quotes and backslashes of a word as bash passes it.
+func unquoteWord(w string) string {
+	if w != "" && (w[0] == '"' || w[0] == '\'') {
+		return strings.TrimSuffix(w[1:], w[:1])
+	}
+	return strings.ReplaceAll(w, `\ `, " ")
+}
+
+// commandFlags finds the flags of the command in args, and whether each
+// takes a value, from its own -h output, so the list cannot drift from
+// the code.
+func commandFlags(args []string) map[string]bool {
+	argv := []string{args[0]}
+	if subcommands[args[0]] != nil && len(args) > 1 {
+		argv = append(argv, args[1])
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	help, _ := exec.CommandContext(ctx, exe, append(argv, "-h")...).CombinedOutput()
+	flags := map[string]bool{"output": true}
+	sc := bufio.NewScanner(bytes.NewReader(help))
+	for sc.Scan() {
+		// "  -name type" or "  -name" for booleans, as printed by flag.PrintDefaults
+		line, ok := strings.CutPrefix(sc.Text(), "  -")
+		if !ok {
+			continue
+		}
+		name, typ, _ := strings.Cut(strings.SplitN(line, "\t", 2)[0], " ")
+		flags[name] = typ != ""
+	}
+	return flags
+}
+
+func flagNames(flags map[string]bool) []string {
+	var out []string
+	for name := range flags {
+		out = append(out, "--"+name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// flagValues completes the value of flag name.
+func flagValues(cmd, name, cur string, args []string) []string {
+	switch {
+	case pathFlags[name]:
+		return completePath(cur, name == "file")
+	case name == "title" || name == "id":
+		return entryCompletions(cmd, vaultArg(args), name == "id")
+	case name == "format" && cmd == "report":
+		return []string{"table", "json", "html"}
+	case name == "format":
+		return []string{"bitwarden", "kdbx", "1pux", "lastpass"}
+	}
+	return valueFlags[name]
+}
+
+// vaultArg returns the --file given in args, or the default.
+func vaultArg(args []string) string {
+	for i, a := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || name != "file" {
+			continue
+		}
+		if hasValue {
+			return value
+		}
+		if i+1 < len(args)-1 {
+			return args[i+1]
+		}
+	}
+	return "vault.json"
+}
+
+// entryCompletions lists the titles, or ids with their titles, of the vault
+// at file: of the trash for the trash command. Titles are stored in the
+// clear, so this needs neither the master password nor the agent.
+func entryCompletions(cmd, file string, ids bool) []string {
+	v, err := pwmanager.Load(expandHome(file))
+	if err != nil {
+		return nil
+	}
+	entries := v.List()
+	if cmd == "trash" {
+		entries = v.TrashList()
+	}
+	var out []string
+	for _, e := range entries {
+		if ids {
+			out = append(out, e.ID+"\t"+e.Title)
+		} else {
+			out = append(out, e.Title)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// completePath lists the directories and files starting with cur. For
+// --file only vaults (*.json) are offered, and the GUI's vaults with them.
+func completePath(cur string, vaults bool) []string {
+	dir, base := filepath.Split(cur)
+	var out []string
+	entries, _ := os.ReadDir(expandHome(dir))
+	if dir == "" {
+		entries, _ = os.ReadDir(".")
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		isDir := e.IsDir()
+		if e.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(expandHome(dir), name)); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		switch {
+		case isDir:
+			out = append(out, dir+name+string(filepath.Separator))
+		case !vaults || strings.HasSuffix(name, ".json"):
+			out = append(out, dir+name)
+		}
+	}
+	if vaults {
+		files, _ := pwmanager.VaultFiles()
+		for _, f := range files {
+			if strings.HasPrefix(f, expandHome(cur)) {
+				out = append(out, f)
+			}
+		}
+	}
+	return out
+}
+
+// expandHome expands a leading ~/ the way the shell would have.
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
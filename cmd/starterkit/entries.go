@@ -0,0 +1,380 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// entryID resolves the entry selectors the commands share: --id, an exact
+// (case-insensitive) --title, or with neither a pick from every entry. A
+// title shared by several entries is offered as a pick too.
+func entryID(v *pwmanager.Vault, id, title string) string {
+	switch {
+	case id != "":
+		if _, ok := v.Entries[id]; !ok {
+			failf(exitNotFound, "no entry with id %s", id)
+		}
+		return id
+	case title != "":
+		matches := v.FindByExactTitle(title)
+		if len(matches) == 0 {
+			failf(exitNotFound, "no entry titled %q", title)
+		}
+		if len(matches) == 1 {
+			return matches[0].ID
+		}
+		return pickEntry(matches)
+	}
+	require(interactive() && !machine(), "id or --title")
+	all := v.List()
+	if len(all) == 0 {
+		failf(exitNotFound, "(empty)")
+	}
+	sort.Slice(all, func(i, j int) bool { return strings.ToLower(all[i].Title) < strings.ToLower(all[j].Title) })
+	return pickEntry(all)
+}
+
+// pickEntry asks which of several entries is meant. Without a terminal to
+// ask on, or for --output other than table, it fails as ambiguous, so
+// scripts fail instead of waiting for an answer.
+func pickEntry(cands []pwmanager.CipherEntry) string {
+	if !interactive() || machine() {
+		failf(exitAmbiguous, "%d entries match; choose one with --id", len(cands))
+	}
+	fmt.Println("Multiple matches:")
+	for i, e := range cands {
+		fmt.Printf("  [%d] %-12s | %s | %s\n", i+1, e.Title, e.ID, e.ModifiedAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Print("Pick number: ")
+	line, err := readLine(os.Stdin)
+	check(err, "pick")
+	n, err := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || n < 1 || n > len(cands) {
+		failf(exitUsage, "invalid selection")
+	}
+	return cands[n-1].ID
+}
+
+// interactive reports whether stdin is a terminal someone can answer on.
+func interactive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// confirm asks a yes/no question on the terminal; anything but y means no.
+func confirm(question string) bool {
+	fmt.Print(question + " [y/N] ")
+	line, err := readLine(os.Stdin)
+	return err == nil && strings.EqualFold(strings.TrimSpace(line), "y")
+}
+
+// entryField returns a field of a decrypted entry by name: password,
+// username, url, notes or totp, else the custom field of that name.
+func entryField(e entryView, name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "password":
+		return e.Password, true
+	case "username":
+		return e.Username, true
+	case "url":
+		return e.URL, true
+	case "notes":
+		return e.Notes, true
+	case "totp":
+		return e.TOTP, true
+	}
+	return e.Field(name)
+}
+
+// cmdEdit changes the fields given on the command line and leaves the rest
+// as they are.
+func cmdEdit(args []string) {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title")
+	username := fs.String("username", "", "new username")
+	password := fs.String("password", "", "new password")
+	url := fs.String("url", "", "new URL (its password rules apply to --generate)")
+	notes := fs.String("notes", "", "new notes")
+	generate := fs.Bool("generate", false, "replace the password with a generated one (takes the generate flags)")
+	gen := addGeneratorFlags(fs, false)
+	fs.Parse(args)
+	if *generate && isFlagSet(fs, "password") {
+		failf(exitUsage, "give either --password or --generate")
+	}
+
+	var userP, pwP, urlP, notesP *string
+	if isFlagSet(fs, "username") {
+		userP = username
+	}
+	if isFlagSet(fs, "password") {
+		pwP = password
+	}
+	if isFlagSet(fs, "url") {
+		urlP = url
+	}
+	if isFlagSet(fs, "notes") {
+		notesP = notes
+	}
+	if userP == nil && pwP == nil && urlP == nil && notesP == nil && !*generate {
+		failf(exitUsage, "nothing to change; give --username, --password, --generate, --url or --notes")
+	}
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	target := entryID(v, *id, *title)
+	plain, _, err := v.GetDecrypted(key, target)
+	check(err, "decrypt")
+	if (pwP != nil || *generate) && plain.IsDerived() {
+		failf(exitConflict, "the password of a derived entry is computed; change it with derive --rotate")
+	}
+	if *generate {
+		site := plain.URL
+		if urlP != nil {
+			site = *urlP
+		}
+		pw, err := pwmanager.GeneratePassword(gen.options(fs, site))
+		check(err, "generate")
+		pwP = &pw
+	}
+	check(v.UpdateEntry(key, target, nil, userP, pwP, urlP, notesP), "update")
+	check(v.Save(*file), "save")
+	res := changeResult{Action: "updated", ID: target, Title: v.Entries[target].Title}
+	if *generate {
+		res.Password = *pwP
+	}
+	emit(res, func() {
+		if *generate {
+			fmt.Println(*pwP)
+		}
+		fmt.Fprintln(os.Stderr, "updated entry id:", target)
+	})
+}
+
+// cmdRm moves an entry to the trash, from which trash restore brings it
+// back; --purge deletes it for good.
+func cmdRm(args []string) {
+	fs := flag.NewFlagSet("rm", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title")
+	purge := fs.Bool("purge", false, "delete for good instead of moving to the trash")
+	yes := fs.Bool("yes", false, "purge without asking")
+	fs.Parse(args)
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	target := entryID(v, *id, *title)
+	name := v.Entries[target].Title
+
+	if !*purge {
+		check(v.MoveToTrash(target), "rm")
+		check(v.Save(*file), "save")
+		emit(changeResult{Action: "trashed", ID: target, Title: name}, func() { fmt.Printf("moved %q to the trash\n", name) })
+		return
+	}
+	if !*yes {
+		require(interactive() && !machine(), "yes")
+		if !confirm(fmt.Sprintf("Delete %q for good?", name)) {
+			fmt.Println("kept")
+			return
+		}
+	}
+	v.Delete(target)
+	check(v.Save(*file), "save")
+	emit(changeResult{Action: "purged", ID: target, Title: name}, func() { fmt.Printf("deleted %q\n", name) })
+}
+
+// cmdTrash lists, restores or purges the entries rm moved to the trash.
+func cmdTrash(args []string) {
+	if len(args) == 0 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	fs := flag.NewFlagSet("trash "+args[0], flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id (restore)")
+	title := fs.String("title", "", "exact entry title (restore)")
+	olderThan := fs.Int("older-than", 0, "only purge entries deleted more than this many days ago (empty)")
+	fs.Parse(args[1:])
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+
+	switch args[0] {
+	case "list":
+		trashed := v.TrashList()
+		emit(summarize(trashed), func() {
+			if len(trashed) == 0 {
+				fmt.Println("(empty)")
+				return
+			}
+			fmt.Println("ID                                   | Title         | Deleted")
+			fmt.Println(strings.Repeat("-", 88))
+			for _, e := range trashed {
+				fmt.Printf("%-35s | %-13s | %s\n", e.ID, e.Title, e.DeletedAt.Local().Format("2006-01-02 15:04:05"))
+			}
+		})
+	case "restore":
+		target := trashID(v, *id, *title)
+		check(v.Restore(target), "restore")
+		check(v.Save(*file), "save")
+		name := v.Entries[target].Title
+		emit(changeResult{Action: "restored", ID: target, Title: name}, func() { fmt.Printf("restored %q\n", name) })
+	case "empty":
+		n := v.EmptyTrash(time.Duration(*olderThan) * 24 * time.Hour)
+		check(v.Save(*file), "save")
+		emit(purgeResult{Purged: n}, func() { fmt.Printf("purged %d entries\n", n) })
+	default:
+		usage()
+		os.Exit(exitUsage)
+	}
+}
+
+// purgeResult reports trash empty.
+type purgeResult struct {
+	Purged int `json:"purged"`
+}
+
+// trashID is entryID for the entries in the trash.
+func trashID(v *pwmanager.Vault, id, title string) string {
+	if id != "" {
+		if _, ok := v.Trash[id]; !ok {
+			failf(exitNotFound, "no entry in the trash with id %s", id)
+		}
+		return id
+	}
+	var matches []pwmanager.CipherEntry
+	for _, e := range v.TrashList() {
+		if title == "" || strings.EqualFold(e.Title, title) {
+			matches = append(matches, e)
+		}
+	}
+	switch {
+	case len(matches) == 0 && title != "":
+		failf(exitNotFound, "no entry titled %q in the trash", title)
+	case len(matches) == 0:
+		failf(exitNotFound, "the trash is empty")
+	case len(matches) == 1 && title != "":
+		return matches[0].ID
+	}
+	require(title != "" || (interactive() && !machine()), "id or --title")
+	return pickEntry(matches)
+}
+
+// cmdMv renames an entry.
+func cmdMv(args []string) {
+	fs := flag.NewFlagSet("mv", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title")
+	to := fs.String("to", "", "new title")
+	fs.Parse(args)
+	require(strings.TrimSpace(*to) != "", "to")
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	target := entryID(v, *id, *title)
+	old := v.Entries[target].Title
+	check(v.UpdateEntry(key, target, to, nil, nil, nil, nil), "rename")
+	check(v.Save(*file), "save")
+	emit(changeResult{Action: "renamed", ID: target, Title: *to}, func() { fmt.Printf("renamed %q to %q\n", old, *to) })
+}
+
+// cmdPasswd changes the master password. Only the wrapped master key is
+// redone, so entries stay as they are, but derived passwords come from the
+// master password and change with it: with derived entries in the vault it
+// refuses, before anything is written, unless --force is given.
+func cmdPasswd(args []string) {
+	fs := flag.NewFlagSet("passwd", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	newMaster := newMasterFlags(fs)
+	force := fs.Bool("force", false, "change it even though derived passwords change with it")
+	fs.Parse(args)
+
+	// always the current password, never the agent: it proves who is asking
+	v, err := pwmanager.Load(*file)
+	check(err, "load")
+	key, err := v.Unlock(master.password(false))
+	check(err, "unlock (check master password)")
+	defer key.Destroy()
+
+	recs, err := v.Records(key)
+	check(err, "passwd")
+	derived := 0
+	for _, r := range recs {
+		if r.IsDerived() {
+			derived++
+		}
+	}
+	if derived > 0 && !*force {
+		fmt.Fprintln(os.Stderr, "derived passwords are computed from the master password; re-run with --force, "+
+			"then update them on their sites (show prints the new ones)")
+		failf(exitRefused, "%d derived entries would change their passwords", derived)
+	}
+
+	check(v.ChangePassword(key, newMaster.password(true)), "passwd")
+	check(v.Save(*file), "save")
+	res := passwdResult{File: *file, DerivedChanged: derived, AgentLocked: lockAgentFor(*file)}
+	emit(res, func() {
+		fmt.Println("master password changed")
+		if res.DerivedChanged > 0 {
+			fmt.Fprintf(os.Stderr, "%d derived passwords have changed with it; update them on their sites\n", res.DerivedChanged)
+		}
+		if res.AgentLocked {
+			fmt.Fprintln(os.Stderr, "locked the agent, which held the old keys")
+		}
+	})
+}
+
+// passwdResult reports passwd.
+type passwdResult struct {
+	File           string `json:"file"`
+	DerivedChanged int    `json:"derivedChanged"` // derived entries whose passwords changed, with --force
+	AgentLocked    bool   `json:"agentLocked"`    // a running agent held the old keys and was locked
+}
+
+// cmdSearch lists the entries matching a query over all fields, best match
+// first. The query may come before the flags, as with match.
+func cmdSearch(args []string) {
+	var words []string
+	for len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		words = append(words, args[0])
+		args = args[1:]
+	}
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	limit := fs.Int("limit", 20, "show at most this many matches (0 for all)")
+	fs.Parse(args)
+	words = append(words, fs.Args()...)
+	if len(words) == 0 {
+		failf(exitUsage, "provide a query: search alice github --file vault.json (narrow with user:, url:, notes:, tag:)")
+	}
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	ix := sessionIndex(v, key)
+	found := searchEntries(v, ix, strings.Join(words, " "))
+	ix.Wipe()
+	if len(found) == 0 {
+		failf(exitNotFound, "no matches")
+	}
+	if *limit > 0 && len(found) > *limit {
+		found = found[:*limit]
+	}
+	emit(summarize(found), func() { printEntries(found) })
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/passwordrules"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// generatorFlags are the password generator's options, shared by generate
+// and edit --generate.
+type generatorFlags struct {
+	length      *int
+	upper       *bool
+	lower       *bool
+	digits      *bool
+	symbols     *bool
+	noSimilar   *bool
+	noAmbiguous *bool
+	words       *int
+	wordList    *string
+	separator   *string
+	capitalize  *string
+	addDigit    *bool
+	addSymbol   *bool
+	rules       *string
+	siteURL     *string
+	rulesDB     *string
+}
+
+// addGeneratorFlags registers a flag for every pwmanager.PasswordOptions field
+// on fs. --url, for the site whose rules apply, is left to the caller unless
+// siteFlag is set.
+func addGeneratorFlags(fs *flag.FlagSet, siteFlag bool) *generatorFlags {
+	g := &generatorFlags{
+		length:      fs.Int("length", 16, "password length (random-character mode)"),
+		upper:       fs.Bool("upper", true, "include uppercase letters"),
+		lower:       fs.Bool("lower", true, "include lowercase letters"),
+		digits:      fs.Bool("digits", true, "include digits"),
+		symbols:     fs.Bool("symbols", true, "include symbols"),
+		noSimilar:   fs.Bool("exclude-similar", false, "leave out look-alike characters (l, 1, I, o, 0, O)"),
+		noAmbiguous: fs.Bool("exclude-ambiguous", false, "leave out brackets, quotes and other ambiguous symbols"),
+		words:       fs.Int("words", 0, "generate a diceware passphrase with this many words instead"),
+		wordList:    fs.String("wordlist", pwmanager.WordListEFFLarge, "passphrase word list: eff-large, eff-short or a file (one word per line)"),
+		separator:   fs.String("separator", "-", "passphrase word separator"),
+		capitalize:  fs.String("capitalize", "none", "passphrase capitalization: none, first, all or random"),
+		addDigit:    fs.Bool("digit", false, "append a random digit to one passphrase word"),
+		addSymbol:   fs.Bool("symbol", false, "append a random symbol to one passphrase word"),
+		rules:       fs.String("rules", "", "site password rules in Apple passwordrules syntax, e.g. \"minlength: 8; maxlength: 16; required: digit;\""),
+		siteURL:     new(string),
+		rulesDB:     fs.String("rules-db", "", "password-rules.json file to merge over the built-in site rules"),
+	}
+	if siteFlag {
+		g.siteURL = fs.String("url", "", "apply the known password rules for this site, if any")
+	}
+	return g
+}
+
+// options builds the generator options from the parsed flags. siteURL is
+// the site the password is for when --url does not say; its known password
+// rules apply unless --rules is given.
+func (g *generatorFlags) options(fs *flag.FlagSet, siteURL string) pwmanager.PasswordOptions {
+	opts := pwmanager.PasswordOptions{
+		Length:           *g.length,
+		IncludeUpper:     *g.upper,
+		IncludeLower:     *g.lower,
+		IncludeNumbers:   *g.digits,
+		IncludeSymbols:   *g.symbols,
+		ExcludeSimilar:   *g.noSimilar,
+		ExcludeAmbiguous: *g.noAmbiguous,
+		Words:            *g.words,
+		WordList:         *g.wordList,
+		Separator:        *g.separator,
+		Capitalize:       *g.capitalize,
+		InjectDigit:      *g.addDigit,
+		InjectSymbol:     *g.addSymbol,
+		Rules:            *g.rules,
+	}
+	if opts.Capitalize == "none" {
+		opts.Capitalize = pwmanager.CapNone
+	}
+	if *g.rulesDB != "" {
+		check(passwordrules.LoadFile(*g.rulesDB), "rules-db")
+	}
+	if *g.siteURL != "" {
+		siteURL = *g.siteURL
+	}
+	if opts.Rules == "" && siteURL != "" {
+		if site, ok := passwordrules.Default().Lookup(siteURL); ok {
+			opts.Rules = site.Rules
+			fmt.Fprintf(os.Stderr, "using password rules for %s: %s\n", site.Domain, site.Rules)
+		}
+	}
+	if opts.Rules != "" && !isFlagSet(fs, "length") {
+		opts.Length = 0 // as long as the rules allow
+	}
+	return opts
+}
+
+func cmdGenerate(args []string) {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	gen := addGeneratorFlags(fs, true)
+	count := fs.Int("count", 1, "number of passwords to generate")
+	clip := addClipFlags(fs, "password", false)
+	fs.Parse(args)
+	if *clip.on && *count != 1 {
+		failf(exitUsage, "--clip copies one password; leave out --count")
+	}
+	opts := gen.options(fs, "")
+
+	bits, err := pwmanager.EntropyBits(opts)
+	check(err, "generate")
+	if bits == 0 {
+		failf(exitUsage, "no characters selected")
+	}
+	res := generateResult{Passwords: make([]string, 0, *count), EntropyBits: bits}
+	for range *count {
+		pw, err := pwmanager.GeneratePassword(opts)
+		check(err, "generate")
+		res.Passwords = append(res.Passwords, pw)
+	}
+	if *clip.on {
+		copied := clip.copy("password", res.Passwords[0])
+		emit(copied, func() {
+			printCopied(copied)
+			fmt.Fprintf(os.Stderr, "entropy: %.1f bits\n", bits)
+		})
+		return
+	}
+	emit(res, func() {
+		for _, pw := range res.Passwords {
+			fmt.Println(pw)
+		}
+		// on stderr so that $(starterkit generate) captures only the password
+		fmt.Fprintf(os.Stderr, "entropy: %.1f bits\n", bits)
+	})
+}
+
+// generateResult reports generate.
+type generateResult struct {
+	Passwords   []string `json:"passwords"`
+	EntropyBits float64  `json:"entropyBits"` // of each password
+}
+
+// isFlagSet reports whether name was given on the command line.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
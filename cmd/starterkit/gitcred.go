@@ -0,0 +1,296 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/agent"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"appliedcryptography-starter-kit/internal/urlmatch"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// gitHelperName is the program git runs for credential.helper "starterkit
+// git-credential": it prepends "git credential-" to helper names that are not
+// paths or shell commands. A link by that name to starterkit is enough.
+const gitHelperName = "git-credential-starterkit"
+
+// errAgentLocked is returned when the helper has no way to the key but a
+// prompt, which it does not show: the terminal is git's.
+var errAgentLocked = errors.New("the agent is not running or is locked; run: starterkit agent unlock --file VAULT")
+
+// gitCredential is what git sends a helper and expects back, as key=value
+// lines; see gitcredentials(7).
+type gitCredential struct {
+	Protocol string
+	Host     string // with the port, if any
+	Path     string // only with credential.useHttpPath
+	Username string
+	Password string
+}
+
+// cmdGitCredential implements the get, store and erase actions of git's
+// credential helper protocol. get answers with the best login whose URL
+// matches, as match would pick it; store saves new credentials in --folder,
+// or updates the password of the login it would have answered with; erase
+// moves a login of --folder with the rejected password to the trash.
+//
+// Errors go to stderr and unknown actions are ignored, as git expects of a
+// helper; stdout carries nothing but the protocol.
+func cmdGitCredential(args []string) {
+	fs := flag.NewFlagSet("git-credential", flag.ExitOnError)
+	file := fs.String("file", "", "path to vault file (default: the vault the agent has unlocked)")
+	master := masterFlags(fs)
+	folder := fs.String("folder", "git", "folder of the entries store creates")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		failf(exitUsage, "usage: git-credential [--file vault.json] [--folder git] get|store|erase   (run by git: "+
+			"git config --global credential.helper \"starterkit git-credential\")")
+	}
+	action := fs.Arg(0)
+	if action != "get" && action != "store" && action != "erase" {
+		return
+	}
+
+	c, err := readGitCredential(os.Stdin)
+	gitCheck(err)
+	if c.Protocol == "" || c.Host == "" {
+		return
+	}
+	var v *pwmanager.Vault
+	var key *secret.Buffer
+	path, err := helperPath(*file, master)
+	if err == nil {
+		if action != "get" {
+			// git may store the credentials of several remotes at once
+			unlock, err := lockVault(path)
+			gitCheck(err)
+			defer unlock()
+		}
+		v, key, err = helperVault(path, master)
+	}
+	if errors.Is(err, errAgentLocked) {
+		// git asks at the terminal itself, or another helper answers
+		fmt.Fprintln(os.Stderr, "starterkit git-credential:", err)
+		return
+	}
+	gitCheck(err)
+	defer key.Destroy()
+	recs, err := v.Records(key)
+	gitCheck(err)
+
+	switch action {
+	case "get":
+		answer, ok, err := gitGet(v, key, master, recs, c)
+		if errors.Is(err, urlmatch.ErrLookalike) {
+			fmt.Fprintf(os.Stderr, "starterkit git-credential: refusing credentials for %s: %v\n", c.Host, err)
+			os.Exit(exitRefused)
+		}
+		gitCheck(err)
+		if ok {
+			fmt.Printf("username=%s\npassword=%s\n", answer.Username, answer.Password)
+		}
+	case "store":
+		changed, err := gitStore(v, key, recs, c, *folder)
+		gitCheck(err)
+		if changed {
+			gitCheck(v.Save(path))
+		}
+	case "erase":
+		changed, err := gitErase(v, recs, c, *folder)
+		gitCheck(err)
+		if changed {
+			gitCheck(v.Save(path))
+		}
+	}
+}
+
+// gitGet returns the credentials of the best login for c whose username and
+// password git can take, as match would pick it; a username from git narrows
+// the choice. A page that imitates a known site gets an error wrapping
+// urlmatch.ErrLookalike instead.
+func gitGet(v *pwmanager.Vault, key *secret.Buffer, master *masterInput, recs []pwmanager.Record, c gitCredential) (gitCredential, bool, error) {
+	results, verdict, err := urlmatch.Find(recs, c.url())
+	if err != nil {
+		return c, false, err
+	}
+	if err := verdict.Err(); err != nil {
+		return c, false, err
+	}
+	for _, r := range results {
+		if c.Username != "" && r.Username != c.Username {
+			continue
+		}
+		e, err := openEntry(v, key, master, r.ID)
+		if err != nil {
+			return c, false, err
+		}
+		if e.Password == "" || !gitValue(e.Username) || !gitValue(e.Password) {
+			continue
+		}
+		c.Username, c.Password = e.Username, e.Password
+		return c, true, nil
+	}
+	return c, false, nil
+}
+
+// gitStore saves credentials git reports as working: it updates the password
+// of the login get would have answered with, or adds a login to folder. It
+// reports whether the vault changed. A derived login is left alone.
+func gitStore(v *pwmanager.Vault, key *secret.Buffer, recs []pwmanager.Record, c gitCredential, folder string) (bool, error) {
+	if c.Username == "" || c.Password == "" {
+		return false, nil
+	}
+	if r, ok := c.login(recs); ok {
+		if r.Derived != nil || r.Password == c.Password {
+			return false, nil
+		}
+		return true, v.UpdateEntry(key, r.ID, nil, nil, &c.Password, nil, nil)
+	}
+	_, err := v.AddRecord(key, strings.TrimSuffix(c.Host+"/"+c.Path, "/"), pwmanager.PlainEntry{
+		Username: c.Username, Password: c.Password, URL: c.url(), Folder: folder,
+	})
+	return err == nil, err
+}
+
+// gitErase moves a login git reports as rejected to the trash, but only one
+// of folder, where store puts them, and only while its password is still the
+// rejected one. It reports whether the vault changed.
+func gitErase(v *pwmanager.Vault, recs []pwmanager.Record, c gitCredential, folder string) (bool, error) {
+	r, ok := c.login(recs)
+	if !ok || r.Folder != folder || c.Password == "" || r.Password != c.Password {
+		return false, nil
+	}
+	return true, v.MoveToTrash(r.ID)
+}
+
+// readGitCredential reads key=value lines up to a blank line or the end of
+// r. A url key is split into the other keys; keys the helper does not use,
+// such as capability[] and wwwauth[], are skipped.
+func readGitCredential(r io.Reader) (gitCredential, error) {
+	var c gitCredential
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			break
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return c, fmt.Errorf("bad line %q: want key=value", line)
+		}
+		switch key {
+		case "protocol":
+			c.Protocol = value
+		case "host":
+			c.Host = value
+		case "path":
+			c.Path = value
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		case "url":
+			u, err := url.Parse(value)
+			if err != nil {
+				return c, err
+			}
+			c.Protocol, c.Host, c.Path = u.Scheme, u.Host, strings.TrimPrefix(u.Path, "/")
+			if u.User != nil {
+				c.Username = u.User.Username()
+			}
+		}
+	}
+	return c, sc.Err()
+}
+
+// url is the page the credential is for, as urlmatch sees it.
+func (c gitCredential) url() string {
+	return c.Protocol + "://" + c.Host + "/" + c.Path
+}
+
+// login returns the login of c's user on c's host: the entry get would
+// answer with when told the username, unless it only shares the domain.
+func (c gitCredential) login(recs []pwmanager.Record) (pwmanager.Record, bool) {
+	results, _, err := urlmatch.Find(recs, c.url())
+	if err != nil {
+		return pwmanager.Record{}, false
+	}
+	for _, res := range results {
+		if res.Quality < urlmatch.MatchHost || res.Username != c.Username {
+			continue
+		}
+		for _, r := range recs {
+			if r.ID == res.ID {
+				return r, true
+			}
+		}
+	}
+	return pwmanager.Record{}, false
+}
+
+// gitValue reports whether s can be sent in a key=value line.
+func gitValue(s string) bool {
+	return !strings.ContainsAny(s, "\n\x00")
+}
+
+// helperPath returns file, or the vault the agent has unlocked when neither
+// a file nor a master flag is given.
+func helperPath(file string, master *masterInput) (string, error) {
+	if file == "" && !master.given() {
+		c, err := agent.Dial(agent.SocketPath())
+		if err != nil {
+			return "", errAgentLocked
+		}
+		st, err := c.Status()
+		c.Close()
+		if err != nil || !st.Unlocked {
+			return "", errAgentLocked
+		}
+		return st.File, nil
+	}
+	if file == "" {
+		return "", errors.New("with a master flag, --file is needed too")
+	}
+	return expandHome(file), nil
+}
+
+// helperVault opens the vault at path with the key from a master flag or
+// from the agent. It never prompts.
+func helperVault(path string, master *masterInput) (*pwmanager.Vault, *secret.Buffer, error) {
+	if *master.stdin {
+		return nil, nil, errors.New("stdin carries git's request; use --master-file or --master-fd")
+	}
+	v, err := pwmanager.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if master.given() {
+		pw, err := master.readPassword(false)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := v.Unlock(pw)
+		return v, key, err
+	}
+	key, dk, err := agentKeys(path)
+	if err != nil {
+		return nil, nil, errAgentLocked
+	}
+	agentDerivKey.Destroy()
+	agentDerivKey = dk
+	return v, key, nil
+}
+
+// gitCheck reports err on stderr and exits with its code.
+func gitCheck(err error) {
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "starterkit git-credential:", err)
+		os.Exit(exitCode(err))
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"appliedcryptography-starter-kit/internal/urlmatch"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadGitCredential(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want gitCredential
+	}{
+		{"protocol=https\nhost=github.com\nusername=alice\npassword=pw\n\n",
+			gitCredential{Protocol: "https", Host: "github.com", Username: "alice", Password: "pw"}},
+		{"protocol=https\nhost=git.example.com:8443\npath=team/repo.git\n",
+			gitCredential{Protocol: "https", Host: "git.example.com:8443", Path: "team/repo.git"}},
+		{"url=https://bob@git.example.com:8443/team/repo.git\n",
+			gitCredential{Protocol: "https", Host: "git.example.com:8443", Path: "team/repo.git", Username: "bob"}},
+		{"capability[]=authtype\nwwwauth[]=Basic realm=\"x\"\nprotocol=https\nhost=h\n",
+			gitCredential{Protocol: "https", Host: "h"}},
+		{"password=a=b\n", gitCredential{Password: "a=b"}},
+		{"host=first\n\nhost=second\n", gitCredential{Host: "first"}},
+		{"", gitCredential{}},
+	} {
+		got, err := readGitCredential(strings.NewReader(tt.in))
+		if err != nil || got != tt.want {
+			t.Errorf("readGitCredential(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"host github.com\n", "url=://bad\n"} {
+		if _, err := readGitCredential(strings.NewReader(in)); err == nil {
+			t.Errorf("readGitCredential(%q) accepted", in)
+		}
+	}
+}
+
+// gitVault returns an unlocked vault with two GitHub logins, one saved by
+// store in the git folder, another login on that site's domain and a
+// derived login.
+func gitVault(t *testing.T) (*pwmanager.Vault, *secret.Buffer, map[string]string) {
+	t.Helper()
+	v, key, err := pwmanager.Create("git-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(key.Destroy)
+	ids := map[string]string{}
+	for _, e := range []struct {
+		name, title string
+		plain       pwmanager.PlainEntry
+	}{
+		{"alice", "GitHub", pwmanager.PlainEntry{Username: "alice", Password: "gh-alice", URL: "https://github.com"}},
+		{"bob", "GitHub", pwmanager.PlainEntry{Username: "bob", Password: "gh-bob", URL: "https://github.com"}},
+		{"stored", "git.example.com/team/repo", pwmanager.PlainEntry{
+			Username: "carol", Password: "old-pass", URL: "https://git.example.com/team/repo", Folder: "git"}},
+		{"sso", "Example SSO", pwmanager.PlainEntry{Username: "carol", Password: "sso-pass", URL: "https://sso.example.com"}},
+	} {
+		id, err := v.AddRecord(key, e.title, e.plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[e.name] = id
+	}
+	id, err := v.AddDerivedEntry(key, "GitLab", pwmanager.DefaultDerivedParams("gitlab.com", "dave"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids["derived"] = id
+	return v, key, ids
+}
+
+func records(t *testing.T, v *pwmanager.Vault, key *secret.Buffer) []pwmanager.Record {
+	t.Helper()
+	recs, err := v.Records(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recs
+}
+
+func TestGitLogin(t *testing.T) {
+	v, key, ids := gitVault(t)
+	recs := records(t, v, key)
+	for _, tt := range []struct {
+		c    gitCredential
+		want string // key in ids; empty for none
+	}{
+		{gitCredential{Protocol: "https", Host: "github.com", Username: "alice"}, "alice"},
+		{gitCredential{Protocol: "https", Host: "github.com", Path: "alice/repo.git", Username: "bob"}, "bob"},
+		{gitCredential{Protocol: "https", Host: "github.com", Username: "carol"}, ""},
+		{gitCredential{Protocol: "https", Host: "git.example.com", Path: "team/repo", Username: "carol"}, "stored"},
+		// the same domain is not enough: store would overwrite the SSO password
+		{gitCredential{Protocol: "https", Host: "other.example.com", Username: "carol"}, ""},
+		{gitCredential{Protocol: "https", Host: "gitlab.com", Username: "dave"}, "derived"},
+	} {
+		r, ok := tt.c.login(recs)
+		if ok != (tt.want != "") || (ok && r.ID != ids[tt.want]) {
+			t.Errorf("%+v: login = %q, %v; want %s", tt.c, r.Title, ok, tt.want)
+		}
+	}
+}
+
+func TestGitGet(t *testing.T) {
+	v, key, _ := gitVault(t)
+	recs := records(t, v, key)
+	for _, tt := range []struct {
+		c        gitCredential
+		user, pw string // empty: no answer
+	}{
+		{gitCredential{Protocol: "https", Host: "github.com", Username: "alice"}, "alice", "gh-alice"},
+		{gitCredential{Protocol: "https", Host: "github.com", Username: "bob"}, "bob", "gh-bob"},
+		{gitCredential{Protocol: "https", Host: "github.com", Username: "mallory"}, "", ""},
+		{gitCredential{Protocol: "https", Host: "git.example.com", Path: "team/repo"}, "carol", "old-pass"},
+		{gitCredential{Protocol: "https", Host: "unknown.org"}, "", ""},
+	} {
+		got, ok, err := gitGet(v, key, knownMaster("git-master"), recs, tt.c)
+		if err != nil || ok != (tt.user != "") || (ok && (got.Username != tt.user || got.Password != tt.pw)) {
+			t.Errorf("%+v: get = %+v, %v, %v; want %s:%s", tt.c, got, ok, err, tt.user, tt.pw)
+		}
+	}
+
+	// a derived password is recomputed from the master password
+	dk, err := pwmanager.DerivationKey("git-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dk.Destroy()
+	want, err := pwmanager.DerivePassword(dk, pwmanager.DefaultDerivedParams("gitlab.com", "dave"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := gitGet(v, key, knownMaster("git-master"), recs, gitCredential{Protocol: "https", Host: "gitlab.com"})
+	if err != nil || !ok || got.Username != "dave" || got.Password != want {
+		t.Errorf("derived login: %+v, %v, %v; want password %q", got, ok, err, want)
+	}
+	_, ok, err = gitGet(v, key, knownMaster("git-master"), recs, gitCredential{Protocol: "https", Host: "xn--gthub-n2e.com", Username: "alice"})
+	if ok || !errors.Is(err, urlmatch.ErrLookalike) {
+		t.Errorf("look-alike host: %v, %v", ok, err)
+	}
+}
+
+func TestGitStore(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		c       gitCredential
+		changed bool
+		check   func(t *testing.T, v *pwmanager.Vault, key *secret.Buffer, ids map[string]string)
+	}{
+		{"new host", gitCredential{Protocol: "https", Host: "git.new.org", Path: "x/y.git", Username: "erin", Password: "p1"}, true,
+			func(t *testing.T, v *pwmanager.Vault, key *secret.Buffer, ids map[string]string) {
+				matches := v.FindByExactTitle("git.new.org/x/y.git")
+				if len(matches) != 1 {
+					t.Fatalf("%d entries added", len(matches))
+				}
+				p, _, err := v.GetDecrypted(key, matches[0].ID)
+				if err != nil || p.Username != "erin" || p.Password != "p1" || p.Folder != "git" || p.URL != "https://git.new.org/x/y.git" {
+					t.Errorf("added %+v, %v", p, err)
+				}
+			}},
+		{"new password of a known login", gitCredential{Protocol: "https", Host: "github.com", Username: "alice", Password: "new"}, true,
+			func(t *testing.T, v *pwmanager.Vault, key *secret.Buffer, ids map[string]string) {
+				p, _, err := v.GetDecrypted(key, ids["alice"])
+				if err != nil || p.Password != "new" || p.Folder != "" || len(v.Entries) != len(ids) {
+					t.Errorf("updated %+v, %v; %d entries", p, err, len(v.Entries))
+				}
+			}},
+		{"new user on a known site", gitCredential{Protocol: "https", Host: "github.com", Username: "frank", Password: "f"}, true, nil},
+		{"unchanged password", gitCredential{Protocol: "https", Host: "github.com", Username: "bob", Password: "gh-bob"}, false, nil},
+		{"derived login", gitCredential{Protocol: "https", Host: "gitlab.com", Username: "dave", Password: "typed"}, false, nil},
+		{"no password", gitCredential{Protocol: "https", Host: "github.com", Username: "alice"}, false, nil},
+		{"no username", gitCredential{Protocol: "https", Host: "github.com", Password: "p"}, false, nil},
+		// a login on another host of the domain is not touched; a new one is added
+		{"same domain only", gitCredential{Protocol: "https", Host: "other.example.com", Username: "carol", Password: "c"}, true,
+			func(t *testing.T, v *pwmanager.Vault, key *secret.Buffer, ids map[string]string) {
+				if p, _, _ := v.GetDecrypted(key, ids["sso"]); p.Password != "sso-pass" {
+					t.Errorf("SSO password changed to %q", p.Password)
+				}
+			}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v, key, ids := gitVault(t)
+			before := len(v.Entries)
+			changed, err := gitStore(v, key, records(t, v, key), tt.c, "git")
+			if err != nil || changed != tt.changed {
+				t.Fatalf("store = %v, %v; want %v", changed, err, tt.changed)
+			}
+			if !changed && len(v.Entries) != before {
+				t.Error("entries added without a change")
+			}
+			if tt.check != nil {
+				tt.check(t, v, key, ids)
+			}
+		})
+	}
+}
+
+func TestGitErase(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		c     gitCredential
+		trash string // key in ids of the entry erased; empty for none
+	}{
+		{"rejected password", gitCredential{Protocol: "https", Host: "git.example.com", Path: "team/repo", Username: "carol", Password: "old-pass"}, "stored"},
+		{"already replaced", gitCredential{Protocol: "https", Host: "git.example.com", Path: "team/repo", Username: "carol", Password: "older"}, ""},
+		{"no password", gitCredential{Protocol: "https", Host: "git.example.com", Path: "team/repo", Username: "carol"}, ""},
+		{"outside the folder", gitCredential{Protocol: "https", Host: "github.com", Username: "alice", Password: "gh-alice"}, ""},
+		{"unknown login", gitCredential{Protocol: "https", Host: "github.com", Username: "mallory", Password: "x"}, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v, key, ids := gitVault(t)
+			changed, err := gitErase(v, records(t, v, key), tt.c, "git")
+			if err != nil || changed != (tt.trash != "") {
+				t.Fatalf("erase = %v, %v", changed, err)
+			}
+			if tt.trash == "" {
+				if len(v.Trash) != 0 {
+					t.Errorf("trashed %d entries", len(v.Trash))
+				}
+				return
+			}
+			if _, ok := v.Trash[ids[tt.trash]]; !ok || len(v.Trash) != 1 {
+				t.Errorf("trash = %v, want %s", v.TrashList(), tt.trash)
+			}
+		})
+	}
+	// --folder moves the rules to another folder
+	v, key, _ := gitVault(t)
+	c := gitCredential{Protocol: "https", Host: "git.example.com", Path: "team/repo", Username: "carol", Password: "old-pass"}
+	if changed, err := gitErase(v, records(t, v, key), c, "work"); changed || err != nil {
+		t.Errorf("erased outside --folder: %v, %v", changed, err)
+	}
+}
+
+func TestLockVault(t *testing.T) {
+	switch runtime.GOOS {
+	case "darwin", "dragonfly", "freebsd", "linux", "netbsd", "openbsd", "windows":
+	default:
+		t.Skip("no vault lock on", runtime.GOOS)
+	}
+	v, _, _ := gitVault(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if err := v.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := lockVault(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locked := make(chan func())
+	go func() {
+		second, err := lockVault(path)
+		if err != nil {
+			t.Error(err)
+			second = func() {}
+		}
+		locked <- second
+	}()
+	select {
+	case second := <-locked:
+		second()
+		t.Fatal("locked twice at once")
+	case <-time.After(100 * time.Millisecond):
+	}
+	// the holder can still save
+	if err := v.Save(path); err != nil {
+		t.Errorf("save under the lock: %v", err)
+	}
+	unlock()
+	select {
+	case second := <-locked:
+		second()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lock was not released")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/hibp"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// openBreaches opens the HIBP dump given with --hibp; nil when the flag is unset.
+func openBreaches(path string) *hibp.DB {
+	if path == "" {
+		return nil
+	}
+	db, err := hibp.Open(path)
+	check(err, "open HIBP dump")
+	return db
+}
+
+// watchBreaches makes AddEntry/UpdateEntry print a warning for breached passwords.
+func watchBreaches(v *pwmanager.Vault, db *hibp.DB) {
+	if db == nil {
+		return
+	}
+	v.Breaches = db
+	v.Warn = func(msg string) { fmt.Fprintln(os.Stderr, "warning:", msg) }
+}
+
+func cmdHIBPIndex(args []string) {
+	fs := flag.NewFlagSet("hibp-index", flag.ExitOnError)
+	dump := fs.String("dump", "", "sorted HIBP hash file (HASH:COUNT per line)")
+	out := fs.String("out", "", "index file (default: DUMP.idx, where lookups find it)")
+	fs.Parse(args)
+	require(*dump != "", "dump")
+	if *out == "" {
+		*out = hibp.IndexPath(*dump)
+	}
+	check(hibp.BuildIndex(*dump, *out), "index")
+	emit(fileResult{File: *out}, func() { fmt.Println("index written to", *out) })
+}
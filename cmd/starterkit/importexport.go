@@ -0,0 +1,220 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/bitwarden"
+	"appliedcryptography-starter-kit/internal/kdbx"
+	"appliedcryptography-starter-kit/internal/lastpass"
+	"appliedcryptography-starter-kit/internal/onepassword"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+func cmdImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	format := fs.String("format", "bitwarden", "source format: bitwarden, kdbx, 1pux or lastpass")
+	in := fs.String("in", "", "file to import")
+	filePassword := fs.String("password", "", "password of an encrypted export file or KeePass database")
+	keyFile := fs.String("keyfile", "", "KeePass key file (kdbx only)")
+	isBundle := fs.Bool("bundle", false, "import a signed pwmanager bundle (ignores --format)")
+	expectSigner := fs.String("expect-signer", "", "refuse a bundle unless it is signed by this fingerprint")
+	fs.Parse(args)
+	require(*in != "", "in")
+
+	data, err := os.ReadFile(*in)
+	check(err, "read")
+	if *isBundle {
+		importBundle(*file, master, data, *filePassword, *expectSigner)
+		return
+	}
+
+	var recs []pwmanager.Record
+	var skipped []string
+	var source string
+	switch *format {
+	case "bitwarden":
+		exp, err := bitwarden.Parse(data, *filePassword)
+		if errors.Is(err, bitwarden.ErrPasswordRequired) {
+			failf(exitUsage, "this export is password protected; pass --password")
+		}
+		check(err, "parse")
+		recs, source = exp.Records(), "Bitwarden"
+	case "kdbx":
+		db, err := kdbx.Decode(data, kdbxCredentials(*filePassword, *keyFile))
+		check(err, "open KeePass database")
+		recs, source = db.Records(), "KeePass"
+	case "1pux":
+		exp, err := onepassword.Parse(data)
+		check(err, "parse")
+		recs = exp.Records()
+		skipped, source = exp.Skipped, "1Password"
+	case "lastpass":
+		exp, err := lastpass.Parse(data)
+		check(err, "parse")
+		recs = exp.Records()
+		skipped, source = exp.Skipped, "LastPass"
+	default:
+		failf(exitUsage, "unknown --format: %s", *format)
+	}
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	_, err = v.ImportRecords(key, recs)
+	check(err, "import")
+	check(v.Save(*file), "save")
+	sum := pwmanager.Summarize(source, recs, skipped)
+	emit(newImportResult(sum), func() { fmt.Print(sum) })
+}
+
+// importResult reports import.
+type importResult struct {
+	Source      string         `json:"source"`
+	Imported    int            `json:"imported"`
+	ByKind      map[string]int `json:"byKind"` // login, note, card or identity
+	Folders     int            `json:"folders"`
+	Fields      int            `json:"fields"`
+	Attachments int            `json:"attachments"`
+	Skipped     []string       `json:"skipped"` // why each item that was not imported was left out
+	Bundle      *bundleInfo    `json:"bundle,omitempty"`
+}
+
+// bundleInfo describes an imported bundle.
+type bundleInfo struct {
+	Created time.Time `json:"created"`
+	Comment string    `json:"comment,omitempty"`
+	Signer  string    `json:"signer"` // fingerprint of the verified signing key
+}
+
+func newImportResult(s pwmanager.ImportSummary) importResult {
+	r := importResult{
+		Source: s.Source, Imported: s.Total, ByKind: map[string]int{},
+		Folders: s.Folders, Fields: s.Fields, Attachments: s.Attachments, Skipped: s.Skipped,
+	}
+	for kind, n := range s.ByKind {
+		if kind == pwmanager.KindLogin {
+			kind = "login"
+		}
+		r.ByKind[kind] = n
+	}
+	if r.Skipped == nil {
+		r.Skipped = []string{}
+	}
+	return r
+}
+
+// exportResult reports export.
+type exportResult struct {
+	File    string `json:"file"`
+	Format  string `json:"format"` // bitwarden, kdbx or bundle
+	Entries int    `json:"entries"`
+	Signer  string `json:"signer,omitempty"` // bundles: fingerprint of the signing key
+}
+
+func cmdExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	format := fs.String("format", "bitwarden", "target format: bitwarden or kdbx")
+	out := fs.String("out", "", "file to write")
+	filePassword := fs.String("password", "", "encrypt the export with this password (recommended; required for kdbx)")
+	keyFile := fs.String("keyfile", "", "KeePass key file to require in addition to the password (kdbx only)")
+	kdf := fs.String("kdf", "", "KDF: pbkdf2 or argon2id (bitwarden), argon2d, argon2id or aes (kdbx)")
+	iterations := fs.Int("iterations", 0, "KDF iterations (default: format default)")
+	isBundle := fs.Bool("bundle", false, "write a signed, encrypted pwmanager bundle (ignores --format)")
+	recipient := fs.String("recipient", "", "bundle: encrypt to this X25519 public key (base64) instead of --password")
+	ids := fs.String("id", "", "bundle: comma-separated entry IDs to include")
+	search := fs.String("search", "", "bundle: include entries whose title contains this text")
+	tag := fs.String("tag", "", "bundle: include entries with this tag (comma-separated for several)")
+	all := fs.Bool("all", false, "bundle: include every entry")
+	comment := fs.String("comment", "", "bundle: note stored in the manifest")
+	fs.Parse(args)
+	require(*out != "", "out")
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	recs, err := v.Records(key)
+	check(err, "decrypt")
+	if pwmanager.HasDerived(recs) {
+		dk, err := derivationKey(master)
+		check(err, "derive")
+		defer dk.Destroy()
+		check(pwmanager.ResolveDerivedRecords(recs, dk), "derive")
+	}
+
+	if *isBundle {
+		sel := pwmanager.Selector{IDs: splitList(*ids), Title: *search, Tags: splitList(*tag)}
+		if !*all {
+			if sel.Empty() {
+				failf(exitUsage, "select entries with --id, --search or --tag (or pass --all)")
+			}
+			recs = pwmanager.Select(recs, sel)
+		}
+		exportBundle(key, recs, *out, *filePassword, *recipient, *comment)
+		return
+	}
+
+	var data []byte
+	switch *format {
+	case "bitwarden":
+		exp := bitwarden.FromRecords(recs)
+		if *filePassword == "" {
+			fmt.Fprintln(os.Stderr, "warning: writing an UNENCRYPTED export; use --password to protect it")
+			data, err = exp.Marshal()
+			break
+		}
+		cfg := bitwarden.DefaultPBKDF2
+		switch *kdf {
+		case "", "pbkdf2":
+		case "argon2id":
+			cfg = bitwarden.DefaultArgon2id
+		default:
+			failf(exitUsage, "unknown --kdf: %s", *kdf)
+		}
+		if *iterations > 0 {
+			cfg.Iterations = *iterations
+		}
+		data, err = exp.MarshalEncrypted(*filePassword, cfg)
+	case "kdbx":
+		require(*filePassword != "", "password")
+		opts := kdbx.DefaultOptions
+		switch *kdf {
+		case "", "argon2d":
+		case "argon2id":
+			opts.KDF = kdbx.Argon2id
+		case "aes":
+			opts.KDF, opts.Iterations = kdbx.AESKDF, 2000000
+		default:
+			failf(exitUsage, "unknown --kdf: %s", *kdf)
+		}
+		if *iterations > 0 {
+			opts.Iterations = uint64(*iterations)
+		}
+		var db *kdbx.Database
+		db, err = kdbx.FromRecords("pwmanager export", recs)
+		check(err, "convert")
+		data, err = db.Encode(kdbxCredentials(*filePassword, *keyFile), opts)
+	default:
+		failf(exitUsage, "unknown --format: %s", *format)
+	}
+	check(err, "encode")
+	check(os.WriteFile(*out, data, 0600), "write")
+	emit(exportResult{File: *out, Format: *format, Entries: len(recs)}, func() {
+		fmt.Printf("exported %d entries to %s\n", len(recs), *out)
+	})
+}
+
+// kdbxCredentials reads the optional key file and pairs it with the password.
+func kdbxCredentials(password, keyFile string) kdbx.Credentials {
+	creds := kdbx.Credentials{Password: password}
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		check(err, "read key file")
+		creds.KeyFile = data
+	}
+	return creds
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// injectResult is the result of inject when it writes a file or checks.
+type injectResult struct {
+	Template string     `json:"template"`
+	Out      string     `json:"out,omitempty"` // empty under --check
+	Refs     []refCheck `json:"refs"`
+}
+
+// refCheck is one reference of a template, as found in the vault.
+type refCheck struct {
+	Ref     string `json:"ref"`
+	ID      string `json:"id,omitempty"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// cmdInject renders a text/template with vault fields in it, so config files
+// can be kept in the repository with references where the secrets go:
+//
+//	password: {{ vault "Prod DB" "password" }}
+//	token: {{ ref "vault://CI/token" }}
+//
+// A reference to an entry or field that does not exist renders as nothing,
+// with a warning, unless --strict is given.
+func cmdInject(args []string) {
+	fs := flag.NewFlagSet("inject", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	in := fs.String("i", "", "template to render")
+	out := fs.String("o", "-", "file to write (mode 0600), or - for stdout")
+	strict := fs.Bool("strict", false, "fail on references to entries or fields that do not exist")
+	checkOnly := fs.Bool("check", false, "only check that every reference names one entry; nothing is decrypted")
+	fs.Parse(args)
+	require(*in != "", "i")
+
+	text, err := os.ReadFile(*in)
+	check(err, "template")
+	if *checkOnly {
+		checkTemplate(*file, *in, string(text))
+		return
+	}
+
+	v, key := openVault(*file, master)
+	var refs []refCheck
+	var buf bytes.Buffer
+	defer func() { clear(buf.Bytes()) }()
+	err = render(&buf, *in, string(text), vaultLookup(newResolver(v, key, master), *strict, &refs))
+	key.Destroy()
+	agentDerivKey.Destroy()
+	check(err, "inject")
+
+	if *out == "-" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	check(writePrivate(*out, buf.Bytes()), "write")
+	emit(injectResult{Template: *in, Out: *out, Refs: refs}, func() {
+		fmt.Printf("wrote %s (%d references)\n", *out, len(refs))
+	})
+}
+
+// render executes the template text with the lookup functions: vault TITLE
+// [FIELD] and ref "vault://TITLE/FIELD". On failure w is wiped and emptied,
+// since it may hold the secrets rendered before the error.
+func render(w *bytes.Buffer, name, text string, lookup func(secretRef) (string, error)) error {
+	funcs := template.FuncMap{
+		"vault": func(entry string, field ...string) (string, error) {
+			ref := secretRef{Entry: entry, Field: "password"}
+			switch len(field) {
+			case 0:
+			case 1:
+				ref.Field = field[0]
+			default:
+				return "", fmt.Errorf("vault takes a title and at most one field, not %d fields", len(field))
+			}
+			if ref.Entry == "" || ref.Field == "" {
+				return "", fmt.Errorf("vault %q %q: want a title and a field", entry, ref.Field)
+			}
+			return lookup(ref)
+		},
+		"ref": func(s string) (string, error) {
+			ref, ok, err := parseRef(s)
+			if !ok {
+				err = fmt.Errorf("ref %q: want %sTITLE/FIELD", s, refScheme)
+			}
+			if err != nil {
+				return "", err
+			}
+			return lookup(ref)
+		},
+	}
+	t, err := template.New(filepath.Base(name)).Funcs(funcs).Parse(text)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(w, nil); err != nil {
+		clear(w.Bytes())
+		w.Reset()
+		return err
+	}
+	return nil
+}
+
+// vaultLookup resolves references for render and records them in refs. A
+// reference to an entry or field that does not exist is an error with
+// strict, and otherwise renders as nothing with a warning.
+func vaultLookup(r *resolver, strict bool, refs *[]refCheck) func(secretRef) (string, error) {
+	return func(ref secretRef) (string, error) {
+		value, err := r.resolve(ref)
+		switch {
+		case err == nil:
+			*refs = append(*refs, refCheck{Ref: ref.String()})
+			return value, nil
+		case errors.Is(err, pwmanager.ErrNotFound) && !strict:
+			fmt.Fprintln(os.Stderr, "warning:", err)
+			*refs = append(*refs, refCheck{Ref: ref.String(), Problem: err.Error()})
+			return "", nil
+		}
+		return "", err
+	}
+}
+
+// checkTemplate runs the template against the titles of the vault only, and
+// reports every reference that does not name exactly one entry. Fields are
+// encrypted with the rest of the entry, so they are not checked.
+func checkTemplate(file, name, text string) {
+	v, err := pwmanager.Load(file)
+	check(err, "load")
+	refs, code, err := checkRefs(v, name, text)
+	check(err, "inject")
+	emit(injectResult{Template: name, Refs: refs}, func() {
+		for _, c := range refs {
+			if c.Problem != "" {
+				fmt.Println("unresolved:", c.Problem)
+			} else {
+				fmt.Printf("ok: %s (%s)\n", c.Ref, c.ID)
+			}
+		}
+		fmt.Printf("%d references checked\n", len(refs))
+	})
+	if code != 0 {
+		os.Exit(code)
+	}
+}
+
+// checkRefs finds the entry of every reference in the template, and returns
+// them with the exit code of the first that does not name exactly one.
+func checkRefs(v *pwmanager.Vault, name, text string) ([]refCheck, int, error) {
+	var refs []refCheck
+	code := 0
+	lookup := func(ref secretRef) (string, error) {
+		c := refCheck{Ref: ref.String()}
+		id, err := findRef(v, ref)
+		if err != nil {
+			c.Problem = err.Error()
+			if code == 0 {
+				code = exitCode(err)
+			}
+		}
+		c.ID = id
+		refs = append(refs, c)
+		return "", nil
+	}
+	var discard bytes.Buffer
+	err := render(&discard, name, text, lookup)
+	return refs, code, err
+}
+
+// writePrivate writes data to path with mode 0600. It goes through a
+// temporary file, which CreateTemp makes private, so an existing file with a
+// wider mode is replaced rather than written into, and a failed write leaves
+// the old file as it was.
+func writePrivate(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testVault returns an unlocked vault with a database login, two entries
+// titled GitHub and a derived entry.
+func testVault(t *testing.T) (*pwmanager.Vault, *secret.Buffer) {
+	t.Helper()
+	v, key, err := pwmanager.Create("test-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(key.Destroy)
+	for _, e := range []struct{ title, user, pw, url string }{
+		{"Prod DB", "dbuser", "dbpass", "postgres://db.example.com"},
+		{"GitHub", "alice", "gh-alice", "https://github.com"},
+		{"GitHub", "bob", "gh-bob", "https://github.com"},
+	} {
+		if _, err := v.AddEntry(key, e.title, e.user, e.pw, e.url, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := v.AddDerivedEntry(key, "Derived", pwmanager.DefaultDerivedParams("example.com", "carol"), "", ""); err != nil {
+		t.Fatal(err)
+	}
+	return v, key
+}
+
+func TestInjectRender(t *testing.T) {
+	v, key := testVault(t)
+	for _, tt := range []struct {
+		name     string
+		tmpl     string
+		strict   bool
+		want     string
+		problems int
+		fails    bool
+		is       error // what a failure wraps, if anything
+	}{
+		{"fields", `{{ vault "Prod DB" "username" }}:{{ vault "prod db" }}@{{ ref "vault://Prod DB/url" }}`, true,
+			"dbuser:dbpass@postgres://db.example.com", 0, false, nil},
+		{"missing entry, lenient", `a{{ vault "Nope" }}b`, false, "ab", 1, false, nil},
+		{"missing entry, strict", `a{{ vault "Nope" }}b`, true, "", 0, true, pwmanager.ErrNotFound},
+		{"missing field, lenient", `a{{ ref "vault://Prod DB/port" }}b`, false, "ab", 1, false, nil},
+		{"missing field, strict", `a{{ ref "vault://Prod DB/port" }}b`, true, "", 0, true, pwmanager.ErrNotFound},
+		{"ambiguous title, lenient", `{{ vault "GitHub" }}`, false, "", 0, true, errAmbiguous},
+		{"bad reference", `{{ ref "https://example.com" }}`, false, "", 0, true, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var refs []refCheck
+			var buf bytes.Buffer
+			err := render(&buf, "test.tmpl", tt.tmpl, vaultLookup(newResolver(v, key, knownMaster("test-master")), tt.strict, &refs))
+			switch {
+			case !tt.fails && err != nil:
+				t.Fatalf("render: %v", err)
+			case tt.fails && err == nil:
+				t.Fatalf("render = %q, want an error", buf.String())
+			case tt.is != nil && !errors.Is(err, tt.is):
+				t.Fatalf("render: %v, want %v", err, tt.is)
+			}
+			if tt.fails {
+				return
+			}
+			if buf.String() != tt.want {
+				t.Errorf("rendered %q, want %q", buf.String(), tt.want)
+			}
+			problems := 0
+			for _, c := range refs {
+				if c.Problem != "" {
+					problems++
+				}
+			}
+			if problems != tt.problems {
+				t.Errorf("%d problems in %+v, want %d", problems, refs, tt.problems)
+			}
+		})
+	}
+
+	// a derived password is computed from the vault
+	var refs []refCheck
+	var buf bytes.Buffer
+	if err := render(&buf, "test.tmpl", `{{ vault "Derived" }}`, vaultLookup(newResolver(v, key, knownMaster("test-master")), true, &refs)); err != nil || buf.Len() != 16 {
+		t.Errorf("derived password rendered as %q, %v", buf.String(), err)
+	}
+
+	// what was rendered before a failure is wiped
+	buf.Reset()
+	if err := render(&buf, "test.tmpl", `{{ vault "Prod DB" }}{{ vault "Nope" }}`, vaultLookup(newResolver(v, key, knownMaster("test-master")), true, &refs)); err == nil {
+		t.Fatal("rendered a missing entry with strict")
+	}
+	if all := buf.AvailableBuffer(); buf.Len() != 0 || bytes.Contains(all[:cap(all)], []byte("dbpass")) {
+		t.Errorf("buffer holds %q after a failed render", all[:cap(all)])
+	}
+}
+
+func TestInjectCheck(t *testing.T) {
+	v, _ := testVault(t)
+	for _, tt := range []struct {
+		tmpl string
+		code int
+		bad  []int // indexes of the refs with a problem
+	}{
+		{`{{ vault "Prod DB" }}{{ ref "vault://Derived/username" }}`, 0, nil},
+		{`{{ vault "Prod DB" }}{{ vault "GitHub" }}{{ vault "Nope" }}`, exitAmbiguous, []int{1, 2}},
+		{`{{ vault "Nope" }}{{ vault "GitHub" "username" }}`, exitNotFound, []int{0, 1}},
+		// fields are sealed with the entry, so a missing one is not seen
+		{`{{ vault "Prod DB" "port" }}`, 0, nil},
+	} {
+		refs, code, err := checkRefs(v, "test.tmpl", tt.tmpl)
+		if err != nil || code != tt.code || len(refs) != strings.Count(tt.tmpl, "{{") {
+			t.Errorf("%s: %+v, code %d, %v; want code %d", tt.tmpl, refs, code, err, tt.code)
+			continue
+		}
+		var bad []int
+		for i, c := range refs {
+			if c.Problem != "" {
+				bad = append(bad, i)
+			} else if c.ID == "" {
+				t.Errorf("%s: %s resolved without an id", tt.tmpl, c.Ref)
+			}
+		}
+		if !slices.Equal(bad, tt.bad) {
+			t.Errorf("%s: problems at %v, want %v", tt.tmpl, bad, tt.bad)
+		}
+	}
+	if refs, _, _ := checkRefs(v, "test.tmpl", `{{ vault "GitHub" }}`); len(refs) != 1 || !strings.Contains(refs[0].Problem, "2 entries") {
+		t.Errorf("ambiguous title reported as %+v", refs)
+	}
+}
+
+func TestWritePrivate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.env")
+	if err := os.WriteFile(path, []byte("old contents, readable by all\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writePrivate(path, []byte("DB_PASSWORD=dbpass\n")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "DB_PASSWORD=dbpass\n" {
+		t.Errorf("wrote %q, %v", data, err)
+	}
+	if runtime.GOOS != "windows" && runtime.GOOS != "plan9" {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Errorf("mode = %v, want 0600", fi.Mode().Perm())
+		}
+	}
+	if names, _ := os.ReadDir(dir); len(names) != 1 {
+		t.Errorf("left temporary files behind: %v", names)
+	}
+
+	if err := writePrivate(filepath.Join(dir, "missing", "app.env"), nil); err == nil {
+		t.Error("wrote into a missing directory")
+	}
+}
@@ -1,326 +1,373 @@
-package main
-
-import (
-	"appliedcryptography-starter-kit/internal/pwmanager"
-	"bufio"
-	"flag"
-	"fmt"
-	"os"
-	"strings"
-)
-
-func usage() {
-	fmt.Print(`Usage:
-  go run ./cmd/starterkit --help
-  go run ./cmd/starterkit init   --file vault.json --master MASTER
-  go run ./cmd/starterkit add    --file vault.json --master MASTER --title "GitHub" --username "alice" --password "S3cret!" [--url ...] [--notes ...]
-  go run ./cmd/starterkit list   --file vault.json
-  go run ./cmd/starterkit show   --file vault.json --master MASTER (--id ENTRY_ID | --title "GitHub")
-  go run ./cmd/starterkit ui     --file vault.json          (interactive menu)
-`)
-}
-
-func main() {
-	if len(os.Args) < 2 || os.Args[1] == "--help" || os.Args[1] == "-h" {
-		usage()
-		return
-	}
-	switch os.Args[1] {
-	case "init":
-		cmdInit(os.Args[2:])
-	case "add":
-		cmdAdd(os.Args[2:])
-	case "list":
-		cmdList(os.Args[2:])
-	case "show":
-		cmdShow(os.Args[2:])
-	case "ui":
-		cmdUI(os.Args[2:])
-	default:
-		usage()
-	}
-}
-
-func cmdInit(args []string) {
-	fs := flag.NewFlagSet("init", flag.ExitOnError)
-	file := fs.String("file", "vault.json", "path to vault file")
-	master := fs.String("master", "", "master password (plain)")
-	fs.Parse(args)
-	require(*master != "", "master")
-	v, _, err := pwmanager.Create(*master)
-	check(err, "init")
-	check(v.Save(*file), "save")
-	fmt.Println("vault created at", *file)
-}
-
-func cmdAdd(args []string) {
-	fs := flag.NewFlagSet("add", flag.ExitOnError)
-	file := fs.String("file", "vault.json", "path to vault file")
-	master := fs.String("master", "", "master password (plain)")
-	title := fs.String("title", "", "title")
-	username := fs.String("username", "", "username")
-	password := fs.String("password", "", "password")
-	url := fs.String("url", "", "optional URL")
-	notes := fs.String("notes", "", "optional notes")
-	fs.Parse(args)
-	require(*master != "", "master")
-	require(*title != "", "title")
-	require(*username != "", "username")
-	require(*password != "", "password")
-
-	v, err := pwmanager.Load(*file)
-	check(err, "load")
-	key, err := v.Unlock(*master)
-	check(err, "unlock (check master password)")
-	id, err := v.AddEntry(key, *title, *username, *password, *url, *notes)
-	check(err, "add entry")
-	check(v.Save(*file), "save")
-	fmt.Println("added entry id:", id)
-}
-
-func cmdList(args []string) {
-	fs := flag.NewFlagSet("list", flag.ExitOnError)
-	file := fs.String("file", "vault.json", "path to vault file")
-	fs.Parse(args)
-	v, err := pwmanager.Load(*file)
-	check(err, "load")
-	entries := v.List()
-	if len(entries) == 0 {
-		fmt.Println("(empty)")
-		return
-	}
-	fmt.Println("ID                                   | Title         | Modified")
-	fmt.Println(strings.Repeat("-", 88))
-	for _, e := range entries {
-		fmt.Printf("%-35s | %-13s | %s\n", e.ID, e.Title, e.ModifiedAt.Format("2006-01-02 15:04:05"))
-	}
-}
-
-func cmdShow(args []string) {
-	fs := flag.NewFlagSet("show", flag.ExitOnError)
-	file := fs.String("file", "vault.json", "path to vault file")
-	master := fs.String("master", "", "master password (plain)")
-	id := fs.String("id", "", "entry id")
-	title := fs.String("title", "", "entry title (case-insensitive; allows partial match)")
-	fs.Parse(args)
-	require(*master != "", "master")
-	if *id == "" && strings.TrimSpace(*title) == "" {
-		fmt.Println("provide either --id or --title")
-		os.Exit(1)
-	}
-
-	v, err := pwmanager.Load(*file)
-	check(err, "load")
-	key, err := v.Unlock(*master)
-	check(err, "unlock")
-
-	// resolve id from title if needed
-	targetID := *id
-	if targetID == "" {
-		// prefer exact title; otherwise substring search
-		exact := v.FindByExactTitle(*title)
-		candidates := exact
-		if len(candidates) == 0 {
-			candidates = v.SearchTitles(*title)
-		}
-		if len(candidates) == 0 {
-			fmt.Println("no entry found matching title:", *title)
-			os.Exit(1)
-		}
-		if len(candidates) > 1 {
-			// ask user to choose
-			fmt.Println("Multiple matches:")
-			for i, e := range candidates {
-				fmt.Printf("  [%d] %-12s | %s | %s\n", i+1, e.Title, e.ID, e.ModifiedAt.Format("2006-01-02 15:04:05"))
-			}
-			fmt.Print("Pick number: ")
-			var n int
-			_, scanErr := fmt.Scanf("%d", &n)
-			if scanErr != nil || n < 1 || n > len(candidates) {
-				fmt.Println("invalid selection")
-				os.Exit(1)
-			}
-			targetID = candidates[n-1].ID
-		} else {
-			targetID = candidates[0].ID
-		}
-	}
-
-	plain, meta, err := v.GetDecrypted(key, targetID)
-	check(err, "get")
-	fmt.Println("Title:   ", meta.Title)
-	fmt.Println("Username:", plain.Username)
-	fmt.Println("Password:", plain.Password)
-	if plain.URL != "" {
-		fmt.Println("URL:     ", plain.URL)
-	}
-	if plain.Notes != "" {
-		fmt.Println("Notes:   ", plain.Notes)
-	}
-}
-
-func cmdUI(args []string) {
-	fs := flag.NewFlagSet("ui", flag.ExitOnError)
-	file := fs.String("file", "vault.json", "path to vault file")
-	fs.Parse(args)
-
-	v, err := pwmanager.Load(*file)
-	check(err, "load")
-
-	in := bufio.NewReader(os.Stdin)
-	fmt.Println("=== Simple Password Manager ===")
-	master := promptLine(in, "Enter master password (not hidden): ")
-	key, err := v.Unlock(master)
-	if err != nil {
-		fmt.Println("Unlock failed:", err)
-		return
-	}
-	fmt.Println("Unlocked ✔")
-
-	for {
-		fmt.Println()
-		fmt.Println("[A]dd  [L]ist  [S]how(by title)  [D]elete  [Q]uit")
-		choice := strings.ToLower(promptLine(in, "> "))
-
-		switch choice {
-		case "a", "add":
-			title := promptLine(in, "Title: ")
-			username := promptLine(in, "Username: ")
-			password := promptLine(in, "Password: ")
-			url := promptLine(in, "URL (optional): ")
-			notes := promptLine(in, "Notes (optional): ")
-			id, err := v.AddEntry(key, title, username, password, url, notes)
-			if err != nil {
-				fmt.Println("Add error:", err)
-				continue
-			}
-			if err := v.Save(*file); err != nil {
-				fmt.Println("Save error:", err)
-				continue
-			}
-			fmt.Println("Added. ID:", id)
-
-		case "l", "list":
-			entries := v.List()
-			if len(entries) == 0 {
-				fmt.Println("(empty)")
-				continue
-			}
-			fmt.Println("ID                                   | Title         | Modified")
-			fmt.Println(strings.Repeat("-", 88))
-			for _, e := range entries {
-				fmt.Printf("%-35s | %-13s | %s\n", e.ID, e.Title, e.ModifiedAt.Format("2006-01-02 15:04:05"))
-			}
-
-		case "s", "show":
-			query := promptLine(in, "Title (partial ok): ")
-			cands := v.FindByExactTitle(query)
-			if len(cands) == 0 {
-				cands = v.SearchTitles(query)
-			}
-			if len(cands) == 0 {
-				fmt.Println("No match.")
-				continue
-			}
-			if len(cands) > 1 {
-				fmt.Println("Multiple matches:")
-				for i, e := range cands {
-					fmt.Printf("  [%d] %-12s | %s\n", i+1, e.Title, e.ID)
-				}
-				num := promptLine(in, "Pick number: ")
-				i := atoiSafe(num)
-				if i < 1 || i > len(cands) {
-					fmt.Println("Invalid selection.")
-					continue
-				}
-				showOne(v, key, cands[i-1].ID)
-			} else {
-				showOne(v, key, cands[0].ID)
-			}
-
-		case "d", "delete":
-			query := promptLine(in, "Title (partial ok): ")
-			cands := v.FindByExactTitle(query)
-			if len(cands) == 0 {
-				cands = v.SearchTitles(query)
-			}
-			if len(cands) == 0 {
-				fmt.Println("No match.")
-				continue
-			}
-			var target string
-			if len(cands) > 1 {
-				for i, e := range cands {
-					fmt.Printf("  [%d] %-12s | %s\n", i+1, e.Title, e.ID)
-				}
-				num := promptLine(in, "Pick number to delete: ")
-				i := atoiSafe(num)
-				if i < 1 || i > len(cands) {
-					fmt.Println("Invalid selection.")
-					continue
-				}
-				target = cands[i-1].ID
-			} else {
-				target = cands[0].ID
-			}
-			if ok := v.Delete(target); !ok {
-				fmt.Println("No such entry.")
-				continue
-			}
-			if err := v.Save(*file); err != nil {
-				fmt.Println("Save error:", err)
-				continue
-			}
-			fmt.Println("Deleted.")
-
-		case "q", "quit":
-			fmt.Println("Bye!")
-			return
-
-		default:
-			fmt.Println("Unknown choice.")
-		}
-	}
-}
-
-func showOne(v *pwmanager.Vault, key []byte, id string) {
-	plain, meta, err := v.GetDecrypted(key, id)
-	if err != nil {
-		fmt.Println("Show error:", err)
-		return
-	}
-	fmt.Println("Title:   ", meta.Title)
-	fmt.Println("Username:", plain.Username)
-	fmt.Println("Password:", plain.Password)
-	if plain.URL != "" {
-		fmt.Println("URL:     ", plain.URL)
-	}
-	if plain.Notes != "" {
-		fmt.Println("Notes:   ", plain.Notes)
-	}
-}
-
-func promptLine(in *bufio.Reader, label string) string {
-	fmt.Print(label)
-	text, _ := in.ReadString('\n')
-	return strings.TrimSpace(text)
-}
-
-func atoiSafe(s string) int {
-	var n int
-	fmt.Sscanf(strings.TrimSpace(s), "%d", &n)
-	return n
-}
-
-func require(ok bool, name string) {
-	if !ok {
-		fmt.Printf("missing --%s\n", name)
-		os.Exit(1)
-	}
-}
-func check(err error, where string) {
-	if err != nil {
-		fmt.Printf("%s error: %v\n", where, err)
-		os.Exit(1)
-	}
-}
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/passwordrules"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/search"
+	"appliedcryptography-starter-kit/internal/secret"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func usage() {
+	fmt.Print(`Usage:
+  go run ./cmd/starterkit --help
+  go run ./cmd/starterkit init   --file vault.json
+  go run ./cmd/starterkit add    --file vault.json --title "GitHub" --username "alice" --password "S3cret!" [--url ...] [--notes ...] [--hibp DUMP]
+  go run ./cmd/starterkit list   --file vault.json
+  go run ./cmd/starterkit show   --file vault.json (--id ENTRY_ID | --title "GitHub" | --search "user:alice url:github") [--clip]
+  go run ./cmd/starterkit copy   --file vault.json [--id ENTRY_ID | --title "GitHub"] [--field password|username|url|notes|totp|FIELD] [--clip-clear 30s]
+  go run ./cmd/starterkit otp    --file vault.json [--id ENTRY_ID | --title "GitHub"] [--clip]   (the current TOTP code)
+  go run ./cmd/starterkit search alice github --file vault.json [--limit 20]   (all fields; narrow with user:, url:, notes:, tag:)
+  go run ./cmd/starterkit edit   --file vault.json [--id ENTRY_ID | --title "GitHub"] [--username ...] [--password ... | --generate [generate flags]] [--url ...] [--notes ...]
+  go run ./cmd/starterkit mv     --file vault.json [--id ENTRY_ID | --title "GitHub"] --to "GitHub (work)"   (alias: rename)
+  go run ./cmd/starterkit rm     --file vault.json [--id ENTRY_ID | --title "GitHub"] [--purge [--yes]]   (moves to the trash unless --purge)
+  go run ./cmd/starterkit trash  list | restore [--id ENTRY_ID | --title "GitHub"] | empty [--older-than DAYS]   --file vault.json
+  go run ./cmd/starterkit passwd --file vault.json [--new-master-stdin | --new-master-fd N | --new-master-file FILE] [--force]
+  go run ./cmd/starterkit ui     --file vault.json [--idle 5m] [--clip-clear 30s]   (full-screen terminal ui)
+  go run ./cmd/starterkit run    --file vault.json --env DB_PASS="vault://Prod DB/password" [--env-file FILE] [--mask] -- ./deploy.sh ARGS...
+                                 (exits with the command's status, 127 if it cannot be started)
+  go run ./cmd/starterkit inject --file vault.json -i app.yml.tmpl [-o app.yml] [--strict] [--check]
+                                 (renders {{ vault "Prod DB" "password" }} and {{ ref "vault://Prod DB/password" }})
+  go run ./cmd/starterkit git-credential [--file vault.json] [--folder git] get|store|erase
+                                 (a git credential helper: link starterkit as git-credential-starterkit on the PATH,
+                                  then git config --global credential.helper "starterkit git-credential")
+  go run ./cmd/starterkit agent  unlock --file vault.json | lock | status
+                                 (talks to pwagent, started with: eval "$(go run ./cmd/pwagent)";
+                                  while it is unlocked, the commands below need no master password)
+  go run ./cmd/starterkit match  https://eu.app.example.co.uk/login --file vault.json [--reveal [--force]] [--psl public_suffix_list.dat]
+  go run ./cmd/starterkit match  --file vault.json --set domain|host|startswith|exact|regex|never (--id ENTRY_ID | --title "GitHub")
+  go run ./cmd/starterkit generate [--length 16] [--upper=false] [--lower=false] [--digits=false] [--symbols=false] [--exclude-similar] [--exclude-ambiguous] [--count N | --clip]
+  go run ./cmd/starterkit generate --words 6 [--wordlist eff-large|eff-short|FILE] [--separator -] [--capitalize none|first|all|random] [--digit] [--symbol]
+  go run ./cmd/starterkit generate --rules "minlength: 8; maxlength: 16; required: digit;" | --url https://example.com [--rules-db password-rules.json] [--length N]
+  go run ./cmd/starterkit derive --site github.com --login alice [--counter 1] [--length 16] [--symbols=false] [--file vault.json --add "GitHub"]
+  go run ./cmd/starterkit derive --file vault.json --rotate (--id ENTRY_ID | --title "GitHub")
+  go run ./cmd/starterkit expiry --file vault.json (--id ENTRY_ID | --title "GitHub" | --tag TAG) (--days 90 | --expires 2026-12-31 | --clear)
+  go run ./cmd/starterkit due    --file vault.json [--within 14]   (exits 9 if any rotation is overdue)
+  go run ./cmd/starterkit rotate --file vault.json (--id ENTRY_ID | --title "GitHub") [--length 20] [--confirm | --cancel]
+  go run ./cmd/starterkit import --file vault.json --format bitwarden --in export.json [--password FILEPASS]
+  go run ./cmd/starterkit export --file vault.json --format bitwarden --out export.json [--password FILEPASS] [--kdf pbkdf2|argon2id]
+  go run ./cmd/starterkit import --file vault.json --format kdbx --in db.kdbx --password DBPASS [--keyfile db.keyx]
+  go run ./cmd/starterkit import --file vault.json --format 1pux --in export.1pux
+  go run ./cmd/starterkit import --file vault.json --format lastpass --in lastpass.csv
+  go run ./cmd/starterkit export --file vault.json --format kdbx --out db.kdbx --password DBPASS [--keyfile db.keyx] [--kdf argon2d|argon2id|aes]
+  go run ./cmd/starterkit export --file vault.json --bundle --out audit.pwb (--id ID,... | --search TEXT | --tag TAG | --all) (--password EXPORTPASS | --recipient X25519KEY) [--comment ...]
+  go run ./cmd/starterkit import --file vault.json --bundle --in audit.pwb [--password EXPORTPASS] [--expect-signer FINGERPRINT]
+  go run ./cmd/starterkit report --file vault.json [--format table|json|html] [--out report.html] [--max-age DAYS] [--all] [--hibp DUMP]
+  go run ./cmd/starterkit hibp-index --dump pwned-passwords-sha1.txt   (prebuild the lookup index for a sorted HIBP dump)
+  go run ./cmd/starterkit identity --file vault.json   (signing fingerprint and bundle recipient key)
+  go run ./cmd/starterkit schema [COMMAND]   (JSON Schema of the --output json result of a command, or of all)
+  go run ./cmd/starterkit completion bash|zsh|fish   (e.g. source <(starterkit completion bash); completes titles and ids from the vault)
+
+Without --id or --title, commands that act on one entry ask which one at the
+terminal; an exact title shared by several entries is asked about the same way.
+
+Every command takes --output table|json|yaml|tsv. In the machine formats the
+result goes to stdout (see schema), errors are printed there as
+{"error": {"code", "kind", "where", "message"}}, and nothing is prompted for
+except the master password. Exit codes: 0 success, 1 other error, 2 usage,
+3 wrong master password, 4 not found, 5 ambiguous match, 6 conflict with the
+vault's state, 7 corrupt vault, 8 refused for safety (reveal on a lookalike page,
+bundle from an unexpected signer), 9 rotation overdue.
+
+copy, show --clip and generate --clip put the value on the clipboard instead of
+printing it: wl-copy on Wayland, xclip or xsel on X11, pbcopy on macOS, else
+OSC 52 through the terminal (set $STARTERKIT_CLIPBOARD to wayland, x11, macos,
+osc52 or file:PATH to choose). It is cleared after --clip-clear (30s) unless
+something else was copied meanwhile.
+
+The master password is prompted for without echo (twice for init), or read from
+the first line of --master-stdin, --master-fd N or --master-file FILE (mode 0600),
+or with --master-env from $STARTERKIT_MASTER. --master MASTER still works but is
+deprecated: it leaks into shell history and ps.
+`)
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "--help" || os.Args[1] == "-h" {
+		usage()
+		return
+	}
+	if strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe") == gitHelperName {
+		// credential.helper "starterkit git-credential" runs us as
+		// "git-credential-starterkit git-credential ACTION"
+		args := os.Args[1:]
+		if len(args) > 0 && args[0] == "git-credential" {
+			args = args[1:]
+		}
+		cmdGitCredential(args)
+		return
+	}
+	if os.Args[1] == "__complete" {
+		// before takeOutputFlag: the words being completed are not ours to check
+		cmdComplete(os.Args[2:])
+		return
+	}
+	args := takeOutputFlag(os.Args[2:])
+	switch os.Args[1] {
+	case "init":
+		cmdInit(args)
+	case "add":
+		cmdAdd(args)
+	case "list":
+		cmdList(args)
+	case "show":
+		cmdShow(args)
+	case "search":
+		cmdSearch(args)
+	case "copy":
+		cmdCopy(args)
+	case "otp":
+		cmdOTP(args)
+	case "clip-clear":
+		cmdClipClear(args)
+	case "edit":
+		cmdEdit(args)
+	case "mv", "rename":
+		cmdMv(args)
+	case "rm":
+		cmdRm(args)
+	case "trash":
+		cmdTrash(args)
+	case "passwd":
+		cmdPasswd(args)
+	case "ui":
+		cmdUI(args)
+	case "run":
+		cmdRun(args)
+	case "inject":
+		cmdInject(args)
+	case "git-credential":
+		cmdGitCredential(args)
+	case "agent":
+		cmdAgent(args)
+	case "match":
+		cmdMatch(args)
+	case "generate":
+		cmdGenerate(args)
+	case "derive":
+		cmdDerive(args)
+	case "expiry":
+		cmdExpiry(args)
+	case "due":
+		cmdDue(args)
+	case "rotate":
+		cmdRotate(args)
+	case "import":
+		cmdImport(args)
+	case "export":
+		cmdExport(args)
+	case "report":
+		cmdReport(args)
+	case "identity":
+		cmdIdentity(args)
+	case "hibp-index":
+		cmdHIBPIndex(args)
+	case "schema":
+		cmdSchema(args)
+	case "completion":
+		cmdCompletion(args)
+	default:
+		usage()
+		os.Exit(exitUsage)
+	}
+}
+
+func cmdInit(args []string) {
+	fs := flag.NewFlagSet("init", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	fs.Parse(args)
+	v, key, err := pwmanager.Create(master.password(true))
+	check(err, "init")
+	key.Destroy()
+	check(v.Save(*file), "save")
+	emit(fileResult{File: *file}, func() { fmt.Println("vault created at", *file) })
+}
+
+func cmdAdd(args []string) {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	title := fs.String("title", "", "title")
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "password")
+	url := fs.String("url", "", "optional URL")
+	notes := fs.String("notes", "", "optional notes")
+	breaches := fs.String("hibp", "", "warn if the password is in this local HIBP dump (file or prefix directory)")
+	fs.Parse(args)
+	require(*title != "", "title")
+	require(*username != "", "username")
+	require(*password != "", "password")
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	if db := openBreaches(*breaches); db != nil {
+		defer db.Close()
+		watchBreaches(v, db)
+	}
+	if rules, site, ok := passwordrules.ForURL(*url); ok {
+		if err := rules.Check(*password); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s may reject this password: %v\n", site.Domain, err)
+		}
+	}
+	id, err := v.AddEntry(key, *title, *username, *password, *url, *notes)
+	check(err, "add entry")
+	check(v.Save(*file), "save")
+	emit(changeResult{Action: "added", ID: id, Title: *title}, func() { fmt.Println("added entry id:", id) })
+}
+
+func cmdList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	fs.Parse(args)
+	v, err := pwmanager.Load(*file)
+	check(err, "load")
+	entries := v.List()
+	emit(summarize(entries), func() { printEntries(entries) })
+}
+
+// printEntries prints the list table.
+func printEntries(entries []pwmanager.CipherEntry) {
+	if len(entries) == 0 {
+		fmt.Println("(empty)")
+		return
+	}
+	fmt.Println("ID                                   | Title         | Modified")
+	fmt.Println(strings.Repeat("-", 88))
+	for _, e := range entries {
+		fmt.Printf("%-35s | %-13s | %s\n", e.ID, e.Title, e.ModifiedAt.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func cmdShow(args []string) {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "entry title (case-insensitive; allows partial match)")
+	query := fs.String("search", "", `search all fields, e.g. "alice github" or "user:alice url:github notes:wifi"`)
+	clip := addClipFlags(fs, "password", false)
+	fs.Parse(args)
+	noSelector := *id == "" && strings.TrimSpace(*title) == "" && strings.TrimSpace(*query) == ""
+	if noSelector && (!interactive() || machine()) {
+		failf(exitUsage, "provide --id, --title or --search")
+	}
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+
+	// resolve id from title if needed
+	targetID := *id
+	if noSelector {
+		targetID = entryID(v, "", "")
+	}
+	if targetID == "" {
+		// prefer exact title, then substring, then a fuzzy title search;
+		// --search looks through every field instead
+		var candidates []pwmanager.CipherEntry
+		if *query == "" {
+			candidates = v.FindByExactTitle(*title)
+			if len(candidates) == 0 {
+				candidates = v.SearchTitles(*title)
+			}
+		}
+		if len(candidates) == 0 {
+			q := *query
+			if q == "" {
+				q = "title:" + strconv.Quote(*title)
+			}
+			ix := sessionIndex(v, key)
+			candidates = searchEntries(v, ix, q)
+			ix.Wipe()
+		}
+		if len(candidates) == 0 {
+			failf(exitNotFound, "no entry found matching: %s", strings.TrimSpace(*title+" "+*query))
+		}
+		if len(candidates) > 1 {
+			targetID = pickEntry(candidates)
+		} else {
+			targetID = candidates[0].ID
+		}
+	}
+
+	e, err := openEntry(v, key, master, targetID)
+	check(err, "show")
+	if *clip.on {
+		if e.Password == "" {
+			failf(exitNotFound, "%q has no password", e.Title)
+		}
+		res := clip.copy("password", e.Password)
+		res.ID, res.Title = e.ID, e.Title
+		e.Password = "(on the clipboard)"
+		emit(res, func() { printEntry(e) })
+		return
+	}
+	emit(e, func() { printEntry(e) })
+}
+
+// openEntry decrypts an entry for show; the master password, or the agent
+// that unlocked the vault, is needed to recompute the password of a derived entry.
+func openEntry(v *pwmanager.Vault, key *secret.Buffer, master *masterInput, id string) (entryView, error) {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return entryView{}, err
+	}
+	if plain.IsDerived() {
+		dk, err := derivationKey(master)
+		if err == nil {
+			err = plain.ResolveDerived(dk)
+			dk.Destroy()
+		}
+		if err != nil {
+			return entryView{}, fmt.Errorf("derive: %w", err)
+		}
+	}
+	return entryView{ID: id, Title: meta.Title, PlainEntry: *plain}, nil
+}
+
+// printEntry prints an entry for people.
+func printEntry(e entryView) {
+	plain := &e.PlainEntry
+	fmt.Println("Title:   ", e.Title)
+	fmt.Println("Username:", plain.Username)
+	fmt.Println("Password:", plain.Password)
+	if d := plain.Derived; d != nil {
+		fmt.Printf("Derived:  %s, counter %d, %d characters\n", d.Site, d.Counter, d.Length)
+	}
+	if plain.URL != "" {
+		fmt.Println("URL:     ", plain.URL)
+	}
+	if plain.Expiry != nil {
+		fmt.Println("Expiry:  ", plain.Expiry)
+	}
+	if p := plain.Pending; p != nil {
+		fmt.Printf("Pending:  %s (since %s; rotate --confirm to use it)\n", p.Password, p.CreatedAt.Format("2006-01-02"))
+	}
+	if plain.Notes != "" {
+		fmt.Println("Notes:   ", plain.Notes)
+	}
+}
+
+// sessionIndex decrypts every entry into a search index; callers Wipe it
+// once the vault is done with, so decrypted text does not outlive the session.
+func sessionIndex(v *pwmanager.Vault, key *secret.Buffer) *search.Index {
+	recs, err := v.Records(key)
+	check(err, "decrypt")
+	return search.Build(recs)
+}
+
+// searchEntries runs query against ix, best match first.
+func searchEntries(v *pwmanager.Vault, ix *search.Index, query string) []pwmanager.CipherEntry {
+	var out []pwmanager.CipherEntry
+	for _, r := range ix.Search(query) {
+		if e, ok := v.Entries[r.ID]; ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
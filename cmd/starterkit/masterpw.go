@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// masterEnv is read for the master password only with --master-env.
+const masterEnv = "STARTERKIT_MASTER"
+
+// masterInput is where a command gets the master password from. Without any
+// of its flags the password comes from a running agent, else from a prompt
+// on the terminal that does not echo.
+type masterInput struct {
+	plain *string // deprecated: visible in shell history and ps
+	stdin *bool
+	fd    *int
+	file  *string
+	env   *bool
+
+	name  string // flag prefix, "master" or "new-master"
+	label string // prompt, "Master password"
+	value string
+	read  bool
+}
+
+// masterFlags registers the master-password sources on fs.
+func masterFlags(fs *flag.FlagSet) *masterInput {
+	return &masterInput{
+		plain: fs.String("master", "", "master password (deprecated: leaks into shell history and ps)"),
+		stdin: fs.Bool("master-stdin", false, "read the master password from the first line of stdin"),
+		fd:    fs.Int("master-fd", -1, "read the master password from this file descriptor"),
+		file:  fs.String("master-file", "", "read the master password from this file (must be private to you)"),
+		env:   fs.Bool("master-env", false, "read the master password from $"+masterEnv),
+		name:  "master",
+		label: "Master password",
+	}
+}
+
+// newMasterFlags registers the sources of a new master password, for passwd:
+// the prompt or --new-master-stdin, --new-master-fd and --new-master-file.
+func newMasterFlags(fs *flag.FlagSet) *masterInput {
+	return &masterInput{
+		plain: new(string),
+		stdin: fs.Bool("new-master-stdin", false, "read the new master password from the next line of stdin"),
+		fd:    fs.Int("new-master-fd", -1, "read the new master password from this file descriptor"),
+		file:  fs.String("new-master-file", "", "read the new master password from this file (must be private to you)"),
+		env:   new(bool),
+		name:  "new-master",
+		label: "New master password",
+	}
+}
+
+// knownMaster wraps a password that was already read, e.g. by the ui prompt.
+func knownMaster(pw string) *masterInput {
+	return &masterInput{value: pw, read: true, name: "master"}
+}
+
+// given reports whether a source was named on the command line.
+func (m *masterInput) given() bool {
+	return m.read || m.sources() > 0
+}
+
+func (m *masterInput) sources() int {
+	n := 0
+	for _, on := range []bool{*m.plain != "", *m.stdin, *m.fd >= 0, *m.file != "", *m.env} {
+		if on {
+			n++
+		}
+	}
+	return n
+}
+
+// password returns the master password, reading it on first use; confirm
+// asks twice at the terminal prompt, for passwords being set. It exits on error.
+func (m *masterInput) password(confirm bool) string {
+	if m.read {
+		return m.value
+	}
+	what := strings.ReplaceAll(m.name, "-", " ") + " password"
+	pw, err := m.readPassword(confirm)
+	check(err, what)
+	if pw == "" {
+		fail(exitUsage, what, "empty password")
+	}
+	m.value, m.read = pw, true
+	return pw
+}
+
+func (m *masterInput) readPassword(confirm bool) (string, error) {
+	if m.sources() > 1 {
+		return "", errors.New("give only one source for it")
+	}
+	switch {
+	case *m.plain != "":
+		fmt.Fprintln(os.Stderr, "warning: --master is deprecated: the password ends up in shell history and is visible in ps; "+
+			"leave it out to be prompted, or use --master-stdin, --master-fd or --master-file")
+		return *m.plain, nil
+	case *m.stdin:
+		return readLine(os.Stdin)
+	case *m.fd >= 0:
+		f := os.NewFile(uintptr(*m.fd), "master-fd")
+		if f == nil {
+			return "", fmt.Errorf("bad file descriptor %d", *m.fd)
+		}
+		defer f.Close()
+		return readLine(f)
+	case *m.file != "":
+		f, err := os.Open(*m.file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		if err := checkSecretFile(f); err != nil {
+			return "", err
+		}
+		return readLine(f)
+	case *m.env:
+		pw, ok := os.LookupEnv(masterEnv)
+		if !ok {
+			return "", fmt.Errorf("$%s is not set", masterEnv)
+		}
+		// keep it from the children of commands like run
+		os.Unsetenv(masterEnv)
+		return pw, nil
+	}
+	pw, err := promptHidden(m.label, confirm)
+	if errors.Is(err, errNoTerminal) {
+		err = fmt.Errorf("%w; use --%[2]s-stdin, --%[2]s-fd or --%[2]s-file", err, m.name)
+	}
+	return pw, err
+}
+
+var errNoTerminal = errors.New("stdin is not a terminal")
+
+// promptHidden asks for a password on the terminal without echoing it.
+func promptHidden(label string, confirm bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errNoTerminal
+	}
+	fmt.Fprint(os.Stderr, label+": ")
+	pw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil || !confirm {
+		return string(pw), err
+	}
+	fmt.Fprint(os.Stderr, "Repeat "+strings.ToLower(label)+": ")
+	again, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(pw) != string(again) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(pw), nil
+}
+
+// maxLine bounds what readLine accepts: far more than any password, and
+// still little enough to stop reading a file given by mistake.
+const maxLine = 4096
+
+// readLine reads up to the first newline one byte at a time, so nothing
+// after the password is consumed from a shared stdin. A longer line is an
+// error rather than cut short, which would unlock with another password.
+func readLine(r io.Reader) (string, error) {
+	var b strings.Builder
+	buf := make([]byte, 1)
+	for {
+		n, err := r.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			if b.Len() == maxLine {
+				return "", fmt.Errorf("line longer than %d bytes", maxLine)
+			}
+			b.WriteByte(buf[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\r"), nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReadLine(t *testing.T) {
+	for _, tt := range []struct {
+		in, want, rest string
+	}{
+		{"hunter2\nnext line", "hunter2", "next line"},
+		{"hunter2\r\n", "hunter2", ""},
+		{"no newline", "no newline", ""},
+		{"\nsecond", "", "second"},
+		{"", "", ""},
+		{strings.Repeat("a", maxLine) + "\nrest", strings.Repeat("a", maxLine), "rest"},
+	} {
+		r := strings.NewReader(tt.in)
+		got, err := readLine(r)
+		if err != nil || got != tt.want {
+			t.Errorf("readLine(%.20q) = %.20q, %v; want %.20q", tt.in, got, err, tt.want)
+		}
+		// what follows the password is left for the command
+		if rest, _ := io.ReadAll(r); string(rest) != tt.rest {
+			t.Errorf("readLine(%.20q) left %.20q, want %.20q", tt.in, rest, tt.rest)
+		}
+	}
+
+	// a longer line is refused, not cut short
+	for _, in := range []string{strings.Repeat("a", maxLine+1), strings.Repeat("a", 5000) + "\n"} {
+		if got, err := readLine(strings.NewReader(in)); err == nil || got != "" {
+			t.Errorf("readLine of %d bytes = %.20q, %v; want an error", len(in), got, err)
+		}
+	}
+}
+
+// secretFile writes content to a file with the given mode.
+func secretFile(t *testing.T, content string, mode os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "master")
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, mode); err != nil { // past the umask
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckSecretFile(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("file modes are not checked on", runtime.GOOS)
+	}
+	for _, tt := range []struct {
+		mode os.FileMode
+		ok   bool
+	}{
+		{0600, true},
+		{0400, true},
+		{0640, false}, // group-readable
+		{0604, false}, // world-readable
+		{0620, false}, // group-writable
+	} {
+		f, err := os.Open(secretFile(t, "pw\n", tt.mode))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = checkSecretFile(f)
+		f.Close()
+		if (err == nil) != tt.ok {
+			t.Errorf("mode %v: %v", tt.mode, err)
+		}
+	}
+
+	dir, err := os.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dir.Close()
+	if err := checkSecretFile(dir); err == nil {
+		t.Error("directory accepted")
+	}
+}
+
+// parseMaster registers the master flags and parses args.
+func parseMaster(t *testing.T, args ...string) *masterInput {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	m := masterFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMasterSources(t *testing.T) {
+	file := secretFile(t, "from-file\nignored\n", 0600)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.WriteString("from-fd\n")
+	w.Close()
+	got, err := parseMaster(t, "--master-fd", strconv.Itoa(int(r.Fd()))).readPassword(false)
+	if err != nil || got != "from-fd" {
+		t.Errorf("--master-fd = %q, %v", got, err)
+	}
+
+	stdin := os.Stdin
+	t.Cleanup(func() { os.Stdin = stdin })
+	r, w, err = os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	w.WriteString("from-stdin\nrest of stdin\n")
+	w.Close()
+	os.Stdin = r
+	got, err = parseMaster(t, "--master-stdin").readPassword(false)
+	if err != nil || got != "from-stdin" {
+		t.Errorf("--master-stdin = %q, %v", got, err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "rest of stdin\n" {
+		t.Errorf("--master-stdin consumed %q", rest)
+	}
+
+	got, err = parseMaster(t, "--master-file", file).readPassword(false)
+	if err != nil || got != "from-file" {
+		t.Errorf("--master-file = %q, %v", got, err)
+	}
+	if _, err := parseMaster(t, "--master-file", filepath.Join(t.TempDir(), "missing")).readPassword(false); err == nil {
+		t.Error("missing --master-file accepted")
+	}
+
+	t.Setenv(masterEnv, "from-env")
+	got, err = parseMaster(t, "--master-env").readPassword(false)
+	if _, set := os.LookupEnv(masterEnv); err != nil || got != "from-env" || set {
+		t.Errorf("--master-env = %q, %v; still set: %v", got, err, set)
+	}
+	if _, err := parseMaster(t, "--master-env").readPassword(false); err == nil {
+		t.Error("--master-env accepted an unset variable")
+	}
+
+	for _, args := range [][]string{
+		{"--master-file", file, "--master-stdin"},
+		{"--master-fd", "0", "--master-env"},
+		{"--master", "plain", "--master-file", file},
+	} {
+		m := parseMaster(t, args...)
+		if !m.given() {
+			t.Errorf("%q: no source given", args)
+		}
+		if _, err := m.readPassword(false); err == nil || !strings.Contains(err.Error(), "only one source") {
+			t.Errorf("%q: %v", args, err)
+		}
+	}
+	if parseMaster(t).given() {
+		t.Error("a source is given without flags")
+	}
+}
+
+func TestMasterFileModes(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("file modes are not checked on", runtime.GOOS)
+	}
+	for _, mode := range []os.FileMode{0640, 0644} {
+		file := secretFile(t, "pw\n", mode)
+		_, err := parseMaster(t, "--master-file", file).readPassword(false)
+		if err == nil || !strings.Contains(err.Error(), "chmod 600") {
+			t.Errorf("mode %v: %v", mode, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/urlmatch"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// cmdMatch prints the logins that apply to a page, best first, the way an
+// autofill integration would pick them, and warns when the page looks like an
+// imitation of a site in the vault. --reveal prints the best login's
+// credentials, which a high-risk page only gets with --force. With --set it
+// changes how one entry's URL is matched instead.
+func cmdMatch(args []string) {
+	fs := flag.NewFlagSet("match", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	psl := fs.String("psl", "", "public_suffix_list.dat to merge over the built-in public suffixes")
+	set := fs.String("set", "", "set the match mode of the entry chosen by --id or --title: "+urlmatch.ModeNames())
+	id := fs.String("id", "", "entry id (with --set)")
+	title := fs.String("title", "", "exact entry title (with --set)")
+	reveal := fs.Bool("reveal", false, "print the username and password of the best match")
+	force := fs.Bool("force", false, "reveal credentials even if the page looks like phishing")
+	// the page may come before the flags: "match https://example.com --file ..."
+	var page string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		page, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if page == "" {
+		page = fs.Arg(0)
+	}
+	if *psl != "" {
+		check(urlmatch.LoadFile(*psl), "psl")
+	}
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+
+	if *set != "" {
+		mode, err := urlmatch.ParseMode(*set)
+		check(err, "set")
+		target := entryID(v, *id, *title)
+		stored := string(mode)
+		if mode == urlmatch.ModeDomain {
+			stored = ""
+		}
+		check(v.SetURLMatch(key, target, stored), "set")
+		check(v.Save(*file), "save")
+		emit(changeResult{Action: "match mode set to " + string(mode), ID: target, Title: v.Entries[target].Title}, func() {
+			fmt.Printf("entry %s now matches by %s\n", target, mode)
+		})
+		return
+	}
+
+	if page == "" {
+		failf(exitUsage, "provide the page URL: match https://example.com/login --file vault.json")
+	}
+	recs, err := v.Records(key)
+	check(err, "decrypt")
+	results, verdict, err := urlmatch.Find(recs, page)
+	check(err, "match")
+	if verdict.Risk != urlmatch.RiskNone {
+		fmt.Fprintf(os.Stderr, "warning: %s phishing risk: %s\n", verdict.Risk, verdict.Reason)
+	}
+	// before looking at the matches: a look-alike page may well have some,
+	// e.g. a login saved on it earlier
+	if err := verdict.Err(); err != nil && *reveal && !*force {
+		fmt.Fprintln(os.Stderr, "re-run with --force if you are sure this is the real site")
+		fail(exitRefused, "refusing to reveal credentials", err.Error())
+	}
+	if len(results) == 0 {
+		failf(exitNotFound, "no logins for %s", page)
+	}
+	res := matchResult{Page: page, Risk: verdict.Risk.String(), Reason: verdict.Reason, Resembles: verdict.Target}
+	for _, r := range results {
+		res.Matches = append(res.Matches, matchRow{
+			Quality: r.Quality.String(), Mode: string(r.Mode), ID: r.ID, Title: r.Title, Username: r.Username, URL: r.URL,
+		})
+	}
+	if *reveal {
+		e, err := openEntry(v, key, master, results[0].ID)
+		check(err, "show")
+		res.Revealed = &e
+	}
+
+	emit(res, func() {
+		fmt.Println("Match   | Title         | Username             | URL                            | ID")
+		fmt.Println(strings.Repeat("-", 110))
+		for _, r := range results {
+			fmt.Printf("%-7s | %-13s | %-20s | %-30s | %s\n", r.Quality, r.Title, r.Username, r.URL, r.ID)
+		}
+		if res.Revealed != nil {
+			fmt.Println()
+			printEntry(*res.Revealed)
+		}
+	})
+}
+
+// matchResult reports the logins for a page, best first.
+type matchResult struct {
+	Page      string     `json:"page"`
+	Risk      string     `json:"risk"` // phishing risk of the page: none, low or high
+	Reason    string     `json:"reason,omitempty"`
+	Resembles string     `json:"resembles,omitempty"` // the known site the page imitates
+	Matches   []matchRow `json:"matches"`
+	Revealed  *entryView `json:"revealed,omitempty"` // --reveal: the best match, decrypted
+}
+
+type matchRow struct {
+	Quality  string `json:"quality"`
+	Mode     string `json:"mode"`
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Username string `json:"username"`
+	URL      string `json:"url"`
+}
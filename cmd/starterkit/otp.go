@@ -0,0 +1,59 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/totp"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// otpResult reports otp: the current code of an entry's TOTP secret.
+type otpResult struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Code       string    `json:"code"`
+	ValidUntil time.Time `json:"validUntil"`
+}
+
+// cmdOTP prints the current TOTP code of an entry, or copies it with --clip.
+// The secret itself is never printed.
+func cmdOTP(args []string) {
+	fs := flag.NewFlagSet("otp", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title (case-insensitive)")
+	clip := addClipFlags(fs, "code", false)
+	fs.Parse(args)
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	e, err := openEntry(v, key, master, entryID(v, *id, *title))
+	check(err, "otp")
+	code, until, err := otpCode(e, time.Now())
+	check(err, "otp")
+	if *clip.on {
+		res := clip.copy("totp code", code)
+		res.ID, res.Title = e.ID, e.Title
+		emit(res, func() { printCopied(res) })
+		return
+	}
+	res := otpResult{ID: e.ID, Title: e.Title, Code: code, ValidUntil: until.UTC()}
+	emit(res, func() {
+		fmt.Printf("%s (valid for %ds)\n", code, int(time.Until(until).Round(time.Second).Seconds()))
+	})
+}
+
+// otpCode computes the code of e's TOTP secret at now, and when it expires.
+func otpCode(e entryView, now time.Time) (string, time.Time, error) {
+	if e.TOTP == "" {
+		return "", time.Time{}, fmt.Errorf("%q has no TOTP secret: %w", e.Title, pwmanager.ErrNotFound)
+	}
+	k, err := totp.Parse(e.TOTP)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("%q: %w", e.Title, err)
+	}
+	code, left := k.Code(now)
+	return code, now.Add(left), nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOTPCode(t *testing.T) {
+	e := entryView{Title: "GitHub", PlainEntry: pwmanager.PlainEntry{TOTP: "otpauth://totp/GitHub:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8"}}
+	code, until, err := otpCode(e, time.Unix(1111111109, 0))
+	if err != nil || code != "07081804" || !until.Equal(time.Unix(1111111110, 0)) {
+		t.Errorf("otpCode = %q, %v, %v", code, until, err)
+	}
+
+	e.TOTP = ""
+	if _, _, err := otpCode(e, time.Now()); !errors.Is(err, pwmanager.ErrNotFound) {
+		t.Errorf("no secret: %v", err)
+	}
+	e.TOTP = "not base32!"
+	if _, _, err := otpCode(e, time.Now()); err == nil {
+		t.Error("bad secret accepted")
+	}
+}
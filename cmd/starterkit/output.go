@@ -0,0 +1,248 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/agent"
+	"appliedcryptography-starter-kit/internal/output"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/report"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// Exit codes, stable for scripts and listed in the usage text.
+const (
+	exitError     = 1 // any failure not listed below
+	exitUsage     = 2 // bad flags or arguments, as for the flag package
+	exitAuth      = 3 // wrong master password
+	exitNotFound  = 4 // no such entry, trash entry or vault file
+	exitAmbiguous = 5 // several entries match and none could be picked
+	exitConflict  = 6 // the change clashes with the vault's state
+	exitCorrupt   = 7 // the vault file or an entry in it cannot be read
+	exitRefused   = 8 // refused for safety: reveal on a lookalike page, a bundle from the wrong signer
+	exitOverdue   = 9 // due found an overdue rotation
+)
+
+var exitKinds = map[int]string{
+	exitError:     "error",
+	exitUsage:     "usage",
+	exitAuth:      "wrong-password",
+	exitNotFound:  "not-found",
+	exitAmbiguous: "ambiguous",
+	exitConflict:  "conflict",
+	exitCorrupt:   "corrupt",
+	exitRefused:   "refused",
+	exitOverdue:   "overdue",
+}
+
+// outputFormat is the --output every command accepts: "table" for people,
+// or one of the machine-readable formats of package output.
+var outputFormat = "table"
+
+// takeOutputFlag removes --output FORMAT (or --output=FORMAT) from a
+// command's arguments and sets outputFormat, so the flag works the same on
+// every command and in any position.
+func takeOutputFlag(args []string) []string {
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--" { // the rest belongs to another program, as for run
+			return append(rest, args[i:]...)
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || name != "output" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				fail(exitUsage, "", "--output needs a format: "+strings.Join(output.Formats, ", "))
+			}
+			i++
+			value = args[i]
+		}
+		if !output.Valid(value) {
+			fail(exitUsage, "", fmt.Sprintf("unknown --output %q; use %s", value, strings.Join(output.Formats, ", ")))
+		}
+		outputFormat = value
+	}
+	return rest
+}
+
+// machine reports whether output is for a program rather than a person.
+// Prompts are off then: what a person would be asked fails instead.
+func machine() bool {
+	return outputFormat != "table"
+}
+
+// emit prints a command's result: table prints it for people, the other
+// formats encode result.
+func emit(result any, table func()) {
+	if !machine() {
+		table()
+		return
+	}
+	if err := output.Write(os.Stdout, outputFormat, result); err != nil {
+		fmt.Fprintln(os.Stderr, "output error:", err)
+		os.Exit(exitError)
+	}
+}
+
+// errorResult is how failures are printed in the machine formats.
+type errorResult struct {
+	Error struct {
+		Code    int    `json:"code"` // the exit code
+		Kind    string `json:"kind"` // the exit code's name: not-found, conflict, ...
+		Where   string `json:"where,omitempty"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// fail reports a failure and exits with code. People get "where error:
+// message" (or the bare message), programs an errorResult on stdout.
+func fail(code int, where, msg string) {
+	if !machine() {
+		if where != "" {
+			msg = where + " error: " + msg
+		}
+		fmt.Println(msg)
+		os.Exit(code)
+	}
+	var r errorResult
+	r.Error.Code, r.Error.Kind, r.Error.Where, r.Error.Message = code, exitKinds[code], where, msg
+	output.Write(os.Stdout, outputFormat, r)
+	os.Exit(code)
+}
+
+// failf is fail without a where, for messages that say it all.
+func failf(code int, format string, args ...any) {
+	fail(code, "", fmt.Sprintf(format, args...))
+}
+
+// exitCode maps an error to the exit code of its kind.
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, pwmanager.ErrWrongPassword):
+		return exitAuth
+	case errors.Is(err, pwmanager.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, errAmbiguous):
+		return exitAmbiguous
+	case errors.Is(err, pwmanager.ErrConflict):
+		return exitConflict
+	case errors.Is(err, pwmanager.ErrCorrupt):
+		return exitCorrupt
+	}
+	return exitError
+}
+
+func require(ok bool, name string) {
+	if !ok {
+		fail(exitUsage, "", "missing --"+name)
+	}
+}
+
+func check(err error, where string) {
+	if err != nil {
+		fail(exitCode(err), where, err.Error())
+	}
+}
+
+// entrySummary is an entry as list, search and trash list show it.
+type entrySummary struct {
+	ID       string    `json:"id"`
+	Title    string    `json:"title"`
+	Modified time.Time `json:"modified"`
+	Deleted  time.Time `json:"deleted,omitzero"` // trash list only
+}
+
+func summarize(entries []pwmanager.CipherEntry) []entrySummary {
+	out := make([]entrySummary, len(entries))
+	for i, e := range entries {
+		out[i] = entrySummary{ID: e.ID, Title: e.Title, Modified: e.ModifiedAt, Deleted: e.DeletedAt}
+	}
+	return out
+}
+
+// changeResult reports a change to one entry.
+type changeResult struct {
+	Action   string `json:"action"` // added, updated, renamed, trashed, purged, restored, ...
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Password string `json:"password,omitempty"` // a password the command generated or derived
+}
+
+// entryView is a decrypted entry as show prints it; derived passwords are
+// already computed.
+type entryView struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	pwmanager.PlainEntry
+}
+
+// resultTypes maps each command to the type of its machine-readable result,
+// for the schema command.
+var resultTypes = map[string]any{
+	"init":          fileResult{},
+	"add":           changeResult{},
+	"list":          []entrySummary{},
+	"show":          entryView{},
+	"search":        []entrySummary{},
+	"copy":          clipResult{},
+	"otp":           otpResult{},
+	"inject":        injectResult{},
+	"edit":          changeResult{},
+	"mv":            changeResult{},
+	"rm":            changeResult{},
+	"trash list":    []entrySummary{},
+	"trash restore": changeResult{},
+	"trash empty":   purgeResult{},
+	"passwd":        passwdResult{},
+	"agent":         agent.Status{},
+	"match":         matchResult{},
+	"generate":      generateResult{},
+	"derive":        deriveResult{},
+	"expiry":        expiryResult{},
+	"due":           dueResult{},
+	"rotate":        changeResult{},
+	"import":        importResult{},
+	"export":        exportResult{},
+	"report":        report.Report{},
+	"identity":      identityResult{},
+	"hibp-index":    fileResult{},
+	"error":         errorResult{},
+}
+
+// fileResult names the file a command wrote.
+type fileResult struct {
+	File string `json:"file"`
+}
+
+// cmdSchema prints the JSON Schema of a command's result, or of every
+// command's when none is named.
+func cmdSchema(args []string) {
+	if name := strings.Join(args, " "); name != "" {
+		v, ok := resultTypes[name]
+		if !ok {
+			failf(exitNotFound, "no schema for %q", name)
+		}
+		writeSchema(output.Schema("starterkit "+name, v))
+		return
+	}
+	all := make(map[string]any, len(resultTypes))
+	for name, v := range resultTypes {
+		all[name] = output.Schema("starterkit "+name, v)
+	}
+	writeSchema(all)
+}
+
+// writeSchema prints a schema as JSON, unless --output asks for YAML.
+func writeSchema(v any) {
+	format := "json"
+	if outputFormat == "yaml" {
+		format = "yaml"
+	}
+	check(output.Write(os.Stdout, format, v), "schema")
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// detachProcess does nothing: the child already outlives its parent.
+func detachProcess(cmd *exec.Cmd) {}
+
+// killedBy reports nothing: only Unix ends processes with signals.
+func killedBy(ps *os.ProcessState) (int, bool) { return 0, false }
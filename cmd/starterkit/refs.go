@@ -0,0 +1,93 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// refScheme starts a reference to a vault field in run and inject.
+const refScheme = "vault://"
+
+// errAmbiguous marks a reference that names several entries.
+var errAmbiguous = errors.New("ambiguous")
+
+// secretRef is a parsed vault://TITLE/FIELD reference. The last slash
+// separates the field, so titles may contain slashes when a field is given;
+// without one the field is the password.
+type secretRef struct {
+	Entry string // an exact title (case-insensitive) or an entry id
+	Field string // as for entryField
+}
+
+func (r secretRef) String() string { return refScheme + r.Entry + "/" + r.Field }
+
+// parseRef parses s if it is a vault:// reference.
+func parseRef(s string) (ref secretRef, ok bool, err error) {
+	rest, ok := strings.CutPrefix(s, refScheme)
+	if !ok {
+		return secretRef{}, false, nil
+	}
+	ref.Entry, ref.Field = rest, "password"
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		ref.Entry, ref.Field = rest[:i], rest[i+1:]
+	}
+	if ref.Entry == "" || ref.Field == "" {
+		return secretRef{}, true, fmt.Errorf("bad reference %q: want %sTITLE/FIELD", s, refScheme)
+	}
+	return ref, true, nil
+}
+
+// findRef returns the id of the entry a reference names: the entry with that
+// id, else the one entry with that exact title. It only reads titles, so it
+// needs no key.
+func findRef(v *pwmanager.Vault, ref secretRef) (string, error) {
+	if _, ok := v.Entries[ref.Entry]; ok {
+		return ref.Entry, nil
+	}
+	matches := v.FindByExactTitle(ref.Entry)
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%s: no entry titled %q: %w", ref, ref.Entry, pwmanager.ErrNotFound)
+	case 1:
+		return matches[0].ID, nil
+	}
+	return "", fmt.Errorf("%s: %d entries are titled %q; refer to one by id: %w", ref, len(matches), ref.Entry, errAmbiguous)
+}
+
+// resolver looks up references, decrypting each entry once.
+type resolver struct {
+	v       *pwmanager.Vault
+	key     *secret.Buffer
+	master  *masterInput
+	entries map[string]entryView
+	values  []string // every value resolved, for masking
+}
+
+func newResolver(v *pwmanager.Vault, key *secret.Buffer, master *masterInput) *resolver {
+	return &resolver{v: v, key: key, master: master, entries: map[string]entryView{}}
+}
+
+// resolve returns the value ref points to. A field the entry does not have
+// is an error; an empty one is not.
+func (r *resolver) resolve(ref secretRef) (string, error) {
+	id, err := findRef(r.v, ref)
+	if err != nil {
+		return "", err
+	}
+	e, ok := r.entries[id]
+	if !ok {
+		if e, err = openEntry(r.v, r.key, r.master, id); err != nil {
+			return "", fmt.Errorf("%s: %w", ref, err)
+		}
+		r.entries[id] = e
+	}
+	value, ok := entryField(e, ref.Field)
+	if !ok {
+		return "", fmt.Errorf("%s: %q has no field %q: %w", ref, e.Title, ref.Field, pwmanager.ErrNotFound)
+	}
+	r.values = append(r.values, value)
+	return value, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/output"
+	"appliedcryptography-starter-kit/internal/report"
+	"flag"
+	"io"
+	"os"
+	"time"
+)
+
+func cmdReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	format := fs.String("format", "table", "output format: table, json or html (--output json, yaml or tsv also work)")
+	out := fs.String("out", "", "write the report to this file instead of stdout")
+	maxAge := fs.Int("max-age", 365, "flag passwords not changed for this many days")
+	weakBelow := fs.Int("weak-below", 60, "flag passwords with a strength score below this")
+	all := fs.Bool("all", false, "list entries without issues too")
+	breaches := fs.String("hibp", "", "flag passwords found in this local HIBP dump (file or prefix directory)")
+	fs.Parse(args)
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	dk, err := derivationKey(master)
+	check(err, "derive")
+	defer dk.Destroy()
+	opts := report.Options{
+		MaxAge:        time.Duration(*maxAge) * 24 * time.Hour,
+		WeakBelow:     *weakBelow,
+		IncludeClean:  *all,
+		DerivationKey: dk,
+	}
+	if db := openBreaches(*breaches); db != nil {
+		defer db.Close()
+		opts.Breaches = db
+	}
+	rep, err := report.Build(v, key, opts)
+	check(err, "analyze")
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.OpenFile(*out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		check(err, "create")
+		defer f.Close()
+		w = f
+	}
+	switch {
+	case machine() && !isFlagSet(fs, "format"):
+		err = output.Write(w, outputFormat, rep)
+	case *format == "table":
+		err = rep.WriteTable(w)
+	case *format == "json":
+		err = rep.WriteJSON(w)
+	case *format == "html":
+		err = rep.WriteHTML(w)
+	default:
+		failf(exitUsage, "unknown --format: %s", *format)
+	}
+	check(err, "write")
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// cmdDue lists the entries whose passwords are overdue for rotation or due
+// within --within days, most urgent first.
+func cmdDue(args []string) {
+	fs := flag.NewFlagSet("due", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	within := fs.Int("within", 14, "also list rotations due in this many days")
+	fs.Parse(args)
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	recs, err := v.Records(key)
+	check(err, "decrypt")
+	tags, err := v.TagPolicies(key)
+	check(err, "tag policies")
+
+	now := time.Now()
+	due := pwmanager.DueRotations(recs, tags, now, time.Duration(*within)*24*time.Hour)
+	res := dueResult{WithinDays: *within, Rotations: make([]dueRow, len(due))}
+	for i, r := range due {
+		res.Rotations[i] = dueRow{ID: r.ID, Title: r.Title, Due: r.DueAt, Overdue: r.Overdue(now), Rule: r.Rule, Source: r.Source, Pending: r.Pending}
+		if r.Overdue(now) {
+			res.Overdue++
+		}
+	}
+	emit(res, func() {
+		if len(due) == 0 {
+			fmt.Printf("nothing due in the next %d days\n", *within)
+			return
+		}
+		fmt.Println("Status       | Due        | Title         | Rule                  | From        | ID")
+		fmt.Println(strings.Repeat("-", 110))
+		for _, r := range due {
+			days := int(r.DueAt.Sub(now).Hours() / 24)
+			status := fmt.Sprintf("in %d days", days)
+			if r.Overdue(now) {
+				status = fmt.Sprintf("OVERDUE %dd", -days)
+			}
+			if r.Pending {
+				status += "*"
+			}
+			fmt.Printf("%-12s | %s | %-13s | %-21s | %-11s | %s\n",
+				status, r.DueAt.Format("2006-01-02"), r.Title, r.Rule, r.Source, r.ID)
+		}
+		fmt.Printf("\n%d overdue, %d upcoming; * marks a pending rotation (rotate --confirm)\n", res.Overdue, len(due)-res.Overdue)
+	})
+	if res.Overdue > 0 {
+		os.Exit(exitOverdue)
+	}
+}
+
+// dueResult reports due, most urgent first.
+type dueResult struct {
+	WithinDays int      `json:"withinDays"`
+	Overdue    int      `json:"overdue"` // how many of Rotations are overdue
+	Rotations  []dueRow `json:"rotations"`
+}
+
+type dueRow struct {
+	ID      string    `json:"id"`
+	Title   string    `json:"title"`
+	Due     time.Time `json:"due"`
+	Overdue bool      `json:"overdue"`
+	Rule    string    `json:"rule"`    // "rotate every N days" or "hard expiry"
+	Source  string    `json:"source"`  // where the policy comes from: the entry or a tag
+	Pending bool      `json:"pending"` // a replacement awaits rotate --confirm
+}
+
+// cmdExpiry sets the rotation policy of one entry, or of every entry with a tag.
+func cmdExpiry(args []string) {
+	fs := flag.NewFlagSet("expiry", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title")
+	tag := fs.String("tag", "", "apply the policy to every entry with this tag instead")
+	days := fs.Int("days", 0, "rotate this many days after each password change")
+	expires := fs.String("expires", "", "hard expiry date (YYYY-MM-DD)")
+	remove := fs.Bool("clear", false, "remove the policy")
+	fs.Parse(args)
+	require(*remove || *days > 0 || *expires != "", "days, --expires or --clear")
+
+	var policy *pwmanager.ExpiryPolicy
+	if !*remove {
+		policy = &pwmanager.ExpiryPolicy{RotateDays: *days}
+		if *expires != "" {
+			t, err := time.ParseInLocation("2006-01-02", *expires, time.Local)
+			check(err, "expires")
+			policy.ExpiresAt = t.UTC()
+		}
+	}
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	if *tag != "" {
+		check(v.SetTagPolicy(key, *tag, policy), "set tag policy")
+		check(v.Save(*file), "save")
+		emit(expiryResult{Tag: *tag, Policy: policy}, func() {
+			fmt.Printf("entries tagged %q: %s\n", *tag, describePolicy(policy))
+		})
+		return
+	}
+	target := entryID(v, *id, *title)
+	check(v.SetExpiry(key, target, policy), "set expiry")
+	check(v.Save(*file), "save")
+	emit(expiryResult{ID: target, Title: v.Entries[target].Title, Policy: policy}, func() {
+		fmt.Printf("entry %s: %s\n", target, describePolicy(policy))
+	})
+}
+
+// expiryResult reports expiry for an entry or a tag; a null policy was cleared.
+type expiryResult struct {
+	ID     string                  `json:"id,omitempty"`
+	Title  string                  `json:"title,omitempty"`
+	Tag    string                  `json:"tag,omitempty"`
+	Policy *pwmanager.ExpiryPolicy `json:"policy"`
+}
+
+func describePolicy(p *pwmanager.ExpiryPolicy) string {
+	if p == nil {
+		return "policy removed"
+	}
+	return p.String()
+}
+
+// cmdRotate replaces an entry's password in two steps, so a site that
+// rejects the new password does not leave the vault out of date: the first
+// run generates a replacement and keeps it pending, --confirm makes it current
+// once the site has accepted it, and --cancel throws it away.
+func cmdRotate(args []string) {
+	fs := flag.NewFlagSet("rotate", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	id := fs.String("id", "", "entry id")
+	title := fs.String("title", "", "exact entry title")
+	length := fs.Int("length", 20, "length of the new password (site rules may cap it)")
+	confirm := fs.Bool("confirm", false, "make the pending password current")
+	cancel := fs.Bool("cancel", false, "discard the pending password")
+	fs.Parse(args)
+
+	v, key := openVault(*file, master)
+	defer key.Destroy()
+	target := entryID(v, *id, *title)
+
+	name := v.Entries[target].Title
+	switch {
+	case *confirm:
+		check(v.ConfirmRotation(key, target), "confirm")
+		check(v.Save(*file), "save")
+		emit(changeResult{Action: "rotation confirmed", ID: target, Title: name}, func() {
+			fmt.Println("rotation confirmed; the old password is kept in the entry history")
+		})
+		return
+	case *cancel:
+		check(v.CancelRotation(key, target), "cancel")
+		check(v.Save(*file), "save")
+		emit(changeResult{Action: "rotation cancelled", ID: target, Title: name}, func() {
+			fmt.Println("pending password discarded")
+		})
+		return
+	}
+
+	plain, _, err := v.GetDecrypted(key, target)
+	check(err, "decrypt")
+	opts := pwmanager.PasswordOptions{
+		Length: *length, IncludeUpper: true, IncludeLower: true, IncludeNumbers: true, IncludeSymbols: true,
+	}
+	if rules, domain, ok := pwmanager.SiteRules(plain.URL); ok {
+		opts.Rules = rules
+		fmt.Fprintf(os.Stderr, "using password rules for %s\n", domain)
+	}
+	pw, err := pwmanager.GeneratePassword(opts)
+	check(err, "generate")
+	check(v.StartRotation(key, target, pw), "rotate")
+	check(v.Save(*file), "save")
+	emit(changeResult{Action: "rotation started", ID: target, Title: name, Password: pw}, func() {
+		fmt.Println(pw)
+		fmt.Fprintln(os.Stderr, "change the password on the site, then run rotate --confirm (or --cancel)")
+	})
+}
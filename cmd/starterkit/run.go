@@ -0,0 +1,175 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/redact"
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// exitNoCommand is run's status when the command cannot be started, as
+// for env and xargs; any other status is the command's own.
+const exitNoCommand = 127
+
+// envFlags collects repeated --env flags.
+type envFlags []string
+
+func (e *envFlags) String() string { return strings.Join(*e, " ") }
+
+func (e *envFlags) Set(s string) error {
+	if name, _, ok := strings.Cut(s, "="); !ok || name == "" {
+		return errors.New("want NAME=VALUE")
+	}
+	*e = append(*e, s)
+	return nil
+}
+
+// cmdRun runs a command with vault fields in its environment, so secrets
+// never touch a .env file. Values that are vault:// references are looked
+// up; others are passed as they are.
+func cmdRun(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	var envs envFlags
+	fs.Var(&envs, "env", "NAME=vault://TITLE/FIELD (or NAME=VALUE) to set for the command; repeatable")
+	envFile := fs.String("env-file", "", "read more NAME=VALUE lines from this file (# comments; --env wins)")
+	mask := fs.Bool("mask", false, "replace the secret values in the command's stdout and stderr with "+redact.Mask)
+	fs.Parse(args)
+	argv := fs.Args()
+	if len(argv) == 0 {
+		failf(exitUsage, "usage: run [--env NAME=vault://TITLE/FIELD ...] [--env-file FILE] [--mask] -- COMMAND [ARGS...]")
+	}
+
+	var pairs []string
+	if *envFile != "" {
+		lines, err := readEnvFile(*envFile)
+		check(err, "env-file")
+		pairs = lines
+	}
+	pairs = append(pairs, envs...)
+	if len(pairs) == 0 {
+		failf(exitUsage, "nothing to set; give --env or --env-file")
+	}
+
+	v, key := openVault(*file, master)
+	r := newResolver(v, key, master)
+	var vars []string
+	for _, p := range pairs {
+		name, value, _ := strings.Cut(p, "=")
+		ref, isRef, err := parseRef(value)
+		check(err, "run")
+		if isRef {
+			value, err = r.resolve(ref)
+			check(err, "run")
+		}
+		vars = append(vars, name+"="+value)
+	}
+	// the command gets the values, not the keys
+	key.Destroy()
+	agentDerivKey.Destroy()
+
+	var secrets []string
+	if *mask {
+		secrets = r.values
+	}
+	os.Exit(runChild(argv, childEnv(os.Environ(), vars), secrets))
+}
+
+// readEnvFile reads NAME=VALUE lines. Blank lines and lines starting with #
+// are skipped, a leading "export " is dropped and a value in matching
+// quotes is unquoted, so most .env files read as they are.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s:%d: want NAME=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		out = append(out, name+"="+value)
+	}
+	return out, sc.Err()
+}
+
+// childEnv is env with vars set over it, less the master password.
+func childEnv(env, vars []string) []string {
+	drop := map[string]bool{masterEnv: true}
+	for _, kv := range vars {
+		name, _, _ := strings.Cut(kv, "=")
+		drop[name] = true
+	}
+	var out []string
+	for _, kv := range env {
+		if name, _, _ := strings.Cut(kv, "="); !drop[name] {
+			out = append(out, kv)
+		}
+	}
+	return append(out, vars...)
+}
+
+// runChild runs argv with env and returns its exit status. Interrupts and
+// terminations sent to run are passed on to the command. With secrets, its
+// stdout and stderr go through redact writers.
+func runChild(argv, env, secrets []string) int {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	var writers []io.Closer
+	if len(secrets) > 0 {
+		stdout, stderr := redact.NewWriter(os.Stdout, secrets), redact.NewWriter(os.Stderr, secrets)
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		writers = append(writers, stdout, stderr)
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "run error:", err)
+		return exitNoCommand
+	}
+	go func() {
+		for s := range sigs {
+			cmd.Process.Signal(s)
+		}
+	}()
+	err := cmd.Wait()
+	for _, w := range writers {
+		w.Close()
+	}
+
+	var exit *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exit):
+		if sig, ok := killedBy(exit.ProcessState); ok {
+			return 128 + sig // as the shell reports it
+		}
+		return exit.ExitCode()
+	}
+	fmt.Fprintln(os.Stderr, "run error:", err)
+	return exitError
+}
@@ -0,0 +1,714 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/clipboard"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/search"
+	"appliedcryptography-starter-kit/internal/secret"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// cmdUI runs the full-screen terminal ui: the entry list with a live filter,
+// a details pane, an add/edit form and clipboard copy. It locks itself after
+// --idle without a key press.
+func cmdUI(args []string) {
+	fs := flag.NewFlagSet("ui", flag.ExitOnError)
+	file := fs.String("file", "vault.json", "path to vault file")
+	master := masterFlags(fs)
+	idle := fs.Duration("idle", 5*time.Minute, "lock after this long without a key press (0: never)")
+	clipClear := fs.Duration("clip-clear", 30*time.Second, "clear a copied value from the clipboard after this long (0: never)")
+	fs.Parse(args)
+
+	if machine() {
+		failf(exitUsage, "ui is interactive; --output does not apply")
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		failf(exitUsage, "ui needs a terminal; in scripts use list, search, show and edit")
+	}
+	v, key := openVault(*file, master)
+	t := &tui{file: *file, v: v, key: key, master: master, idle: *idle, clipClear: *clipClear}
+	if err := t.reindex(); err != nil {
+		key.Destroy()
+		check(err, "decrypt")
+	}
+	check(t.run(), "ui")
+}
+
+// Modes of the ui; each has its own keys and help line.
+const (
+	modeBrowse  = iota // moving through the list
+	modeFilter         // typing the filter
+	modeForm           // adding or editing an entry
+	modeConfirm        // asked whether to move an entry to the trash
+	modeLocked         // waiting for the master password
+)
+
+// tui is the state of the ui. Everything decrypted hangs off it, so lock can
+// drop it all at once.
+type tui struct {
+	file   string
+	v      *pwmanager.Vault
+	key    *secret.Buffer // nil while locked
+	master *masterInput   // nil while locked
+	ix     *search.Index
+
+	idle, clipClear time.Duration
+	lastKey         time.Time
+	clip            clipboard.Backend // detected on the first copy
+	clipDigest      clipboard.Digest  // of the last copy
+	clipUntil       time.Time         // when to clear the clipboard; zero if there is nothing to clear
+	w, h            int
+	quit            bool
+
+	mode       int
+	filter     []rune
+	list       []pwmanager.CipherEntry
+	sel, top   int
+	detail     *entryView // the selected entry, decrypted on first view
+	detailErr  error
+	reveal     bool
+	showDetail bool // narrow terminals show the details instead of the list
+	form       *entryForm
+	pwInput    []rune // master password typed at the lock screen
+
+	status    string
+	statusErr bool
+}
+
+func (t *tui) run() error {
+	fd := int(os.Stdin.Fd())
+	restoreVT := enableVT(os.Stdout)
+	defer restoreVT()
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		t.clearClipboard()
+		t.wipe()
+		os.Stdout.WriteString(styleReset + cursorShow + altScreenOff)
+		term.Restore(fd, state)
+	}()
+	os.Stdout.WriteString(altScreenOn + cursorHide)
+
+	keys := make(chan key, 64)
+	go readKeys(os.Stdin, keys)
+	resize, quit := make(chan struct{}, 1), make(chan struct{}, 1)
+	defer watchSignals(resize, quit)()
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	t.lastKey = time.Now()
+	for !t.quit {
+		t.draw()
+		select {
+		case k, ok := <-keys:
+			if !ok {
+				return nil // the terminal went away
+			}
+			t.lastKey = time.Now()
+			t.status, t.statusErr = "", false
+			t.handle(k)
+		case <-resize:
+		case <-quit:
+			return nil
+		case now := <-tick.C:
+			t.tick(now)
+		}
+	}
+	return nil
+}
+
+// tick clears the clipboard and locks the vault when their time is up.
+func (t *tui) tick(now time.Time) {
+	if !t.clipUntil.IsZero() && !now.Before(t.clipUntil) {
+		t.say("Clipboard cleared.")
+		t.clearClipboard()
+	}
+	if t.idle > 0 && t.mode != modeLocked && now.Sub(t.lastKey) >= t.idle {
+		t.lock()
+		t.say("Locked after " + t.idle.String() + " without a key press.")
+	}
+}
+
+func (t *tui) say(msg string) { t.status, t.statusErr = msg, false }
+
+func (t *tui) sayErr(where string, err error) {
+	t.status, t.statusErr = where+" error: "+err.Error(), true
+}
+
+// ---------- vault state ----------
+
+// reindex rebuilds the search index after a change and refilters the list.
+func (t *tui) reindex() error {
+	recs, err := t.v.Records(t.key)
+	if err != nil {
+		return err
+	}
+	if t.ix != nil {
+		t.ix.Wipe()
+	}
+	t.ix = search.Build(recs)
+	t.refilter("")
+	return nil
+}
+
+// refilter applies the filter to the list, keeping keepID (or else the
+// current entry) selected if it is still there. Without a filter the list
+// is every entry by title.
+func (t *tui) refilter(keepID string) {
+	if keepID == "" && t.sel < len(t.list) {
+		keepID = t.list[t.sel].ID
+	}
+	if q := strings.TrimSpace(string(t.filter)); q != "" {
+		t.list = searchEntries(t.v, t.ix, q)
+	} else {
+		t.list = t.v.List()
+		slices.SortFunc(t.list, func(a, b pwmanager.CipherEntry) int {
+			if c := strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title)); c != 0 {
+				return c
+			}
+			return strings.Compare(a.ID, b.ID)
+		})
+	}
+	sel := slices.IndexFunc(t.list, func(e pwmanager.CipherEntry) bool { return e.ID == keepID })
+	t.selectAt(max(sel, 0))
+}
+
+// selectAt moves the selection, hiding what the previous entry revealed.
+func (t *tui) selectAt(i int) {
+	i = max(min(i, len(t.list)-1), 0)
+	if t.detail != nil && (i >= len(t.list) || t.list[i].ID != t.detail.ID) {
+		t.detail, t.detailErr = nil, nil
+	}
+	if i != t.sel {
+		t.reveal = false
+	}
+	t.sel = i
+}
+
+// selected returns the selected entry, decrypting it on first use.
+func (t *tui) selected() (*entryView, error) {
+	if t.sel >= len(t.list) {
+		return nil, nil
+	}
+	id := t.list[t.sel].ID
+	if t.detail == nil || t.detail.ID != id {
+		plain, meta, err := t.v.GetDecrypted(t.key, id)
+		t.detail, t.detailErr = nil, err
+		if err == nil {
+			t.detail = &entryView{ID: id, Title: meta.Title, PlainEntry: *plain}
+		}
+	}
+	return t.detail, t.detailErr
+}
+
+// password returns the selected entry's password, computing it for a
+// derived entry. The ui never prompts, so that needs the master password
+// from the command line or the lock screen, or the agent's derivation key.
+func (t *tui) password(e *entryView) (string, error) {
+	if !e.IsDerived() || e.Password != "" {
+		return e.Password, nil
+	}
+	if t.master == nil || (!t.master.given() && !agentDerivKey.Alive()) {
+		return "", errors.New("a derived password needs the master password; lock (L) and unlock to enter it")
+	}
+	dk, err := derivationKey(t.master)
+	if err != nil {
+		return "", err
+	}
+	defer dk.Destroy()
+	if err := e.ResolveDerived(dk); err != nil {
+		return "", err
+	}
+	return e.Password, nil
+}
+
+// lock drops the keys and everything decrypted, and shows the lock screen.
+func (t *tui) lock() {
+	t.clearClipboard()
+	t.wipe()
+	t.mode, t.pwInput = modeLocked, nil
+}
+
+func (t *tui) wipe() {
+	t.key.Destroy()
+	agentDerivKey.Destroy()
+	if t.ix != nil {
+		t.ix.Wipe()
+	}
+	t.key, agentDerivKey, t.ix, t.master = nil, nil, nil, nil
+	t.detail, t.detailErr, t.form, t.list, t.reveal = nil, nil, nil, nil, false
+}
+
+// unlock reloads the vault, which may have changed meanwhile, and opens it
+// with the password typed at the lock screen.
+func (t *tui) unlock() {
+	pw := string(t.pwInput)
+	t.pwInput = nil
+	v, err := pwmanager.Load(t.file)
+	if err != nil {
+		t.sayErr("load", err)
+		return
+	}
+	key, err := v.Unlock(pw)
+	if err != nil {
+		t.sayErr("unlock", err)
+		return
+	}
+	t.v, t.key, t.master = v, key, knownMaster(pw)
+	if err := t.reindex(); err != nil {
+		t.sayErr("decrypt", err)
+		t.lock()
+		return
+	}
+	t.mode = modeBrowse
+	t.say("Unlocked.")
+}
+
+// copyToClipboard puts text on the clipboard, through OSC 52 when there is
+// no graphical session, so it works on a remote host too. It is cleared
+// after --clip-clear, on lock and on quit, unless something else was copied
+// meanwhile.
+func (t *tui) copyToClipboard(what, text string) {
+	if t.clip == nil {
+		b, err := clipboard.Detect()
+		if err != nil {
+			t.sayErr("clipboard", err)
+			return
+		}
+		t.clip = b
+	}
+	if err := t.clip.Copy(text); err != nil {
+		t.sayErr("clipboard", err)
+		return
+	}
+	if t.clipClear <= 0 {
+		t.clipUntil = time.Time{}
+		t.say(what + " copied.")
+		return
+	}
+	t.clipUntil, t.clipDigest = time.Now().Add(t.clipClear), clipboard.DigestOf(text)
+	t.say(fmt.Sprintf("%s copied; the clipboard is cleared in %s.", what, t.clipClear))
+}
+
+func (t *tui) clearClipboard() {
+	if t.clipUntil.IsZero() {
+		return
+	}
+	t.clipUntil = time.Time{}
+	if _, err := clipboard.ClearIf(t.clip, t.clipDigest); err != nil {
+		t.sayErr("clipboard", err)
+	}
+}
+
+// ---------- keys ----------
+
+func (t *tui) handle(k key) {
+	if k.name == "ctrl-c" && t.mode != modeForm {
+		t.quit = true
+		return
+	}
+	switch t.mode {
+	case modeLocked:
+		t.handleLocked(k)
+	case modeForm:
+		t.handleForm(k)
+	case modeConfirm:
+		t.handleConfirm(k)
+	case modeFilter:
+		t.handleFilter(k)
+	default:
+		t.handleBrowse(k)
+	}
+}
+
+func (t *tui) handleBrowse(k key) {
+	page := max(t.bodyHeight()-1, 1)
+	switch {
+	case k.name == "up" || k.r == 'k':
+		t.selectAt(t.sel - 1)
+	case k.name == "down" || k.r == 'j':
+		t.selectAt(t.sel + 1)
+	case k.name == "pgup":
+		t.selectAt(t.sel - page)
+	case k.name == "pgdn":
+		t.selectAt(t.sel + page)
+	case k.name == "home" || k.r == 'g':
+		t.selectAt(0)
+	case k.name == "end" || k.r == 'G':
+		t.selectAt(len(t.list) - 1)
+	case k.r == '/':
+		t.mode, t.showDetail = modeFilter, false
+	case k.name == "enter":
+		t.showDetail = !t.showDetail
+	case k.name == "esc":
+		if t.showDetail {
+			t.showDetail = false
+		} else if len(t.filter) > 0 {
+			t.filter = nil
+			t.refilter("")
+		}
+	case k.r == 'q':
+		t.quit = true
+	case k.r == 'L':
+		t.lock()
+		t.say("Locked.")
+	case k.r == 'a':
+		t.form = newEntryForm(nil)
+		t.mode = modeForm
+	case k.r == 'r', k.r == 'c', k.r == 'u', k.r == 'e', k.r == 'd':
+		e, err := t.selected()
+		if err != nil {
+			t.sayErr("show", err)
+			return
+		}
+		if e == nil {
+			t.say("No entry selected.")
+			return
+		}
+		t.onEntry(k.r, e)
+	}
+}
+
+// onEntry carries out the browse keys that act on the selected entry.
+func (t *tui) onEntry(r rune, e *entryView) {
+	switch r {
+	case 'r':
+		if !t.reveal {
+			if _, err := t.password(e); err != nil {
+				t.sayErr("derive", err)
+				return
+			}
+		}
+		t.reveal = !t.reveal
+	case 'c':
+		pw, err := t.password(e)
+		if err != nil {
+			t.sayErr("derive", err)
+			return
+		}
+		t.copyToClipboard("Password", pw)
+	case 'u':
+		t.copyToClipboard("Username", e.Username)
+	case 'e':
+		t.form = newEntryForm(e)
+		t.mode = modeForm
+	case 'd':
+		t.mode = modeConfirm
+	}
+}
+
+func (t *tui) handleFilter(k key) {
+	switch {
+	case k.printable():
+		t.filter = append(t.filter, k.r)
+	case k.name == "backspace":
+		if len(t.filter) > 0 {
+			t.filter = t.filter[:len(t.filter)-1]
+		}
+	case k.name == "ctrl-u":
+		t.filter = nil
+	case k.name == "esc":
+		t.filter = nil
+		t.mode = modeBrowse
+	case k.name == "enter":
+		t.mode = modeBrowse
+		return
+	case k.name == "up", k.name == "down", k.name == "pgup", k.name == "pgdn":
+		t.handleBrowse(k)
+		return
+	default:
+		return
+	}
+	t.refilter("")
+	if k.name != "esc" {
+		t.selectAt(0) // the best match
+	}
+}
+
+func (t *tui) handleConfirm(k key) {
+	t.mode = modeBrowse
+	if k.r != 'y' && k.r != 'Y' {
+		t.say("Kept.")
+		return
+	}
+	e := t.list[t.sel]
+	if err := t.v.MoveToTrash(e.ID); err != nil {
+		t.sayErr("delete", err)
+		return
+	}
+	if err := t.v.Save(t.file); err != nil {
+		t.sayErr("save", err)
+		return
+	}
+	t.detail = nil
+	if err := t.reindex(); err != nil {
+		t.sayErr("decrypt", err)
+	}
+	t.say(fmt.Sprintf("Moved %q to the trash (trash restore brings it back).", e.Title))
+}
+
+func (t *tui) handleLocked(k key) {
+	switch {
+	case k.printable():
+		t.pwInput = append(t.pwInput, k.r)
+	case k.name == "backspace":
+		if len(t.pwInput) > 0 {
+			t.pwInput = t.pwInput[:len(t.pwInput)-1]
+		}
+	case k.name == "ctrl-u", k.name == "esc":
+		t.pwInput = nil
+	case k.name == "enter" && len(t.pwInput) > 0:
+		t.unlock()
+	}
+}
+
+// ---------- drawing ----------
+
+// bodyHeight is the number of rows between the filter line and the status line.
+func (t *tui) bodyHeight() int { return t.h - 4 }
+
+// narrow reports whether the list and the details take turns on the screen.
+func (t *tui) narrow() bool { return t.w < 70 }
+
+// draw repaints the whole screen in one write. Rows are padded to the full
+// width instead of erased, so there is no flicker over slow links.
+func (t *tui) draw() {
+	if w, h, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		t.w, t.h = w, h
+	}
+	var rows []string
+	switch {
+	case t.w < 40 || t.h < 10:
+		rows = make([]string, max(t.h, 1))
+		rows[0] = fit("terminal too small", t.w)
+	case t.mode == modeLocked:
+		rows = t.lockRows()
+	default:
+		rows = append(rows, t.titleRow(), t.filterRow())
+		var body []string
+		switch {
+		case t.mode == modeForm:
+			body = t.formRows(t.w, t.bodyHeight())
+		case t.narrow() && t.showDetail:
+			body = t.detailRows(t.w, t.bodyHeight())
+		case t.narrow():
+			body = t.listRows(t.w, t.bodyHeight())
+		default:
+			lw := min(max(t.w/3, 24), 50)
+			list := t.listRows(lw, t.bodyHeight())
+			detail := t.detailRows(t.w-lw-3, t.bodyHeight())
+			for i := range list {
+				body = append(body, list[i]+styleDim+" │ "+styleReset+detail[i])
+			}
+		}
+		rows = append(rows, body...)
+		rows = append(rows, t.statusRow(), seg(styleDim, t.help(), t.w))
+	}
+	var b strings.Builder
+	for i, r := range rows {
+		fmt.Fprintf(&b, "\x1b[%d;1H%s", i+1, r)
+	}
+	os.Stdout.WriteString(b.String())
+}
+
+func (t *tui) titleRow() string {
+	left := fmt.Sprintf(" %s — %d entries", filepath.Base(t.file), len(t.v.Entries))
+	var right []string
+	now := time.Now()
+	if !t.clipUntil.IsZero() {
+		right = append(right, fmt.Sprintf("clipboard clears in %ds", int(t.clipUntil.Sub(now).Seconds()+0.5)))
+	}
+	if t.idle > 0 {
+		left := max(t.idle-now.Sub(t.lastKey), 0).Round(time.Second)
+		right = append(right, fmt.Sprintf("locks in %d:%02d", int(left.Minutes()), int(left.Seconds())%60))
+	}
+	r := strings.Join(right, "  ·  ") + " "
+	return styleReverse + fit(left, max(t.w-len([]rune(r)), 0)) + fit(r, min(len([]rune(r)), t.w)) + styleReset
+}
+
+func (t *tui) filterRow() string {
+	switch {
+	case t.mode == modeFilter:
+		return seg(styleBold, " / "+string(t.filter)+"▏", t.w)
+	case len(t.filter) > 0:
+		return fit(fmt.Sprintf(" / %s   (%d of %d; Esc clears)", string(t.filter), len(t.list), len(t.v.Entries)), t.w)
+	}
+	return seg(styleDim, " / to filter: words, or user:, url:, notes:, tag:", t.w)
+}
+
+func (t *tui) statusRow() string {
+	switch {
+	case t.mode == modeConfirm:
+		return seg(styleBold, fmt.Sprintf(" Move %q to the trash? y/n", t.list[t.sel].Title), t.w)
+	case t.statusErr:
+		return seg(styleError, " "+t.status, t.w)
+	}
+	return fit(" "+t.status, t.w)
+}
+
+func (t *tui) help() string {
+	switch t.mode {
+	case modeFilter:
+		return " type to filter  ↑↓ move  Enter done  Esc clear"
+	case modeForm:
+		return " Tab/↓ next  ↑ prev  ^G generate  ^R reveal  ^S save  Esc cancel"
+	case modeLocked:
+		return " Enter unlock  ^C quit"
+	}
+	if t.narrow() {
+		return " Enter details  r reveal  c copy  e edit  / filter  q quit"
+	}
+	return " ↑↓ move  / filter  r reveal  c copy password  u copy user  a add  e edit  d delete  L lock  q quit"
+}
+
+func (t *tui) listRows(w, h int) []string {
+	rows := make([]string, h)
+	if len(t.list) == 0 {
+		rows[0] = seg(styleDim, " (no entries; a adds one)", w)
+		if len(t.filter) > 0 {
+			rows[0] = seg(styleDim, " (no match)", w)
+		}
+		for i := 1; i < h; i++ {
+			rows[i] = fit("", w)
+		}
+		return rows
+	}
+	// keep the selection in view
+	if t.sel < t.top {
+		t.top = t.sel
+	}
+	if t.sel >= t.top+h {
+		t.top = t.sel - h + 1
+	}
+	t.top = max(min(t.top, len(t.list)-h), 0)
+	for i := range rows {
+		n := t.top + i
+		switch {
+		case n >= len(t.list):
+			rows[i] = fit("", w)
+		case n == t.sel:
+			rows[i] = seg(styleReverse, " "+t.list[n].Title, w)
+		default:
+			rows[i] = fit(" "+t.list[n].Title, w)
+		}
+	}
+	return rows
+}
+
+// mask stands in for a hidden value; it does not give away the length.
+const mask = "••••••••"
+
+func (t *tui) detailRows(w, h int) []string {
+	var lines []string
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, fmt.Sprintf("%-10s %s", label, value))
+		}
+	}
+	e, err := t.selected()
+	switch {
+	case err != nil:
+		lines = []string{"Show error: " + err.Error()}
+	case e == nil:
+	default:
+		hidden := func(v string) string {
+			if t.reveal {
+				return v
+			}
+			return mask + "  (r reveals)"
+		}
+		lines = append(lines, e.Title, "")
+		if e.Kind != pwmanager.KindLogin {
+			add("Kind", e.Kind)
+		}
+		add("Username", e.Username)
+		switch {
+		case e.IsDerived() && t.reveal:
+			add("Password", e.Password)
+		case e.IsDerived():
+			add("Password", mask+"  (derived; r computes it)")
+		case e.Password != "":
+			add("Password", hidden(e.Password))
+		}
+		if d := e.Derived; d != nil {
+			add("Derived", fmt.Sprintf("%s, counter %d, %d characters", d.Site, d.Counter, d.Length))
+		}
+		add("URL", e.URL)
+		add("Folder", e.Folder)
+		add("Tags", strings.Join(e.Tags, ", "))
+		if e.TOTP != "" {
+			add("TOTP", hidden(e.TOTP))
+		}
+		for _, f := range e.Fields {
+			v := f.Value
+			if f.Hidden {
+				v = hidden(v)
+			}
+			add(f.Name, v)
+		}
+		if e.Expiry != nil {
+			add("Expiry", e.Expiry.String())
+		}
+		if p := e.Pending; p != nil {
+			add("Pending", hidden(p.Password)+"  since "+p.CreatedAt.Format("2006-01-02"))
+		}
+		if len(e.History) > 0 {
+			add("History", fmt.Sprintf("%d earlier passwords", len(e.History)))
+		}
+		for _, a := range e.Attachments {
+			add("Attached", fmt.Sprintf("%s (%d bytes)", a.Name, len(a.Data)))
+		}
+		add("Modified", e.ModifiedAt.Local().Format("2006-01-02 15:04"))
+		if e.Notes != "" {
+			lines = append(lines, "", "Notes")
+			lines = append(lines, wrap(e.Notes, max(w-2, 1))...)
+		}
+	}
+	rows := make([]string, h)
+	for i := range rows {
+		if i < len(lines) {
+			style := ""
+			if i == 0 {
+				style = styleBold
+			}
+			rows[i] = seg(style, " "+lines[i], w)
+		} else {
+			rows[i] = fit("", w)
+		}
+	}
+	return rows
+}
+
+func (t *tui) lockRows() []string {
+	rows := make([]string, t.h)
+	for i := range rows {
+		rows[i] = fit("", t.w)
+	}
+	mid := t.h/2 - 2
+	rows[mid] = seg(styleBold, center(filepath.Base(t.file)+" is locked", t.w), t.w)
+	rows[mid+2] = fit(center("Master password: "+strings.Repeat("•", len(t.pwInput))+"▏", t.w), t.w)
+	if t.status != "" {
+		style := ""
+		if t.statusErr {
+			style = styleError
+		}
+		rows[mid+4] = seg(style, center(t.status, t.w), t.w)
+	}
+	rows[t.h-1] = seg(styleDim, t.help(), t.w)
+	return rows
+}
+
+func center(s string, w int) string {
+	return strings.Repeat(" ", max((w-len([]rune(s)))/2, 0)) + s
+}
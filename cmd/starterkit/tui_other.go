@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package main
+
+import "os"
+
+func watchSignals(resize, quit chan<- struct{}) (stop func()) { return func() {} }
+
+func enableVT(*os.File) (restore func()) { return func() {} }
@@ -0,0 +1,42 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSignals reports terminal resizes on resize, and a hangup or
+// termination on quit, so the ui can restore the terminal and clear the
+// clipboard on the way out. The returned func stops it.
+func watchSignals(resize, quit chan<- struct{}) (stop func()) {
+	sigs := make(chan os.Signal, 4)
+	signal.Notify(sigs, syscall.SIGWINCH, syscall.SIGHUP, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case s := <-sigs:
+				ch := quit
+				if s == syscall.SIGWINCH {
+					ch = resize
+				}
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// enableVT is a no-op: Unix terminals understand escape sequences.
+func enableVT(*os.File) (restore func()) { return func() {} }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// watchSignals is a no-op on Windows, which has no SIGWINCH: the ui picks up
+// a new console size when it next redraws, at the latest a second later.
+func watchSignals(resize, quit chan<- struct{}) (stop func()) { return func() {} }
+
+// enableVT turns on escape-sequence processing for the console, which
+// Windows 10 and later support but leave off for older programs.
+func enableVT(f *os.File) (restore func()) {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if windows.GetConsoleMode(h, &mode) != nil {
+		return func() {}
+	}
+	windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	return func() { windows.SetConsoleMode(h, mode) }
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Fields of the add/edit form, in order.
+const (
+	formTitle = iota
+	formUsername
+	formPassword
+	formURL
+	formNotes
+	formFields
+)
+
+var formLabels = [formFields]string{"Title", "Username", "Password", "URL", "Notes"}
+
+// entryForm is the add/edit form of the ui.
+type entryForm struct {
+	id      string // the entry being edited; "" for a new one
+	values  [formFields][]rune
+	orig    [formFields]string
+	field   int
+	reveal  bool
+	derived bool // the password is derived: derive --rotate changes it, not the form
+}
+
+// newEntryForm starts a form for e, or for a new entry if e is nil.
+func newEntryForm(e *entryView) *entryForm {
+	f := &entryForm{}
+	if e != nil {
+		f.id, f.derived = e.ID, e.IsDerived()
+		f.orig = [formFields]string{e.Title, e.Username, e.Password, e.URL, e.Notes}
+		if f.derived {
+			f.orig[formPassword] = ""
+		}
+	}
+	for i, v := range f.orig {
+		f.values[i] = []rune(v)
+	}
+	return f
+}
+
+func (f *entryForm) value(i int) string { return string(f.values[i]) }
+
+func (t *tui) handleForm(k key) {
+	f := t.form
+	editable := f.field != formPassword || !f.derived
+	switch {
+	case k.name == "esc", k.name == "ctrl-c":
+		t.form, t.mode = nil, modeBrowse
+		t.say("Not saved.")
+	case k.name == "tab", k.name == "down":
+		f.field = (f.field + 1) % formFields
+	case k.name == "backtab", k.name == "up":
+		f.field = (f.field + formFields - 1) % formFields
+	case k.name == "enter" && f.field < formFields-1:
+		f.field++
+	case k.name == "enter", k.name == "ctrl-s":
+		t.saveForm()
+	case k.name == "ctrl-r":
+		f.reveal = !f.reveal
+	case k.name == "ctrl-g":
+		t.generateInForm()
+	case !editable:
+		if k.printable() || k.name == "backspace" {
+			t.say("The password is derived; change it with derive --rotate.")
+		}
+	case k.printable():
+		f.values[f.field] = append(f.values[f.field], k.r)
+	case k.name == "backspace":
+		if v := f.values[f.field]; len(v) > 0 {
+			f.values[f.field] = v[:len(v)-1]
+		}
+	case k.name == "ctrl-u":
+		f.values[f.field] = nil
+	}
+}
+
+// generateInForm fills in a random password, following the site's password
+// rules when the URL has known ones.
+func (t *tui) generateInForm() {
+	f := t.form
+	if f.derived {
+		t.say("The password is derived; change it with derive --rotate.")
+		return
+	}
+	opts := pwmanager.PasswordOptions{Length: 20, IncludeUpper: true, IncludeLower: true, IncludeNumbers: true, IncludeSymbols: true}
+	rules, domain, ok := pwmanager.SiteRules(f.value(formURL))
+	if ok {
+		opts.Rules = rules
+	}
+	pw, err := pwmanager.GeneratePassword(opts)
+	if err != nil {
+		t.sayErr("generate", err)
+		return
+	}
+	f.values[formPassword], f.field = []rune(pw), formPassword
+	if ok {
+		t.say(fmt.Sprintf("Generated %d characters following the password rules for %s.", len(pw), domain))
+	} else {
+		t.say(fmt.Sprintf("Generated %d characters.", len(pw)))
+	}
+}
+
+// saveForm adds the entry, or writes the fields that changed, and saves the vault.
+func (t *tui) saveForm() {
+	f := t.form
+	title := strings.TrimSpace(f.value(formTitle))
+	if title == "" {
+		t.sayErr("save", errors.New("the title is empty"))
+		f.field = formTitle
+		return
+	}
+	f.values[formTitle] = []rune(title)
+
+	id, action := f.id, "Updated"
+	if id == "" {
+		var err error
+		id, err = t.v.AddEntry(t.key, title, f.value(formUsername), f.value(formPassword), f.value(formURL), f.value(formNotes))
+		if err != nil {
+			t.sayErr("add", err)
+			return
+		}
+		action = "Added"
+	} else {
+		var changed [formFields]*string
+		n := 0
+		for i := range changed {
+			if v := f.value(i); v != f.orig[i] && (i != formPassword || !f.derived) {
+				changed[i] = &v
+				n++
+			}
+		}
+		if n == 0 {
+			t.form, t.mode = nil, modeBrowse
+			t.say("No changes.")
+			return
+		}
+		err := t.v.UpdateEntry(t.key, id, changed[formTitle], changed[formUsername], changed[formPassword], changed[formURL], changed[formNotes])
+		if err != nil {
+			t.sayErr("edit", err)
+			return
+		}
+	}
+	if err := t.v.Save(t.file); err != nil {
+		t.sayErr("save", err)
+		return
+	}
+	t.form, t.mode, t.detail = nil, modeBrowse, nil
+	if err := t.reindex(); err != nil {
+		t.sayErr("decrypt", err)
+		return
+	}
+	t.refilter(id)
+	t.say(fmt.Sprintf("%s %q.", action, title))
+}
+
+func (t *tui) formRows(w, h int) []string {
+	f := t.form
+	heading := "New entry"
+	if f.id != "" {
+		heading = fmt.Sprintf("Edit %q", f.orig[formTitle])
+	}
+	lines := []string{heading, ""}
+	for i, label := range formLabels {
+		v := f.value(i)
+		switch {
+		case i == formPassword && f.derived:
+			v = mask + "  (derived; derive --rotate changes it)"
+		case i == formPassword && !f.reveal:
+			v = strings.Repeat("•", len(f.values[i]))
+		}
+		cursor, mark := "", "  "
+		if i == f.field {
+			cursor, mark = "▏", "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-9s %s%s", mark, label, v, cursor))
+	}
+	if pw := f.value(formPassword); !f.derived && pw != "" {
+		score, feedback := pwmanager.AnalyzePasswordStrength(pw, f.value(formTitle), f.value(formUsername), f.value(formURL))
+		lines = append(lines, "", fmt.Sprintf("  %-9s %d/100  %s", "Strength", score, feedback[len(feedback)-1]))
+		for _, fb := range feedback[:len(feedback)-1] {
+			lines = append(lines, "            "+fb)
+		}
+	}
+	if _, domain, ok := pwmanager.SiteRules(f.value(formURL)); ok && !f.derived {
+		lines = append(lines, fmt.Sprintf("  %-9s ^G follows the password rules for %s", "Rules", domain))
+	}
+	rows := make([]string, h)
+	for i := range rows {
+		switch {
+		case i >= len(lines):
+			rows[i] = fit("", w)
+		case i == 0:
+			rows[i] = seg(styleBold, " "+lines[i], w)
+		case i == f.field+2:
+			rows[i] = seg(styleBold, " "+lines[i], w)
+		default:
+			rows[i] = fit(" "+lines[i], w)
+		}
+	}
+	return rows
+}
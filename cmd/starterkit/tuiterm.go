@@ -0,0 +1,148 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Terminal control sequences used by the ui. Only widely supported VT100 and
+// xterm sequences, so the ui also works over SSH and inside tmux or screen.
+const (
+	altScreenOn  = "\x1b[?1049h"
+	altScreenOff = "\x1b[?1049l"
+	cursorHide   = "\x1b[?25l"
+	cursorShow   = "\x1b[?25h"
+
+	styleReset   = "\x1b[0m"
+	styleBold    = "\x1b[1m"
+	styleDim     = "\x1b[2m"
+	styleReverse = "\x1b[7m"
+	styleError   = "\x1b[1;31m"
+)
+
+// key is one key press: a printable rune, or a name such as "up", "enter",
+// "esc", "backtab" or "ctrl-g".
+type key struct {
+	r    rune
+	name string
+}
+
+// csiKeys names the CSI and SS3 sequences of the keys the ui uses.
+var csiKeys = map[string]string{
+	"A": "up", "B": "down", "C": "right", "D": "left",
+	"H": "home", "F": "end", "1~": "home", "7~": "home", "4~": "end", "8~": "end",
+	"5~": "pgup", "6~": "pgdn", "3~": "delete", "Z": "backtab",
+}
+
+// readKeys decodes key presses from the raw terminal r until it fails.
+func readKeys(r io.Reader, keys chan<- key) {
+	buf := make([]byte, 256)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		for b := buf[:n]; len(b) > 0; {
+			k, used := parseKey(b)
+			if k != (key{}) {
+				keys <- k
+			}
+			b = b[used:]
+		}
+	}
+}
+
+// parseKey decodes the first key press in b and reports how many bytes it
+// took. An escape sequence arrives in one read, so an ESC on its own is the
+// Esc key. Unknown sequences decode to the zero key.
+func parseKey(b []byte) (key, int) {
+	c := b[0]
+	switch {
+	case c == 0x1b:
+		if len(b) == 1 {
+			return key{name: "esc"}, 1
+		}
+		if b[1] != '[' && b[1] != 'O' {
+			return key{name: "esc"}, 1 // Alt+key: the key follows on its own
+		}
+		// parameters, then a final byte in 0x40-0x7e
+		i := 2
+		for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+			i++
+		}
+		if i == len(b) {
+			return key{}, len(b)
+		}
+		return key{name: csiKeys[string(b[2:i+1])]}, i + 1
+	case c == '\r' || c == '\n':
+		return key{name: "enter"}, 1
+	case c == '\t':
+		return key{name: "tab"}, 1
+	case c == 0x7f || c == 0x08:
+		return key{name: "backspace"}, 1
+	case c < 0x20:
+		return key{name: "ctrl-" + string(rune('a'+c-1))}, 1
+	}
+	r, n := utf8.DecodeRune(b)
+	return key{r: r}, n
+}
+
+// printable reports whether k types a character.
+func (k key) printable() bool {
+	return k.name == "" && k.r >= ' ' && k.r != 0x7f
+}
+
+// clean replaces control characters, so text from the vault cannot smuggle
+// escape sequences to the terminal.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || (r >= 0x7f && r < 0xa0) {
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// fit cuts or pads s to exactly w cells, marking a cut with an ellipsis.
+// Every rune is taken to be one cell wide.
+func fit(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	r := []rune(clean(s))
+	if len(r) > w {
+		return string(r[:w-1]) + "…"
+	}
+	return string(r) + strings.Repeat(" ", w-len(r))
+}
+
+// seg is fit with a style, reset at the end.
+func seg(style, s string, w int) string {
+	if style == "" {
+		return fit(s, w)
+	}
+	return style + fit(s, w) + styleReset
+}
+
+// wrap breaks s into lines of at most w runes, at spaces where it can.
+func wrap(s string, w int) []string {
+	var out []string
+	for _, para := range strings.Split(s, "\n") {
+		r := []rune(clean(para))
+		for len(r) > w {
+			cut := w
+			for i := w; i > w/2; i-- {
+				if r[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			out = append(out, string(r[:cut]))
+			r = []rune(strings.TrimLeft(string(r[cut:]), " "))
+		}
+		out = append(out, string(r))
+	}
+	return out
+}
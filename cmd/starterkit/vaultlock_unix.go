@@ -0,0 +1,33 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockVault takes an exclusive lock on the vault file at path, waiting for
+// another process that holds it, and returns the function that releases it.
+// The lock is advisory: it only keeps out other holders of lockVault, such as
+// concurrent git credential runs, between their Load and Save. It uses flock
+// rather than fcntl, whose locks are dropped as soon as Save closes its own
+// descriptor of the file.
+func lockVault(path string) (unlock func(), err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+		if err != unix.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() { f.Close() }, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockVault takes an exclusive lock on the vault file at path, waiting for
+// another process that holds it, and returns the function that releases it.
+// Windows locks are mandatory, so the locked byte lies far past the end of
+// the file, where Save never writes; other holders of lockVault still wait
+// for it.
+func lockVault(path string) (unlock func(), err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	h := windows.Handle(f.Fd())
+	ol := &windows.Overlapped{OffsetHigh: 0x40000000}
+	if err := windows.LockFileEx(h, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		windows.UnlockFileEx(h, 0, 1, 0, ol)
+		f.Close()
+	}, nil
+}
@@ -5,10 +5,13 @@ go 1.24.0
 require (
 	github.com/lxn/walk v0.0.0-20210112085537-c389da54e794
 	golang.org/x/crypto v0.42.0
+	golang.org/x/net v0.44.0
+	golang.org/x/sys v0.36.0
+	golang.org/x/term v0.35.0
 )
 
 require (
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e // indirect
-	golang.org/x/sys v0.36.0 // indirect
+	golang.org/x/text v0.29.0 // indirect
 	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
 )
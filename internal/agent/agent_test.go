@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"bytes"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func startAgent(t *testing.T, idle, maxLife time.Duration) (*Server, string) {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "freebsd" {
+		t.Skip("no peer credentials on", runtime.GOOS)
+	}
+	sock := filepath.Join(t.TempDir(), "run", "agent.sock")
+	l, err := Listen(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(idle, maxLife)
+	s.Logf = t.Logf
+	go s.Serve(l)
+	t.Cleanup(func() { l.Close(); s.Lock() })
+	return s, sock
+}
+
+func testVault(t *testing.T) (file string, key []byte) {
+	t.Helper()
+	v, k, err := pwmanager.Create("agent-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Destroy()
+	if _, err := v.AddEntry(k, "GitHub", "alice", "gh-pass", "https://github.com", ""); err != nil {
+		t.Fatal(err)
+	}
+	file = filepath.Join(t.TempDir(), "vault.json")
+	if err := v.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	return file, bytes.Clone(k.Bytes())
+}
+
+func TestAgent(t *testing.T) {
+	_, sock := startAgent(t, time.Hour, 0)
+	file, want := testVault(t)
+
+	c, err := Dial(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, _, err := c.Keys(file); err != ErrLocked {
+		t.Fatalf("Keys before unlock = %v", err)
+	}
+	if err := c.Unlock(file, "wrong"); err == nil {
+		t.Fatal("unlocked with the wrong master password")
+	}
+	if err := c.Unlock(file, "agent-master"); err != nil {
+		t.Fatal(err)
+	}
+	key, dk, err := c.Keys(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key.Bytes(), want) || dk.Len() != 32 {
+		t.Error("Keys returned the wrong key")
+	}
+	key.Destroy()
+	dk.Destroy()
+	if _, _, err := c.Keys(filepath.Join(filepath.Dir(file), "other.json")); err == nil {
+		t.Error("handed out the key for another vault")
+	}
+
+	e, err := c.Get(file, "", "github")
+	if err != nil || e.Plain.Password != "gh-pass" || e.Title != "GitHub" {
+		t.Fatalf("Get = %+v, %v", e, err)
+	}
+	st, err := c.Status()
+	if err != nil || !st.Unlocked || st.File != file || st.IdleLock.IsZero() || !st.HardLock.IsZero() {
+		t.Errorf("Status = %+v, %v", st, err)
+	}
+
+	if err := c.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(file, "", "GitHub"); err != ErrLocked {
+		t.Errorf("Get after lock = %v", err)
+	}
+}
+
+func TestAgentTimeouts(t *testing.T) {
+	file, _ := testVault(t)
+	for _, tt := range []struct {
+		name          string
+		idle, maxLife time.Duration
+	}{
+		{"idle", 100 * time.Millisecond, 0},
+		{"max lifetime", time.Hour, 100 * time.Millisecond},
+	} {
+		_, sock := startAgent(t, tt.idle, tt.maxLife)
+		c, err := Dial(sock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Unlock(file, "agent-master"); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if st, err := c.Status(); err != nil || st.Unlocked {
+			t.Errorf("%s: still unlocked: %+v, %v", tt.name, st, err)
+		}
+		c.Close()
+	}
+}
+
+func TestSocketChecks(t *testing.T) {
+	_, sock := startAgent(t, 0, 0)
+	if _, err := Listen(sock); err == nil {
+		t.Error("a second agent took over a live socket")
+	}
+
+	open := filepath.Join(t.TempDir(), "open")
+	if err := os.Mkdir(open, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.Chmod(open, 0755) // regardless of umask
+	if _, err := Listen(filepath.Join(open, "agent.sock")); err == nil {
+		t.Error("listened in a directory other users can enter")
+	}
+
+	link := filepath.Join(t.TempDir(), "link.sock")
+	if err := os.Symlink(sock, link); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Dial(link); err == nil {
+		t.Error("dialed through a symlink")
+	}
+
+	// a raw connection that sends garbage is dropped, not answered
+	c, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Write([]byte{0xff, 0xff, 0xff, 0xff})
+	c.SetReadDeadline(time.Now().Add(time.Second))
+	if n, _ := c.Read(make([]byte, 1)); n != 0 {
+		t.Error("agent answered an oversized frame")
+	}
+}
@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"errors"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+// Client is a connection to an agent. It is not safe for concurrent use.
+type Client struct {
+	conn net.Conn
+}
+
+// Dial connects to the agent socket at path after checking that it belongs
+// to the current user, so the master password is never sent to an agent
+// someone else planted.
+func Dial(path string) (*Client, error) {
+	if err := checkPrivate(path, false); err != nil {
+		return nil, err
+	}
+	c, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: c}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error { return c.conn.Close() }
+
+func (c *Client) call(req *Request) (*Response, error) {
+	if err := writeFrame(c.conn, req); err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := readFrame(c.conn, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		secret.Wipe(resp.MasterKey)
+		secret.Wipe(resp.DerivationKey)
+		// errors callers test for survive the trip by their text
+		for _, known := range []error{ErrLocked, pwmanager.ErrWrongPassword} {
+			if resp.Error == known.Error() {
+				return nil, known
+			}
+		}
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Unlock makes the agent unlock the vault at file with master.
+func (c *Client) Unlock(file, master string) error {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return err
+	}
+	pw := []byte(master)
+	defer secret.Wipe(pw)
+	_, err = c.call(&Request{Op: OpUnlock, File: abs, Master: pw})
+	return err
+}
+
+// Lock makes the agent destroy its keys.
+func (c *Client) Lock() error {
+	_, err := c.call(&Request{Op: OpLock})
+	return err
+}
+
+// Status reports the agent's state.
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.call(&Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, errors.New("agent sent no status")
+	}
+	return resp.Status, nil
+}
+
+// Keys fetches the master and derivation keys of the vault at file. The
+// caller destroys both.
+func (c *Client) Keys(file string) (key, derivKey *secret.Buffer, err error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.call(&Request{Op: OpKeys, File: abs})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer secret.Wipe(resp.DerivationKey)
+	if len(resp.MasterKey) == 0 || len(resp.DerivationKey) == 0 {
+		secret.Wipe(resp.MasterKey)
+		return nil, nil, errors.New("agent sent no keys")
+	}
+	if key, err = secret.FromBytes(resp.MasterKey); err != nil {
+		return nil, nil, err
+	}
+	if derivKey, err = secret.FromBytes(resp.DerivationKey); err != nil {
+		key.Destroy()
+		return nil, nil, err
+	}
+	return key, derivKey, nil
+}
+
+// Get decrypts the entry with the given id, or else the one titled title, of
+// the vault at file.
+func (c *Client) Get(file, id, title string) (*Entry, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" && title == "" {
+		return nil, errors.New("need an entry id or title")
+	}
+	resp, err := c.call(&Request{Op: OpGet, File: abs, ID: id, Title: title})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Entry == nil {
+		return nil, errors.New("agent sent no entry")
+	}
+	return resp.Entry, nil
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package agent
+
+import "os"
+
+func fileOwner(fi os.FileInfo) (uid int, ok bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd
+
+package agent
+
+import (
+	"errors"
+	"net"
+)
+
+// peerCred is unsupported here, so the agent answers no requests: without
+// the peer's identity it cannot tell its owner from anyone else.
+func peerCred(c *net.UnixConn) (uid, pid int, err error) {
+	return 0, 0, errors.New("agent: peer credentials are not available on this platform")
+}
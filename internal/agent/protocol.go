@@ -0,0 +1,128 @@
+// Package agent keeps an unlocked vault key in a background process, in the
+// spirit of ssh-agent, so commands need neither the master password nor a
+// fresh scrypt run each time.
+//
+// Clients talk to the agent over a Unix socket in a directory only its owner
+// can enter. Every message is a frame: a 4-byte big-endian length followed by
+// that many bytes of JSON, a Request from the client or a Response from the
+// agent. A connection may carry any number of requests; before answering each
+// one the agent checks that the peer runs as the same user as itself.
+//
+// The agent locks, destroying the keys, after Server.Idle without a request,
+// Server.MaxLife after unlocking, or on an explicit lock.
+//
+// Unlike ssh-agent, which never lets a private key out, the agent hands the
+// vault's master key, and the derivation key of its derived passwords, to
+// clients that ask with OpKeys. The commands need them for whole-vault work:
+// list, search, match and report decrypt every entry, while add, edit, import
+// and rotate seal new ones. Serving all of that from the agent would move most
+// of the command set into it. The trust boundary is therefore the user
+// account, as for the agent's socket: any process of the owner may fetch the
+// keys, and no one else gets through the socket directory and the per-request
+// peer check. A master key that was handed out stays valid until the vault is
+// re-keyed; passwd re-wraps the same key. Tools that only read entries should
+// use OpGet, which keeps the keys in the agent.
+package agent
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Operations a Request may ask for.
+const (
+	OpUnlock = "unlock" // File, Master: unlock the vault at File
+	OpLock   = "lock"   // destroy the keys
+	OpStatus = "status" // report whether and until when the agent is unlocked
+	OpKeys   = "keys"   // File: hand the master and derivation keys to the caller; see the package doc
+	OpGet    = "get"    // File, ID or Title: decrypt one entry
+)
+
+// maxFrame bounds a message, so a confused peer cannot make the agent allocate
+// without limit.
+const maxFrame = 1 << 20
+
+// Request is a message from a client.
+type Request struct {
+	Op     string `json:"op"`
+	File   string `json:"file,omitempty"`   // absolute vault path
+	Master []byte `json:"master,omitempty"` // OpUnlock only; wiped once used
+	ID     string `json:"id,omitempty"`
+	Title  string `json:"title,omitempty"`
+}
+
+// Response is the agent's answer to one Request.
+type Response struct {
+	OK     bool    `json:"ok"`
+	Error  string  `json:"error,omitempty"`
+	Status *Status `json:"status,omitempty"`
+
+	// OpKeys
+	MasterKey     []byte `json:"masterKey,omitempty"`
+	DerivationKey []byte `json:"derivationKey,omitempty"`
+
+	// OpGet
+	Entry *Entry `json:"entry,omitempty"`
+}
+
+// Status describes the agent's state.
+type Status struct {
+	Unlocked bool      `json:"unlocked"`
+	File     string    `json:"file,omitempty"`
+	Locked   bool      `json:"keyInLockedMemory,omitempty"` // the key could be mlock'd
+	IdleLock time.Time `json:"idleLock,omitzero"`           // when the idle timeout will lock
+	HardLock time.Time `json:"hardLock,omitzero"`           // when the maximum lifetime will lock
+}
+
+// Entry is a decrypted entry returned by OpGet; derived passwords are
+// already resolved.
+type Entry struct {
+	ID    string               `json:"id"`
+	Title string               `json:"title"`
+	Plain pwmanager.PlainEntry `json:"plain"`
+}
+
+// ErrLocked is returned by clients when the agent holds no key.
+var ErrLocked = errors.New("agent is locked")
+
+// writeFrame sends v as one frame and wipes the encoded copy, which may hold keys.
+func writeFrame(w io.Writer, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	defer secret.Wipe(body)
+	if len(body) > maxFrame {
+		return fmt.Errorf("agent: message of %d bytes exceeds the frame limit", len(body))
+	}
+	frame := make([]byte, 4+len(body))
+	defer secret.Wipe(frame)
+	binary.BigEndian.PutUint32(frame, uint32(len(body)))
+	copy(frame[4:], body)
+	_, err = w.Write(frame)
+	return err
+}
+
+// readFrame reads one frame into v and wipes the raw bytes.
+func readFrame(r io.Reader, v any) error {
+	var hdr [4]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(hdr[:])
+	if n > maxFrame {
+		return fmt.Errorf("agent: frame of %d bytes exceeds the limit", n)
+	}
+	body := make([]byte, n)
+	defer secret.Wipe(body)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
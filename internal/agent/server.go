@@ -0,0 +1,291 @@
+package agent
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server holds at most one unlocked vault key and answers Requests for it.
+type Server struct {
+	Idle    time.Duration // lock after this long without a keys or get request; 0 never
+	MaxLife time.Duration // lock this long after unlocking, however busy; 0 never
+	Logf    func(format string, args ...any)
+
+	mu         sync.Mutex
+	file       string
+	key        *secret.Buffer
+	derivKey   *secret.Buffer
+	unlockedAt time.Time
+	lastUsed   time.Time
+	timer      *time.Timer
+}
+
+// NewServer returns a locked server with the given timeouts that logs to the
+// standard logger.
+func NewServer(idle, maxLife time.Duration) *Server {
+	return &Server{Idle: idle, MaxLife: maxLife, Logf: log.Printf}
+}
+
+// Serve accepts connections on l until it is closed. Each connection is served
+// on its own goroutine.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		uc, ok := c.(*net.UnixConn)
+		if !ok {
+			c.Close()
+			continue
+		}
+		go s.serveConn(uc)
+	}
+}
+
+func (s *Server) serveConn(c *net.UnixConn) {
+	defer c.Close()
+	for {
+		var req Request
+		if err := readFrame(c, &req); err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.logf("read request: %v", err)
+			}
+			return
+		}
+		// checked per request, not per connection: a descriptor can be
+		// passed to another process after it was opened
+		uid, pid, err := peerCred(c)
+		if err == nil && uid != os.Getuid() {
+			err = fmt.Errorf("peer uid %d (pid %d) is not the agent's owner", uid, pid)
+		}
+		if err != nil {
+			secret.Wipe(req.Master)
+			s.logf("refusing %s: %v", req.Op, err)
+			writeFrame(c, &Response{Error: "permission denied"})
+			return
+		}
+		resp := s.handle(&req)
+		err = writeFrame(c, resp)
+		secret.Wipe(resp.MasterKey)
+		secret.Wipe(resp.DerivationKey)
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) handle(req *Request) *Response {
+	var err error
+	resp := &Response{}
+	switch req.Op {
+	case OpUnlock:
+		err = s.unlock(req.File, req.Master)
+	case OpLock:
+		s.Lock()
+	case OpStatus:
+		resp.Status = s.status()
+	case OpKeys:
+		resp.MasterKey, resp.DerivationKey, err = s.keys(req.File)
+	case OpGet:
+		resp.Entry, err = s.get(req.File, req.ID, req.Title)
+	default:
+		err = fmt.Errorf("unknown operation %q", req.Op)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.OK = true
+	return resp
+}
+
+// unlock verifies master against the vault at file and keeps its keys,
+// replacing any vault unlocked before.
+func (s *Server) unlock(file string, master []byte) error {
+	defer secret.Wipe(master)
+	if !filepath.IsAbs(file) {
+		return errors.New("vault path must be absolute")
+	}
+	v, err := pwmanager.Load(file)
+	if err != nil {
+		return err
+	}
+	key, err := v.Unlock(string(master))
+	if err != nil {
+		return err
+	}
+	dk, err := pwmanager.DerivationKey(string(master))
+	if err != nil {
+		key.Destroy()
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lockLocked()
+	s.file, s.key, s.derivKey = file, key, dk
+	s.unlockedAt = time.Now()
+	s.lastUsed = s.unlockedAt
+	s.scheduleLocked()
+	if !key.Locked() {
+		s.logf("warning: the key could not be locked in RAM and may be swapped out")
+	}
+	s.logf("unlocked %s", file)
+	return nil
+}
+
+// Lock destroys the keys. It is safe to call at any time, e.g. from a signal handler.
+func (s *Server) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key != nil {
+		s.logf("locked %s", s.file)
+	}
+	s.lockLocked()
+}
+
+func (s *Server) lockLocked() {
+	s.key.Destroy()
+	s.derivKey.Destroy()
+	s.key, s.derivKey, s.file = nil, nil, ""
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+}
+
+// deadlinesLocked returns when the idle timeout and the maximum lifetime
+// will lock the agent; zero when disabled.
+func (s *Server) deadlinesLocked() (idle, hard time.Time) {
+	if s.Idle > 0 {
+		idle = s.lastUsed.Add(s.Idle)
+	}
+	if s.MaxLife > 0 {
+		hard = s.unlockedAt.Add(s.MaxLife)
+	}
+	return idle, hard
+}
+
+// scheduleLocked arms the timer for the earlier deadline.
+func (s *Server) scheduleLocked() {
+	idle, hard := s.deadlinesLocked()
+	next := idle
+	if next.IsZero() || (!hard.IsZero() && hard.Before(next)) {
+		next = hard
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if next.IsZero() {
+		return
+	}
+	s.timer = time.AfterFunc(time.Until(next), s.expire)
+}
+
+// expire locks the agent once a deadline has passed; a timer that fired late
+// after a request extended the idle deadline just re-arms.
+func (s *Server) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		return
+	}
+	now := time.Now()
+	idle, hard := s.deadlinesLocked()
+	switch {
+	case !hard.IsZero() && !now.Before(hard):
+		s.logf("maximum lifetime reached; locked %s", s.file)
+	case !idle.IsZero() && !now.Before(idle):
+		s.logf("idle timeout; locked %s", s.file)
+	default:
+		s.scheduleLocked()
+		return
+	}
+	s.lockLocked()
+}
+
+func (s *Server) status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		return &Status{}
+	}
+	idle, hard := s.deadlinesLocked()
+	return &Status{Unlocked: true, File: s.file, Locked: s.key.Locked(), IdleLock: idle, HardLock: hard}
+}
+
+// useLocked checks that the agent holds the key of file and counts the
+// request as activity.
+func (s *Server) useLocked(file string) error {
+	if s.key == nil {
+		return ErrLocked
+	}
+	if file != s.file {
+		return fmt.Errorf("agent holds the key of %s, not %s", s.file, file)
+	}
+	s.lastUsed = time.Now()
+	s.scheduleLocked()
+	return nil
+}
+
+// keys returns copies of the master and derivation keys of file, which
+// serveConn wipes once they are sent; why the agent hands them out at all is
+// explained in the package doc.
+func (s *Server) keys(file string) (key, dk []byte, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.useLocked(file); err != nil {
+		return nil, nil, err
+	}
+	return append([]byte(nil), s.key.Bytes()...), append([]byte(nil), s.derivKey.Bytes()...), nil
+}
+
+// get decrypts one entry of the vault as it is on disk now, so changes saved
+// by other processes since the unlock are seen.
+func (s *Server) get(file, id, title string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.useLocked(file); err != nil {
+		return nil, err
+	}
+	v, err := pwmanager.Load(s.file)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		matches := v.FindByExactTitle(title)
+		if len(matches) != 1 {
+			return nil, fmt.Errorf("%d entries titled %q", len(matches), strings.TrimSpace(title))
+		}
+		id = matches[0].ID
+	}
+	plain, meta, err := v.GetDecrypted(s.key, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := plain.ResolveDerived(s.derivKey); err != nil {
+		return nil, err
+	}
+	return &Entry{ID: id, Title: meta.Title, Plain: *plain}, nil
+}
+
+func (s *Server) logf(format string, args ...any) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// SocketEnv names the environment variable that points clients at the agent.
+const SocketEnv = "PWAGENT_SOCK"
+
+// SocketPath returns the socket named by PWAGENT_SOCK, or the per-user
+// default: $XDG_RUNTIME_DIR/pwagent/agent.sock, else a pwagent-UID directory
+// under the system temp directory.
+func SocketPath() string {
+	if p := os.Getenv(SocketEnv); p != "" {
+		return p
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pwagent", "agent.sock")
+	}
+	return filepath.Join(os.TempDir(), "pwagent-"+strconv.Itoa(os.Getuid()), "agent.sock")
+}
+
+// Listen creates the agent socket at path. Its directory is created private
+// to the current user, and an existing one is refused unless it is a real
+// directory owned by the user and closed to everyone else. A socket left
+// behind by a dead agent is replaced; one a live agent answers on is not.
+func Listen(path string) (net.Listener, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := checkPrivate(dir, true); err != nil {
+		return nil, err
+	}
+	if _, err := os.Lstat(path); err == nil {
+		if c, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			c.Close()
+			return nil, fmt.Errorf("an agent is already listening on %s", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// checkPrivate verifies that path is owned by the current user and, for a
+// directory, that no one else may enter it. Symlinks are refused, so the
+// check cannot be redirected.
+func checkPrivate(path string, isDir bool) error {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	switch {
+	case fi.Mode()&os.ModeSymlink != 0:
+		return fmt.Errorf("%s is a symlink", path)
+	case isDir && !fi.IsDir():
+		return fmt.Errorf("%s is not a directory", path)
+	case !isDir && fi.Mode()&os.ModeSocket == 0:
+		return fmt.Errorf("%s is not a socket", path)
+	case isDir && fi.Mode().Perm()&0077 != 0:
+		return fmt.Errorf("%s is accessible to other users (mode %v)", path, fi.Mode().Perm())
+	}
+	uid, ok := fileOwner(fi)
+	if !ok {
+		return errors.New("agent: file ownership cannot be checked on this platform")
+	}
+	if uid != os.Getuid() {
+		return fmt.Errorf("%s belongs to uid %d, not %d", path, uid, os.Getuid())
+	}
+	return nil
+}
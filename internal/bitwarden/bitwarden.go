@@ -0,0 +1,189 @@
+// Package bitwarden reads and writes Bitwarden JSON exports, both the plain
+// format and the "password protected" encrypted format, and maps them onto
+// pwmanager records.
+package bitwarden
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Item types used by Bitwarden.
+const (
+	TypeLogin      = 1
+	TypeSecureNote = 2
+	TypeCard       = 3
+	TypeIdentity   = 4
+)
+
+// Custom field types used by Bitwarden.
+const (
+	FieldText    = 0
+	FieldHidden  = 1
+	FieldBoolean = 2
+	FieldLinked  = 3
+)
+
+// Linked field targets (subset: only the login properties can be resolved).
+const (
+	linkedUsername = 100
+	linkedPassword = 101
+)
+
+// Export is the decrypted body of a Bitwarden JSON export.
+type Export struct {
+	Encrypted bool     `json:"encrypted"`
+	Folders   []Folder `json:"folders"`
+	Items     []Item   `json:"items"`
+}
+
+// Folder is a named folder referenced by Item.FolderID.
+type Folder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Item is a single Bitwarden cipher (login, secure note, card or identity).
+type Item struct {
+	ID             string      `json:"id"`
+	OrganizationID *string     `json:"organizationId"`
+	FolderID       *string     `json:"folderId"`
+	Type           int         `json:"type"`
+	Reprompt       int         `json:"reprompt"`
+	Name           string      `json:"name"`
+	Notes          *string     `json:"notes"`
+	Favorite       bool        `json:"favorite"`
+	Fields         []Field     `json:"fields,omitempty"`
+	Login          *Login      `json:"login,omitempty"`
+	SecureNote     *SecureNote `json:"secureNote,omitempty"`
+	Card           *Card       `json:"card,omitempty"`
+	Identity       *Identity   `json:"identity,omitempty"`
+	CollectionIDs  []string    `json:"collectionIds"`
+	RevisionDate   *time.Time  `json:"revisionDate,omitempty"`
+	CreationDate   *time.Time  `json:"creationDate,omitempty"`
+}
+
+// Field is a custom field on an item.
+type Field struct {
+	Name     string  `json:"name"`
+	Value    *string `json:"value"`
+	Type     int     `json:"type"`
+	LinkedID *int    `json:"linkedId"`
+}
+
+// Login holds the login-specific properties of an item.
+type Login struct {
+	URIs     []URI  `json:"uris,omitempty"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	TOTP     string `json:"totp"`
+}
+
+// URI is a login URI with an optional match detection override.
+type URI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+// SecureNote marks an item as a secure note; Type is always 0 (generic).
+type SecureNote struct {
+	Type int `json:"type"`
+}
+
+// Card holds payment card properties.
+type Card struct {
+	CardholderName string `json:"cardholderName"`
+	Brand          string `json:"brand"`
+	Number         string `json:"number"`
+	ExpMonth       string `json:"expMonth"`
+	ExpYear        string `json:"expYear"`
+	Code           string `json:"code"`
+}
+
+// Identity holds personal identity properties.
+type Identity struct {
+	Title          string `json:"title"`
+	FirstName      string `json:"firstName"`
+	MiddleName     string `json:"middleName"`
+	LastName       string `json:"lastName"`
+	Address1       string `json:"address1"`
+	Address2       string `json:"address2"`
+	Address3       string `json:"address3"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	PostalCode     string `json:"postalCode"`
+	Country        string `json:"country"`
+	Company        string `json:"company"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	SSN            string `json:"ssn"`
+	Username       string `json:"username"`
+	PassportNumber string `json:"passportNumber"`
+	LicenseNumber  string `json:"licenseNumber"`
+}
+
+// envelope is the outer shape shared by every export; it is decoded first to
+// decide whether the body needs decrypting.
+type envelope struct {
+	Encrypted         bool `json:"encrypted"`
+	PasswordProtected bool `json:"passwordProtected"`
+}
+
+// ErrPasswordRequired is returned by Parse for a password-protected export when no password was given.
+var ErrPasswordRequired = errors.New("export is password protected")
+
+// Parse decodes a Bitwarden JSON export. Password-protected exports are decrypted
+// with password; account-key encrypted exports cannot be read outside Bitwarden.
+func Parse(data []byte, password string) (*Export, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("not a Bitwarden export: %w", err)
+	}
+
+	if env.Encrypted {
+		if !env.PasswordProtected {
+			return nil, errors.New("account-encrypted exports are not supported; export with a file password instead")
+		}
+		if password == "" {
+			return nil, ErrPasswordRequired
+		}
+		plain, err := decryptExport(data, password)
+		if err != nil {
+			return nil, err
+		}
+		data = plain
+	}
+
+	var exp Export
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return nil, fmt.Errorf("bad export body: %w", err)
+	}
+	return &exp, nil
+}
+
+// Marshal encodes the export as an unencrypted Bitwarden JSON file.
+func (e *Export) Marshal() ([]byte, error) {
+	out := *e
+	out.Encrypted = false
+	if out.Folders == nil {
+		out.Folders = []Folder{}
+	}
+	if out.Items == nil {
+		out.Items = []Item{}
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// MarshalEncrypted encodes the export as a password-protected Bitwarden JSON file.
+func (e *Export) MarshalEncrypted(password string, kdf KDFConfig) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("export password cannot be empty")
+	}
+	body, err := e.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return encryptExport(body, password, kdf)
+}
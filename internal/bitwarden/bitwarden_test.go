@@ -0,0 +1,182 @@
+package bitwarden
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const plainExport = `{
+  "encrypted": false,
+  "folders": [{"id": "f1", "name": "Work"}],
+  "items": [
+    {
+      "id": "i1", "organizationId": null, "folderId": "f1", "type": 1, "reprompt": 0,
+      "name": "GitHub", "notes": "2FA on", "favorite": false,
+      "fields": [
+        {"name": "PIN", "value": "1234", "type": 1, "linkedId": null},
+        {"name": "Login alias", "value": null, "type": 3, "linkedId": 100}
+      ],
+      "login": {
+        "uris": [{"match": 1, "uri": "https://github.com"}, {"match": null, "uri": "https://gist.github.com"}],
+        "username": "alice", "password": "S3cret!", "totp": "JBSWY3DPEHPK3PXP"
+      },
+      "collectionIds": null,
+      "revisionDate": "2024-03-01T10:00:00.000Z", "creationDate": "2024-01-01T10:00:00.000Z"
+    },
+    {
+      "id": "i2", "organizationId": null, "folderId": null, "type": 3, "reprompt": 0,
+      "name": "Visa", "notes": null, "favorite": false,
+      "card": {"cardholderName": "Alice", "brand": "Visa", "number": "4111111111111111", "expMonth": "1", "expYear": "2030", "code": "123"},
+      "collectionIds": null
+    },
+    {
+      "id": "i3", "organizationId": null, "folderId": null, "type": 2, "reprompt": 0,
+      "name": "Wifi", "notes": "door code 42", "favorite": false,
+      "secureNote": {"type": 0}, "collectionIds": null
+    }
+  ]
+}`
+
+func TestParsePlainExport(t *testing.T) {
+	exp, err := Parse([]byte(plainExport), "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	recs := exp.Records()
+	if len(recs) != 3 {
+		t.Fatalf("Records() got %d records, want 3", len(recs))
+	}
+
+	gh := recs[0]
+	if gh.Title != "GitHub" || gh.Username != "alice" || gh.Password != "S3cret!" || gh.URL != "https://github.com" {
+		t.Errorf("login mapped wrong: %+v", gh)
+	}
+	if gh.Folder != "Work" || gh.TOTP != "JBSWY3DPEHPK3PXP" || gh.Notes != "2FA on" {
+		t.Errorf("folder/totp/notes mapped wrong: %+v", gh)
+	}
+	if gh.URLMatch != "host" {
+		t.Errorf("URI match = %q, want host", gh.URLMatch)
+	}
+	if v, ok := gh.Field("URI 2"); !ok || v != "https://gist.github.com" {
+		t.Errorf("second URI not kept: %q", v)
+	}
+	if v, _ := gh.Field("PIN"); v != "1234" {
+		t.Errorf("hidden field value = %q, want 1234", v)
+	}
+	if v, _ := gh.Field("Login alias"); v != "alice" {
+		t.Errorf("linked field value = %q, want alice", v)
+	}
+	if gh.CreatedAt.Year() != 2024 || !gh.ModifiedAt.After(gh.CreatedAt) {
+		t.Errorf("timestamps not kept: %v / %v", gh.CreatedAt, gh.ModifiedAt)
+	}
+
+	if recs[1].Kind != pwmanager.KindCard {
+		t.Errorf("card kind = %q", recs[1].Kind)
+	}
+	if v, _ := recs[1].Field("number"); v != "4111111111111111" {
+		t.Errorf("card number = %q", v)
+	}
+	if recs[2].Kind != pwmanager.KindNote || recs[2].Notes != "door code 42" {
+		t.Errorf("secure note mapped wrong: %+v", recs[2])
+	}
+}
+
+func TestRoundTripThroughRecords(t *testing.T) {
+	exp, err := Parse([]byte(plainExport), "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	data, err := FromRecords(exp.Records()).Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	again, err := Parse(data, "")
+	if err != nil {
+		t.Fatalf("Parse() of own export error = %v", err)
+	}
+
+	if len(again.Folders) != 1 || again.Folders[0].Name != "Work" {
+		t.Errorf("folders = %+v", again.Folders)
+	}
+	gh := again.Items[0]
+	if gh.Type != TypeLogin || len(gh.Login.URIs) != 2 || gh.Login.TOTP != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("login item = %+v", gh.Login)
+	}
+	if m := gh.Login.URIs[0].Match; m == nil || *m != 1 {
+		t.Errorf("URI match = %v, want 1 (host)", m)
+	}
+	if card := again.Items[1]; card.Type != TypeCard || card.Card.Number != "4111111111111111" || len(card.Fields) != 0 {
+		t.Errorf("card item = %+v", card)
+	}
+	if note := again.Items[2]; note.Type != TypeSecureNote || note.SecureNote == nil {
+		t.Errorf("note item = %+v", note)
+	}
+}
+
+func TestEncryptedExport(t *testing.T) {
+	exp, err := Parse([]byte(plainExport), "")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	configs := map[string]KDFConfig{
+		"pbkdf2":   {Type: KDFPBKDF2, Iterations: 1000},
+		"argon2id": {Type: KDFArgon2id, Iterations: 1, MemoryMiB: 1, Parallelism: 1},
+	}
+	for name, kdf := range configs {
+		t.Run(name, func(t *testing.T) {
+			data, err := exp.MarshalEncrypted("export-pass", kdf)
+			if err != nil {
+				t.Fatalf("MarshalEncrypted() error = %v", err)
+			}
+
+			if _, err := Parse(data, ""); !errors.Is(err, ErrPasswordRequired) {
+				t.Errorf("Parse() without password error = %v, want ErrPasswordRequired", err)
+			}
+			if _, err := Parse(data, "wrong"); err == nil {
+				t.Error("Parse() with wrong password should fail")
+			}
+
+			got, err := Parse(data, "export-pass")
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(got.Items) != 3 || got.Items[0].Login.Password != "S3cret!" {
+				t.Errorf("decrypted export mismatch: %+v", got.Items)
+			}
+		})
+	}
+}
+
+func TestEncryptedExportLimits(t *testing.T) {
+	exp, err := Parse([]byte(plainExport), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := exp.MarshalEncrypted("export-pass", KDFConfig{Type: KDFArgon2id, Iterations: 1, MemoryMiB: 1, Parallelism: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a crafted file names parameters that would take hours or terabytes
+	for name, edit := range map[string]func(m map[string]any){
+		"pbkdf2 iterations": func(m map[string]any) { m["kdfType"], m["kdfIterations"] = 0, 1<<40 },
+		"argon2 iterations": func(m map[string]any) { m["kdfIterations"] = 1 << 31 },
+		"argon2 memory":     func(m map[string]any) { m["kdfMemory"] = 4 << 20 },
+		"argon2 threads":    func(m map[string]any) { m["kdfParallelism"] = 255 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var m map[string]any
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatal(err)
+			}
+			edit(m)
+			crafted, _ := json.Marshal(m)
+			if _, err := Parse(crafted, "export-pass"); err == nil || !strings.Contains(err.Error(), "out of range") {
+				t.Errorf("Parse() error = %v, want out of range", err)
+			}
+		})
+	}
+}
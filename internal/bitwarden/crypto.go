@@ -0,0 +1,218 @@
+package bitwarden
+
+import (
+	"appliedcryptography-starter-kit/internal/encrypt"
+	"appliedcryptography-starter-kit/internal/hash"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KDFType selects the password KDF of an encrypted export.
+type KDFType int
+
+const (
+	KDFPBKDF2   KDFType = 0 // PBKDF2-HMAC-SHA256
+	KDFArgon2id KDFType = 1 // Argon2id with a SHA-256 hashed salt
+)
+
+// KDFConfig holds the KDF parameters written into an encrypted export.
+type KDFConfig struct {
+	Type        KDFType
+	Iterations  int
+	MemoryMiB   int // Argon2id only
+	Parallelism int // Argon2id only
+}
+
+// Bitwarden's current client defaults.
+var (
+	DefaultPBKDF2   = KDFConfig{Type: KDFPBKDF2, Iterations: 600000}
+	DefaultArgon2id = KDFConfig{Type: KDFArgon2id, Iterations: 3, MemoryMiB: 64, Parallelism: 4}
+)
+
+// Upper bounds on the KDF parameters, the largest the Bitwarden clients let
+// users choose. An export names its own parameters, so without them a crafted
+// file could keep the import busy for hours or ask for terabytes of memory.
+const (
+	maxPBKDF2Iterations  = 2000000
+	maxArgon2Iterations  = 10
+	maxArgon2MemoryMiB   = 1024
+	maxArgon2Parallelism = 16
+)
+
+// check rejects parameters that are out of range.
+func (kdf KDFConfig) check() error {
+	switch kdf.Type {
+	case KDFPBKDF2:
+		if kdf.Iterations <= 0 || kdf.Iterations > maxPBKDF2Iterations {
+			return fmt.Errorf("PBKDF2 iterations %d out of range 1..%d", kdf.Iterations, maxPBKDF2Iterations)
+		}
+	case KDFArgon2id:
+		switch {
+		case kdf.Iterations <= 0 || kdf.Iterations > maxArgon2Iterations:
+			return fmt.Errorf("Argon2id iterations %d out of range 1..%d", kdf.Iterations, maxArgon2Iterations)
+		case kdf.MemoryMiB <= 0 || kdf.MemoryMiB > maxArgon2MemoryMiB:
+			return fmt.Errorf("Argon2id memory %d MiB out of range 1..%d", kdf.MemoryMiB, maxArgon2MemoryMiB)
+		case kdf.Parallelism <= 0 || kdf.Parallelism > maxArgon2Parallelism:
+			return fmt.Errorf("Argon2id parallelism %d out of range 1..%d", kdf.Parallelism, maxArgon2Parallelism)
+		}
+	default:
+		return fmt.Errorf("unsupported kdfType %d", kdf.Type)
+	}
+	return nil
+}
+
+// encString type 2: AES-256-CBC with HMAC-SHA256, serialized as "2.iv|ct|mac".
+const encTypeAESCBC256HMAC = "2"
+
+// encryptedExport is the JSON layout of a password-protected export.
+type encryptedExport struct {
+	Encrypted         bool   `json:"encrypted"`
+	PasswordProtected bool   `json:"passwordProtected"`
+	Salt              string `json:"salt"`
+	KDFType           int    `json:"kdfType"`
+	KDFIterations     int    `json:"kdfIterations"`
+	KDFMemory         *int   `json:"kdfMemory"`
+	KDFParallelism    *int   `json:"kdfParallelism"`
+	EncKeyValidation  string `json:"encKeyValidation_DO_NOT_EDIT"`
+	Data              string `json:"data"`
+}
+
+// deriveKeys turns the export password into the AES and MAC keys.
+// The salt string is used as-is (its UTF-8 bytes), mirroring the Bitwarden clients.
+func deriveKeys(password, salt string, kdf KDFConfig) (encKey, macKey []byte, err error) {
+	if err := kdf.check(); err != nil {
+		return nil, nil, err
+	}
+	var master []byte
+	switch kdf.Type {
+	case KDFPBKDF2:
+		master, err = hash.PBKDF2([]byte(password), []byte(salt), kdf.Iterations, 32)
+	case KDFArgon2id:
+		master, err = hash.Argon2id([]byte(password), hash.SHA256([]byte(salt)),
+			uint32(kdf.Iterations), uint32(kdf.MemoryMiB)*1024, uint8(kdf.Parallelism), 32)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Stretch the master key into independent encryption and MAC keys
+	if encKey, err = hash.HKDFExpand(master, []byte("enc"), 32); err != nil {
+		return nil, nil, err
+	}
+	if macKey, err = hash.HKDFExpand(master, []byte("mac"), 32); err != nil {
+		return nil, nil, err
+	}
+	return encKey, macKey, nil
+}
+
+func encryptString(encKey, macKey, plaintext []byte) (string, error) {
+	iv, err := encrypt.GenerateNonce(16)
+	if err != nil {
+		return "", err
+	}
+	ct, mac, err := encrypt.EncryptAESCBCHMAC(encKey, macKey, iv, plaintext)
+	if err != nil {
+		return "", err
+	}
+	b64 := base64.StdEncoding.EncodeToString
+	return encTypeAESCBC256HMAC + "." + b64(iv) + "|" + b64(ct) + "|" + b64(mac), nil
+}
+
+func decryptString(encKey, macKey []byte, s string) ([]byte, error) {
+	typ, rest, ok := strings.Cut(s, ".")
+	if !ok || typ != encTypeAESCBC256HMAC {
+		return nil, errors.New("unsupported encrypted string type")
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed encrypted string")
+	}
+	var raw [3][]byte
+	for i, p := range parts {
+		b, err := base64.StdEncoding.DecodeString(p)
+		if err != nil {
+			return nil, fmt.Errorf("malformed encrypted string: %w", err)
+		}
+		raw[i] = b
+	}
+	return encrypt.DecryptAESCBCHMAC(encKey, macKey, raw[0], raw[1], raw[2])
+}
+
+func decryptExport(data []byte, password string) ([]byte, error) {
+	var ee encryptedExport
+	if err := json.Unmarshal(data, &ee); err != nil {
+		return nil, fmt.Errorf("bad encrypted export: %w", err)
+	}
+	kdf := KDFConfig{Type: KDFType(ee.KDFType), Iterations: ee.KDFIterations}
+	if ee.KDFMemory != nil {
+		kdf.MemoryMiB = *ee.KDFMemory
+	}
+	if ee.KDFParallelism != nil {
+		kdf.Parallelism = *ee.KDFParallelism
+	}
+
+	encKey, macKey, err := deriveKeys(password, ee.Salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	// The validation string only exists to tell a wrong password from a corrupt body
+	if _, err := decryptString(encKey, macKey, ee.EncKeyValidation); err != nil {
+		return nil, errors.New("wrong export password")
+	}
+	plain, err := decryptString(encKey, macKey, ee.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt export data: %w", err)
+	}
+	return plain, nil
+}
+
+func encryptExport(body []byte, password string, kdf KDFConfig) ([]byte, error) {
+	saltBytes := make([]byte, 16)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return nil, err
+	}
+	salt := base64.StdEncoding.EncodeToString(saltBytes)
+
+	encKey, macKey, err := deriveKeys(password, salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+
+	validation, err := encryptString(encKey, macKey, []byte(newUUID()))
+	if err != nil {
+		return nil, err
+	}
+	payload, err := encryptString(encKey, macKey, body)
+	if err != nil {
+		return nil, err
+	}
+
+	ee := encryptedExport{
+		Encrypted:         true,
+		PasswordProtected: true,
+		Salt:              salt,
+		KDFType:           int(kdf.Type),
+		KDFIterations:     kdf.Iterations,
+		EncKeyValidation:  validation,
+		Data:              payload,
+	}
+	if kdf.Type == KDFArgon2id {
+		mem, par := kdf.MemoryMiB, kdf.Parallelism
+		ee.KDFMemory, ee.KDFParallelism = &mem, &par
+	}
+	return json.MarshalIndent(ee, "", "  ")
+}
+
+// newUUID returns a random RFC 4122 version 4 UUID, the ID format Bitwarden uses.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
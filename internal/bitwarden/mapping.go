@@ -0,0 +1,240 @@
+package bitwarden
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/urlmatch"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Field names used to carry card and identity properties through pwmanager
+// custom fields; they match the Bitwarden JSON keys so round trips are lossless.
+var (
+	cardFields     = []string{"cardholderName", "brand", "number", "expMonth", "expYear", "code"}
+	identityFields = []string{"title", "firstName", "middleName", "lastName", "address1", "address2", "address3",
+		"city", "state", "postalCode", "country", "company", "email", "phone", "ssn", "username",
+		"passportNumber", "licenseNumber"}
+	hiddenFields = map[string]bool{"number": true, "code": true, "ssn": true, "passportNumber": true, "licenseNumber": true}
+)
+
+// extraURIPrefix names the custom fields that hold a login's second and later URIs.
+const extraURIPrefix = "URI "
+
+// matchMode maps a Bitwarden URI match type to a urlmatch mode name. Bitwarden
+// numbers its types in the order of urlmatch.Modes; the default (domain) and
+// unknown types become "".
+func matchMode(match *int) string {
+	if match == nil || *match <= 0 || *match >= len(urlmatch.Modes) {
+		return ""
+	}
+	return string(urlmatch.Modes[*match])
+}
+
+// matchType is the inverse of matchMode; nil means Bitwarden's global default.
+func matchType(mode string) *int {
+	m, err := urlmatch.ParseMode(mode)
+	if mode == "" || err != nil {
+		return nil
+	}
+	n := slices.Index(urlmatch.Modes, m)
+	return &n
+}
+
+// Records converts the export into pwmanager records, resolving folder IDs to names.
+func (e *Export) Records() []pwmanager.Record {
+	folders := make(map[string]string, len(e.Folders))
+	for _, f := range e.Folders {
+		folders[f.ID] = f.Name
+	}
+
+	out := make([]pwmanager.Record, 0, len(e.Items))
+	for _, it := range e.Items {
+		out = append(out, itemToRecord(it, folders))
+	}
+	return out
+}
+
+func itemToRecord(it Item, folders map[string]string) pwmanager.Record {
+	rec := pwmanager.Record{Title: it.Name}
+	p := &rec.PlainEntry
+	if it.Notes != nil {
+		p.Notes = *it.Notes
+	}
+	if it.FolderID != nil {
+		p.Folder = folders[*it.FolderID]
+	}
+	if it.CreationDate != nil {
+		p.CreatedAt = it.CreationDate.UTC()
+	}
+	if it.RevisionDate != nil {
+		p.ModifiedAt = it.RevisionDate.UTC()
+	}
+
+	switch it.Type {
+	case TypeSecureNote:
+		p.Kind = pwmanager.KindNote
+	case TypeCard:
+		p.Kind = pwmanager.KindCard
+		if c := it.Card; c != nil {
+			appendKnown(p, cardFields, []string{c.CardholderName, c.Brand, c.Number, c.ExpMonth, c.ExpYear, c.Code})
+		}
+	case TypeIdentity:
+		p.Kind = pwmanager.KindIdentity
+		if id := it.Identity; id != nil {
+			appendKnown(p, identityFields, []string{id.Title, id.FirstName, id.MiddleName, id.LastName,
+				id.Address1, id.Address2, id.Address3, id.City, id.State, id.PostalCode, id.Country,
+				id.Company, id.Email, id.Phone, id.SSN, id.Username, id.PassportNumber, id.LicenseNumber})
+		}
+	default:
+		if l := it.Login; l != nil {
+			p.Username = l.Username
+			p.Password = l.Password
+			p.TOTP = l.TOTP
+			for i, u := range l.URIs {
+				if i == 0 {
+					p.URL = u.URI
+					p.URLMatch = matchMode(u.Match)
+					continue
+				}
+				p.Fields = append(p.Fields, pwmanager.CustomField{Name: fmt.Sprintf("%s%d", extraURIPrefix, i+1), Value: u.URI})
+			}
+		}
+	}
+
+	for _, f := range it.Fields {
+		cf := pwmanager.CustomField{Name: f.Name, Hidden: f.Type == FieldHidden}
+		if f.Value != nil {
+			cf.Value = *f.Value
+		}
+		if f.Type == FieldLinked {
+			if f.LinkedID == nil {
+				continue
+			}
+			// Linked fields mirror another property; copy its value since pwmanager has no links
+			switch *f.LinkedID {
+			case linkedUsername:
+				cf.Value = p.Username
+			case linkedPassword:
+				cf.Value, cf.Hidden = p.Password, true
+			default:
+				continue
+			}
+		}
+		p.Fields = append(p.Fields, cf)
+	}
+	return rec
+}
+
+func appendKnown(p *pwmanager.PlainEntry, names, values []string) {
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		p.Fields = append(p.Fields, pwmanager.CustomField{Name: names[i], Value: v, Hidden: hiddenFields[names[i]]})
+	}
+}
+
+// FromRecords builds an export from pwmanager records. Folders are created from the
+// distinct folder paths; card and identity entries are rebuilt from their custom fields.
+func FromRecords(recs []pwmanager.Record) *Export {
+	exp := &Export{Folders: []Folder{}, Items: make([]Item, 0, len(recs))}
+
+	folderIDs := make(map[string]string)
+	for _, r := range recs {
+		if r.Folder != "" && folderIDs[r.Folder] == "" {
+			folderIDs[r.Folder] = newUUID()
+		}
+	}
+	names := make([]string, 0, len(folderIDs))
+	for name := range folderIDs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		exp.Folders = append(exp.Folders, Folder{ID: folderIDs[name], Name: name})
+	}
+
+	for _, r := range recs {
+		exp.Items = append(exp.Items, recordToItem(r, folderIDs))
+	}
+	return exp
+}
+
+func recordToItem(r pwmanager.Record, folderIDs map[string]string) Item {
+	it := Item{ID: newUUID(), Name: r.Title}
+	if r.Notes != "" {
+		notes := r.Notes
+		it.Notes = &notes
+	}
+	if id, ok := folderIDs[r.Folder]; ok {
+		it.FolderID = &id
+	}
+	if !r.CreatedAt.IsZero() {
+		created := r.CreatedAt
+		it.CreationDate = &created
+	}
+	if !r.ModifiedAt.IsZero() {
+		modified := r.ModifiedAt
+		it.RevisionDate = &modified
+	}
+
+	// Pull out the fields that have a dedicated Bitwarden slot for this kind
+	used := make(map[int]bool)
+	take := func(name string) string {
+		for i, f := range r.Fields {
+			if !used[i] && f.Name == name {
+				used[i] = true
+				return f.Value
+			}
+		}
+		return ""
+	}
+
+	switch r.Kind {
+	case pwmanager.KindNote:
+		it.Type = TypeSecureNote
+		it.SecureNote = &SecureNote{}
+	case pwmanager.KindCard:
+		it.Type = TypeCard
+		it.Card = &Card{
+			CardholderName: take("cardholderName"), Brand: take("brand"), Number: take("number"),
+			ExpMonth: take("expMonth"), ExpYear: take("expYear"), Code: take("code"),
+		}
+	case pwmanager.KindIdentity:
+		it.Type = TypeIdentity
+		it.Identity = &Identity{
+			Title: take("title"), FirstName: take("firstName"), MiddleName: take("middleName"), LastName: take("lastName"),
+			Address1: take("address1"), Address2: take("address2"), Address3: take("address3"),
+			City: take("city"), State: take("state"), PostalCode: take("postalCode"), Country: take("country"),
+			Company: take("company"), Email: take("email"), Phone: take("phone"), SSN: take("ssn"),
+			Username: take("username"), PassportNumber: take("passportNumber"), LicenseNumber: take("licenseNumber"),
+		}
+	default:
+		it.Type = TypeLogin
+		it.Login = &Login{Username: r.Username, Password: r.Password, TOTP: r.TOTP}
+		if r.URL != "" {
+			it.Login.URIs = append(it.Login.URIs, URI{URI: r.URL, Match: matchType(r.URLMatch)})
+		}
+		for i, f := range r.Fields {
+			if strings.HasPrefix(f.Name, extraURIPrefix) && f.Value != "" {
+				used[i] = true
+				it.Login.URIs = append(it.Login.URIs, URI{URI: f.Value})
+			}
+		}
+	}
+
+	for i, f := range r.Fields {
+		if used[i] {
+			continue
+		}
+		value := f.Value
+		typ := FieldText
+		if f.Hidden {
+			typ = FieldHidden
+		}
+		it.Fields = append(it.Fields, Field{Name: f.Name, Value: &value, Type: typ})
+	}
+	return it
+}
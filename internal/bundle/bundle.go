@@ -0,0 +1,252 @@
+// Package bundle writes and reads portable export bundles: a selection of vault
+// records encrypted under an export password (scrypt) or a recipient's X25519
+// key, described by a manifest and signed with the owner's Ed25519 key.
+package bundle
+
+import (
+	"appliedcryptography-starter-kit/internal/dh"
+	"appliedcryptography-starter-kit/internal/encrypt"
+	"appliedcryptography-starter-kit/internal/hash"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/sign"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	formatName    = "pwmanager-bundle"
+	formatVersion = 1
+
+	// Encryption modes recorded in the manifest
+	ModePassword = "password"
+	ModeX25519   = "x25519"
+)
+
+// scrypt parameters for password-protected bundles (same cost as the vault KDF)
+const (
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	// ErrBadSignature is returned when the signature does not match the bundle contents.
+	ErrBadSignature = errors.New("bundle signature is invalid")
+	// ErrDecrypt is returned for a wrong password or key, or a damaged ciphertext.
+	ErrDecrypt = errors.New("cannot decrypt bundle: wrong password or key")
+)
+
+// Manifest describes a bundle without revealing its contents.
+type Manifest struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Entries   int       `json:"entries"`
+	Mode      string    `json:"mode"`
+	Recipient string    `json:"recipient,omitempty"` // fingerprint of the recipient X25519 key
+	Comment   string    `json:"comment,omitempty"`
+}
+
+// KDFParams are the scrypt settings of a password-protected bundle.
+type KDFParams struct {
+	Salt []byte `json:"salt"`
+	N    int    `json:"N"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+// file is the on-disk JSON layout. The manifest is kept as raw bytes so the
+// signature covers exactly what was written (modulo whitespace).
+type file struct {
+	Manifest     json.RawMessage `json:"manifest"`
+	KDF          *KDFParams      `json:"kdf,omitempty"`
+	EphemeralKey []byte          `json:"ephemeralKey,omitempty"`
+	Nonce        []byte          `json:"nonce"`
+	Ciphertext   []byte          `json:"ciphertext"`
+	Signer       []byte          `json:"signer"`
+	Signature    []byte          `json:"signature"`
+}
+
+// Bundle is a parsed bundle whose signature has been verified.
+type Bundle struct {
+	Manifest Manifest
+	f        file
+}
+
+// SealWithPassword encrypts recs under password and signs the bundle with signer.
+func SealWithPassword(recs []pwmanager.Record, password string, signer *sign.KeyPair, comment string) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("export password is empty")
+	}
+	salt, err := encrypt.GenerateKey(16)
+	if err != nil {
+		return nil, err
+	}
+	kdf := &KDFParams{Salt: salt, N: scryptN, R: scryptR, P: scryptP}
+	key, err := hash.Scrypt([]byte(password), salt, kdf.N, kdf.R, kdf.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest(len(recs), ModePassword, comment)
+	return seal(recs, key, m, file{KDF: kdf}, signer)
+}
+
+// SealForRecipient encrypts recs to the recipient's X25519 public key using an
+// ephemeral key pair, and signs the bundle with signer.
+func SealForRecipient(recs []pwmanager.Record, recipient []byte, signer *sign.KeyPair, comment string) ([]byte, error) {
+	if len(recipient) != dh.PublicKeySize {
+		return nil, fmt.Errorf("recipient key must be %d bytes", dh.PublicKeySize)
+	}
+	eph, err := dh.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	key, err := recipientKey(eph.PrivateKey, recipient, eph.PublicKey, recipient)
+	if err != nil {
+		return nil, err
+	}
+	m := newManifest(len(recs), ModeX25519, comment)
+	m.Recipient = sign.Fingerprint(recipient)
+	return seal(recs, key, m, file{EphemeralKey: eph.PublicKey}, signer)
+}
+
+func newManifest(n int, mode, comment string) Manifest {
+	return Manifest{
+		Format: formatName, Version: formatVersion, CreatedAt: time.Now().UTC().Truncate(time.Second),
+		Entries: n, Mode: mode, Comment: comment,
+	}
+}
+
+// recipientKey turns the X25519 shared secret into the bundle key, binding both public keys.
+func recipientKey(priv, peer, ephPub, recipientPub []byte) ([]byte, error) {
+	shared, err := dh.ComputeSharedSecret(priv, peer)
+	if err != nil {
+		return nil, err
+	}
+	salt := append(append([]byte(nil), ephPub...), recipientPub...)
+	return hash.HKDF(shared, salt, []byte(formatName+" x25519"), 32)
+}
+
+func seal(recs []pwmanager.Record, key []byte, m Manifest, f file, signer *sign.KeyPair) ([]byte, error) {
+	if signer == nil {
+		return nil, errors.New("no signing key")
+	}
+	manifest, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(recs)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := encrypt.GenerateNonce(12)
+	if err != nil {
+		return nil, err
+	}
+	// The manifest is authenticated data so it cannot be swapped onto another ciphertext
+	ct, err := encrypt.EncryptAESGCM(key, nonce, plain, manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Manifest, f.Nonce, f.Ciphertext, f.Signer = manifest, nonce, ct, signer.PublicKey
+	sig, err := sign.Sign(signer.PrivateKey, f.signedBytes())
+	if err != nil {
+		return nil, err
+	}
+	f.Signature = sig
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// signedBytes is the length-prefixed concatenation of every field except the signature.
+func (f *file) signedBytes() []byte {
+	var b bytes.Buffer
+	b.WriteString(formatName)
+	kdf, _ := json.Marshal(f.KDF)
+	for _, part := range [][]byte{f.Manifest, kdf, f.EphemeralKey, f.Nonce, f.Ciphertext, f.Signer} {
+		binary.Write(&b, binary.BigEndian, uint32(len(part)))
+		b.Write(part)
+	}
+	return b.Bytes()
+}
+
+// Parse reads a bundle and verifies its signature against the embedded signer key.
+// Callers should show SignerFingerprint to the user (or compare it to a known
+// value) before trusting the contents.
+func Parse(data []byte) (*Bundle, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	// The manifest is signed in compact form; indentation in the file is cosmetic
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, f.Manifest); err != nil {
+		return nil, fmt.Errorf("not a bundle: %w", err)
+	}
+	f.Manifest = compact.Bytes()
+	var m Manifest
+	if err := json.Unmarshal(f.Manifest, &m); err != nil || m.Format != formatName {
+		return nil, errors.New("not a bundle: bad manifest")
+	}
+	if m.Version != formatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", m.Version)
+	}
+	ok, err := sign.Verify(f.Signer, f.signedBytes(), f.Signature)
+	if err != nil || !ok {
+		return nil, ErrBadSignature
+	}
+	return &Bundle{Manifest: m, f: f}, nil
+}
+
+// Signer returns the Ed25519 public key that signed the bundle.
+func (b *Bundle) Signer() []byte { return b.f.Signer }
+
+// SignerFingerprint returns the fingerprint of the signing key.
+func (b *Bundle) SignerFingerprint() string { return sign.Fingerprint(b.f.Signer) }
+
+// OpenWithPassword decrypts a password-protected bundle.
+func (b *Bundle) OpenWithPassword(password string) ([]pwmanager.Record, error) {
+	if b.Manifest.Mode != ModePassword || b.f.KDF == nil {
+		return nil, errors.New("bundle is not password protected")
+	}
+	k := b.f.KDF
+	key, err := hash.Scrypt([]byte(password), k.Salt, k.N, k.R, k.P, 32)
+	if err != nil {
+		return nil, err
+	}
+	return b.open(key)
+}
+
+// OpenWithKey decrypts a bundle addressed to the given X25519 key pair.
+func (b *Bundle) OpenWithKey(kp *dh.KeyPair) ([]pwmanager.Record, error) {
+	if b.Manifest.Mode != ModeX25519 {
+		return nil, errors.New("bundle is not encrypted to a key")
+	}
+	if b.Manifest.Recipient != sign.Fingerprint(kp.PublicKey) {
+		return nil, fmt.Errorf("bundle is addressed to %s, not this vault", b.Manifest.Recipient)
+	}
+	key, err := recipientKey(kp.PrivateKey, b.f.EphemeralKey, b.f.EphemeralKey, kp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return b.open(key)
+}
+
+func (b *Bundle) open(key []byte) ([]pwmanager.Record, error) {
+	plain, err := encrypt.DecryptAESGCM(key, b.f.Nonce, b.f.Ciphertext, b.f.Manifest)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	var recs []pwmanager.Record
+	if err := json.Unmarshal(plain, &recs); err != nil {
+		return nil, fmt.Errorf("bad bundle payload: %w", err)
+	}
+	if len(recs) != b.Manifest.Entries {
+		return nil, fmt.Errorf("manifest lists %d entries but bundle holds %d", b.Manifest.Entries, len(recs))
+	}
+	return recs, nil
+}
@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"appliedcryptography-starter-kit/internal/dh"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/sign"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var testRecords = []pwmanager.Record{
+	{ID: "a", Title: "GitHub", PlainEntry: pwmanager.PlainEntry{Username: "alice", Password: "S3cret!", Tags: []string{"dev"}}},
+	{ID: "b", Title: "Bank", PlainEntry: pwmanager.PlainEntry{Username: "bob", Password: "hunter2"}},
+}
+
+func TestPasswordBundle(t *testing.T) {
+	signer, _ := sign.GenerateKeyPair()
+	data, err := SealWithPassword(testRecords, "export-pw", signer, "audit 2024")
+	if err != nil {
+		t.Fatalf("SealWithPassword() error = %v", err)
+	}
+	if bytes.Contains(data, []byte("hunter2")) || bytes.Contains(data, []byte("GitHub")) {
+		t.Fatal("bundle leaks entry contents")
+	}
+
+	b, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if b.SignerFingerprint() != sign.Fingerprint(signer.PublicKey) {
+		t.Error("signer fingerprint mismatch")
+	}
+	if b.Manifest.Entries != 2 || b.Manifest.Mode != ModePassword || b.Manifest.Comment != "audit 2024" {
+		t.Errorf("manifest = %+v", b.Manifest)
+	}
+
+	if _, err := b.OpenWithPassword("wrong"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong password: got %v, want ErrDecrypt", err)
+	}
+	recs, err := b.OpenWithPassword("export-pw")
+	if err != nil {
+		t.Fatalf("OpenWithPassword() error = %v", err)
+	}
+	if len(recs) != 2 || recs[1].Password != "hunter2" || recs[0].Tags[0] != "dev" {
+		t.Errorf("records = %+v", recs)
+	}
+}
+
+func TestRecipientBundle(t *testing.T) {
+	signer, _ := sign.GenerateKeyPair()
+	recipient, _ := dh.GenerateKeyPair()
+	other, _ := dh.GenerateKeyPair()
+
+	data, err := SealForRecipient(testRecords[:1], recipient.PublicKey, signer, "")
+	if err != nil {
+		t.Fatalf("SealForRecipient() error = %v", err)
+	}
+	b, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if _, err := b.OpenWithKey(other); err == nil {
+		t.Error("bundle opened with the wrong key")
+	}
+	recs, err := b.OpenWithKey(recipient)
+	if err != nil {
+		t.Fatalf("OpenWithKey() error = %v", err)
+	}
+	if len(recs) != 1 || recs[0].Title != "GitHub" {
+		t.Errorf("records = %+v", recs)
+	}
+}
+
+func TestTamperedBundle(t *testing.T) {
+	signer, _ := sign.GenerateKeyPair()
+	data, err := SealWithPassword(testRecords, "pw", signer, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Editing the manifest breaks the signature
+	var f map[string]json.RawMessage
+	json.Unmarshal(data, &f)
+	var m map[string]any
+	json.Unmarshal(f["manifest"], &m)
+	m["entries"] = 1
+	f["manifest"], _ = json.Marshal(m)
+	edited, _ := json.Marshal(f)
+	if _, err := Parse(edited); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("edited manifest: got %v, want ErrBadSignature", err)
+	}
+
+	// Re-signing with another key verifies, but under a different fingerprint
+	var raw file
+	json.Unmarshal(data, &raw)
+	var compact bytes.Buffer
+	json.Compact(&compact, raw.Manifest)
+	raw.Manifest = compact.Bytes()
+	mallory, _ := sign.GenerateKeyPair()
+	raw.Signer = mallory.PublicKey
+	raw.Signature, _ = sign.Sign(mallory.PrivateKey, raw.signedBytes())
+	resigned, _ := json.Marshal(raw)
+	b, err := Parse(resigned)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if b.SignerFingerprint() == sign.Fingerprint(signer.PublicKey) {
+		t.Error("re-signed bundle reports the original signer")
+	}
+}
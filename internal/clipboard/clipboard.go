@@ -0,0 +1,223 @@
+// Package clipboard puts secrets on the system clipboard and takes them off
+// again.
+//
+// A Backend is one way to reach a clipboard: the wl-copy and wl-paste tools
+// on Wayland, xclip or xsel on X11, pbcopy and pbpaste on macOS, the OSC 52
+// escape sequence, which the terminal carries out and which therefore also
+// works over SSH, or a plain file for tests. Detect picks the one that fits
+// the session.
+//
+// ClearIf removes a secret only while the clipboard still holds it, so
+// whatever the user copied since is left alone. The digest of the secret is
+// all it needs, which lets a background process do the clearing without ever
+// holding the secret itself.
+package clipboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// EnvBackend names the backend to use instead of detecting one: "wayland",
+// "x11", "macos", "osc52" or "file:PATH".
+const EnvBackend = "STARTERKIT_CLIPBOARD"
+
+// ErrUnavailable is returned when no clipboard can be reached.
+var ErrUnavailable = errors.New("no clipboard available: no Wayland or X11 session with wl-copy, xclip or xsel, and no terminal for OSC 52")
+
+// ErrUnreadable is returned by Paste on backends that can only write.
+var ErrUnreadable = errors.New("this clipboard cannot be read back")
+
+// Backend reaches one clipboard.
+type Backend interface {
+	// Name identifies the backend for Open.
+	Name() string
+	// Copy replaces the clipboard's content with text. Backends that can
+	// tell clipboard managers to keep a copy out of their history do.
+	Copy(text string) error
+	// Paste returns the clipboard's content, or ErrUnreadable.
+	Paste() (string, error)
+	// Clear empties the clipboard.
+	Clear() error
+}
+
+// Digest identifies a clipboard content for ClearIf without revealing it.
+type Digest [sha256.Size]byte
+
+// DigestOf returns the digest of text.
+func DigestOf(text string) Digest {
+	return sha256.Sum256([]byte(text))
+}
+
+// ClearIf empties the clipboard if it still holds the content with digest d,
+// and reports whether it did. A backend that cannot be read back is emptied
+// regardless: a secret left behind is worse than a lost copy.
+func ClearIf(b Backend, d Digest) (bool, error) {
+	current, err := b.Paste()
+	switch {
+	case errors.Is(err, ErrUnreadable):
+	case err != nil:
+		return false, err
+	default:
+		if sum := DigestOf(current); subtle.ConstantTimeCompare(sum[:], d[:]) != 1 {
+			return false, nil
+		}
+	}
+	return true, b.Clear()
+}
+
+// Open returns the backend with the given name; "" or "auto" detects one.
+func Open(name string) (Backend, error) {
+	switch {
+	case name == "" || name == "auto":
+		return Detect()
+	case name == "wayland":
+		return wayland()
+	case name == "x11":
+		return x11()
+	case name == "macos":
+		return macos()
+	case name == "osc52":
+		return OSC52()
+	case strings.HasPrefix(name, "file:") && len(name) > len("file:"):
+		return File(strings.TrimPrefix(name, "file:")), nil
+	}
+	return nil, fmt.Errorf("unknown clipboard %q: use wayland, x11, macos, osc52 or file:PATH", name)
+}
+
+// Detect returns the backend named by $STARTERKIT_CLIPBOARD, else the
+// clipboard of the graphical session, else OSC 52 if there is a terminal to
+// send it to.
+func Detect() (Backend, error) {
+	if name := os.Getenv(EnvBackend); name != "" {
+		return Open(name)
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if b, err := wayland(); err == nil {
+			return b, nil
+		}
+	}
+	if os.Getenv("DISPLAY") != "" {
+		if b, err := x11(); err == nil {
+			return b, nil
+		}
+	}
+	if runtime.GOOS == "darwin" {
+		if b, err := macos(); err == nil {
+			return b, nil
+		}
+	}
+	if b, err := OSC52(); err == nil {
+		return b, nil
+	}
+	return nil, ErrUnavailable
+}
+
+// tool is a backend that runs helper programs.
+type tool struct {
+	name  string
+	copy  []string // reads the text on stdin
+	paste []string // writes the clipboard to stdout
+	clear []string // nil: copy an empty text
+}
+
+func (t *tool) Name() string { return t.name }
+
+func (t *tool) Copy(text string) error {
+	return run(t.copy, text, nil)
+}
+
+func (t *tool) Paste() (string, error) {
+	var out bytes.Buffer
+	if err := run(t.paste, "", &out); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (t *tool) Clear() error {
+	if t.clear == nil {
+		return t.Copy("")
+	}
+	return run(t.clear, "", nil)
+}
+
+// run runs a helper program. Its stderr goes to a file rather than a pipe:
+// xclip and wl-copy leave a child behind that serves the selection and
+// inherits stderr, and Run would wait for a pipe until that child exits,
+// that is until something else is copied.
+func run(argv []string, stdin string, stdout io.Writer) error {
+	stderr, err := os.CreateTemp("", "clipboard-stderr-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(stderr.Name())
+	defer stderr.Close()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		msg, _ := os.ReadFile(stderr.Name())
+		if msg := strings.TrimSpace(string(msg)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", argv[0], err, msg)
+		}
+		return fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return nil
+}
+
+// wayland uses wl-clipboard. Versions that know --sensitive mark the copy
+// with x-kde-passwordManagerHint, which clipboard managers skip.
+func wayland() (Backend, error) {
+	if _, err := exec.LookPath("wl-copy"); err != nil {
+		return nil, err
+	}
+	t := &tool{
+		name:  "wayland",
+		copy:  []string{"wl-copy"},
+		paste: []string{"wl-paste", "--no-newline"},
+		clear: []string{"wl-copy", "--clear"},
+	}
+	if help, _ := exec.Command("wl-copy", "--help").CombinedOutput(); bytes.Contains(help, []byte("--sensitive")) {
+		t.copy = append(t.copy, "--sensitive")
+	}
+	return t, nil
+}
+
+// x11 uses xclip, or else xsel, on the CLIPBOARD selection. Neither can
+// offer the password-manager hint next to the text.
+func x11() (Backend, error) {
+	if _, err := exec.LookPath("xclip"); err == nil {
+		return &tool{
+			name:  "x11",
+			copy:  []string{"xclip", "-selection", "clipboard", "-in"},
+			paste: []string{"xclip", "-selection", "clipboard", "-out"},
+		}, nil
+	}
+	if _, err := exec.LookPath("xsel"); err != nil {
+		return nil, errors.New("neither xclip nor xsel is installed")
+	}
+	return &tool{
+		name:  "x11",
+		copy:  []string{"xsel", "--clipboard", "--input"},
+		paste: []string{"xsel", "--clipboard", "--output"},
+		clear: []string{"xsel", "--clipboard", "--clear"},
+	}, nil
+}
+
+func macos() (Backend, error) {
+	if _, err := exec.LookPath("pbcopy"); err != nil {
+		return nil, err
+	}
+	return &tool{name: "macos", copy: []string{"pbcopy"}, paste: []string{"pbpaste"}}, nil
+}
@@ -0,0 +1,88 @@
+package clipboard
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClearIf(t *testing.T) {
+	b := File(filepath.Join(t.TempDir(), "clip"))
+	if err := b.Copy("s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := b.Paste(); got != "s3cret" {
+		t.Fatalf("paste = %q", got)
+	}
+
+	// something else was copied since: leave it
+	d := DigestOf("s3cret")
+	b.Copy("newer")
+	if cleared, err := ClearIf(b, d); err != nil || cleared {
+		t.Fatalf("cleared a newer copy: %v, %v", cleared, err)
+	}
+	if got, _ := b.Paste(); got != "newer" {
+		t.Fatalf("paste = %q", got)
+	}
+
+	b.Copy("s3cret")
+	if cleared, err := ClearIf(b, d); err != nil || !cleared {
+		t.Fatalf("did not clear: %v, %v", cleared, err)
+	}
+	if got, _ := b.Paste(); got != "" {
+		t.Fatalf("paste after clear = %q", got)
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	var out strings.Builder
+	b := NewOSC52(&out)
+	b.Copy("hi")
+	if out.String() != "\x1b]52;c;aGk=\a" {
+		t.Fatalf("copy wrote %q", out.String())
+	}
+	// write-only: cleared whatever is there now
+	out.Reset()
+	if cleared, err := ClearIf(b, DigestOf("other")); err != nil || !cleared || out.String() != "\x1b]52;c;\a" {
+		t.Fatalf("clear: %v, %v, %q", cleared, err, out.String())
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "clip")
+	t.Setenv(EnvBackend, "file:"+path)
+	b, err := Detect()
+	if err != nil || b.Name() != "file:"+path {
+		t.Fatalf("detect = %v, %v", b, err)
+	}
+	// the name opens the same backend again, as the background clearer does
+	if again, err := Open(b.Name()); err != nil || again.Name() != b.Name() {
+		t.Fatalf("reopen = %v, %v", again, err)
+	}
+	if _, err := Open("carrier-pigeon"); err == nil {
+		t.Error("opened an unknown backend")
+	}
+}
+
+func TestToolLeavesChild(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	// like xclip and wl-copy, the copy tool forks a child that keeps serving
+	// the selection, with stderr inherited
+	b := &tool{name: "test", copy: []string{"sh", "-c", "cat >/dev/null; sleep 5 &"}}
+	start := time.Now()
+	if err := b.Copy("s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("copy waited %v for the child to exit", d)
+	}
+
+	b.copy = []string{"sh", "-c", "echo no display >&2; exit 1"}
+	if err := b.Copy("s3cret"); err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("failed copy reported as %v", err)
+	}
+}
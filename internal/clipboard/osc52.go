@@ -0,0 +1,47 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// osc52 sets the clipboard with the OSC 52 escape sequence. The terminal
+// does the work, so it reaches the clipboard of the machine the user sits
+// at even from a remote host, if the terminal allows it (tmux needs
+// set-clipboard on). It cannot be read back.
+type osc52 struct{ w io.Writer }
+
+// OSC52 returns a backend that writes to the controlling terminal, or to
+// stderr or stdout when one of them is a terminal.
+func OSC52() (Backend, error) {
+	if tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		return NewOSC52(tty), nil
+	}
+	for _, f := range []*os.File{os.Stderr, os.Stdout} {
+		if term.IsTerminal(int(f.Fd())) {
+			return NewOSC52(f), nil
+		}
+	}
+	return nil, ErrUnavailable
+}
+
+// NewOSC52 returns a backend that writes the escape sequence to w, which
+// should reach a terminal.
+func NewOSC52(w io.Writer) Backend { return &osc52{w: w} }
+
+func (o *osc52) Name() string { return "osc52" }
+
+func (o *osc52) Copy(text string) error {
+	_, err := io.WriteString(o.w, "\x1b]52;c;"+base64.StdEncoding.EncodeToString([]byte(text))+"\a")
+	return err
+}
+
+func (o *osc52) Paste() (string, error) { return "", ErrUnreadable }
+
+func (o *osc52) Clear() error {
+	_, err := io.WriteString(o.w, "\x1b]52;c;\a")
+	return err
+}
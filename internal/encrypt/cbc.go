@@ -0,0 +1,117 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// EncryptAESCBC encrypts plaintext using AES in Cipher Block Chaining (CBC) mode with PKCS#7 padding.
+// Note: CBC mode provides confidentiality but NOT authentication; pair it with a MAC
+// (see EncryptAESCBCHMAC). The IV must be 16 bytes and unpredictable for each encryption.
+func EncryptAESCBC(key, iv, plaintext []byte) ([]byte, error) {
+	// Validate inputs
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("key must be 16, 24, or 32 bytes")
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("IV must be %d bytes", aes.BlockSize)
+	}
+
+	// Create AES cipher
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	// Pad to a whole number of blocks (PKCS#7 always adds at least one byte)
+	padLen := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := make([]byte, len(plaintext)+padLen)
+	copy(padded, plaintext)
+	copy(padded[len(plaintext):], bytes.Repeat([]byte{byte(padLen)}, padLen))
+
+	// Encrypt
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	return ciphertext, nil
+}
+
+// DecryptAESCBC decrypts ciphertext produced by EncryptAESCBC and removes the PKCS#7 padding.
+func DecryptAESCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	// Validate inputs
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("key must be 16, 24, or 32 bytes")
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("IV must be %d bytes", aes.BlockSize)
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("ciphertext is not a multiple of the block size")
+	}
+
+	// Create AES cipher
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+
+	// Decrypt
+	padded := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(padded, ciphertext)
+
+	// Strip and check padding
+	padLen := int(padded[len(padded)-1])
+	if padLen == 0 || padLen > aes.BlockSize {
+		return nil, errors.New("invalid padding")
+	}
+	for _, b := range padded[len(padded)-padLen:] {
+		if int(b) != padLen {
+			return nil, errors.New("invalid padding")
+		}
+	}
+
+	return padded[:len(padded)-padLen], nil
+}
+
+// EncryptAESCBCHMAC encrypts with AES-CBC and then authenticates IV||ciphertext with HMAC-SHA256
+// (Encrypt-then-MAC). encKey and macKey must be independent keys.
+// Returns the ciphertext and the 32-byte MAC.
+func EncryptAESCBCHMAC(encKey, macKey, iv, plaintext []byte) (ciphertext, mac []byte, err error) {
+	if len(macKey) == 0 {
+		return nil, nil, errors.New("MAC key cannot be empty")
+	}
+
+	ciphertext, err = EncryptAESCBC(encKey, iv, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ciphertext, cbcMAC(macKey, iv, ciphertext), nil
+}
+
+// DecryptAESCBCHMAC verifies the HMAC-SHA256 over IV||ciphertext and only then decrypts.
+// Returns an error if the MAC does not match.
+func DecryptAESCBCHMAC(encKey, macKey, iv, ciphertext, mac []byte) ([]byte, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("MAC key cannot be empty")
+	}
+
+	// Verify before decrypting to avoid padding oracles
+	if !hmac.Equal(cbcMAC(macKey, iv, ciphertext), mac) {
+		return nil, errors.New("decryption failed (MAC verification failed)")
+	}
+
+	return DecryptAESCBC(encKey, iv, ciphertext)
+}
+
+func cbcMAC(macKey, iv, ciphertext []byte) []byte {
+	h := hmac.New(sha256.New, macKey)
+	h.Write(iv)
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
@@ -0,0 +1,35 @@
+package encrypt
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// EncryptChaCha20 encrypts plaintext with the ChaCha20 stream cipher (RFC 8439, counter starting at 0).
+// Like CTR mode it provides confidentiality but NOT authentication.
+// The key must be 32 bytes and the nonce 12 bytes, unique for each encryption with the same key.
+func EncryptChaCha20(key, nonce, plaintext []byte) ([]byte, error) {
+	if len(key) != chacha20.KeySize {
+		return nil, errors.New("key must be 32 bytes")
+	}
+	if len(nonce) != chacha20.NonceSize {
+		return nil, errors.New("nonce must be 12 bytes for ChaCha20")
+	}
+
+	stream, err := chacha20.NewUnauthenticatedCipher(key, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ChaCha20 cipher: %w", err)
+	}
+
+	ciphertext := make([]byte, len(plaintext))
+	stream.XORKeyStream(ciphertext, plaintext)
+	return ciphertext, nil
+}
+
+// DecryptChaCha20 decrypts ciphertext produced by EncryptChaCha20.
+// As with CTR mode, decryption is the same operation as encryption.
+func DecryptChaCha20(key, nonce, ciphertext []byte) ([]byte, error) {
+	return EncryptChaCha20(key, nonce, ciphertext)
+}
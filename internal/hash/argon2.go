@@ -0,0 +1,33 @@
+package hash
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2id derives a key using Argon2id (RFC 9106).
+// memoryKiB is the memory cost in KiB, iterations the time cost and threads the parallelism.
+// Returns a key of length keyLen.
+func Argon2id(password, salt []byte, iterations, memoryKiB uint32, threads uint8, keyLen uint32) ([]byte, error) {
+	if iterations == 0 || memoryKiB == 0 || threads == 0 {
+		return nil, errors.New("argon2 parameters must be positive")
+	}
+	if keyLen == 0 {
+		return nil, errors.New("keyLen must be positive")
+	}
+	return argon2.IDKey(password, salt, iterations, memoryKiB, threads, keyLen), nil
+}
+
+// Argon2d derives a key using Argon2d (RFC 9106).
+// Its memory access pattern depends on the password, so prefer Argon2id; this
+// variant exists because formats such as KeePass KDBX 4 use it by default.
+func Argon2d(password, salt []byte, iterations, memoryKiB uint32, threads uint8, keyLen uint32) ([]byte, error) {
+	if iterations == 0 || memoryKiB == 0 || threads == 0 {
+		return nil, errors.New("argon2 parameters must be positive")
+	}
+	if keyLen < 4 {
+		return nil, errors.New("keyLen must be at least 4 bytes")
+	}
+	return argon2d(password, salt, iterations, memoryKiB, threads, keyLen), nil
+}
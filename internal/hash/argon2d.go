@@ -0,0 +1,220 @@
+package hash
+
+// Argon2d is not exported by golang.org/x/crypto/argon2, so this file carries a
+// small single-mode port of that package's generic implementation (BSD licensed,
+// Copyright 2017 The Go Authors). Only data-dependent addressing is needed here.
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	argon2Version     = 0x13
+	argon2dType       = 0
+	argon2BlockWords  = 128
+	argon2SyncPoints  = 4
+	argon2BlockLength = argon2BlockWords * 8
+)
+
+type argon2Block [argon2BlockWords]uint64
+
+func argon2d(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	return argon2dKey(password, salt, nil, nil, time, memory, threads, keyLen)
+}
+
+func argon2dKey(password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	lanesCount := uint32(threads)
+	h0 := argon2InitHash(password, salt, secret, data, time, memory, lanesCount, keyLen)
+
+	memory = memory / (argon2SyncPoints * lanesCount) * (argon2SyncPoints * lanesCount)
+	if memory < 2*argon2SyncPoints*lanesCount {
+		memory = 2 * argon2SyncPoints * lanesCount
+	}
+
+	B := make([]argon2Block, memory)
+	laneLen := memory / lanesCount
+	var buf [argon2BlockLength]byte
+	for lane := uint32(0); lane < lanesCount; lane++ {
+		binary.LittleEndian.PutUint32(h0[blake2b.Size+4:], lane)
+		for i := uint32(0); i < 2; i++ {
+			binary.LittleEndian.PutUint32(h0[blake2b.Size:], i)
+			blake2bLong(buf[:], h0[:])
+			for w := range B[lane*laneLen+i] {
+				B[lane*laneLen+i][w] = binary.LittleEndian.Uint64(buf[w*8:])
+			}
+		}
+	}
+
+	segments := laneLen / argon2SyncPoints
+	for n := uint32(0); n < time; n++ {
+		for slice := uint32(0); slice < argon2SyncPoints; slice++ {
+			var wg sync.WaitGroup
+			for lane := uint32(0); lane < lanesCount; lane++ {
+				wg.Add(1)
+				go func(lane uint32) {
+					defer wg.Done()
+					index := uint32(0)
+					if n == 0 && slice == 0 {
+						index = 2 // the first two blocks are already filled
+					}
+					offset := lane*laneLen + slice*segments + index
+					for index < segments {
+						prev := offset - 1
+						if index == 0 && slice == 0 {
+							prev += laneLen // last block in lane
+						}
+						ref := argon2IndexAlpha(B[prev][0], laneLen, segments, lanesCount, n, slice, lane, index)
+						argon2Compress(&B[offset], &B[prev], &B[ref], n > 0)
+						index, offset = index+1, offset+1
+					}
+				}(lane)
+			}
+			wg.Wait()
+		}
+	}
+
+	// XOR the last block of every lane and hash it down to the tag
+	final := B[memory-1]
+	for lane := uint32(0); lane < lanesCount-1; lane++ {
+		for i, v := range B[lane*laneLen+laneLen-1] {
+			final[i] ^= v
+		}
+	}
+	for i, v := range final {
+		binary.LittleEndian.PutUint64(buf[i*8:], v)
+	}
+	key := make([]byte, keyLen)
+	blake2bLong(key, buf[:])
+	return key
+}
+
+func argon2InitHash(password, salt, secret, data []byte, time, memory, threads, keyLen uint32) [blake2b.Size + 8]byte {
+	var h0 [blake2b.Size + 8]byte
+	b2, _ := blake2b.New512(nil)
+	writeLE := func(v uint32) {
+		var tmp [4]byte
+		binary.LittleEndian.PutUint32(tmp[:], v)
+		b2.Write(tmp[:])
+	}
+	writeLE(threads)
+	writeLE(keyLen)
+	writeLE(memory)
+	writeLE(time)
+	writeLE(argon2Version)
+	writeLE(argon2dType)
+	for _, b := range [][]byte{password, salt, secret, data} {
+		writeLE(uint32(len(b)))
+		b2.Write(b)
+	}
+	b2.Sum(h0[:0])
+	return h0
+}
+
+// blake2bLong is the variable-length hash H' from RFC 9106 section 3.3.
+func blake2bLong(out, in []byte) {
+	var prefix [4]byte
+	binary.LittleEndian.PutUint32(prefix[:], uint32(len(out)))
+
+	if len(out) <= blake2b.Size {
+		b2, _ := blake2b.New(len(out), nil)
+		b2.Write(prefix[:])
+		b2.Write(in)
+		b2.Sum(out[:0])
+		return
+	}
+
+	b2, _ := blake2b.New512(nil)
+	b2.Write(prefix[:])
+	b2.Write(in)
+	var v [blake2b.Size]byte
+	b2.Sum(v[:0])
+	copy(out, v[:32])
+	rest := out[32:]
+	for len(rest) > blake2b.Size {
+		v = blake2b.Sum512(v[:])
+		copy(rest, v[:32])
+		rest = rest[32:]
+	}
+	last, _ := blake2b.New(len(rest), nil)
+	last.Write(v[:])
+	last.Sum(rest[:0])
+}
+
+func argon2IndexAlpha(rand uint64, laneLen, segments, threads, n, slice, lane, index uint32) uint32 {
+	refLane := uint32(rand>>32) % threads
+	if n == 0 && slice == 0 {
+		refLane = lane
+	}
+	m, s := 3*segments, ((slice+1)%argon2SyncPoints)*segments
+	if lane == refLane {
+		m += index
+	}
+	if n == 0 {
+		m, s = slice*segments, 0
+		if slice == 0 || lane == refLane {
+			m += index
+		}
+	}
+	if index == 0 || lane == refLane {
+		m--
+	}
+	p := rand & 0xFFFFFFFF
+	p = (p * p) >> 32
+	p = (p * uint64(m)) >> 32
+	return refLane*laneLen + uint32((uint64(s)+uint64(m)-(p+1))%uint64(laneLen))
+}
+
+// argon2Compress is the compression function G; with xor set the result is
+// XORed into out (passes after the first), otherwise it overwrites out.
+func argon2Compress(out, in1, in2 *argon2Block, xor bool) {
+	var t argon2Block
+	for i := range t {
+		t[i] = in1[i] ^ in2[i]
+	}
+	for i := 0; i < argon2BlockWords; i += 16 {
+		blamkaRound(&t, i, i+1, i+2, i+3, i+4, i+5, i+6, i+7, i+8, i+9, i+10, i+11, i+12, i+13, i+14, i+15)
+	}
+	for i := 0; i < argon2BlockWords/8; i += 2 {
+		blamkaRound(&t, i, i+1, 16+i, 16+i+1, 32+i, 32+i+1, 48+i, 48+i+1,
+			64+i, 64+i+1, 80+i, 80+i+1, 96+i, 96+i+1, 112+i, 112+i+1)
+	}
+	for i := range t {
+		v := in1[i] ^ in2[i] ^ t[i]
+		if xor {
+			out[i] ^= v
+		} else {
+			out[i] = v
+		}
+	}
+}
+
+func blamkaRound(t *argon2Block, i00, i01, i02, i03, i04, i05, i06, i07, i08, i09, i10, i11, i12, i13, i14, i15 int) {
+	blamkaG(t, i00, i04, i08, i12)
+	blamkaG(t, i01, i05, i09, i13)
+	blamkaG(t, i02, i06, i10, i14)
+	blamkaG(t, i03, i07, i11, i15)
+	blamkaG(t, i00, i05, i10, i15)
+	blamkaG(t, i01, i06, i11, i12)
+	blamkaG(t, i02, i07, i08, i13)
+	blamkaG(t, i03, i04, i09, i14)
+}
+
+func blamkaG(t *argon2Block, a, b, c, d int) {
+	va, vb, vc, vd := t[a], t[b], t[c], t[d]
+	va += vb + 2*uint64(uint32(va))*uint64(uint32(vb))
+	vd ^= va
+	vd = vd>>32 | vd<<32
+	vc += vd + 2*uint64(uint32(vc))*uint64(uint32(vd))
+	vb ^= vc
+	vb = vb>>24 | vb<<40
+	va += vb + 2*uint64(uint32(va))*uint64(uint32(vb))
+	vd ^= va
+	vd = vd>>16 | vd<<48
+	vc += vd + 2*uint64(uint32(vc))*uint64(uint32(vd))
+	vb ^= vc
+	vb = vb>>63 | vb<<1
+	t[a], t[b], t[c], t[d] = va, vb, vc, vd
+}
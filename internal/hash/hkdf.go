@@ -1,19 +1,30 @@
-package hash
-
-import (
-	"crypto/sha256"
-	"fmt"
-	"io"
-
-	"golang.org/x/crypto/hkdf"
-)
-
-// HKDF derives a key using HKDF-SHA256
-func HKDF(masterKey, salt, info []byte, length int) ([]byte, error) {
-	hkdf := hkdf.New(sha256.New, masterKey, salt, info)
-	key := make([]byte, length)
-	if _, err := io.ReadFull(hkdf, key); err != nil {
-		return nil, fmt.Errorf("failed to generate HKDF key: %w", err)
-	}
-	return key, nil
-}
+package hash
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// HKDF derives a key using HKDF-SHA256
+func HKDF(masterKey, salt, info []byte, length int) ([]byte, error) {
+	hkdf := hkdf.New(sha256.New, masterKey, salt, info)
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf, key); err != nil {
+		return nil, fmt.Errorf("failed to generate HKDF key: %w", err)
+	}
+	return key, nil
+}
+
+// HKDFExpand runs only the HKDF-SHA256 expand step, treating prk as an already
+// uniformly random pseudorandom key. Some formats (e.g. Bitwarden) stretch keys this way.
+func HKDFExpand(prk, info []byte, length int) ([]byte, error) {
+	r := hkdf.Expand(sha256.New, prk, info)
+	key := make([]byte, length)
+	if _, err := io.ReadFull(r, key); err != nil {
+		return nil, fmt.Errorf("failed to expand HKDF key: %w", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,37 @@
+package hash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// PBKDF2 derives a key from the password and salt using PBKDF2-HMAC-SHA256.
+// It is much weaker than scrypt or Argon2 against GPU attacks and is provided
+// for interoperability with formats that mandate it (e.g. Bitwarden exports).
+func PBKDF2(password, salt []byte, iterations, keyLen int) ([]byte, error) {
+	if iterations <= 0 {
+		return nil, errors.New("iterations must be positive")
+	}
+	if keyLen <= 0 {
+		return nil, errors.New("keyLen must be positive")
+	}
+	return pbkdf2.Key(password, salt, iterations, keyLen, sha256.New), nil
+}
+
+// HMACSHA256 computes a standard HMAC-SHA256 tag (RFC 2104).
+// Unlike HMAC, which uses BLAKE2b keyed hashing, this accepts keys of any length
+// and is the construction expected by external file formats.
+// Returns a 32-byte MAC.
+func HMACSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// VerifyHMACSHA256 checks an HMAC-SHA256 tag in constant time.
+func VerifyHMACSHA256(key, data, expectedMAC []byte) bool {
+	return hmac.Equal(HMACSHA256(key, data), expectedMAC)
+}
@@ -0,0 +1,339 @@
+// Package hibp looks passwords up in a local copy of the Have I Been Pwned
+// "Pwned Passwords" corpus, so breach checks never touch the network.
+//
+// Two layouts are supported, in either SHA-1 or NTLM flavour:
+//
+//   - a single file of "HASH:COUNT" lines sorted by hash, searched in place
+//     with a binary search (optionally narrowed by a prebuilt index, see BuildIndex);
+//   - the per-prefix directory written by the official downloader, one file per
+//     5-hex-digit prefix ("ABCDE" or "ABCDE.txt") holding "SUFFIX:COUNT" lines.
+package hibp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// HashType selects the hash a dump is keyed by.
+type HashType int
+
+const (
+	SHA1 HashType = iota
+	NTLM
+)
+
+func (t HashType) String() string {
+	if t == NTLM {
+		return "ntlm"
+	}
+	return "sha1"
+}
+
+// hexLen is the length of a full hash in hex digits.
+func (t HashType) hexLen() int {
+	if t == NTLM {
+		return 32
+	}
+	return 40
+}
+
+// Sum returns the uppercase hex digest of password as it appears in the dumps.
+// NTLM is MD4 over the UTF-16LE encoding of the password.
+func (t HashType) Sum(password string) string {
+	if t == NTLM {
+		units := utf16.Encode([]rune(password))
+		buf := make([]byte, 2*len(units))
+		for i, u := range units {
+			buf[2*i] = byte(u)
+			buf[2*i+1] = byte(u >> 8)
+		}
+		h := md4.New()
+		h.Write(buf)
+		return strings.ToUpper(hex.EncodeToString(h.Sum(nil)))
+	}
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// prefixLen is the number of hex digits the range API and the directory layout split on.
+const prefixLen = 5
+
+var (
+	ErrUnknownFormat = errors.New("hibp: unrecognized dump format")
+	ErrStaleIndex    = errors.New("hibp: index does not match the dump (rebuild it)")
+	ErrUnsorted      = errors.New("hibp: dump is not sorted by hash")
+)
+
+// DB is an opened dump. It is safe for concurrent use.
+type DB struct {
+	Type HashType
+
+	dir   string   // per-prefix directory layout
+	file  *os.File // sorted single-file layout
+	size  int64
+	index *os.File // optional prefix index for file
+}
+
+// Open opens a dump at path: a directory is taken as the per-prefix layout,
+// anything else as a sorted file. The hash type is detected from the first
+// entry. If path+".idx" exists it is used to narrow searches.
+func Open(path string) (*DB, error) {
+	st, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if st.IsDir() {
+		return openDir(path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	db := &DB{file: f, size: st.Size()}
+	line, err := readLine(f, 0)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("hibp: read %s: %w", path, err)
+	}
+	if db.Type, err = detect(hashPart(line), 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	if idx, err := os.Open(IndexPath(path)); err == nil {
+		if err := checkIndex(idx, db.size); err != nil {
+			idx.Close()
+			f.Close()
+			return nil, err
+		}
+		db.index = idx
+	} else if !errors.Is(err, os.ErrNotExist) {
+		f.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func openDir(dir string) (*DB, error) {
+	names, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range names {
+		name := strings.TrimSuffix(e.Name(), ".txt")
+		if e.IsDir() || !isHex(name) || len(name) != prefixLen {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		line, err := readLine(f, 0)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("hibp: read %s: %w", e.Name(), err)
+		}
+		typ, err := detect(hashPart(line), prefixLen)
+		if err != nil {
+			return nil, err
+		}
+		return &DB{Type: typ, dir: dir}, nil
+	}
+	return nil, fmt.Errorf("%w: no prefix files in %s", ErrUnknownFormat, dir)
+}
+
+// detect infers the hash type from a hash with the first trim digits removed.
+func detect(h []byte, trim int) (HashType, error) {
+	if !isHex(string(h)) {
+		return 0, ErrUnknownFormat
+	}
+	switch len(h) + trim {
+	case SHA1.hexLen():
+		return SHA1, nil
+	case NTLM.hexLen():
+		return NTLM, nil
+	}
+	return 0, ErrUnknownFormat
+}
+
+// Close releases the open files.
+func (db *DB) Close() error {
+	var err error
+	if db.index != nil {
+		err = db.index.Close()
+	}
+	if db.file != nil {
+		if cerr := db.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// Count returns how many times password appears in the corpus; 0 means it was not found.
+func (db *DB) Count(password string) (int, error) {
+	return db.CountHash(db.Type.Sum(password))
+}
+
+// CountHash looks up a hex digest of the dump's hash type.
+func (db *DB) CountHash(digest string) (int, error) {
+	digest = strings.ToUpper(digest)
+	if len(digest) != db.Type.hexLen() || !isHex(digest) {
+		return 0, fmt.Errorf("hibp: not a %s digest: %q", db.Type, digest)
+	}
+	if db.dir != "" {
+		return db.countDir(digest)
+	}
+	lo, hi := int64(0), db.size
+	if db.index != nil {
+		p, _ := strconv.ParseUint(digest[:prefixLen], 16, 32)
+		var err error
+		if lo, hi, err = indexRange(db.index, int(p)); err != nil {
+			return 0, err
+		}
+	}
+	return db.search([]byte(digest), lo, hi)
+}
+
+// search binary-searches the lines starting in [lo, hi). lo must be a line start.
+func (db *DB) search(digest []byte, lo, hi int64) (int, error) {
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start := mid
+		if mid > lo {
+			// Skip to the first line that starts at or after mid.
+			var err error
+			if start, err = nextLineStart(db.file, mid); err != nil {
+				return 0, err
+			}
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+		line, err := readLine(db.file, start)
+		if err != nil {
+			return 0, err
+		}
+		switch c := bytes.Compare(bytes.ToUpper(hashPart(line)), digest); {
+		case c < 0:
+			lo = start + int64(len(line)) + 1
+		case c > 0:
+			hi = start
+		default:
+			return parseCount(line)
+		}
+	}
+	return 0, nil
+}
+
+func (db *DB) countDir(digest string) (int, error) {
+	prefix, suffix := digest[:prefixLen], []byte(digest[prefixLen:])
+	f, err := os.Open(filepath.Join(db.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		f, err = os.Open(filepath.Join(db.dir, prefix))
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	// Prefix files are small (a few hundred lines), so a sequential scan
+	// that stops once past the suffix is as fast as anything smarter.
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Bytes()
+		switch c := bytes.Compare(bytes.ToUpper(hashPart(line)), suffix); {
+		case c == 0:
+			return parseCount(line)
+		case c > 0:
+			return 0, nil
+		}
+	}
+	return 0, sc.Err()
+}
+
+// maxLine bounds a dump line: a 40-digit hash, a colon, a count and CRLF.
+const maxLine = 128
+
+// readLine returns the line starting at off without its line terminator.
+func readLine(r io.ReaderAt, off int64) ([]byte, error) {
+	buf := make([]byte, maxLine)
+	n, err := r.ReadAt(buf, off)
+	if n == 0 && err != nil {
+		return nil, err
+	}
+	buf = buf[:n]
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		buf = buf[:i]
+	} else if err == nil {
+		return nil, fmt.Errorf("hibp: line at offset %d is too long", off)
+	}
+	return buf, nil
+}
+
+// nextLineStart returns the offset of the first line starting at or after off (off > 0).
+func nextLineStart(r io.ReaderAt, off int64) (int64, error) {
+	buf := make([]byte, maxLine)
+	n, err := r.ReadAt(buf, off-1)
+	if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+		return off + int64(i), nil
+	}
+	if err == io.EOF {
+		return off + int64(n), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("hibp: line near offset %d is too long", off)
+}
+
+// hashPart returns the part of a "HASH:COUNT" line before the colon.
+func hashPart(line []byte) []byte {
+	line = bytes.TrimRight(line, "\r")
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func parseCount(line []byte) (int, error) {
+	line = bytes.TrimRight(line, "\r")
+	i := bytes.IndexByte(line, ':')
+	if i < 0 {
+		return 1, nil // bare hash lists carry no count
+	}
+	n, err := strconv.Atoi(string(bytes.TrimSpace(line[i+1:])))
+	if err != nil {
+		return 0, fmt.Errorf("hibp: bad count in %q", line)
+	}
+	return n, nil
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package hibp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var breached = map[string]int{"password": 9659365, "123456": 37359195, "hunter2": 32}
+
+// writeDump writes a sorted full-hash dump of the breached passwords plus filler lines.
+func writeDump(t *testing.T, typ HashType) string {
+	t.Helper()
+	var lines []string
+	for pw, n := range breached {
+		lines = append(lines, fmt.Sprintf("%s:%d", typ.Sum(pw), n))
+	}
+	for i := range 500 {
+		lines = append(lines, fmt.Sprintf("%s:%d", typ.Sum(fmt.Sprintf("filler-%d", i)), i+1))
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func checkCounts(t *testing.T, db *DB) {
+	t.Helper()
+	for pw, want := range breached {
+		if n, err := db.Count(pw); err != nil || n != want {
+			t.Errorf("Count(%q) = %d, %v; want %d", pw, n, err, want)
+		}
+	}
+	if n, err := db.Count("filler-0"); err != nil || n != 1 {
+		t.Errorf("first filler = %d, %v", n, err)
+	}
+	if n, err := db.Count("x9$Lq!r2Vb#8mZt-not-in-dump"); err != nil || n != 0 {
+		t.Errorf("unknown password = %d, %v; want 0", n, err)
+	}
+}
+
+func TestSortedFile(t *testing.T) {
+	for _, typ := range []HashType{SHA1, NTLM} {
+		t.Run(typ.String(), func(t *testing.T) {
+			db, err := Open(writeDump(t, typ))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			if db.Type != typ {
+				t.Fatalf("detected %s", db.Type)
+			}
+			checkCounts(t, db)
+		})
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dump := writeDump(t, SHA1)
+	if err := BuildIndex(dump, IndexPath(dump)); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Open(dump)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.index == nil {
+		t.Fatal("index not picked up")
+	}
+	checkCounts(t, db)
+	db.Close()
+
+	// Appending to the dump invalidates the index.
+	f, _ := os.OpenFile(dump, os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF:1\r\n")
+	f.Close()
+	if _, err := Open(dump); !errors.Is(err, ErrStaleIndex) {
+		t.Errorf("stale index: err = %v", err)
+	}
+}
+
+func TestBuildIndexUnsorted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.txt")
+	os.WriteFile(path, []byte("BBBBB00000000000000000000000000000000000:1\nAAAAA00000000000000000000000000000000000:1\n"), 0600)
+	if err := BuildIndex(path, IndexPath(path)); !errors.Is(err, ErrUnsorted) {
+		t.Errorf("err = %v, want ErrUnsorted", err)
+	}
+}
+
+func TestPrefixDirectory(t *testing.T) {
+	dir := t.TempDir()
+	buckets := map[string][]string{}
+	for pw, n := range breached {
+		h := SHA1.Sum(pw)
+		buckets[h[:5]] = append(buckets[h[:5]], fmt.Sprintf("%s:%d", h[5:], n))
+	}
+	for i := range 500 {
+		h := SHA1.Sum(fmt.Sprintf("filler-%d", i))
+		buckets[h[:5]] = append(buckets[h[:5]], fmt.Sprintf("%s:%d", h[5:], i+1))
+	}
+	for prefix, lines := range buckets {
+		sort.Strings(lines)
+		if err := os.WriteFile(filepath.Join(dir, prefix+".txt"), []byte(strings.Join(lines, "\r\n")), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if db.Type != SHA1 {
+		t.Fatalf("detected %s", db.Type)
+	}
+	checkCounts(t, db)
+}
+
+func TestSum(t *testing.T) {
+	// Well-known digests of "password".
+	if got := SHA1.Sum("password"); got != "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8" {
+		t.Errorf("SHA1 = %s", got)
+	}
+	if got := NTLM.Sum("password"); got != "8846F7EAEE8FB117AD06BDD830B7586C" {
+		t.Errorf("NTLM = %s", got)
+	}
+}
+
+func TestOpenUnknownFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.txt")
+	os.WriteFile(path, []byte("not a hash dump\n"), 0600)
+	if _, err := Open(path); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("err = %v", err)
+	}
+}
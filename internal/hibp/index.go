@@ -0,0 +1,110 @@
+package hibp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// The index maps each 5-hex-digit prefix to the byte offset of its first line
+// in the sorted dump, so a lookup only binary-searches one prefix bucket
+// (a few dozen KB) instead of the whole multi-GB file.
+//
+// Layout: magic, the dump size as a staleness check, then 2^20+1 little-endian
+// uint64 offsets; entry p is where prefix p starts and entry p+1 where it ends.
+const (
+	indexMagic   = "HIBPIDX1"
+	indexBuckets = 1 << (4 * prefixLen)
+	indexHeader  = len(indexMagic) + 8
+)
+
+// IndexPath is where Open looks for the index of a dump.
+func IndexPath(dump string) string { return dump + ".idx" }
+
+// BuildIndex scans the sorted dump once and writes its prefix index to out.
+func BuildIndex(dump, out string) error {
+	f, err := os.Open(dump)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	offsets := make([]uint64, indexBuckets+1)
+	next := 0 // first bucket whose start is not yet known
+	var off int64
+	var prev []byte
+	r := bufio.NewReaderSize(f, 1<<20)
+	for {
+		line, err := r.ReadSlice('\n')
+		if len(line) > 0 {
+			h := bytes.ToUpper(hashPart(bytes.TrimRight(line, "\n")))
+			if len(h) < prefixLen || !isHex(string(h)) {
+				return fmt.Errorf("%w: line at offset %d", ErrUnknownFormat, off)
+			}
+			if bytes.Compare(h, prev) < 0 {
+				return fmt.Errorf("%w: line at offset %d", ErrUnsorted, off)
+			}
+			prev = append(prev[:0], h...)
+			p, _ := strconv.ParseUint(string(h[:prefixLen]), 16, 32)
+			for ; next <= int(p); next++ {
+				offsets[next] = uint64(off)
+			}
+			off += int64(len(line))
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("hibp: read %s: %w", dump, err)
+		}
+	}
+	for ; next <= indexBuckets; next++ {
+		offsets[next] = uint64(off)
+	}
+
+	w, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	bw.WriteString(indexMagic)
+	binary.Write(bw, binary.LittleEndian, uint64(st.Size()))
+	binary.Write(bw, binary.LittleEndian, offsets)
+	if err := bw.Flush(); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// checkIndex verifies the header of an index against the dump size.
+func checkIndex(idx *os.File, dumpSize int64) error {
+	hdr := make([]byte, indexHeader)
+	if _, err := idx.ReadAt(hdr, 0); err != nil {
+		return fmt.Errorf("hibp: read index: %w", err)
+	}
+	if string(hdr[:len(indexMagic)]) != indexMagic {
+		return fmt.Errorf("hibp: %s is not an index", idx.Name())
+	}
+	if binary.LittleEndian.Uint64(hdr[len(indexMagic):]) != uint64(dumpSize) {
+		return ErrStaleIndex
+	}
+	return nil
+}
+
+// indexRange returns the byte range of prefix bucket p.
+func indexRange(idx io.ReaderAt, p int) (int64, int64, error) {
+	var buf [16]byte
+	if _, err := idx.ReadAt(buf[:], int64(indexHeader+8*p)); err != nil {
+		return 0, 0, fmt.Errorf("hibp: read index: %w", err)
+	}
+	return int64(binary.LittleEndian.Uint64(buf[:8])), int64(binary.LittleEndian.Uint64(buf[8:])), nil
+}
@@ -0,0 +1,305 @@
+package kdbx
+
+import (
+	"appliedcryptography-starter-kit/internal/encrypt"
+	"appliedcryptography-starter-kit/internal/hash"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+)
+
+// Cipher and KDF UUIDs as stored in the header.
+var (
+	cipherAES256   = mustHex("31c1f2e6bf714350be5805216afc5aff")
+	cipherChaCha20 = mustHex("d6038a2b8b6f4cb5a524339a31dbb59a")
+
+	kdfAES      = mustHex("c9d9f39a628a4460bf740d08c18a4fea")
+	kdfArgon2d  = mustHex("ef636ddf8c29444b91f7a9a403e30a0c")
+	kdfArgon2id = mustHex("9e298b1956db4773b23dfc3ec6f0a1e6")
+)
+
+// Inner random stream IDs.
+const (
+	streamSalsa20  = 2
+	streamChaCha20 = 3
+)
+
+// blockSize is the payload size of each HMAC block written by Encode.
+const blockSize = 1 << 20
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// Credentials unlock a database. Either or both of Password and KeyFile may be set.
+type Credentials struct {
+	Password string
+	KeyFile  []byte // raw contents of a KeePass key file
+}
+
+// compositeKey hashes each credential component and then the concatenation.
+func (c Credentials) compositeKey() ([]byte, error) {
+	var parts []byte
+	if c.Password != "" || c.KeyFile == nil {
+		parts = append(parts, hash.SHA256([]byte(c.Password))...)
+	}
+	if c.KeyFile != nil {
+		k, err := keyFileKey(c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, k...)
+	}
+	return hash.SHA256(parts), nil
+}
+
+// keyFileKey extracts the 32-byte key from a key file in any of the formats KeePass accepts:
+// XML (version 1.0 base64 or 2.0 hex), 32 raw bytes, 64 hex characters, or anything else hashed.
+func keyFileKey(data []byte) ([]byte, error) {
+	var kf struct {
+		Meta struct {
+			Version string `xml:"Version"`
+		} `xml:"Meta"`
+		Key struct {
+			Data struct {
+				Hash  string `xml:"Hash,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"Data"`
+		} `xml:"Key"`
+	}
+	if bytes.Contains(data, []byte("<KeyFile")) && xml.Unmarshal(data, &kf) == nil {
+		value := strings.Join(strings.Fields(kf.Key.Data.Value), "")
+		if strings.HasPrefix(kf.Meta.Version, "2.") {
+			key, err := hex.DecodeString(value)
+			if err != nil || len(key) != 32 {
+				return nil, errors.New("bad key file data")
+			}
+			if kf.Key.Data.Hash != "" {
+				sum := sha256.Sum256(key)
+				if !strings.EqualFold(hex.EncodeToString(sum[:4]), kf.Key.Data.Hash) {
+					return nil, errors.New("key file hash mismatch")
+				}
+			}
+			return key, nil
+		}
+		return base64.StdEncoding.DecodeString(value)
+	}
+	if len(data) == 32 {
+		return data, nil
+	}
+	if len(data) == 64 {
+		if key, err := hex.DecodeString(string(data)); err == nil {
+			return key, nil
+		}
+	}
+	return hash.SHA256(data), nil
+}
+
+// Upper bounds on the KDF parameters, well above what KeePassXC picks for a
+// one-second delay on current hardware. The header is read before anything
+// is authenticated, so without them a crafted file could keep the CPU busy
+// for days or ask for terabytes of memory.
+const (
+	maxAESRounds        = 1 << 28
+	maxArgon2Iterations = 100
+	maxArgon2Memory     = 2 << 30 // bytes
+	maxArgon2Lanes      = 64
+)
+
+// transformKey runs the KDF described by the header parameters.
+func transformKey(composite []byte, p variantDict) ([]byte, error) {
+	uuid := p.bytes("$UUID")
+	switch {
+	case bytes.Equal(uuid, kdfAES):
+		seed := p.bytes("S")
+		rounds := p.uint64("R")
+		if len(seed) != 32 {
+			return nil, errors.New("bad AES-KDF seed")
+		}
+		if rounds > maxAESRounds {
+			return nil, fmt.Errorf("AES-KDF rounds %d exceed the limit of %d", rounds, maxAESRounds)
+		}
+		block, err := aes.NewCipher(seed)
+		if err != nil {
+			return nil, err
+		}
+		key := append([]byte(nil), composite...)
+		for i := uint64(0); i < rounds; i++ {
+			block.Encrypt(key[:16], key[:16])
+			block.Encrypt(key[16:], key[16:])
+		}
+		return hash.SHA256(key), nil
+
+	case bytes.Equal(uuid, kdfArgon2d), bytes.Equal(uuid, kdfArgon2id):
+		salt := p.bytes("S")
+		iterations := p.uint64("I")
+		memory := p.uint64("M")
+		parallelism := p.uint64("P")
+		switch {
+		case iterations == 0 || memory < 1024 || parallelism == 0:
+			return nil, errors.New("bad Argon2 parameters")
+		case iterations > maxArgon2Iterations:
+			return nil, fmt.Errorf("Argon2 iterations %d exceed the limit of %d", iterations, maxArgon2Iterations)
+		case memory > maxArgon2Memory:
+			return nil, fmt.Errorf("Argon2 memory of %d bytes exceeds the limit of %d", memory, maxArgon2Memory)
+		case parallelism > maxArgon2Lanes:
+			return nil, fmt.Errorf("Argon2 parallelism %d exceeds the limit of %d", parallelism, maxArgon2Lanes)
+		}
+		memory /= 1024 // bytes -> KiB
+		if bytes.Equal(uuid, kdfArgon2d) {
+			return hash.Argon2d(composite, salt, uint32(iterations), uint32(memory), uint8(parallelism), 32)
+		}
+		return hash.Argon2id(composite, salt, uint32(iterations), uint32(memory), uint8(parallelism), 32)
+	}
+	return nil, fmt.Errorf("unsupported KDF %x", uuid)
+}
+
+// deriveKeys returns the payload cipher key and the HMAC base key.
+func deriveKeys(masterSeed, transformed []byte) (cipherKey, hmacBase []byte) {
+	cipherKey = hash.SHA256(append(append([]byte(nil), masterSeed...), transformed...))
+	h := sha512.New()
+	h.Write(masterSeed)
+	h.Write(transformed)
+	h.Write([]byte{0x01})
+	return cipherKey, h.Sum(nil)
+}
+
+// blockHMACKey derives the HMAC key for block index i (2^64-1 is used for the header).
+func blockHMACKey(hmacBase []byte, index uint64) []byte {
+	h := sha512.New()
+	binary.Write(h, binary.LittleEndian, index)
+	h.Write(hmacBase)
+	return h.Sum(nil)
+}
+
+const headerBlockIndex = ^uint64(0)
+
+// readBlocks verifies and concatenates the HMAC block stream.
+func readBlocks(r io.Reader, hmacBase []byte) ([]byte, error) {
+	var out bytes.Buffer
+	for index := uint64(0); ; index++ {
+		var mac [32]byte
+		var size int32
+		if _, err := io.ReadFull(r, mac[:]); err != nil {
+			return nil, fmt.Errorf("%w: truncated block stream", ErrCorrupt)
+		}
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil || size < 0 {
+			return nil, fmt.Errorf("%w: bad block size", ErrCorrupt)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("%w: truncated block", ErrCorrupt)
+		}
+
+		var msg bytes.Buffer
+		binary.Write(&msg, binary.LittleEndian, index)
+		binary.Write(&msg, binary.LittleEndian, size)
+		msg.Write(data)
+		if !hash.VerifyHMACSHA256(blockHMACKey(hmacBase, index), msg.Bytes(), mac[:]) {
+			return nil, fmt.Errorf("%w: block %d failed authentication", ErrCorrupt, index)
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		out.Write(data)
+	}
+}
+
+// writeBlocks splits data into authenticated blocks followed by the empty terminator block.
+func writeBlocks(w io.Writer, data []byte, hmacBase []byte) error {
+	for index := uint64(0); ; index++ {
+		n := min(len(data), blockSize)
+		chunk := data[:n]
+		data = data[n:]
+
+		var msg bytes.Buffer
+		binary.Write(&msg, binary.LittleEndian, index)
+		binary.Write(&msg, binary.LittleEndian, int32(n))
+		msg.Write(chunk)
+		if _, err := w.Write(hash.HMACSHA256(blockHMACKey(hmacBase, index), msg.Bytes())); err != nil {
+			return err
+		}
+		if _, err := w.Write(msg.Bytes()[8:]); err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+func decryptPayload(cipherID, key, iv, ct []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(cipherID, cipherAES256):
+		return encrypt.DecryptAESCBC(key, iv, ct)
+	case bytes.Equal(cipherID, cipherChaCha20):
+		return encrypt.DecryptChaCha20(key, iv, ct)
+	}
+	return nil, fmt.Errorf("unsupported cipher %x", cipherID)
+}
+
+func encryptPayload(cipherID, key, iv, pt []byte) ([]byte, error) {
+	switch {
+	case bytes.Equal(cipherID, cipherAES256):
+		return encrypt.EncryptAESCBC(key, iv, pt)
+	case bytes.Equal(cipherID, cipherChaCha20):
+		return encrypt.EncryptChaCha20(key, iv, pt)
+	}
+	return nil, fmt.Errorf("unsupported cipher %x", cipherID)
+}
+
+// newInnerStream returns the keystream used to (un)mask protected XML values.
+func newInnerStream(id uint32, key []byte) (cipher.Stream, error) {
+	switch id {
+	case streamChaCha20:
+		h := sha512.Sum512(key)
+		return chacha20.NewUnauthenticatedCipher(h[:32], h[32:44])
+	case streamSalsa20:
+		h := sha256.Sum256(key)
+		return newSalsaStream(h, []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}), nil
+	}
+	return nil, fmt.Errorf("unsupported inner random stream %d", id)
+}
+
+// salsaStream is a Salsa20 keystream generator; x/crypto/salsa20 has no streaming API.
+type salsaStream struct {
+	key     [32]byte
+	counter [16]byte // nonce || little-endian block counter
+	buf     []byte   // unused keystream from the current block
+}
+
+func newSalsaStream(key [32]byte, nonce []byte) *salsaStream {
+	s := &salsaStream{key: key}
+	copy(s.counter[:8], nonce)
+	return s
+}
+
+func (s *salsaStream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if len(s.buf) == 0 {
+			block := make([]byte, 64)
+			salsa.XORKeyStream(block, block, &s.counter, &s.key)
+			binary.LittleEndian.PutUint64(s.counter[8:], binary.LittleEndian.Uint64(s.counter[8:])+1)
+			s.buf = block
+		}
+		dst[i] = src[i] ^ s.buf[0]
+		s.buf = s.buf[1:]
+	}
+}
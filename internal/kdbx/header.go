@@ -0,0 +1,366 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// File signature and supported version.
+const (
+	signature1   uint32 = 0x9AA2D903
+	signature2   uint32 = 0xB54BFB67
+	versionMajor uint32 = 4
+	version40    uint32 = 0x00040000
+)
+
+// Outer header field IDs.
+const (
+	hdrEndOfHeader      = 0
+	hdrCipherID         = 2
+	hdrCompressionFlags = 3
+	hdrMasterSeed       = 4
+	hdrEncryptionIV     = 7
+	hdrKdfParameters    = 11
+	hdrPublicCustomData = 12
+)
+
+// Inner header field IDs.
+const (
+	innerEndOfHeader  = 0
+	innerRandomStream = 1
+	innerRandomKey    = 2
+	innerBinary       = 3
+)
+
+// outerHeader holds the parsed outer header plus the raw bytes it was read from,
+// which are covered by the header hash and HMAC.
+type outerHeader struct {
+	version    uint32
+	cipherID   []byte
+	compressed bool
+	masterSeed []byte
+	iv         []byte
+	kdfParams  variantDict
+	publicData []byte
+	raw        []byte
+}
+
+func readOuterHeader(r io.Reader) (*outerHeader, error) {
+	var buf bytes.Buffer
+	tr := io.TeeReader(r, &buf)
+
+	var sig [3]uint32
+	if err := binary.Read(tr, binary.LittleEndian, &sig); err != nil {
+		return nil, ErrNotKDBX
+	}
+	if sig[0] != signature1 || sig[1] != signature2 {
+		return nil, ErrNotKDBX
+	}
+	if sig[2]>>16 != versionMajor {
+		return nil, fmt.Errorf("%w: file version %d.%d", ErrUnsupportedVersion, sig[2]>>16, sig[2]&0xFFFF)
+	}
+
+	h := &outerHeader{version: sig[2]}
+	for {
+		var id uint8
+		var size uint32
+		if err := binary.Read(tr, binary.LittleEndian, &id); err != nil {
+			return nil, fmt.Errorf("truncated header: %w", err)
+		}
+		if err := binary.Read(tr, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("truncated header: %w", err)
+		}
+		if size > 1<<20 {
+			return nil, errors.New("header field too large")
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(tr, data); err != nil {
+			return nil, fmt.Errorf("truncated header: %w", err)
+		}
+
+		switch id {
+		case hdrEndOfHeader:
+			h.raw = buf.Bytes()
+			return h, h.validate()
+		case hdrCipherID:
+			h.cipherID = data
+		case hdrCompressionFlags:
+			if len(data) != 4 {
+				return nil, errors.New("bad compression flags")
+			}
+			h.compressed = binary.LittleEndian.Uint32(data) != 0
+		case hdrMasterSeed:
+			h.masterSeed = data
+		case hdrEncryptionIV:
+			h.iv = data
+		case hdrKdfParameters:
+			d, err := parseVariantDict(data)
+			if err != nil {
+				return nil, fmt.Errorf("bad KDF parameters: %w", err)
+			}
+			h.kdfParams = d
+		case hdrPublicCustomData:
+			h.publicData = data
+		default:
+			// Unknown fields are ignored, as KeePass does
+		}
+	}
+}
+
+func (h *outerHeader) validate() error {
+	switch {
+	case len(h.cipherID) != 16:
+		return errors.New("missing cipher ID")
+	case len(h.masterSeed) != 32:
+		return errors.New("missing master seed")
+	case len(h.iv) == 0:
+		return errors.New("missing encryption IV")
+	case h.kdfParams == nil:
+		return errors.New("missing KDF parameters")
+	}
+	return nil
+}
+
+func (h *outerHeader) marshal() []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, []uint32{signature1, signature2, h.version})
+
+	field := func(id uint8, data []byte) {
+		b.WriteByte(id)
+		binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+		b.Write(data)
+	}
+	comp := make([]byte, 4)
+	if h.compressed {
+		comp[0] = 1
+	}
+	field(hdrCipherID, h.cipherID)
+	field(hdrCompressionFlags, comp)
+	field(hdrMasterSeed, h.masterSeed)
+	field(hdrEncryptionIV, h.iv)
+	field(hdrKdfParameters, h.kdfParams.marshal())
+	if len(h.publicData) > 0 {
+		field(hdrPublicCustomData, h.publicData)
+	}
+	field(hdrEndOfHeader, []byte("\r\n\r\n"))
+
+	h.raw = b.Bytes()
+	return h.raw
+}
+
+// innerHeader carries the protected-value stream and the attachment pool.
+type innerHeader struct {
+	streamID  uint32
+	streamKey []byte
+	binaries  [][]byte
+}
+
+func readInnerHeader(r io.Reader) (*innerHeader, error) {
+	h := &innerHeader{}
+	for {
+		var id uint8
+		var size uint32
+		if err := binary.Read(r, binary.LittleEndian, &id); err != nil {
+			return nil, fmt.Errorf("truncated inner header: %w", err)
+		}
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return nil, fmt.Errorf("truncated inner header: %w", err)
+		}
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("truncated inner header: %w", err)
+		}
+
+		switch id {
+		case innerEndOfHeader:
+			return h, nil
+		case innerRandomStream:
+			if len(data) != 4 {
+				return nil, errors.New("bad inner random stream ID")
+			}
+			h.streamID = binary.LittleEndian.Uint32(data)
+		case innerRandomKey:
+			h.streamKey = data
+		case innerBinary:
+			if len(data) == 0 {
+				return nil, errors.New("empty binary in inner header")
+			}
+			// First byte is a flags field (0x01 = protect in memory), the rest is the content
+			h.binaries = append(h.binaries, data[1:])
+		}
+	}
+}
+
+func (h *innerHeader) marshal() []byte {
+	var b bytes.Buffer
+	field := func(id uint8, data []byte) {
+		b.WriteByte(id)
+		binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+		b.Write(data)
+	}
+	sid := make([]byte, 4)
+	binary.LittleEndian.PutUint32(sid, h.streamID)
+	field(innerRandomStream, sid)
+	field(innerRandomKey, h.streamKey)
+	for _, bin := range h.binaries {
+		field(innerBinary, append([]byte{0x01}, bin...))
+	}
+	field(innerEndOfHeader, nil)
+	return b.Bytes()
+}
+
+// ---------- VariantDictionary (KDF parameters) ----------
+
+const variantDictVersion uint16 = 0x0100
+
+// Value type tags in a VariantDictionary.
+const (
+	vdEnd       = 0x00
+	vdUInt32    = 0x04
+	vdUInt64    = 0x05
+	vdBool      = 0x08
+	vdInt32     = 0x0C
+	vdInt64     = 0x0D
+	vdString    = 0x18
+	vdByteArray = 0x42
+)
+
+// variantDict maps keys to uint32, uint64, bool, int32, int64, string or []byte values.
+type variantDict map[string]any
+
+func parseVariantDict(data []byte) (variantDict, error) {
+	r := bytes.NewReader(data)
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version&0xFF00 != variantDictVersion&0xFF00 {
+		return nil, fmt.Errorf("unsupported VariantDictionary version %#x", version)
+	}
+
+	d := variantDict{}
+	for {
+		typ, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if typ == vdEnd {
+			return d, nil
+		}
+		key, err := readSized(r)
+		if err != nil {
+			return nil, err
+		}
+		val, err := readSized(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch typ {
+		case vdUInt32, vdInt32:
+			if len(val) != 4 {
+				return nil, fmt.Errorf("bad 32-bit value for %q", key)
+			}
+			v := binary.LittleEndian.Uint32(val)
+			if typ == vdInt32 {
+				d[string(key)] = int32(v)
+			} else {
+				d[string(key)] = v
+			}
+		case vdUInt64, vdInt64:
+			if len(val) != 8 {
+				return nil, fmt.Errorf("bad 64-bit value for %q", key)
+			}
+			v := binary.LittleEndian.Uint64(val)
+			if typ == vdInt64 {
+				d[string(key)] = int64(v)
+			} else {
+				d[string(key)] = v
+			}
+		case vdBool:
+			d[string(key)] = len(val) == 1 && val[0] != 0
+		case vdString:
+			d[string(key)] = string(val)
+		case vdByteArray:
+			d[string(key)] = val
+		default:
+			return nil, fmt.Errorf("unknown VariantDictionary type %#x", typ)
+		}
+	}
+}
+
+func readSized(r *bytes.Reader) ([]byte, error) {
+	var n int32
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return nil, err
+	}
+	if n < 0 || int(n) > r.Len() {
+		return nil, errors.New("VariantDictionary item out of range")
+	}
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+func (d variantDict) marshal() []byte {
+	var b bytes.Buffer
+	binary.Write(&b, binary.LittleEndian, variantDictVersion)
+
+	// Sorted for a deterministic encoding ($UUID sorts first)
+	keys := make([]string, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var typ byte
+		var val []byte
+		switch v := d[k].(type) {
+		case uint32:
+			typ, val = vdUInt32, binary.LittleEndian.AppendUint32(nil, v)
+		case uint64:
+			typ, val = vdUInt64, binary.LittleEndian.AppendUint64(nil, v)
+		case bool:
+			typ, val = vdBool, []byte{0}
+			if v {
+				val[0] = 1
+			}
+		case int32:
+			typ, val = vdInt32, binary.LittleEndian.AppendUint32(nil, uint32(v))
+		case int64:
+			typ, val = vdInt64, binary.LittleEndian.AppendUint64(nil, uint64(v))
+		case string:
+			typ, val = vdString, []byte(v)
+		case []byte:
+			typ, val = vdByteArray, v
+		default:
+			continue
+		}
+		b.WriteByte(typ)
+		binary.Write(&b, binary.LittleEndian, int32(len(k)))
+		b.WriteString(k)
+		binary.Write(&b, binary.LittleEndian, int32(len(val)))
+		b.Write(val)
+	}
+	b.WriteByte(vdEnd)
+	return b.Bytes()
+}
+
+func (d variantDict) bytes(key string) []byte {
+	v, _ := d[key].([]byte)
+	return v
+}
+
+func (d variantDict) uint64(key string) uint64 {
+	switch v := d[key].(type) {
+	case uint64:
+		return v
+	case uint32:
+		return uint64(v)
+	}
+	return 0
+}
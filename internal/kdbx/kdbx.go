@@ -0,0 +1,244 @@
+// Package kdbx reads and writes KeePass KDBX 4 databases: the outer header,
+// AES-KDF/Argon2 key derivation, AES-256/ChaCha20 payload encryption, the HMAC
+// block stream, the inner header with its protected-value stream and attachment
+// pool, and the XML body.
+package kdbx
+
+import (
+	"appliedcryptography-starter-kit/internal/hash"
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrNotKDBX is returned for files without the KeePass signature.
+	ErrNotKDBX = errors.New("not a KeePass database")
+	// ErrUnsupportedVersion is returned for KDBX 3.x and older files.
+	ErrUnsupportedVersion = errors.New("only KDBX 4 databases are supported")
+	// ErrWrongCredentials is returned when the header HMAC does not verify.
+	ErrWrongCredentials = errors.New("wrong password or key file")
+	// ErrCorrupt is returned when the file is damaged after the credentials checked out.
+	ErrCorrupt = errors.New("corrupt database")
+)
+
+// Database is a decrypted KDBX 4 file.
+type Database struct {
+	Document Document
+	Binaries [][]byte // attachment pool referenced by BinaryRef.Value.Ref
+}
+
+// Cipher selects the payload cipher used by Encode.
+type Cipher int
+
+const (
+	ChaCha20 Cipher = iota
+	AES256
+)
+
+// KDF selects the key derivation function used by Encode.
+type KDF int
+
+const (
+	Argon2d KDF = iota
+	Argon2id
+	AESKDF
+)
+
+// Options control how Encode protects the database.
+type Options struct {
+	Cipher      Cipher
+	KDF         KDF
+	Iterations  uint64 // Argon2 passes or AES-KDF rounds
+	MemoryBytes uint64 // Argon2 only
+	Parallelism uint32 // Argon2 only
+}
+
+// DefaultOptions mirror what KeePassXC uses for new databases.
+var DefaultOptions = Options{Cipher: ChaCha20, KDF: Argon2d, Iterations: 10, MemoryBytes: 64 << 20, Parallelism: 2}
+
+// Decode decrypts and parses a KDBX 4 file.
+func Decode(data []byte, creds Credentials) (*Database, error) {
+	r := bytes.NewReader(data)
+	hdr, err := readOuterHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var storedHash, storedMAC [32]byte
+	if _, err := io.ReadFull(r, storedHash[:]); err != nil {
+		return nil, fmt.Errorf("%w: truncated header hash", ErrCorrupt)
+	}
+	if _, err := io.ReadFull(r, storedMAC[:]); err != nil {
+		return nil, fmt.Errorf("%w: truncated header HMAC", ErrCorrupt)
+	}
+	if !bytes.Equal(hash.SHA256(hdr.raw), storedHash[:]) {
+		return nil, fmt.Errorf("%w: header checksum mismatch", ErrCorrupt)
+	}
+
+	composite, err := creds.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+	transformed, err := transformKey(composite, hdr.kdfParams)
+	if err != nil {
+		return nil, err
+	}
+	cipherKey, hmacBase := deriveKeys(hdr.masterSeed, transformed)
+	if !hash.VerifyHMACSHA256(blockHMACKey(hmacBase, headerBlockIndex), hdr.raw, storedMAC[:]) {
+		return nil, ErrWrongCredentials
+	}
+
+	ct, err := readBlocks(r, hmacBase)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := decryptPayload(hdr.cipherID, cipherKey, hdr.iv, ct)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if hdr.compressed {
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+		if payload, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+		}
+	}
+
+	pr := bytes.NewReader(payload)
+	inner, err := readInnerHeader(pr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	stream, err := newInnerStream(inner.streamID, inner.streamKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(pr)
+	if err != nil {
+		return nil, err
+	}
+	clear, err := transformProtected(body, stream, true)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &Database{Binaries: inner.binaries}
+	if err := xml.Unmarshal(clear, &db.Document); err != nil {
+		return nil, fmt.Errorf("%w: bad XML: %v", ErrCorrupt, err)
+	}
+	return db, nil
+}
+
+// Encode serializes and encrypts the database as a KDBX 4 file.
+func (db *Database) Encode(creds Credentials, opts Options) ([]byte, error) {
+	hdr := &outerHeader{version: version40, compressed: true, kdfParams: variantDict{}}
+	var err error
+	if hdr.masterSeed, err = randomBytes(32); err != nil {
+		return nil, err
+	}
+
+	switch opts.Cipher {
+	case ChaCha20:
+		hdr.cipherID = cipherChaCha20
+		hdr.iv, err = randomBytes(12)
+	case AES256:
+		hdr.cipherID = cipherAES256
+		hdr.iv, err = randomBytes(16)
+	default:
+		return nil, errors.New("unknown cipher")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.KDF {
+	case Argon2d, Argon2id:
+		hdr.kdfParams["$UUID"] = kdfArgon2d
+		if opts.KDF == Argon2id {
+			hdr.kdfParams["$UUID"] = kdfArgon2id
+		}
+		hdr.kdfParams["S"] = salt
+		hdr.kdfParams["I"] = opts.Iterations
+		hdr.kdfParams["M"] = opts.MemoryBytes
+		hdr.kdfParams["P"] = opts.Parallelism
+		hdr.kdfParams["V"] = uint32(0x13)
+	case AESKDF:
+		hdr.kdfParams["$UUID"] = kdfAES
+		hdr.kdfParams["S"] = salt
+		hdr.kdfParams["R"] = opts.Iterations
+	default:
+		return nil, errors.New("unknown KDF")
+	}
+
+	composite, err := creds.compositeKey()
+	if err != nil {
+		return nil, err
+	}
+	transformed, err := transformKey(composite, hdr.kdfParams)
+	if err != nil {
+		return nil, err
+	}
+	cipherKey, hmacBase := deriveKeys(hdr.masterSeed, transformed)
+
+	// Inner header + XML with protected values masked
+	inner := &innerHeader{streamID: streamChaCha20, binaries: db.Binaries}
+	if inner.streamKey, err = randomBytes(64); err != nil {
+		return nil, err
+	}
+	stream, err := newInnerStream(inner.streamID, inner.streamKey)
+	if err != nil {
+		return nil, err
+	}
+	doc := db.Document
+	if doc.Meta.Generator == "" {
+		doc.Meta.Generator = "pwmanager"
+	}
+	clear, err := xml.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	clear = append([]byte(xml.Header), clear...)
+	body, err := transformProtected(clear, stream, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload bytes.Buffer
+	zw := gzip.NewWriter(&payload)
+	zw.Write(inner.marshal())
+	zw.Write(body)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	ct, err := encryptPayload(hdr.cipherID, cipherKey, hdr.iv, payload.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	raw := hdr.marshal()
+	out.Write(raw)
+	out.Write(hash.SHA256(raw))
+	out.Write(hash.HMACSHA256(blockHMACKey(hmacBase, headerBlockIndex), raw))
+	if err := writeBlocks(&out, ct, hmacBase); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
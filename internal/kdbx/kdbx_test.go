@@ -0,0 +1,179 @@
+package kdbx
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fastOptions keep the KDF cheap so the tests run quickly.
+var fastOptions = Options{Cipher: ChaCha20, KDF: Argon2d, Iterations: 1, MemoryBytes: 64 << 10, Parallelism: 1}
+
+// The fixture was produced by an independent writer (AES-KDF, AES-256-CBC,
+// ChaCha20 inner stream) and exercises groups, protected values, history,
+// attachments and the recycle bin.
+func TestDecodeFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/fixture-aeskdf-aes256.kdbx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := Decode(data, Credentials{Password: "correct horse"})
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	recs := db.Records()
+	if len(recs) != 2 {
+		t.Fatalf("Records() got %d records, want 2 (recycle bin skipped)", len(recs))
+	}
+
+	gh := recs[0]
+	if gh.Title != "GitHub" || gh.Username != "alice" || gh.Password != "S3cret & <pw>" || gh.URL != "https://github.com" {
+		t.Errorf("entry mapped wrong: %+v", gh)
+	}
+	if gh.Notes != "line1\nline2" || gh.TOTP != "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP" {
+		t.Errorf("notes/otp mapped wrong: %q %q", gh.Notes, gh.TOTP)
+	}
+	if v, ok := gh.Field("Recovery code"); !ok || v != "1111-2222" {
+		t.Errorf("protected custom field = %q", v)
+	}
+	if len(gh.Tags) != 2 || gh.Tags[0] != "dev" || gh.Tags[1] != "work" {
+		t.Errorf("tags = %v", gh.Tags)
+	}
+	if len(gh.History) != 1 || gh.History[0].Password != "old-password" {
+		t.Errorf("history = %+v", gh.History)
+	}
+	if len(gh.Attachments) != 1 || gh.Attachments[0].Name != "key.txt" || string(gh.Attachments[0].Data) != "attachment body\n" {
+		t.Errorf("attachments = %+v", gh.Attachments)
+	}
+	if !gh.CreatedAt.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("CreatedAt = %v", gh.CreatedAt)
+	}
+
+	bank := recs[1]
+	if bank.Folder != "Banking/EU" || bank.Password != "hunter2" {
+		t.Errorf("nested group entry mapped wrong: %+v", bank)
+	}
+
+	if _, err := Decode(data, Credentials{Password: "wrong"}); !errors.Is(err, ErrWrongCredentials) {
+		t.Errorf("wrong password: got %v, want ErrWrongCredentials", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	recs := []pwmanager.Record{
+		{Title: "Mail", PlainEntry: pwmanager.PlainEntry{
+			Username: "alice", Password: "pa<ss>&word", URL: "https://mail.example", Folder: "Personal/Email",
+			TOTP: "JBSWY3DPEHPK3PXP", Tags: []string{"a", "b"},
+			Fields:      []pwmanager.CustomField{{Name: "PIN", Value: "0000", Hidden: true}},
+			History:     []pwmanager.HistoryEntry{{Username: "alice", Password: "old", ModifiedAt: now.Add(-time.Hour)}},
+			Attachments: []pwmanager.Attachment{{Name: "a.bin", Data: []byte{0, 1, 2}}},
+			CreatedAt:   now, ModifiedAt: now,
+		}},
+		{Title: "Note", PlainEntry: pwmanager.PlainEntry{Notes: "just text", Kind: pwmanager.KindNote}},
+	}
+
+	ciphers := map[string]Options{"chacha20/argon2d": fastOptions}
+	aes := fastOptions
+	aes.Cipher, aes.KDF = AES256, Argon2id
+	ciphers["aes256/argon2id"] = aes
+	ciphers["aes256/aes-kdf"] = Options{Cipher: AES256, KDF: AESKDF, Iterations: 100}
+
+	for name, opts := range ciphers {
+		t.Run(name, func(t *testing.T) {
+			db, err := FromRecords("Test", recs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := db.Encode(Credentials{Password: "pw"}, opts)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if bytes.Contains(data, []byte("pa<ss>")) {
+				t.Fatal("password visible in encoded file")
+			}
+			back, err := Decode(data, Credentials{Password: "pw"})
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			got := back.Records()
+			if len(got) != 2 {
+				t.Fatalf("got %d records, want 2", len(got))
+			}
+			m := got[1] // root entries come first, so the foldered one is second
+			if m.Password != "pa<ss>&word" || m.Folder != "Personal/Email" || m.TOTP != "JBSWY3DPEHPK3PXP" {
+				t.Errorf("round trip mismatch: %+v", m)
+			}
+			if len(m.Fields) != 1 || !m.Fields[0].Hidden || m.Fields[0].Value != "0000" {
+				t.Errorf("fields = %+v", m.Fields)
+			}
+			if len(m.History) != 1 || m.History[0].Password != "old" || len(m.Attachments) != 1 || !bytes.Equal(m.Attachments[0].Data, []byte{0, 1, 2}) {
+				t.Errorf("history/attachments = %+v %+v", m.History, m.Attachments)
+			}
+			if !m.CreatedAt.Equal(now) {
+				t.Errorf("CreatedAt = %v, want %v", m.CreatedAt, now)
+			}
+		})
+	}
+}
+
+func TestKeyFile(t *testing.T) {
+	keyFile := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<KeyFile>
+	<Meta><Version>2.0</Version></Meta>
+	<Key><Data Hash="AE216C2E">
+		0102030405060708 090A0B0C0D0E0F10
+		1112131415161718 191A1B1C1D1E1F20
+	</Data></Key>
+</KeyFile>`)
+	db, err := FromRecords("K", []pwmanager.Record{{Title: "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := db.Encode(Credentials{Password: "pw", KeyFile: keyFile}, fastOptions)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if _, err := Decode(data, Credentials{Password: "pw", KeyFile: keyFile}); err != nil {
+		t.Fatalf("Decode() with key file error = %v", err)
+	}
+	if _, err := Decode(data, Credentials{Password: "pw"}); !errors.Is(err, ErrWrongCredentials) {
+		t.Errorf("missing key file: got %v, want ErrWrongCredentials", err)
+	}
+}
+
+func TestDecodeRejectsTampering(t *testing.T) {
+	db, _ := FromRecords("T", []pwmanager.Record{{Title: "x", PlainEntry: pwmanager.PlainEntry{Password: "y"}}})
+	data, err := db.Encode(Credentials{Password: "pw"}, fastOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-40] ^= 1 // inside the last payload block
+	if _, err := Decode(data, Credentials{Password: "pw"}); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("tampered block: got %v, want ErrCorrupt", err)
+	}
+	if _, err := Decode([]byte("not a database"), Credentials{}); !errors.Is(err, ErrNotKDBX) {
+		t.Errorf("garbage input: got %v, want ErrNotKDBX", err)
+	}
+}
+
+func TestTransformKeyLimits(t *testing.T) {
+	salt := make([]byte, 32)
+	composite := make([]byte, 32)
+	// the header is not authenticated until the key is known, so these
+	// must be refused before any work is done
+	for name, p := range map[string]variantDict{
+		"aes rounds":        {"$UUID": kdfAES, "S": salt, "R": uint64(1) << 40},
+		"argon2 iterations": {"$UUID": kdfArgon2d, "S": salt, "I": uint64(1) << 32, "M": uint64(64 << 10), "P": uint32(1)},
+		"argon2 memory":     {"$UUID": kdfArgon2id, "S": salt, "I": uint64(1), "M": uint64(4) << 40, "P": uint32(1)},
+		"argon2 lanes":      {"$UUID": kdfArgon2d, "S": salt, "I": uint64(1), "M": uint64(64 << 10), "P": uint32(255)},
+	} {
+		if _, err := transformKey(composite, p); err == nil || !strings.Contains(err.Error(), "limit") {
+			t.Errorf("%s: transformKey() error = %v, want a limit error", name, err)
+		}
+	}
+}
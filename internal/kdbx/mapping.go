@@ -0,0 +1,246 @@
+package kdbx
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"time"
+)
+
+// Standard string keys; everything else becomes a custom field.
+const (
+	keyTitle    = "Title"
+	keyUserName = "UserName"
+	keyPassword = "Password"
+	keyURL      = "URL"
+	keyNotes    = "Notes"
+	keyOTP      = "otp" // KeePassXC stores TOTP as an otpauth:// URI under this key
+)
+
+// Records flattens the group tree into pwmanager records. Group names below the
+// root become the slash-separated Folder; the recycle bin is skipped.
+func (db *Database) Records() []pwmanager.Record {
+	var out []pwmanager.Record
+	var walk func(g Group, path string)
+	walk = func(g Group, path string) {
+		if g.UUID != "" && g.UUID == db.Document.Meta.RecycleBinUUID {
+			return
+		}
+		for _, e := range g.Entries {
+			out = append(out, db.entryToRecord(e, path))
+		}
+		for _, sub := range g.Groups {
+			p := sub.Name
+			if path != "" {
+				p = path + "/" + sub.Name
+			}
+			walk(sub, p)
+		}
+	}
+	// The single top-level group is the database root and does not name a folder
+	for _, root := range db.Document.Root.Groups {
+		walk(root, "")
+	}
+	return out
+}
+
+func (db *Database) entryToRecord(e Entry, folder string) pwmanager.Record {
+	rec := pwmanager.Record{}
+	p := &rec.PlainEntry
+	p.Folder = folder
+	p.CreatedAt = e.Times.CreationTime.Time
+	p.ModifiedAt = e.Times.LastModificationTime.Time
+
+	for _, s := range e.Strings {
+		v := s.Value.Content
+		switch s.Key {
+		case keyTitle:
+			rec.Title = v
+		case keyUserName:
+			p.Username = v
+		case keyPassword:
+			p.Password = v
+		case keyURL:
+			p.URL = v
+		case keyNotes:
+			p.Notes = v
+		case keyOTP:
+			p.TOTP = v
+		default:
+			p.Fields = append(p.Fields, pwmanager.CustomField{Name: s.Key, Value: v, Hidden: s.Value.IsProtected()})
+		}
+	}
+	for _, t := range strings.FieldsFunc(e.Tags, func(r rune) bool { return r == ';' || r == ',' }) {
+		if t = strings.TrimSpace(t); t != "" {
+			p.Tags = append(p.Tags, t)
+		}
+	}
+	for _, b := range e.Binaries {
+		if b.Value.Ref >= 0 && b.Value.Ref < len(db.Binaries) {
+			p.Attachments = append(p.Attachments, pwmanager.Attachment{Name: b.Key, Data: db.Binaries[b.Value.Ref]})
+		}
+	}
+	if e.History != nil {
+		for _, h := range e.History.Entries {
+			hist := pwmanager.HistoryEntry{ModifiedAt: h.Times.LastModificationTime.Time}
+			if v, ok := h.get(keyUserName); ok {
+				hist.Username = v.Content
+			}
+			if v, ok := h.get(keyPassword); ok {
+				hist.Password = v.Content
+			}
+			p.History = append(p.History, hist)
+		}
+	}
+	if p.Password == "" && p.Username == "" && p.URL == "" && p.Notes != "" {
+		p.Kind = pwmanager.KindNote
+	}
+	return rec
+}
+
+// FromRecords builds a database whose group tree mirrors the records' folders.
+// Passwords, TOTP secrets and hidden custom fields are written as protected values.
+func FromRecords(name string, recs []pwmanager.Record) (*Database, error) {
+	now := time.Now().UTC()
+	db := &Database{}
+	db.Document.Meta.DatabaseName = name
+
+	root, err := newGroup(name, now)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name == "" {
+		root.Name = "Root"
+	}
+
+	// Attachments with identical content share one pool slot, as KeePass does
+	pool := map[string]int{}
+	binaryRef := func(data []byte) int {
+		k := string(data)
+		if i, ok := pool[k]; ok {
+			return i
+		}
+		pool[k] = len(db.Binaries)
+		db.Binaries = append(db.Binaries, bytes.Clone(data))
+		return pool[k]
+	}
+
+	for _, r := range recs {
+		e, err := recordToEntry(r, now, binaryRef)
+		if err != nil {
+			return nil, err
+		}
+		g := &root
+		if r.Folder != "" {
+			for _, part := range strings.Split(r.Folder, "/") {
+				if part == "" {
+					continue
+				}
+				if g, err = childGroup(g, part, now); err != nil {
+					return nil, err
+				}
+			}
+		}
+		g.Entries = append(g.Entries, e)
+	}
+	db.Document.Root.Groups = []Group{root}
+	return db, nil
+}
+
+func recordToEntry(r pwmanager.Record, now time.Time, binaryRef func([]byte) int) (Entry, error) {
+	p := r.PlainEntry
+	uuid, err := newUUID()
+	if err != nil {
+		return Entry{}, err
+	}
+	e := Entry{UUID: uuid, Times: newTimes(p.CreatedAt, p.ModifiedAt, now), Tags: strings.Join(p.Tags, ";")}
+
+	add := func(key, value string, protected bool) {
+		v := Value{Content: value}
+		if protected {
+			v.Protected = "True"
+		}
+		e.Strings = append(e.Strings, String{Key: key, Value: v})
+	}
+	add(keyTitle, r.Title, false)
+	add(keyUserName, p.Username, false)
+	add(keyPassword, p.Password, true)
+	add(keyURL, p.URL, false)
+	add(keyNotes, p.Notes, false)
+	if p.TOTP != "" {
+		add(keyOTP, p.TOTP, true)
+	}
+	for _, f := range p.Fields {
+		add(f.Name, f.Value, f.Hidden)
+	}
+
+	for _, a := range p.Attachments {
+		ref := BinaryRef{Key: a.Name}
+		ref.Value.Ref = binaryRef(a.Data)
+		e.Binaries = append(e.Binaries, ref)
+	}
+
+	if len(p.History) > 0 {
+		e.History = &History{}
+		for _, h := range p.History {
+			he := Entry{UUID: uuid, Times: newTimes(h.ModifiedAt, h.ModifiedAt, now)}
+			he.Strings = []String{
+				{Key: keyTitle, Value: Value{Content: r.Title}},
+				{Key: keyUserName, Value: Value{Content: h.Username}},
+				{Key: keyPassword, Value: Value{Protected: "True", Content: h.Password}},
+			}
+			e.History.Entries = append(e.History.Entries, he)
+		}
+	}
+	return e, nil
+}
+
+// childGroup returns g's subgroup with the given name, creating it if needed.
+func childGroup(g *Group, name string, now time.Time) (*Group, error) {
+	for i := range g.Groups {
+		if g.Groups[i].Name == name {
+			return &g.Groups[i], nil
+		}
+	}
+	sub, err := newGroup(name, now)
+	if err != nil {
+		return nil, err
+	}
+	g.Groups = append(g.Groups, sub)
+	return &g.Groups[len(g.Groups)-1], nil
+}
+
+func newGroup(name string, now time.Time) (Group, error) {
+	uuid, err := newUUID()
+	if err != nil {
+		return Group{}, err
+	}
+	return Group{UUID: uuid, Name: name, IconID: 48, Times: newTimes(now, now, now)}, nil
+}
+
+func newTimes(created, modified, now time.Time) Times {
+	if created.IsZero() {
+		created = now
+	}
+	if modified.IsZero() {
+		modified = created
+	}
+	return Times{
+		CreationTime:         Time{created},
+		LastModificationTime: Time{modified},
+		LastAccessTime:       Time{modified},
+		ExpiryTime:           Time{modified},
+		Expires:              "False",
+		LocationChanged:      Time{modified},
+	}
+}
+
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,213 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Document is the XML body of a database, with protected values already in clear text.
+type Document struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    Meta     `xml:"Meta"`
+	Root    Root     `xml:"Root"`
+}
+
+// Meta holds database-wide settings.
+type Meta struct {
+	Generator         string `xml:"Generator"`
+	DatabaseName      string `xml:"DatabaseName"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled,omitempty"`
+	RecycleBinUUID    string `xml:"RecycleBinUUID,omitempty"`
+}
+
+// Root contains the top-level group (KeePass always writes exactly one).
+type Root struct {
+	Groups []Group `xml:"Group"`
+}
+
+// Group is a folder of entries and subgroups.
+type Group struct {
+	UUID    string  `xml:"UUID"`
+	Name    string  `xml:"Name"`
+	Notes   string  `xml:"Notes,omitempty"`
+	IconID  int     `xml:"IconID"`
+	Times   Times   `xml:"Times"`
+	Entries []Entry `xml:"Entry"`
+	Groups  []Group `xml:"Group"`
+}
+
+// Entry is a single credential record; History holds earlier snapshots of it.
+type Entry struct {
+	UUID     string      `xml:"UUID"`
+	IconID   int         `xml:"IconID"`
+	Tags     string      `xml:"Tags,omitempty"`
+	Times    Times       `xml:"Times"`
+	Strings  []String    `xml:"String"`
+	Binaries []BinaryRef `xml:"Binary"`
+	History  *History    `xml:"History,omitempty"`
+}
+
+// History wraps an entry's previous versions.
+type History struct {
+	Entries []Entry `xml:"Entry"`
+}
+
+// String is a key/value field such as Title, UserName or Password.
+type String struct {
+	Key   string `xml:"Key"`
+	Value Value  `xml:"Value"`
+}
+
+// Value is a string value; Protected ("True") marks it as masked in the file.
+type Value struct {
+	Protected string `xml:"Protected,attr,omitempty"`
+	Content   string `xml:",chardata"`
+}
+
+// IsProtected reports whether the value is stored masked by the inner random stream.
+func (v Value) IsProtected() bool { return strings.EqualFold(v.Protected, "True") }
+
+// BinaryRef attaches a binary from the inner header pool to an entry.
+type BinaryRef struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Ref int `xml:"Ref,attr"`
+	} `xml:"Value"`
+}
+
+// Times holds the standard timestamps.
+type Times struct {
+	CreationTime         Time   `xml:"CreationTime"`
+	LastModificationTime Time   `xml:"LastModificationTime"`
+	LastAccessTime       Time   `xml:"LastAccessTime"`
+	ExpiryTime           Time   `xml:"ExpiryTime"`
+	Expires              string `xml:"Expires"`
+	UsageCount           int    `xml:"UsageCount"`
+	LocationChanged      Time   `xml:"LocationChanged"`
+}
+
+// Time is a KDBX 4 timestamp: base64 of the little-endian seconds since 0001-01-01 UTC.
+// ISO 8601 text (KDBX 3 style) is accepted on input.
+type Time struct {
+	time.Time
+}
+
+// epochOffset is the number of seconds from 0001-01-01 to the Unix epoch.
+const epochOffset = 62135596800
+
+// MarshalText encodes t in the KDBX 4 binary form.
+func (t Time) MarshalText() ([]byte, error) {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(t.UTC().Unix()+epochOffset))
+	return []byte(base64.StdEncoding.EncodeToString(buf)), nil
+}
+
+// UnmarshalText accepts both the KDBX 4 base64 form and ISO 8601.
+func (t *Time) UnmarshalText(b []byte) error {
+	s := strings.TrimSpace(string(b))
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil && len(raw) == 8 {
+		secs := int64(binary.LittleEndian.Uint64(raw))
+		t.Time = time.Unix(secs-epochOffset, 0).UTC()
+		return nil
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("bad time %q", s)
+	}
+	t.Time = parsed.UTC()
+	return nil
+}
+
+// get returns the value of the string field with the given key.
+func (e *Entry) get(key string) (Value, bool) {
+	for _, s := range e.Strings {
+		if s.Key == key {
+			return s.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// transformProtected walks the XML in document order and passes every protected
+// value through the inner stream. With reveal set, values are base64-decoded and
+// unmasked; otherwise clear text is masked and base64-encoded. Everything else is
+// copied token by token so the document structure is untouched.
+func transformProtected(data []byte, stream cipher.Stream, reveal bool) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	enc := xml.NewEncoder(&out)
+
+	inProtected := false
+	var text []byte
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: bad XML: %v", ErrCorrupt, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Value" {
+				for _, a := range t.Attr {
+					if a.Name.Local == "Protected" && strings.EqualFold(a.Value, "True") {
+						inProtected, text = true, nil
+					}
+				}
+			}
+		case xml.CharData:
+			if inProtected {
+				text = append(text, t...)
+				continue
+			}
+		case xml.EndElement:
+			if inProtected && t.Name.Local == "Value" {
+				converted, err := convertProtected(text, stream, reveal)
+				if err != nil {
+					return nil, err
+				}
+				if len(converted) > 0 {
+					if err := enc.EncodeToken(xml.CharData(converted)); err != nil {
+						return nil, err
+					}
+				}
+				inProtected = false
+			}
+		}
+		if err := enc.EncodeToken(xml.CopyToken(tok)); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+func convertProtected(text []byte, stream cipher.Stream, reveal bool) ([]byte, error) {
+	if reveal {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(text)))
+		if err != nil {
+			return nil, errors.New("bad protected value encoding")
+		}
+		stream.XORKeyStream(raw, raw)
+		return raw, nil
+	}
+	masked := make([]byte, len(text))
+	stream.XORKeyStream(masked, text)
+	return []byte(base64.StdEncoding.EncodeToString(masked)), nil
+}
@@ -0,0 +1,90 @@
+// Package lastpass reads the CSV produced by LastPass "Export > LastPass CSV File"
+// and maps its rows, including typed secure notes, onto pwmanager records.
+package lastpass
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// secureNoteURL marks a row as a secure note rather than a site login.
+const secureNoteURL = "http://sn"
+
+// groupURL marks a row that only exists to create an empty folder.
+const groupURL = "http://group"
+
+// Row is one line of the CSV export.
+type Row struct {
+	URL      string
+	Username string
+	Password string
+	TOTP     string
+	Extra    string // site notes, or the body of a secure note
+	Name     string
+	Grouping string // folder path, backslash-separated
+	Fav      bool
+}
+
+// Export is a parsed LastPass CSV file.
+type Export struct {
+	Rows    []Row
+	Skipped []string // reasons for rows that carry nothing to import
+}
+
+// Parse reads a LastPass CSV export. Columns are located by header name, so both
+// the current layout (with totp) and older exports are accepted.
+func Parse(data []byte) (*Export, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"url", "username", "password", "name"} {
+		if _, ok := col[required]; !ok {
+			return nil, errors.New("not a LastPass CSV export: missing column " + required)
+		}
+	}
+
+	exp := &Export{}
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		get := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return rec[i]
+			}
+			return ""
+		}
+		row := Row{
+			URL: get("url"), Username: get("username"), Password: get("password"), TOTP: get("totp"),
+			Extra: get("extra"), Name: html.UnescapeString(get("name")),
+			Grouping: html.UnescapeString(get("grouping")), Fav: get("fav") == "1",
+		}
+		switch {
+		case row.URL == groupURL:
+			exp.Skipped = append(exp.Skipped, fmt.Sprintf("line %d: empty folder %q", line, row.Grouping))
+		case row.Name == "" && row.URL == "" && row.Username == "" && row.Password == "" && row.Extra == "":
+			exp.Skipped = append(exp.Skipped, fmt.Sprintf("line %d: empty row", line))
+		default:
+			exp.Rows = append(exp.Rows, row)
+		}
+	}
+	return exp, nil
+}
@@ -0,0 +1,79 @@
+package lastpass
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"testing"
+)
+
+const export = "url,username,password,totp,extra,name,grouping,fav\r\n" +
+	"https://github.com,alice,S3cret!,JBSWY3DPEHPK3PXP,2FA on,GitHub,Work\\Dev,1\r\n" +
+	"http://sn,,,,\"NoteType:Credit Card\nLanguage:en-US\nName on Card:Alice\nType:Visa\nNumber:4111111111111111\nSecurity Code:123\nStart Date:,\nExpiration Date:June,2030\nNotes:keep safe\nsecond line\",Visa,Finance,0\r\n" +
+	"http://sn,,,,\"NoteType:Server\nLanguage:en-US\nHostname:db1\nUsername:root\nPassword:toor\nNotes:\",DB host,,0\r\n" +
+	"http://sn,,,,\"NoteType:SSH Key\nBit Strength:256\nPrivate Key:-----BEGIN KEY-----\nabc\n-----END KEY-----\nNotes:\",Deploy key,,0\r\n" +
+	"http://sn,,,,\"NoteType:Address\nFirst Name:Alice\nLast Name:Smith\nPhone:{\"\"num\"\":\"\"5551234\"\",\"\"ext\"\":\"\"\"\",\"\"cc3l\"\":\"\"USA\"\"}\nNotes:\",Home,,0\r\n" +
+	"http://sn,,,,door code 42,Wifi,,0\r\n" +
+	"http://group,,,,,,Empty,0\r\n"
+
+func TestParseAndMap(t *testing.T) {
+	exp, err := Parse([]byte(export))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(exp.Skipped) != 1 {
+		t.Errorf("Skipped = %v, want the empty folder row", exp.Skipped)
+	}
+	recs := exp.Records()
+	if len(recs) != 6 {
+		t.Fatalf("Records() got %d records, want 6", len(recs))
+	}
+
+	gh := recs[0]
+	if gh.Title != "GitHub" || gh.Username != "alice" || gh.Password != "S3cret!" || gh.TOTP != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("login mapped wrong: %+v", gh)
+	}
+	if gh.Folder != "Work/Dev" || gh.Notes != "2FA on" || len(gh.Tags) != 1 || gh.Tags[0] != FavoriteTag {
+		t.Errorf("folder/notes/tags mapped wrong: %+v", gh)
+	}
+
+	card := recs[1]
+	if card.Kind != pwmanager.KindCard || card.Notes != "keep safe\nsecond line" {
+		t.Errorf("card mapped wrong: %+v", card)
+	}
+	for name, want := range map[string]string{"cardholderName": "Alice", "brand": "Visa", "number": "4111111111111111",
+		"code": "123", "expMonth": "6", "expYear": "2030", "Language": "en-US"} {
+		if v, ok := card.Field(name); !ok || v != want {
+			t.Errorf("card field %s = %q, want %q", name, v, want)
+		}
+	}
+
+	srv := recs[2]
+	if srv.Kind != pwmanager.KindLogin || srv.Username != "root" || srv.Password != "toor" {
+		t.Errorf("server note mapped wrong: %+v", srv)
+	}
+	if v, _ := srv.Field("Hostname"); v != "db1" {
+		t.Errorf("Hostname = %q", v)
+	}
+
+	key := recs[3]
+	if v, _ := key.Field("Private Key"); v != "-----BEGIN KEY-----\nabc\n-----END KEY-----" {
+		t.Errorf("multi-line field = %q", v)
+	}
+	if v, _ := key.Field(noteTypeField); key.Kind != pwmanager.KindNote || v != "SSH Key" {
+		t.Errorf("SSH key note mapped wrong: %+v", key)
+	}
+
+	home := recs[4]
+	if v, _ := home.Field("phone"); home.Kind != pwmanager.KindIdentity || v != "5551234" {
+		t.Errorf("address mapped wrong: %+v", home)
+	}
+
+	if note := recs[5]; note.Kind != pwmanager.KindNote || note.Notes != "door code 42" {
+		t.Errorf("plain note mapped wrong: %+v", note)
+	}
+}
+
+func TestParseRejectsOtherCSV(t *testing.T) {
+	if _, err := Parse([]byte("title,login\nx,y\n")); err == nil {
+		t.Error("Parse() accepted a CSV without LastPass columns")
+	}
+}
@@ -0,0 +1,186 @@
+package lastpass
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FavoriteTag is added to entries that were starred in LastPass.
+const FavoriteTag = "favorite"
+
+// noteTypeField records the template of a typed secure note that has no dedicated kind.
+const noteTypeField = "Note type"
+
+// Template fields with a dedicated slot. Card and identity properties use the same
+// custom field names as the Bitwarden importer so the vault stays uniform.
+var (
+	cardKeys = map[string]string{
+		"Name on Card": "cardholderName", "Type": "brand", "Number": "number", "Security Code": "code",
+	}
+	identityKeys = map[string]string{
+		"Title": "title", "First Name": "firstName", "Middle Name": "middleName", "Last Name": "lastName",
+		"Username": "username", "Company": "company", "Address 1": "address1", "Address 2": "address2",
+		"Address 3": "address3", "City / Town": "city", "State": "state", "Zip / Postal Code": "postalCode",
+		"Country": "country", "Email Address": "email", "Phone": "phone",
+	}
+	// Templates whose number field maps to a specific identity property
+	identityNumberKeys = map[string]string{
+		"Social Security": "ssn", "Passport": "passportNumber", "Driver's License": "licenseNumber",
+	}
+	// Templates that describe something you log in to
+	loginTemplates = map[string]bool{
+		"Server": true, "Database": true, "Email Account": true, "Instant Messenger": true, "Wi-Fi Password": true,
+	}
+	hiddenKeys = map[string]bool{
+		"Password": true, "Security Code": true, "PIN": true, "Private Key": true, "Passphrase": true,
+		"Number": true, "Account Number": true, "License Key": true, "Routing Number": true,
+	}
+)
+
+// Records converts the rows into pwmanager records.
+func (e *Export) Records() []pwmanager.Record {
+	out := make([]pwmanager.Record, 0, len(e.Rows))
+	for _, row := range e.Rows {
+		out = append(out, rowToRecord(row))
+	}
+	return out
+}
+
+func rowToRecord(row Row) pwmanager.Record {
+	rec := pwmanager.Record{Title: row.Name}
+	p := &rec.PlainEntry
+	p.Folder = strings.Trim(strings.ReplaceAll(row.Grouping, `\`, "/"), "/")
+	if row.Fav {
+		p.Tags = []string{FavoriteTag}
+	}
+
+	if row.URL != secureNoteURL {
+		p.URL = row.URL
+		p.Username = row.Username
+		p.Password = row.Password
+		p.TOTP = row.TOTP
+		p.Notes = row.Extra
+		if rec.Title == "" {
+			rec.Title = row.URL
+		}
+		return rec
+	}
+
+	noteType, fields, notes := parseNote(row.Extra)
+	p.Notes = notes
+	switch {
+	case noteType == "":
+		p.Kind = pwmanager.KindNote
+		return rec
+	case noteType == "Credit Card":
+		p.Kind = pwmanager.KindCard
+	case noteType == "Address" || identityNumberKeys[noteType] != "":
+		p.Kind = pwmanager.KindIdentity
+	case loginTemplates[noteType]:
+		p.Kind = pwmanager.KindLogin
+		p.Fields = append(p.Fields, pwmanager.CustomField{Name: noteTypeField, Value: noteType})
+	default:
+		p.Kind = pwmanager.KindNote
+		p.Fields = append(p.Fields, pwmanager.CustomField{Name: noteTypeField, Value: noteType})
+	}
+
+	for _, f := range fields {
+		if f.value == "" || f.value == "," { // "," is an unset month/year date
+			continue
+		}
+		name, value := f.key, f.value
+		switch p.Kind {
+		case pwmanager.KindCard:
+			if f.key == "Expiration Date" {
+				if month, year, ok := parseExpiry(f.value); ok {
+					p.Fields = append(p.Fields,
+						pwmanager.CustomField{Name: "expMonth", Value: month},
+						pwmanager.CustomField{Name: "expYear", Value: year})
+					continue
+				}
+			}
+			if n, ok := cardKeys[f.key]; ok {
+				name = n
+			}
+		case pwmanager.KindIdentity:
+			if n, ok := identityKeys[f.key]; ok {
+				name = n
+			} else if f.key == "Number" {
+				name = identityNumberKeys[noteType]
+			}
+			if strings.Contains(f.key, "Phone") || f.key == "Fax" {
+				value = parsePhone(value)
+			}
+		case pwmanager.KindLogin:
+			switch f.key {
+			case "Username":
+				p.Username = value
+				continue
+			case "Password":
+				p.Password = value
+				continue
+			}
+		}
+		p.Fields = append(p.Fields, pwmanager.CustomField{Name: name, Value: value, Hidden: hiddenKeys[f.key]})
+	}
+	return rec
+}
+
+type noteField struct{ key, value string }
+
+// parseNote splits a secure note body into its template name, "Key:Value" lines and
+// the free-form Notes section, which always comes last and may span several lines.
+// Lines without a colon continue the previous value (e.g. a multi-line private key).
+func parseNote(extra string) (noteType string, fields []noteField, notes string) {
+	extra = strings.ReplaceAll(extra, "\r\n", "\n")
+	if !strings.HasPrefix(extra, "NoteType:") {
+		return "", nil, extra
+	}
+	lines := strings.Split(extra, "\n")
+	for i, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		switch {
+		case i == 0:
+			noteType = value
+		case ok && key == "Notes":
+			notes = strings.Join(append([]string{value}, lines[i+1:]...), "\n")
+			return noteType, fields, strings.TrimRight(notes, "\n")
+		case ok && key != "" && !strings.HasPrefix(key, " "):
+			fields = append(fields, noteField{key, value})
+		case len(fields) > 0:
+			fields[len(fields)-1].value += "\n" + line
+		}
+	}
+	return noteType, fields, ""
+}
+
+// parseExpiry converts LastPass's "January,2025" into "1" and "2025".
+func parseExpiry(s string) (month, year string, ok bool) {
+	m, y, found := strings.Cut(s, ",")
+	if !found {
+		return "", "", false
+	}
+	t, err := time.Parse("January", strings.TrimSpace(m))
+	if err != nil {
+		return "", "", false
+	}
+	return strconv.Itoa(int(t.Month())), strings.TrimSpace(y), true
+}
+
+// parsePhone unwraps the JSON LastPass stores phone numbers as ({"num":..,"ext":..,"cc3l":..}).
+func parsePhone(s string) string {
+	var ph struct {
+		Num string `json:"num"`
+		Ext string `json:"ext"`
+	}
+	if json.Unmarshal([]byte(s), &ph) != nil {
+		return s
+	}
+	if ph.Ext != "" {
+		return ph.Num + " ext. " + ph.Ext
+	}
+	return ph.Num
+}
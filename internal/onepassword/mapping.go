@@ -0,0 +1,274 @@
+package onepassword
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ArchivedTag is added to items that were archived in 1Password.
+const ArchivedTag = "archived"
+
+// categoryField names the custom field that keeps the 1Password category of items
+// that have no dedicated pwmanager kind (software licenses, bank accounts, ...).
+const categoryField = "Category"
+
+// extraURIPrefix matches the Bitwarden importer: second and later URLs become "URI 2", ...
+const extraURIPrefix = "URI "
+
+var categoryNames = map[string]string{
+	CategoryLogin: "Login", CategoryCreditCard: "Credit Card", CategorySecureNote: "Secure Note",
+	CategoryIdentity: "Identity", CategoryPassword: "Password", CategoryDocument: "Document",
+	CategorySoftwareLicense: "Software License", CategoryBankAccount: "Bank Account",
+	CategoryDatabase: "Database", CategoryDriverLicense: "Driver License", "104": "Outdoor License",
+	CategoryMembership: "Membership", CategoryPassport: "Passport", "107": "Reward Program",
+	CategorySSN: "Social Security Number", CategoryRouter: "Wireless Router", CategoryServer: "Server",
+	CategoryEmail: "Email Account", CategoryAPICredential: "API Credential", "113": "Medical Record",
+	CategorySSHKey: "SSH Key", "115": "Crypto Wallet",
+}
+
+// Categories that map one-to-one onto a pwmanager kind and need no Category field.
+var kindCategories = map[string]bool{
+	CategoryLogin: true, CategorySecureNote: true, CategoryCreditCard: true, CategoryIdentity: true,
+}
+
+// Categories that describe something you log in to; their username/password
+// section fields fill the dedicated slots.
+var loginCategories = map[string]bool{
+	CategoryLogin: true, CategoryPassword: true, CategoryDatabase: true, CategoryServer: true,
+	CategoryRouter: true, CategoryEmail: true, CategoryAPICredential: true,
+}
+
+var (
+	usernameIDs = map[string]bool{"username": true, "pop_username": true}
+	passwordIDs = map[string]bool{"password": true, "pop_password": true, "wireless_password": true, "credential": true}
+)
+
+// Section field IDs with a dedicated slot. Card and identity properties use the
+// same custom field names as the Bitwarden importer so the vault stays uniform.
+var (
+	cardIDs     = map[string]string{"cardholder": "cardholderName", "type": "brand", "ccnum": "number", "cvv": "code"}
+	identityIDs = map[string]string{
+		"firstname": "firstName", "initial": "middleName", "lastname": "lastName", "company": "company",
+		"email": "email", "defphone": "phone", "username": "username",
+	}
+	// Identity-like categories whose "number" field maps to a specific property
+	identityNumberIDs = map[string]string{
+		CategorySSN: "ssn", CategoryPassport: "passportNumber", CategoryDriverLicense: "licenseNumber",
+	}
+)
+
+// Records converts every item into a pwmanager record. The vault name becomes the folder.
+// Reasons for dropped items and missing attachments are recorded in e.Skipped.
+func (e *Export) Records() []pwmanager.Record {
+	var out []pwmanager.Record
+	e.Skipped = nil
+	for _, acct := range e.Accounts {
+		for _, v := range acct.Vaults {
+			for _, it := range v.Items {
+				if it.State == "trashed" || it.State == "deleted" {
+					e.Skipped = append(e.Skipped, fmt.Sprintf("%q: in trash", it.Overview.Title))
+					continue
+				}
+				out = append(out, e.itemToRecord(it, v.Attrs.Name))
+			}
+		}
+	}
+	return out
+}
+
+func (e *Export) itemToRecord(it Item, vault string) pwmanager.Record {
+	rec := pwmanager.Record{Title: it.Overview.Title}
+	p := &rec.PlainEntry
+	p.Folder = vault
+	p.Notes = it.Details.NotesPlain
+	p.Tags = append(p.Tags, it.Overview.Tags...)
+	if it.State == "archived" {
+		p.Tags = append(p.Tags, ArchivedTag)
+	}
+	if it.CreatedAt > 0 {
+		p.CreatedAt = time.Unix(it.CreatedAt, 0).UTC()
+	}
+	if it.UpdatedAt > 0 {
+		p.ModifiedAt = time.Unix(it.UpdatedAt, 0).UTC()
+	}
+
+	switch {
+	case it.CategoryUUID == CategoryCreditCard:
+		p.Kind = pwmanager.KindCard
+	case it.CategoryUUID == CategoryIdentity || identityNumberIDs[it.CategoryUUID] != "":
+		p.Kind = pwmanager.KindIdentity
+	case loginCategories[it.CategoryUUID]:
+		p.Kind = pwmanager.KindLogin
+	default:
+		p.Kind = pwmanager.KindNote
+	}
+	if name, ok := categoryNames[it.CategoryUUID]; ok && !kindCategories[it.CategoryUUID] {
+		p.Fields = append(p.Fields, pwmanager.CustomField{Name: categoryField, Value: name})
+	}
+
+	// URLs: the primary one fills the slot, the rest follow the "URI n" convention
+	p.URL = it.Overview.URL
+	n := 1
+	for _, u := range it.Overview.URLs {
+		if u.URL == "" || u.URL == p.URL {
+			continue
+		}
+		if p.URL == "" {
+			p.URL = u.URL
+			continue
+		}
+		n++
+		p.Fields = append(p.Fields, pwmanager.CustomField{Name: fmt.Sprintf("%s%d", extraURIPrefix, n), Value: u.URL})
+	}
+
+	for _, f := range it.Details.LoginFields {
+		switch {
+		case f.Designation == "username" && p.Username == "":
+			p.Username = f.Value
+		case f.Designation == "password" && p.Password == "":
+			p.Password = f.Value
+		case f.Value != "":
+			name := f.Name
+			if name == "" {
+				name = f.Designation
+			}
+			p.Fields = append(p.Fields, pwmanager.CustomField{Name: name, Value: f.Value, Hidden: f.FieldType == "P"})
+		}
+	}
+	if p.Password == "" {
+		p.Password = it.Details.Password
+	}
+
+	for _, s := range it.Details.Sections {
+		for _, f := range s.Fields {
+			e.mapSectionField(it, p, f)
+		}
+	}
+
+	if d := it.Details.Document; d != nil {
+		e.attach(it, p, *d)
+	}
+	for _, h := range it.Details.PasswordHistory {
+		p.History = append(p.History, pwmanager.HistoryEntry{
+			Username: p.Username, Password: h.Value, ModifiedAt: time.Unix(h.Time, 0).UTC(),
+		})
+	}
+	return rec
+}
+
+func (e *Export) mapSectionField(it Item, p *pwmanager.PlainEntry, f SectionField) {
+	v := f.Value
+	name := f.Title
+	if name == "" {
+		name = f.ID
+	}
+	add := func(name, value string, hidden bool) {
+		if value != "" {
+			p.Fields = append(p.Fields, pwmanager.CustomField{Name: name, Value: value, Hidden: hidden})
+		}
+	}
+
+	switch {
+	case v.File != nil:
+		e.attach(it, p, *v.File)
+		return
+	case v.TOTP != nil:
+		if p.TOTP == "" {
+			p.TOTP = *v.TOTP
+		} else {
+			add(name, *v.TOTP, true)
+		}
+		return
+	case v.MonthYear != nil && p.Kind == pwmanager.KindCard && f.ID == "expiry":
+		add("expMonth", fmt.Sprint(*v.MonthYear%100), false)
+		add("expYear", fmt.Sprint(*v.MonthYear/100), false)
+		return
+	case v.Address != nil && p.Kind == pwmanager.KindIdentity:
+		a := v.Address
+		add("address1", a.Street, false)
+		add("city", a.City, false)
+		add("state", a.State, false)
+		add("postalCode", a.Zip, false)
+		add("country", a.Country, false)
+		return
+	}
+
+	value, hidden := fieldText(v)
+	if value == "" {
+		return
+	}
+	id := strings.ToLower(f.ID)
+	switch p.Kind {
+	case pwmanager.KindLogin:
+		if usernameIDs[id] && p.Username == "" {
+			p.Username = value
+			return
+		}
+		if passwordIDs[id] && p.Password == "" {
+			p.Password = value
+			return
+		}
+	case pwmanager.KindCard:
+		if n, ok := cardIDs[id]; ok {
+			name = n
+		}
+	case pwmanager.KindIdentity:
+		if n, ok := identityIDs[id]; ok {
+			name = n
+		} else if n := identityNumberIDs[it.CategoryUUID]; id == "number" && n != "" {
+			name = n
+		}
+	}
+	add(name, value, hidden)
+}
+
+// fieldText renders a typed field value as text and reports whether it is secret.
+func fieldText(v FieldValue) (string, bool) {
+	switch {
+	case v.String != nil:
+		return *v.String, false
+	case v.Concealed != nil:
+		return *v.Concealed, true
+	case v.Email != nil:
+		return v.Email.Address, false
+	case v.Phone != nil:
+		return *v.Phone, false
+	case v.URL != nil:
+		return *v.URL, false
+	case v.Date != nil:
+		return time.Unix(*v.Date, 0).UTC().Format("2006-01-02"), false
+	case v.MonthYear != nil:
+		return fmt.Sprintf("%02d/%d", *v.MonthYear%100, *v.MonthYear/100), false
+	case v.CreditCardNumber != nil:
+		return *v.CreditCardNumber, true
+	case v.CreditCardType != nil:
+		return *v.CreditCardType, false
+	case v.Gender != nil:
+		return *v.Gender, false
+	case v.Menu != nil:
+		return *v.Menu, false
+	case v.Address != nil:
+		a := v.Address
+		parts := []string{}
+		for _, s := range []string{a.Street, a.City, strings.TrimSpace(a.State + " " + a.Zip), a.Country} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, ", "), false
+	case v.SSHKey != nil:
+		return v.SSHKey.PrivateKey, true
+	}
+	return "", false
+}
+
+func (e *Export) attach(it Item, p *pwmanager.PlainEntry, ref FileRef) {
+	data, ok := e.Files[ref.DocumentID]
+	if !ok {
+		e.Skipped = append(e.Skipped, fmt.Sprintf("%q: attachment %q missing from archive", it.Overview.Title, ref.FileName))
+		return
+	}
+	p.Attachments = append(p.Attachments, pwmanager.Attachment{Name: ref.FileName, Data: data})
+}
@@ -0,0 +1,219 @@
+// Package onepassword reads 1Password's .1pux export archive (a zip holding
+// export.data plus a files/ directory of attachments) and maps its items onto
+// pwmanager records.
+package onepassword
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Item categories as stored in categoryUuid.
+const (
+	CategoryLogin           = "001"
+	CategoryCreditCard      = "002"
+	CategorySecureNote      = "003"
+	CategoryIdentity        = "004"
+	CategoryPassword        = "005"
+	CategoryDocument        = "006"
+	CategorySoftwareLicense = "100"
+	CategoryBankAccount     = "101"
+	CategoryDatabase        = "102"
+	CategoryDriverLicense   = "103"
+	CategoryMembership      = "105"
+	CategoryPassport        = "106"
+	CategorySSN             = "108"
+	CategoryRouter          = "109"
+	CategoryServer          = "110"
+	CategoryEmail           = "111"
+	CategoryAPICredential   = "112"
+	CategorySSHKey          = "114"
+)
+
+// maxFileSize bounds a single attachment read from the archive.
+const maxFileSize = 64 << 20
+
+// Export is the parsed contents of a .1pux archive.
+type Export struct {
+	Accounts []Account         `json:"accounts"`
+	Files    map[string][]byte `json:"-"` // attachment contents keyed by documentId
+	Skipped  []string          `json:"-"` // reasons for items that could not be imported
+}
+
+// Account is one 1Password account in the export.
+type Account struct {
+	Attrs struct {
+		Name  string `json:"accountName"`
+		Email string `json:"email"`
+	} `json:"attrs"`
+	Vaults []VaultData `json:"vaults"`
+}
+
+// VaultData is a 1Password vault and its items.
+type VaultData struct {
+	Attrs struct {
+		UUID string `json:"uuid"`
+		Name string `json:"name"`
+	} `json:"attrs"`
+	Items []Item `json:"items"`
+}
+
+// Item is a single 1Password item of any category.
+type Item struct {
+	UUID         string   `json:"uuid"`
+	FavIndex     int      `json:"favIndex"`
+	CreatedAt    int64    `json:"createdAt"`
+	UpdatedAt    int64    `json:"updatedAt"`
+	State        string   `json:"state"` // "active" or "archived"
+	CategoryUUID string   `json:"categoryUuid"`
+	Details      Details  `json:"details"`
+	Overview     Overview `json:"overview"`
+}
+
+// Details holds an item's secret contents.
+type Details struct {
+	LoginFields     []LoginField    `json:"loginFields"`
+	NotesPlain      string          `json:"notesPlain"`
+	Sections        []Section       `json:"sections"`
+	PasswordHistory []PasswordEntry `json:"passwordHistory"`
+	Password        string          `json:"password"` // Password category only
+	Document        *FileRef        `json:"documentAttributes"`
+}
+
+// LoginField is a field of a website login form.
+type LoginField struct {
+	Value       string `json:"value"`
+	Name        string `json:"name"`
+	FieldType   string `json:"fieldType"`   // "T" text, "P" password, "E" email, ...
+	Designation string `json:"designation"` // "username", "password" or empty
+}
+
+// Section groups custom fields under an optional title.
+type Section struct {
+	Title  string         `json:"title"`
+	Name   string         `json:"name"`
+	Fields []SectionField `json:"fields"`
+}
+
+// SectionField is a typed field; Value holds exactly one typed member.
+type SectionField struct {
+	Title string     `json:"title"`
+	ID    string     `json:"id"`
+	Value FieldValue `json:"value"`
+}
+
+// FieldValue is the union of 1Password field types. Only one member is set.
+type FieldValue struct {
+	String    *string `json:"string"`
+	Concealed *string `json:"concealed"`
+	Email     *struct {
+		Address string `json:"email_address"`
+	} `json:"email"`
+	Phone            *string  `json:"phone"`
+	URL              *string  `json:"url"`
+	TOTP             *string  `json:"totp"`
+	Date             *int64   `json:"date"`
+	MonthYear        *int     `json:"monthYear"`
+	CreditCardNumber *string  `json:"creditCardNumber"`
+	CreditCardType   *string  `json:"creditCardType"`
+	Gender           *string  `json:"gender"`
+	Menu             *string  `json:"menu"`
+	Address          *Address `json:"address"`
+	SSHKey           *struct {
+		PrivateKey string `json:"privateKey"`
+	} `json:"sshKey"`
+	File *FileRef `json:"file"`
+}
+
+// Address is a postal address field.
+type Address struct {
+	Street  string `json:"street"`
+	City    string `json:"city"`
+	Country string `json:"country"`
+	Zip     string `json:"zip"`
+	State   string `json:"state"`
+}
+
+// FileRef points at an attachment stored under files/ in the archive.
+type FileRef struct {
+	FileName   string `json:"fileName"`
+	DocumentID string `json:"documentId"`
+}
+
+// PasswordEntry is a previous password of a login.
+type PasswordEntry struct {
+	Value string `json:"value"`
+	Time  int64  `json:"time"`
+}
+
+// Overview holds an item's searchable metadata.
+type Overview struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+	URLs  []struct {
+		Label string `json:"label"`
+		URL   string `json:"url"`
+	} `json:"urls"`
+	Tags []string `json:"tags"`
+}
+
+// Parse reads a .1pux archive.
+func Parse(data []byte) (*Export, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a 1PUX archive: %w", err)
+	}
+
+	exp := &Export{Files: map[string][]byte{}}
+	found := false
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "export.data":
+			body, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(body, exp); err != nil {
+				return nil, fmt.Errorf("parse export.data: %w", err)
+			}
+			found = true
+		case strings.HasPrefix(f.Name, "files/") && !f.FileInfo().IsDir():
+			// Attachments are stored as files/<documentId>__<fileName>
+			name := strings.TrimPrefix(f.Name, "files/")
+			id, _, _ := strings.Cut(name, "__")
+			body, err := readZipFile(f)
+			if err != nil {
+				return nil, err
+			}
+			exp.Files[id] = body
+		}
+	}
+	if !found {
+		return nil, errors.New("not a 1PUX archive: export.data missing")
+	}
+	return exp, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	if f.UncompressedSize64 > maxFileSize {
+		return nil, fmt.Errorf("%s: file too large", f.Name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	body, err := io.ReadAll(io.LimitReader(rc, maxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	if len(body) > maxFileSize {
+		return nil, fmt.Errorf("%s: file too large", f.Name)
+	}
+	return body, nil
+}
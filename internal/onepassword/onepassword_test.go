@@ -0,0 +1,158 @@
+package onepassword
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+const exportData = `{
+  "accounts": [{
+    "attrs": {"accountName": "Alice", "email": "alice@example.com"},
+    "vaults": [{
+      "attrs": {"uuid": "v1", "name": "Personal"},
+      "items": [
+        {
+          "uuid": "i1", "createdAt": 1704103200, "updatedAt": 1709287200, "state": "active", "categoryUuid": "001",
+          "details": {
+            "loginFields": [
+              {"value": "alice", "name": "email", "fieldType": "E", "designation": "username"},
+              {"value": "S3cret!", "name": "password", "fieldType": "P", "designation": "password"},
+              {"value": "1234", "name": "pin", "fieldType": "P", "designation": ""}
+            ],
+            "notesPlain": "2FA on",
+            "sections": [{"title": "Extra", "name": "s1", "fields": [
+              {"title": "one-time password", "id": "totp1", "value": {"totp": "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP"}},
+              {"title": "Recovery", "id": "r1", "value": {"concealed": "1111-2222"}},
+              {"title": "Backup codes", "id": "f1", "value": {"file": {"fileName": "codes.txt", "documentId": "doc1"}}}
+            ]}],
+            "passwordHistory": [{"value": "old", "time": 1706781600}]
+          },
+          "overview": {"title": "GitHub", "url": "https://github.com",
+            "urls": [{"label": "", "url": "https://github.com"}, {"label": "", "url": "https://gist.github.com"}],
+            "tags": ["dev"]}
+        },
+        {
+          "uuid": "i2", "createdAt": 1704103200, "updatedAt": 1704103200, "state": "archived", "categoryUuid": "002",
+          "details": {"sections": [{"title": "", "name": "", "fields": [
+            {"title": "cardholder name", "id": "cardholder", "value": {"string": "Alice"}},
+            {"title": "number", "id": "ccnum", "value": {"creditCardNumber": "4111111111111111"}},
+            {"title": "verification number", "id": "cvv", "value": {"concealed": "123"}},
+            {"title": "expiry date", "id": "expiry", "value": {"monthYear": 203006}}
+          ]}]},
+          "overview": {"title": "Visa"}
+        },
+        {
+          "uuid": "i3", "state": "active", "categoryUuid": "101",
+          "details": {"sections": [{"title": "", "fields": [
+            {"title": "account number", "id": "accountNo", "value": {"concealed": "987654"}}
+          ]}]},
+          "overview": {"title": "Bank"}
+        },
+        {
+          "uuid": "i4", "state": "active", "categoryUuid": "004",
+          "details": {"sections": [{"title": "Identification", "fields": [
+            {"title": "first name", "id": "firstname", "value": {"string": "Alice"}},
+            {"title": "address", "id": "address", "value": {"address": {"street": "1 Main St", "city": "Springfield", "zip": "12345", "state": "IL", "country": "us"}}}
+          ]}]},
+          "overview": {"title": "Me"}
+        },
+        {
+          "uuid": "i5", "state": "active", "categoryUuid": "006",
+          "details": {"documentAttributes": {"fileName": "lost.pdf", "documentId": "missing"}},
+          "overview": {"title": "Scan"}
+        }
+      ]
+    }]
+  }]
+}`
+
+func build1PUX(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range map[string]string{
+		"export.attributes":     `{"version": 3}`,
+		"export.data":           exportData,
+		"files/doc1__codes.txt": "aaaa-bbbb\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseAndMap(t *testing.T) {
+	exp, err := Parse(build1PUX(t))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	recs := exp.Records()
+	if len(recs) != 5 {
+		t.Fatalf("Records() got %d records, want 5", len(recs))
+	}
+	if len(exp.Skipped) != 1 {
+		t.Errorf("Skipped = %v, want the missing attachment", exp.Skipped)
+	}
+
+	gh := recs[0]
+	if gh.Title != "GitHub" || gh.Username != "alice" || gh.Password != "S3cret!" || gh.URL != "https://github.com" {
+		t.Errorf("login mapped wrong: %+v", gh)
+	}
+	if gh.Folder != "Personal" || gh.Notes != "2FA on" || gh.TOTP != "otpauth://totp/GitHub?secret=JBSWY3DPEHPK3PXP" {
+		t.Errorf("folder/notes/totp mapped wrong: %+v", gh)
+	}
+	if v, _ := gh.Field("URI 2"); v != "https://gist.github.com" {
+		t.Errorf("second URL = %q", v)
+	}
+	if v, _ := gh.Field("Recovery"); v != "1111-2222" {
+		t.Errorf("section field = %q", v)
+	}
+	if v, _ := gh.Field("pin"); v != "1234" {
+		t.Errorf("extra login field = %q", v)
+	}
+	if len(gh.History) != 1 || gh.History[0].Password != "old" {
+		t.Errorf("history = %+v", gh.History)
+	}
+	if len(gh.Attachments) != 1 || string(gh.Attachments[0].Data) != "aaaa-bbbb\n" {
+		t.Errorf("attachments = %+v", gh.Attachments)
+	}
+
+	card := recs[1]
+	if card.Kind != pwmanager.KindCard || len(card.Tags) != 1 || card.Tags[0] != ArchivedTag {
+		t.Errorf("card mapped wrong: %+v", card)
+	}
+	for name, want := range map[string]string{"cardholderName": "Alice", "number": "4111111111111111", "code": "123", "expMonth": "6", "expYear": "2030"} {
+		if v, _ := card.Field(name); v != want {
+			t.Errorf("card field %s = %q, want %q", name, v, want)
+		}
+	}
+
+	bank := recs[2]
+	if v, _ := bank.Field(categoryField); bank.Kind != pwmanager.KindNote || v != "Bank Account" {
+		t.Errorf("bank account mapped wrong: %+v", bank)
+	}
+
+	me := recs[3]
+	if v, _ := me.Field("city"); me.Kind != pwmanager.KindIdentity || v != "Springfield" {
+		t.Errorf("identity mapped wrong: %+v", me)
+	}
+
+	sum := pwmanager.Summarize("1Password", recs, exp.Skipped)
+	if sum.ByKind[pwmanager.KindLogin] != 1 || sum.ByKind[pwmanager.KindNote] != 2 || sum.Attachments != 1 || len(sum.Skipped) != 1 {
+		t.Errorf("summary = %+v", sum)
+	}
+}
+
+func TestParseRejectsNon1PUX(t *testing.T) {
+	if _, err := Parse([]byte("not a zip")); err == nil {
+		t.Error("Parse() accepted non-zip input")
+	}
+}
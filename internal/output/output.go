@@ -0,0 +1,292 @@
+// Package output renders command results for scripts: JSON, YAML or
+// tab-separated values, and describes the shape of each result as a JSON
+// Schema.
+//
+// Results are ordinary Go values that encode with encoding/json. YAML and TSV
+// are produced from that JSON encoding, so struct tags, field order and
+// omitted fields are the same in every format.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Formats lists the output formats a command accepts. "table" is each
+// command's own human-readable layout and is not produced by Write.
+var Formats = []string{"table", "json", "yaml", "tsv"}
+
+// Valid reports whether format is one of Formats.
+func Valid(format string) bool {
+	return slices.Contains(Formats, format)
+}
+
+// Write encodes v to w as "json", "yaml" or "tsv".
+//
+// TSV suits lists: an array of objects becomes a header row of field names
+// and one row per element, an object becomes name/value rows and an array of
+// scalars one value per row. Nested values are written as compact JSON, and
+// tabs, newlines and backslashes inside values are escaped as \t, \n, \r and \\.
+func Write(w io.Writer, format string, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if format == "json" {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if format == "json" {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+
+	dec := json.NewDecoder(&buf)
+	dec.UseNumber()
+	n, err := decode(dec)
+	if err != nil {
+		return err
+	}
+	var out strings.Builder
+	switch format {
+	case "yaml":
+		writeYAML(&out, n, 0, "")
+	case "tsv":
+		writeTSV(&out, n)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+	_, err = io.WriteString(w, out.String())
+	return err
+}
+
+// node is a decoded JSON value that keeps the order of object keys.
+type node struct {
+	kind  kind
+	text  string   // scalars: the string, or the number, true, false or null as in JSON
+	keys  []string // objects: the keys, in order
+	elems []*node  // objects: the values, parallel to keys; arrays: the elements
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindLiteral
+	kindObject
+	kindArray
+)
+
+func decode(dec *json.Decoder) (*node, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &node{kind: kindArray}
+		if t == '{' {
+			n.kind = kindObject
+		}
+		for dec.More() {
+			if n.kind == kindObject {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, k.(string))
+			}
+			e, err := decode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.elems = append(n.elems, e)
+		}
+		_, err := dec.Token() // the closing delimiter
+		return n, err
+	case string:
+		return &node{kind: kindString, text: t}, nil
+	case json.Number:
+		return &node{kind: kindLiteral, text: t.String()}, nil
+	case bool:
+		return &node{kind: kindLiteral, text: strconv.FormatBool(t)}, nil
+	default:
+		return &node{kind: kindLiteral, text: "null"}, nil
+	}
+}
+
+// block reports whether n is written over lines of its own: a non-empty
+// object or array.
+func (n *node) block() bool {
+	return (n.kind == kindObject || n.kind == kindArray) && len(n.elems) > 0
+}
+
+// compact returns n as single-line JSON.
+func (n *node) compact() string {
+	switch n.kind {
+	case kindString:
+		return quote(n.text)
+	case kindLiteral:
+		return n.text
+	}
+	var b strings.Builder
+	open, end := "[", "]"
+	if n.kind == kindObject {
+		open, end = "{", "}"
+	}
+	b.WriteString(open)
+	for i, e := range n.elems {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if n.kind == kindObject {
+			b.WriteString(quote(n.keys[i]) + ":")
+		}
+		b.WriteString(e.compact())
+	}
+	b.WriteString(end)
+	return b.String()
+}
+
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// writeYAML writes n in block style at indent levels of two spaces. lead is
+// written in place of the indentation on the first line, which puts the
+// first field of a list item on the same line as its "- ".
+func writeYAML(b *strings.Builder, n *node, indent int, lead string) {
+	pad := strings.Repeat("  ", indent)
+	first := func(i int) string {
+		if i == 0 {
+			return lead
+		}
+		return pad
+	}
+	switch {
+	case n.kind == kindObject && n.block():
+		for i, k := range n.keys {
+			b.WriteString(first(i) + yamlString(k) + ":")
+			if v := n.elems[i]; v.block() {
+				b.WriteString("\n")
+				writeYAML(b, v, indent+1, pad+"  ")
+			} else {
+				b.WriteString(" " + yamlScalar(v) + "\n")
+			}
+		}
+	case n.kind == kindArray && n.block():
+		for i, e := range n.elems {
+			writeYAML(b, e, indent+1, first(i)+"- ")
+		}
+	default:
+		b.WriteString(lead + yamlScalar(n) + "\n")
+	}
+}
+
+func yamlScalar(n *node) string {
+	switch {
+	case n.kind == kindString:
+		return yamlString(n.text)
+	case n.kind == kindObject:
+		return "{}"
+	case n.kind == kindArray:
+		return "[]"
+	}
+	return n.text
+}
+
+// yamlString leaves s plain when YAML reads it back as the same string, and
+// double-quotes it (in JSON syntax, which YAML accepts) otherwise.
+func yamlString(s string) string {
+	if s == "" || s != strings.TrimSpace(s) ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`0123456789.+") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return quote(s)
+	}
+	for _, r := range s {
+		if r < ' ' || r == 0x7f {
+			return quote(s)
+		}
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return quote(s)
+	}
+	return s
+}
+
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+func tsvCell(n *node) string {
+	switch {
+	case n.kind == kindString:
+		return tsvEscaper.Replace(n.text)
+	case n.kind == kindLiteral && n.text == "null":
+		return ""
+	case n.kind == kindLiteral:
+		return n.text
+	}
+	return tsvEscaper.Replace(n.compact())
+}
+
+func writeTSV(b *strings.Builder, n *node) {
+	row := func(cells ...string) { b.WriteString(strings.Join(cells, "\t") + "\n") }
+	switch n.kind {
+	case kindObject:
+		for i, k := range n.keys {
+			row(tsvEscaper.Replace(k), tsvCell(n.elems[i]))
+		}
+	case kindArray:
+		if !allObjects(n.elems) {
+			for _, e := range n.elems {
+				row(tsvCell(e))
+			}
+			return
+		}
+		// the columns are every key seen, in the order first seen
+		var cols []string
+		for _, e := range n.elems {
+			for _, k := range e.keys {
+				if !slices.Contains(cols, k) {
+					cols = append(cols, k)
+				}
+			}
+		}
+		if len(cols) == 0 {
+			return
+		}
+		header := make([]string, len(cols))
+		for i, c := range cols {
+			header[i] = tsvEscaper.Replace(c)
+		}
+		row(header...)
+		for _, e := range n.elems {
+			cells := make([]string, len(cols))
+			for i, k := range e.keys {
+				cells[slices.Index(cols, k)] = tsvCell(e.elems[i])
+			}
+			row(cells...)
+		}
+	default:
+		row(tsvCell(n))
+	}
+}
+
+func allObjects(elems []*node) bool {
+	for _, e := range elems {
+		if e.kind != kindObject {
+			return false
+		}
+	}
+	return len(elems) > 0
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type row struct {
+	ID    string    `json:"id"`
+	Title string    `json:"title"`
+	Tags  []string  `json:"tags,omitempty"`
+	When  time.Time `json:"when"`
+	Note  *string   `json:"note,omitempty"`
+}
+
+var when = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func write(t *testing.T, format string, v any) string {
+	t.Helper()
+	var b strings.Builder
+	if err := Write(&b, format, v); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestWriteYAML(t *testing.T) {
+	v := map[string]any{
+		"entries": []row{
+			{ID: "a1", Title: "GitHub", Tags: []string{"work", "dev"}, When: when},
+			{ID: "b2", Title: "yes", When: when},
+		},
+		"count": 2,
+		"empty": []string{},
+		"odd":   "key: value # not a comment",
+	}
+	want := `count: 2
+empty: []
+entries:
+  - id: a1
+    title: GitHub
+    tags:
+      - work
+      - dev
+    when: "2026-01-02T03:04:05Z"
+  - id: b2
+    title: "yes"
+    when: "2026-01-02T03:04:05Z"
+odd: "key: value # not a comment"
+`
+	if got := write(t, "yaml", v); got != want {
+		t.Errorf("yaml:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteTSV(t *testing.T) {
+	rows := []row{
+		{ID: "a1", Title: "Git\tHub", When: when},
+		{ID: "b2", Title: "Mail", Tags: []string{"x"}, When: when},
+	}
+	want := "id\ttitle\twhen\ttags\n" +
+		"a1\tGit\\tHub\t2026-01-02T03:04:05Z\t\n" +
+		"b2\tMail\t2026-01-02T03:04:05Z\t[\"x\"]\n"
+	if got := write(t, "tsv", rows); got != want {
+		t.Errorf("tsv rows:\n%q\nwant:\n%q", got, want)
+	}
+	if got := write(t, "tsv", row{ID: "a1", Title: "line\nbreak"}); !strings.HasPrefix(got, "id\ta1\ntitle\tline\\nbreak\n") {
+		t.Errorf("tsv object: %q", got)
+	}
+	if got := write(t, "tsv", []string{"p1", "p2"}); got != "p1\np2\n" {
+		t.Errorf("tsv scalars: %q", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	got := write(t, "json", row{ID: "a<b", When: when})
+	var back row
+	if err := json.Unmarshal([]byte(got), &back); err != nil || back.ID != "a<b" || !strings.Contains(got, "a<b") {
+		t.Errorf("json = %s, %v", got, err)
+	}
+	if err := Write(new(strings.Builder), "xml", row{}); err == nil {
+		t.Error("accepted an unknown format")
+	}
+}
+
+func TestSchema(t *testing.T) {
+	type wrapper struct {
+		row
+		Count int            `json:"count"`
+		Extra map[string]int `json:"extra"`
+		Raw   []byte         `json:"raw,omitzero"`
+		Skip  string         `json:"-"`
+	}
+	s := Schema("wrapper", wrapper{})
+	if s["$schema"] != SchemaURI || s["title"] != "wrapper" || s["type"] != "object" {
+		t.Fatalf("header: %v", s)
+	}
+	props := s["properties"].(map[string]any)
+	for name, want := range map[string]any{
+		"id":    map[string]any{"type": "string"},
+		"when":  map[string]any{"type": "string", "format": "date-time"},
+		"tags":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		"count": map[string]any{"type": "integer"},
+		"extra": map[string]any{"type": []string{"object", "null"}, "additionalProperties": map[string]any{"type": "integer"}},
+		"raw":   map[string]any{"type": "string", "contentEncoding": "base64"},
+		"note":  map[string]any{"type": "string"},
+	} {
+		if !reflect.DeepEqual(props[name], want) {
+			t.Errorf("%s: %v, want %v", name, props[name], want)
+		}
+	}
+	if _, ok := props["Skip"]; ok {
+		t.Error(`json:"-" field described`)
+	}
+	if got := s["required"]; !reflect.DeepEqual(got, []string{"id", "title", "when", "count", "extra"}) {
+		t.Errorf("required = %v", got)
+	}
+}
@@ -0,0 +1,105 @@
+package output
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaURI is the JSON Schema dialect Schema produces.
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema describes the JSON encoding of values of v's type, following the
+// same rules as encoding/json: json struct tags name and omit fields,
+// embedded structs without a tag are flattened, []byte is base64 and
+// time.Time an RFC 3339 string. Fields without omitempty or omitzero are
+// required, and nil-able ones among them may be null.
+func Schema(title string, v any) map[string]any {
+	s := schemaOf(reflect.TypeOf(v))
+	s["$schema"] = SchemaURI
+	s["title"] = title
+	return s
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func schemaOf(t reflect.Type) map[string]any {
+	if t == nil {
+		return map[string]any{}
+	}
+	if t == timeType {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaOf(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaOf(t.Elem())}
+	case reflect.Struct:
+		props := map[string]any{}
+		required := []string{}
+		addFields(t, props, &required)
+		s := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return map[string]any{} // interfaces: anything
+}
+
+func addFields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			if ft := deref(f.Type); ft.Kind() == reflect.Struct {
+				addFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := schemaOf(f.Type)
+		optional := strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,")
+		if !optional {
+			*required = append(*required, name)
+			switch f.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+				if typ, ok := s["type"].(string); ok {
+					s["type"] = []string{typ, "null"}
+				}
+			}
+		}
+		props[name] = s
+	}
+}
+
+func deref(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem()
+	}
+	return t
+}
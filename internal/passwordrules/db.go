@@ -0,0 +1,136 @@
+package passwordrules
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Known rules for sites whose requirements differ from the defaults, in the
+// format of Apple's password-manager-resources quirks (password-rules.json).
+// The upstream file can be loaded over it with LoadFile to pick up new sites.
+//
+//go:embed data/password-rules.json
+var builtinJSON []byte
+
+// Site is the rule set recorded for a domain.
+type Site struct {
+	Domain string
+	Rules  string
+	// ExactDomain restricts the rules to Domain itself, not its subdomains.
+	ExactDomain bool
+}
+
+// DB maps domains to their password rules. It is safe for concurrent use.
+type DB struct {
+	mu    sync.RWMutex
+	sites map[string]Site
+}
+
+type jsonSite struct {
+	Rules       string `json:"password-rules"`
+	ExactDomain bool   `json:"exact-domain-match-only,omitempty"`
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDB   *DB
+)
+
+// Default returns the shared database, initialised with the embedded rules.
+func Default() *DB {
+	defaultOnce.Do(func() {
+		defaultDB = &DB{sites: map[string]Site{}}
+		if err := defaultDB.Update(strings.NewReader(string(builtinJSON))); err != nil {
+			panic("passwordrules: embedded database: " + err.Error())
+		}
+	})
+	return defaultDB
+}
+
+// Update merges rules in password-rules.json format into db, replacing the
+// entries of domains it already knows. Nothing is merged if any rule fails to parse.
+func (db *DB) Update(r io.Reader) error {
+	var in map[string]jsonSite
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return fmt.Errorf("password rules database: %w", err)
+	}
+	for domain, s := range in {
+		if _, err := Parse(s.Rules); err != nil {
+			return fmt.Errorf("password rules for %s: %w", domain, err)
+		}
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for domain, s := range in {
+		domain = strings.ToLower(domain)
+		db.sites[domain] = Site{Domain: domain, Rules: s.Rules, ExactDomain: s.ExactDomain}
+	}
+	return nil
+}
+
+// LoadFile merges a password-rules.json file into the default database.
+func LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return Default().Update(f)
+}
+
+// Lookup finds the rules for a URL or bare host name. The host itself is
+// tried first, then each parent domain, so "secure.chase.com" uses the
+// rules recorded for "chase.com".
+func (db *DB) Lookup(rawURL string) (Site, bool) {
+	host := hostOf(rawURL)
+	if host == "" {
+		return Site{}, false
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for exact := true; ; exact = false {
+		if s, ok := db.sites[host]; ok && (exact || !s.ExactDomain) {
+			return s, true
+		}
+		_, parent, ok := strings.Cut(host, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return Site{}, false
+		}
+		host = parent
+	}
+}
+
+// ForURL looks rawURL up in the default database and parses its rules.
+func ForURL(rawURL string) (*Rules, Site, bool) {
+	site, ok := Default().Lookup(rawURL)
+	if !ok {
+		return nil, Site{}, false
+	}
+	r, err := Parse(site.Rules)
+	if err != nil {
+		return nil, Site{}, false
+	}
+	return r, site, true
+}
+
+func hostOf(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	return strings.TrimPrefix(host, "www.")
+}
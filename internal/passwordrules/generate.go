@@ -0,0 +1,176 @@
+package passwordrules
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+const (
+	// DefaultLength is used when neither the caller nor the site picks a length.
+	DefaultLength = 20
+	// maxGenerated caps the "as long as the site allows" length.
+	maxGenerated = 64
+	// attempts bounds each of the two sampling strategies in Generate.
+	attempts = 10000
+)
+
+var ErrUnsatisfiable = errors.New("passwordrules: rules cannot be satisfied")
+
+// Length picks the password length for r. A preferred length of 0 or less
+// means the longest the site accepts, up to 64 characters, or DefaultLength
+// when there is no maximum. The result always lies within the rule's limits.
+func (r *Rules) Length(preferred int) (int, error) {
+	if r.MaxLength > 0 && r.MinLength > r.MaxLength {
+		return 0, fmt.Errorf("%w: minlength %d exceeds maxlength %d", ErrUnsatisfiable, r.MinLength, r.MaxLength)
+	}
+	n := preferred
+	if n <= 0 {
+		n = DefaultLength
+		if r.MaxLength > 0 {
+			n = min(r.MaxLength, maxGenerated)
+		}
+	}
+	n = max(n, r.MinLength, len(r.Required), 1)
+	if r.MaxLength > 0 && n > r.MaxLength {
+		if len(r.Required) > r.MaxLength {
+			return 0, fmt.Errorf("%w: %d required characters but maxlength %d", ErrUnsatisfiable, len(r.Required), r.MaxLength)
+		}
+		n = r.MaxLength
+	}
+	return n, nil
+}
+
+// generation returns the sets passwords are drawn from. Spaces are left out
+// (forms often trim them) unless a requirement can only be met with one.
+func (r *Rules) generation() (chars CharSet, required []CharSet) {
+	noSpace := func(s CharSet) CharSet {
+		if t := s.without(" "); t != "" {
+			return t
+		}
+		return s
+	}
+	for _, req := range r.Required {
+		req = noSpace(req)
+		required = append(required, req)
+		chars = chars.Union(req)
+	}
+	if r.Allowed != "" || len(r.Required) == 0 {
+		chars = chars.Union(r.Characters().without(" "))
+	}
+	return chars, required
+}
+
+// Generate returns a random password of the given length (see Length) that
+// satisfies r. Passwords are drawn uniformly from all valid ones, which is the
+// most entropy the rules permit; when valid passwords are too rare for that
+// to be quick, required characters are placed first and the rest filled in.
+func Generate(r *Rules, length int) (string, error) {
+	n, err := r.Length(length)
+	if err != nil {
+		return "", err
+	}
+	chars, required := r.generation()
+
+	pw := make([]rune, n)
+	valid := func() bool {
+		if r.MaxConsecutive > 0 && longestRun(string(pw)) > r.MaxConsecutive {
+			return false
+		}
+		return r.unmet(pw) == ""
+	}
+
+	for range attempts {
+		for i := range pw {
+			if pw[i], err = pick(chars); err != nil {
+				return "", err
+			}
+		}
+		if valid() {
+			return string(pw), nil
+		}
+	}
+
+	for range attempts {
+		for i := range pw {
+			set := chars
+			if i < len(required) {
+				set = required[i]
+			}
+			if pw[i], err = pick(set); err != nil {
+				return "", err
+			}
+		}
+		if err := shuffle(pw); err != nil {
+			return "", err
+		}
+		if valid() {
+			return string(pw), nil
+		}
+	}
+	return "", ErrUnsatisfiable
+}
+
+// EntropyBits estimates the entropy of Generate(r, length): the log2 of the
+// number of valid passwords, counted by inclusion-exclusion over the required
+// sets. The max-consecutive limit is ignored, so this slightly overestimates.
+func EntropyBits(r *Rules, length int) (float64, error) {
+	n, err := r.Length(length)
+	if err != nil {
+		return 0, err
+	}
+	chars, required := r.generation()
+	size := float64(len(chars))
+	bits := float64(n) * math.Log2(size)
+	if len(required) > 16 {
+		return bits, nil
+	}
+
+	// fraction of all strings over chars that miss none of the required sets
+	frac := 0.0
+	for mask := 0; mask < 1<<len(required); mask++ {
+		var missing CharSet
+		sign := 1.0
+		for i, req := range required {
+			if mask&(1<<i) != 0 {
+				missing = missing.Union(req)
+				sign = -sign
+			}
+		}
+		frac += sign * math.Pow((size-float64(len(missing)))/size, float64(n))
+	}
+	if frac <= 0 {
+		return 0, nil
+	}
+	return bits + math.Log2(frac), nil
+}
+
+func pick(set CharSet) (rune, error) {
+	i, err := randomIndex(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return rune(set[i]), nil
+}
+
+func shuffle(rs []rune) error {
+	for i := len(rs) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return err
+		}
+		rs[i], rs[j] = rs[j], rs[i]
+	}
+	return nil
+}
+
+// randomIndex returns a uniform random integer in [0, n).
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
@@ -0,0 +1,345 @@
+// Package passwordrules parses the Apple "passwordrules" language that sites
+// use to describe what passwords they accept, and generates passwords that
+// satisfy such rules exactly.
+//
+// A rule set is a list of "name: value;" properties:
+//
+//	minlength: 8; maxlength: 20; required: lower, upper; required: digit;
+//	allowed: [-_.!]; max-consecutive: 2;
+//
+// "required" may appear several times; each occurrence needs a character of
+// its own from the union of its classes, so "required: digit; required: digit"
+// asks for two digits. "allowed" widens the character set
+// beyond the required classes. Classes are upper, lower, digit, special,
+// ascii-printable, unicode, or a custom set in brackets, where '-' must come
+// first and ']' last.
+package passwordrules
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Class identifiers and their characters. special is the ASCII punctuation
+// set from Apple's specification, including the space.
+const (
+	upperChars   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	lowerChars   = "abcdefghijklmnopqrstuvwxyz"
+	digitChars   = "0123456789"
+	specialChars = "-~!@#$%^&*_+=`|(){}[:;\"'<>,.? ]"
+)
+
+var identifiers = map[string]string{
+	"upper":           upperChars,
+	"lower":           lowerChars,
+	"digit":           digitChars,
+	"special":         specialChars,
+	"ascii-printable": upperChars + lowerChars + digitChars + specialChars,
+	// Generated passwords stay within ASCII, so unicode is treated as ascii-printable.
+	"unicode": upperChars + lowerChars + digitChars + specialChars,
+}
+
+// CharSet is a set of ASCII characters kept as a sorted string without duplicates.
+type CharSet string
+
+func newCharSet(chars string) CharSet {
+	seen := map[rune]bool{}
+	var rs []rune
+	for _, c := range chars {
+		if c < ' ' || c > '~' || seen[c] {
+			continue
+		}
+		seen[c] = true
+		rs = append(rs, c)
+	}
+	sort.Slice(rs, func(i, j int) bool { return rs[i] < rs[j] })
+	return CharSet(rs)
+}
+
+// Union returns the characters in either set.
+func (s CharSet) Union(o CharSet) CharSet { return newCharSet(string(s) + string(o)) }
+
+// Contains reports whether c is in the set.
+func (s CharSet) Contains(c rune) bool { return strings.ContainsRune(string(s), c) }
+
+// without returns s minus the characters of o.
+func (s CharSet) without(o CharSet) CharSet {
+	var b strings.Builder
+	for _, c := range s {
+		if !o.Contains(c) {
+			b.WriteRune(c)
+		}
+	}
+	return CharSet(b.String())
+}
+
+// Rules is a parsed rule set. Zero lengths and MaxConsecutive mean "no limit".
+type Rules struct {
+	Required       []CharSet // each needs at least one character
+	Allowed        CharSet   // additional characters that may appear
+	MinLength      int
+	MaxLength      int
+	MaxConsecutive int // longest run of one repeated character
+}
+
+var ErrSyntax = errors.New("passwordrules: syntax error")
+
+// Parse reads a rule set. Unknown property names are ignored, as browsers do;
+// repeated length limits keep the strictest value.
+func Parse(s string) (*Rules, error) {
+	r := &Rules{}
+	for _, prop := range splitProperties(s) {
+		prop = strings.TrimSpace(prop)
+		if prop == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(prop, ":")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q has no value", ErrSyntax, prop)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+
+		switch name {
+		case "required", "allowed":
+			set, err := parseClasses(value)
+			if err != nil {
+				return nil, err
+			}
+			if name == "required" {
+				r.Required = append(r.Required, set)
+			} else {
+				r.Allowed = r.Allowed.Union(set)
+			}
+		case "minlength", "maxlength", "max-consecutive":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%w: %s needs a non-negative number, got %q", ErrSyntax, name, value)
+			}
+			switch name {
+			case "minlength":
+				r.MinLength = max(r.MinLength, n)
+			case "maxlength":
+				r.MaxLength = minLimit(r.MaxLength, n)
+			default:
+				r.MaxConsecutive = minLimit(r.MaxConsecutive, n)
+			}
+		}
+	}
+	return r, nil
+}
+
+// minLimit combines two limits where 0 means unlimited.
+func minLimit(a, b int) int {
+	if a == 0 {
+		return b
+	}
+	return min(a, b)
+}
+
+// splitProperties splits on ';' outside custom character classes.
+func splitProperties(s string) []string {
+	var out []string
+	start, inClass := 0, false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case !inClass && s[i] == '[':
+			inClass = true
+		case inClass && s[i] == ']' && classEnds(s, i):
+			inClass = false
+		case !inClass && s[i] == ';':
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+// classEnds reports whether the ']' at i closes a custom class: it does when
+// what follows is the end, ',' or ';' (so "[abc]]" includes a literal ']').
+func classEnds(s string, i int) bool {
+	rest := strings.TrimLeft(s[i+1:], " \t")
+	return rest == "" || rest[0] == ',' || rest[0] == ';'
+}
+
+// parseClasses reads a comma-separated list of identifiers and custom classes.
+func parseClasses(value string) (CharSet, error) {
+	var set CharSet
+	for value != "" {
+		value = strings.TrimLeft(value, " \t")
+		var item string
+		if strings.HasPrefix(value, "[") {
+			end := -1
+			for i := 1; i < len(value); i++ {
+				if value[i] == ']' && classEnds(value, i) {
+					end = i
+					break
+				}
+			}
+			if end < 0 {
+				return "", fmt.Errorf("%w: unterminated character class %q", ErrSyntax, value)
+			}
+			chars := value[1:end]
+			if i := strings.IndexByte(chars, '-'); i > 0 {
+				return "", fmt.Errorf("%w: '-' must come first in %q", ErrSyntax, value[:end+1])
+			}
+			set = set.Union(newCharSet(chars))
+			value = value[end+1:]
+		} else {
+			item, value, _ = strings.Cut(value, ",")
+			item = strings.ToLower(strings.TrimSpace(item))
+			chars, ok := identifiers[item]
+			if !ok {
+				return "", fmt.Errorf("%w: unknown character class %q", ErrSyntax, item)
+			}
+			set = set.Union(newCharSet(chars))
+			continue
+		}
+		value = strings.TrimLeft(value, " \t")
+		if value != "" {
+			if value[0] != ',' {
+				return "", fmt.Errorf("%w: expected ',' before %q", ErrSyntax, value)
+			}
+			value = value[1:]
+		}
+	}
+	if set == "" {
+		return "", fmt.Errorf("%w: empty character class list", ErrSyntax)
+	}
+	return set, nil
+}
+
+// Characters returns every character a password under r may contain: the
+// allowed set plus all required ones, or ascii-printable when neither is given.
+func (r *Rules) Characters() CharSet {
+	set := r.Allowed
+	for _, req := range r.Required {
+		set = set.Union(req)
+	}
+	if set == "" {
+		set = newCharSet(identifiers["ascii-printable"])
+	}
+	return set
+}
+
+// String renders r in canonical passwordrules syntax.
+func (r *Rules) String() string {
+	var parts []string
+	if r.MinLength > 0 {
+		parts = append(parts, fmt.Sprintf("minlength: %d", r.MinLength))
+	}
+	if r.MaxLength > 0 {
+		parts = append(parts, fmt.Sprintf("maxlength: %d", r.MaxLength))
+	}
+	if r.MaxConsecutive > 0 {
+		parts = append(parts, fmt.Sprintf("max-consecutive: %d", r.MaxConsecutive))
+	}
+	for _, req := range r.Required {
+		parts = append(parts, "required: "+formatSet(req))
+	}
+	if r.Allowed != "" {
+		parts = append(parts, "allowed: "+formatSet(r.Allowed))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "; ") + ";"
+}
+
+// formatSet names a set by identifiers where it is made of whole classes,
+// and as a custom class otherwise.
+func formatSet(s CharSet) string {
+	var names []string
+	rest := s
+	for _, id := range []string{"ascii-printable", "upper", "lower", "digit", "special"} {
+		cls := newCharSet(identifiers[id])
+		if cls.without(rest) == "" {
+			names = append(names, id)
+			rest = rest.without(cls)
+		}
+	}
+	if rest != "" {
+		// '-' first and ']' last keep the class parseable
+		custom := string(rest)
+		if strings.Contains(custom, "-") {
+			custom = "-" + strings.ReplaceAll(custom, "-", "")
+		}
+		if strings.Contains(custom, "]") {
+			custom = strings.ReplaceAll(custom, "]", "") + "]"
+		}
+		names = append(names, "["+custom+"]")
+	}
+	return strings.Join(names, ", ")
+}
+
+// Check reports the first rule password breaks, or nil if it satisfies r.
+func (r *Rules) Check(password string) error {
+	n := len([]rune(password))
+	if r.MinLength > 0 && n < r.MinLength {
+		return fmt.Errorf("shorter than %d characters", r.MinLength)
+	}
+	if r.MaxLength > 0 && n > r.MaxLength {
+		return fmt.Errorf("longer than %d characters", r.MaxLength)
+	}
+	chars := r.Characters()
+	for _, c := range password {
+		if !chars.Contains(c) {
+			return fmt.Errorf("character %q is not allowed", c)
+		}
+	}
+	if req := r.unmet([]rune(password)); req != "" {
+		return fmt.Errorf("needs one more of %s", formatSet(req))
+	}
+	if r.MaxConsecutive > 0 && longestRun(password) > r.MaxConsecutive {
+		return fmt.Errorf("more than %d identical characters in a row", r.MaxConsecutive)
+	}
+	return nil
+}
+
+func longestRun(s string) int {
+	best, run := 0, 0
+	var prev rune = -1
+	for _, c := range s {
+		if c == prev {
+			run++
+		} else {
+			run = 1
+		}
+		prev = c
+		best = max(best, run)
+	}
+	return best
+}
+
+// unmet returns a required set that cannot be given a character of its own in
+// password, or "" if every requirement is covered. It is a bipartite matching
+// of requirements to positions, found with augmenting paths.
+func (r *Rules) unmet(password []rune) CharSet {
+	owner := make([]int, len(password)) // requirement holding each position, or -1
+	for i := range owner {
+		owner[i] = -1
+	}
+	var assign func(req int, seen []bool) bool
+	assign = func(req int, seen []bool) bool {
+		for i, c := range password {
+			if seen[i] || !r.Required[req].Contains(c) {
+				continue
+			}
+			seen[i] = true
+			if owner[i] < 0 || assign(owner[i], seen) {
+				owner[i] = req
+				return true
+			}
+		}
+		return false
+	}
+	for req := range r.Required {
+		if !assign(req, make([]bool, len(password))) {
+			return r.Required[req]
+		}
+	}
+	return ""
+}
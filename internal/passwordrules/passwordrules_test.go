@@ -0,0 +1,149 @@
+package passwordrules
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	r, err := Parse("minlength: 8; MAXLENGTH: 20; maxlength: 16; required: lower, upper; required: digit; allowed: [-_.]]; max-consecutive: 2; unknown-rule: 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.MinLength != 8 || r.MaxLength != 16 || r.MaxConsecutive != 2 {
+		t.Errorf("limits = %d/%d/%d", r.MinLength, r.MaxLength, r.MaxConsecutive)
+	}
+	if len(r.Required) != 2 || r.Required[1] != CharSet(digitChars) || len(r.Required[0]) != 52 {
+		t.Errorf("required = %q", r.Required)
+	}
+	if r.Allowed != "-.]_" {
+		t.Errorf("allowed = %q", r.Allowed)
+	}
+
+	// String is canonical and parses back to the same rules
+	want := "minlength: 8; maxlength: 16; max-consecutive: 2; required: upper, lower; required: digit; allowed: [-._]];"
+	if got := r.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	back, err := Parse(r.String())
+	if err != nil || back.String() != want {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+
+	for _, bad := range []string{"minlength: eight", "required: vowels", "allowed: [abc", "required: [a-z]", "required:", "minlength"} {
+		if _, err := Parse(bad); !errors.Is(err, ErrSyntax) {
+			t.Errorf("Parse(%q) err = %v", bad, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	r, _ := Parse("minlength: 6; maxlength: 10; required: digit; required: digit; allowed: lower; max-consecutive: 2")
+	for pw, ok := range map[string]bool{
+		"abc12d":      true,
+		"abcd1e":      false, // one digit for two requirements
+		"ab12":        false,
+		"abcdefgh123": false,
+		"abc12D":      false,
+		"aaa12b":      false,
+	} {
+		if err := r.Check(pw); (err == nil) != ok {
+			t.Errorf("Check(%q) = %v", pw, err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for domain, site := range Default().sites {
+		r, err := Parse(site.Rules)
+		if err != nil {
+			t.Fatalf("%s: %v", domain, err)
+		}
+		for range 50 {
+			pw, err := Generate(r, 0)
+			if err != nil {
+				t.Fatalf("%s: %v", domain, err)
+			}
+			if err := r.Check(pw); err != nil {
+				t.Fatalf("%s: %q: %v", domain, pw, err)
+			}
+			if strings.Contains(pw, " ") {
+				t.Fatalf("%s: %q contains a space", domain, pw)
+			}
+		}
+	}
+
+	// as long as allowed by default, clamped when asked for more
+	r, _ := Parse("maxlength: 16; required: digit; required: digit; required: digit; allowed: [ab]")
+	pw, err := Generate(r, 40)
+	if err != nil || len(pw) != 16 || r.Check(pw) != nil {
+		t.Errorf("Generate = %q, %v", pw, err)
+	}
+
+	// rare but valid: requirements force the fallback strategy
+	r, _ = Parse("minlength: 12; maxlength: 12; required: [x]; required: [y]; required: [z]; required: digit; allowed: ascii-printable; max-consecutive: 1")
+	if pw, err := Generate(r, 0); err != nil || r.Check(pw) != nil {
+		t.Errorf("Generate = %q, %v", pw, err)
+	}
+
+	for _, impossible := range []string{
+		"minlength: 20; maxlength: 10",
+		"maxlength: 2; required: digit; required: lower; required: upper",
+		"minlength: 4; allowed: [a]; max-consecutive: 1",
+	} {
+		r, _ := Parse(impossible)
+		if _, err := Generate(r, 0); !errors.Is(err, ErrUnsatisfiable) {
+			t.Errorf("%q: err = %v", impossible, err)
+		}
+	}
+}
+
+func TestEntropyBits(t *testing.T) {
+	r, _ := Parse("minlength: 10; maxlength: 10; allowed: lower")
+	if bits, _ := EntropyBits(r, 0); math.Abs(bits-10*math.Log2(26)) > 1e-9 {
+		t.Errorf("lowercase only = %.2f bits", bits)
+	}
+	// 4 characters over [ab] with both required: 2^4 minus aaaa and bbbb
+	r, _ = Parse("maxlength: 4; required: [a]; required: [b]")
+	if bits, _ := EntropyBits(r, 0); math.Abs(bits-math.Log2(14)) > 1e-9 {
+		t.Errorf("[a][b] = %.4f bits, want log2(14)", bits)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	db := &DB{sites: map[string]Site{}}
+	err := db.Update(strings.NewReader(`{
+		"example.com": {"password-rules": "maxlength: 16;"},
+		"login.example.org": {"password-rules": "minlength: 10;", "exact-domain-match-only": true}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for in, want := range map[string]string{
+		"https://www.example.com/login":     "example.com",
+		"accounts.eu.example.com":           "example.com",
+		"HTTPS://Example.COM.:443/":         "example.com",
+		"login.example.org":                 "login.example.org",
+		"https://a.login.example.org/":      "",
+		"https://example.com.attacker.net/": "",
+		"":                                  "",
+	} {
+		site, ok := db.Lookup(in)
+		if ok != (want != "") || site.Domain != want {
+			t.Errorf("Lookup(%q) = %q, %v; want %q", in, site.Domain, ok, want)
+		}
+	}
+
+	if err := db.Update(strings.NewReader(`{"example.com": {"password-rules": "required: nonsense;"}}`)); err == nil {
+		t.Error("invalid rules accepted")
+	}
+	if site, _ := db.Lookup("example.com"); site.Rules != "maxlength: 16;" {
+		t.Errorf("failed update changed the database: %q", site.Rules)
+	}
+
+	if r, site, ok := ForURL("https://secure.chase.com/web/auth"); !ok || site.Domain != "chase.com" || r.MaxConsecutive != 2 {
+		t.Errorf("ForURL(chase) = %v, %+v, %v", r, site, ok)
+	}
+}
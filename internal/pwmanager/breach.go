@@ -0,0 +1,24 @@
+package pwmanager
+
+import "fmt"
+
+// BreachChecker reports how many times a password appears in a breach corpus.
+// internal/hibp implements it over a local Have I Been Pwned dump.
+type BreachChecker interface {
+	Count(password string) (int, error)
+}
+
+// warnIfBreached passes a warning to v.Warn when password is in v.Breaches.
+// A failed lookup is reported the same way; neither stops the write.
+func (v *Vault) warnIfBreached(title, password string) {
+	if v.Breaches == nil || v.Warn == nil || password == "" {
+		return
+	}
+	n, err := v.Breaches.Count(password)
+	switch {
+	case err != nil:
+		v.Warn(fmt.Sprintf("breach check for %q failed: %v", title, err))
+	case n > 0:
+		v.Warn(fmt.Sprintf("the password for %q appears %d times in known data breaches", title, n))
+	}
+}
@@ -0,0 +1,296 @@
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/hash"
+	"appliedcryptography-starter-kit/internal/secret"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Derived passwords are recomputed from the master password instead of being
+// stored, LessPass-style, so they can be regenerated on any machine without
+// the vault. Only the parameters below are kept in a derived entry; bumping
+// Counter rotates the password.
+//
+// Version 1 works as follows:
+//
+//	root   = scrypt(master password, salt = derivedSaltV1, N=32768, r=8, p=1, 32 bytes)
+//	stream = HKDF-SHA256(root, salt = site, info = label || login || counter || length || classes)
+//
+// and the password is drawn from stream by rejection sampling: the full length
+// from the union of the selected classes, then one character of each class
+// placed at a random position, so every class appears. Nothing here may
+// change for version 1, or existing passwords would change.
+//
+// The salt is fixed so that the master password is all that is needed, and
+// that has a price. Anyone who learns one derived password, say from a breach
+// of a site that stored it in the clear, can test guesses of the master
+// password offline at the cost of one scrypt each; and since every user shares
+// the salt, each guess is tried against all of them at once. The master
+// password must be strong accordingly, and accounts worth more than it belong
+// in ordinary entries. Changing the master password changes every derived
+// password too.
+
+// DerivedParams are the stored inputs of a derived password.
+type DerivedParams struct {
+	Version int    `json:"version"`
+	Site    string `json:"site"`  // host name, normalized by NormalizeSite
+	Login   string `json:"login"` // username or e-mail, used verbatim
+	Counter int    `json:"counter"`
+	Length  int    `json:"length"`
+	Lower   bool   `json:"lower"`
+	Upper   bool   `json:"upper"`
+	Digits  bool   `json:"digits"`
+	Symbols bool   `json:"symbols"`
+}
+
+const (
+	derivedVersion = 1
+	derivedSaltV1  = "appliedcryptography-starter-kit/derived-password/v1"
+	derivedInfoV1  = "derived-password-v1"
+	// scrypt cost of version 1, frozen apart from the vault's kdfN
+	derivedN, derivedr, derivedp = 32768, 8, 1
+	// HKDF-SHA256 can produce at most 255 blocks; far more than any password needs.
+	derivedStreamLen = 255 * 32
+)
+
+// Character classes of version 1, the generator's sets frozen at the time.
+const (
+	derivedLower   = "abcdefghijklmnopqrstuvwxyz"
+	derivedUpper   = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	derivedDigits  = "0123456789"
+	derivedSymbols = "!@#$%^&*_+-="
+)
+
+// DefaultDerivedParams returns version-1 parameters for site and login: 16
+// characters from all four classes, counter 1.
+func DefaultDerivedParams(site, login string) DerivedParams {
+	return DerivedParams{
+		Version: derivedVersion,
+		Site:    NormalizeSite(site),
+		Login:   login,
+		Counter: 1,
+		Length:  16,
+		Lower:   true,
+		Upper:   true,
+		Digits:  true,
+		Symbols: true,
+	}
+}
+
+// NormalizeSite reduces a URL or host name to the lowercase host without
+// "www.", so "https://www.GitHub.com/login" and "github.com" derive alike.
+func NormalizeSite(site string) string {
+	site = strings.TrimSpace(site)
+	raw := site
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	if u, err := url.Parse(raw); err == nil && u.Hostname() != "" {
+		site = u.Hostname()
+	}
+	site = strings.TrimSuffix(strings.ToLower(site), ".")
+	return strings.TrimPrefix(site, "www.")
+}
+
+// classes returns the selected character classes in their fixed order.
+func (p DerivedParams) classes() []string {
+	var out []string
+	for _, c := range []struct {
+		on    bool
+		chars string
+	}{{p.Lower, derivedLower}, {p.Upper, derivedUpper}, {p.Digits, derivedDigits}, {p.Symbols, derivedSymbols}} {
+		if c.on {
+			out = append(out, c.chars)
+		}
+	}
+	return out
+}
+
+func (p DerivedParams) validate() error {
+	switch {
+	case p.Version != derivedVersion:
+		return fmt.Errorf("unsupported derived password version %d", p.Version)
+	case p.Site == "":
+		return errors.New("derived password needs a site")
+	case p.Counter < 1:
+		return errors.New("derived password counter starts at 1")
+	case len(p.classes()) == 0:
+		return errors.New("derived password needs at least one character class")
+	case p.Length < len(p.classes()) || p.Length > 128:
+		return fmt.Errorf("derived password length must be between %d and 128", len(p.classes()))
+	}
+	return nil
+}
+
+// DerivationKey stretches the master password into the root key of all
+// derived passwords. It is as sensitive as the master password itself, so it
+// lives in a locked buffer the caller destroys.
+func DerivationKey(masterPassword string) (*secret.Buffer, error) {
+	if masterPassword == "" {
+		return nil, errors.New("empty master password")
+	}
+	pw := []byte(masterPassword)
+	defer secret.Wipe(pw)
+	k, err := hash.Scrypt(pw, []byte(derivedSaltV1), derivedN, derivedr, derivedp, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	return secret.FromBytes(k)
+}
+
+// DerivePassword computes the password for p from a DerivationKey.
+func DerivePassword(derivationKey *secret.Buffer, p DerivedParams) (string, error) {
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	classes := p.classes()
+
+	// length-prefixed fields keep ("ab", "c") and ("a", "bc") apart
+	info := []byte(derivedInfoV1)
+	info = binary.BigEndian.AppendUint32(info, uint32(len(p.Login)))
+	info = append(info, p.Login...)
+	info = binary.BigEndian.AppendUint32(info, uint32(p.Counter))
+	info = binary.BigEndian.AppendUint32(info, uint32(p.Length))
+	var mask byte
+	for i, on := range []bool{p.Lower, p.Upper, p.Digits, p.Symbols} {
+		if on {
+			mask |= 1 << i
+		}
+	}
+	info = append(info, mask)
+
+	if !derivationKey.Alive() {
+		return "", errLocked
+	}
+	stream, err := hash.HKDF(derivationKey.Bytes(), []byte(p.Site), info, derivedStreamLen)
+	if err != nil {
+		return "", err
+	}
+	defer secret.Wipe(stream)
+	s := &byteStream{buf: stream}
+
+	all := strings.Join(classes, "")
+	pw := make([]byte, 0, p.Length)
+	for len(pw) < p.Length-len(classes) {
+		i, err := s.index(len(all))
+		if err != nil {
+			return "", err
+		}
+		pw = append(pw, all[i])
+	}
+	for _, class := range classes {
+		c, err := s.index(len(class))
+		if err != nil {
+			return "", err
+		}
+		pos, err := s.index(len(pw) + 1)
+		if err != nil {
+			return "", err
+		}
+		pw = slices.Insert(pw, pos, class[c])
+	}
+	return string(pw), nil
+}
+
+// byteStream hands out unbiased indexes from a fixed pseudorandom buffer.
+type byteStream struct {
+	buf []byte
+	off int
+}
+
+// index returns a uniform integer in [0, n) for n <= 256, rejecting bytes at
+// or above the largest multiple of n so that no value is favored.
+func (s *byteStream) index(n int) (int, error) {
+	limit := 256 - 256%n
+	for s.off < len(s.buf) {
+		b := int(s.buf[s.off])
+		s.off++
+		if b < limit {
+			return b % n, nil
+		}
+	}
+	return 0, errors.New("derived password: random stream exhausted")
+}
+
+// IsDerived reports whether the entry's password is derived rather than stored.
+func (p *PlainEntry) IsDerived() bool { return p.Derived != nil }
+
+// ResolveDerived fills in Password for a derived entry; other entries are left alone.
+func (p *PlainEntry) ResolveDerived(derivationKey *secret.Buffer) error {
+	if p.Derived == nil {
+		return nil
+	}
+	pw, err := DerivePassword(derivationKey, *p.Derived)
+	if err != nil {
+		return err
+	}
+	p.Password = pw
+	return nil
+}
+
+// HasDerived reports whether any of recs is a derived entry.
+func HasDerived(recs []Record) bool {
+	for _, r := range recs {
+		if r.IsDerived() {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveDerivedRecords computes the passwords of derived records and turns
+// them into ordinary ones, as exports need: another vault has another master
+// password, so the parameters alone would yield a different password there.
+func ResolveDerivedRecords(recs []Record, derivationKey *secret.Buffer) error {
+	for i := range recs {
+		if err := recs[i].ResolveDerived(derivationKey); err != nil {
+			return fmt.Errorf("%s: %w", recs[i].Title, err)
+		}
+		recs[i].Derived = nil
+	}
+	return nil
+}
+
+// AddDerivedEntry stores a derived entry: only p, the URL and notes are kept,
+// never the password itself.
+func (v *Vault) AddDerivedEntry(key *secret.Buffer, title string, p DerivedParams, url, notes string) (string, error) {
+	p.Site = NormalizeSite(p.Site)
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	if url == "" {
+		url = "https://" + p.Site
+	}
+	return v.AddRecord(key, title, PlainEntry{
+		Username: p.Login,
+		URL:      url,
+		Notes:    notes,
+		Derived:  &p,
+	})
+}
+
+// RotateDerived bumps the counter of a derived entry, which changes its
+// password, and returns the new parameters.
+func (v *Vault) RotateDerived(key *secret.Buffer, id string) (*DerivedParams, error) {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return nil, err
+	}
+	if plain.Derived == nil {
+		return nil, conflictf("entry %q is not derived", meta.Title)
+	}
+	plain.Derived.Counter++
+	now := time.Now().UTC()
+	plain.ChangedAt = now
+	touch(plain, meta, now)
+	if err := v.sealEntry(key, *meta, plain); err != nil {
+		return nil, err
+	}
+	return plain.Derived, nil
+}
@@ -0,0 +1,294 @@
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/encrypt"
+	"appliedcryptography-starter-kit/internal/secret"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExpiryPolicy says when an entry's password must be replaced. Either rule
+// may be zero; with both set, whichever comes first applies.
+type ExpiryPolicy struct {
+	RotateDays int       `json:"rotateDays,omitempty"` // days after the password last changed
+	ExpiresAt  time.Time `json:"expiresAt,omitzero"`   // hard deadline, regardless of changes
+}
+
+// IsZero reports whether the policy sets no rule.
+func (p ExpiryPolicy) IsZero() bool {
+	return p.RotateDays <= 0 && p.ExpiresAt.IsZero()
+}
+
+func (p ExpiryPolicy) String() string {
+	var parts []string
+	if p.RotateDays > 0 {
+		parts = append(parts, fmt.Sprintf("rotate every %d days", p.RotateDays))
+	}
+	if !p.ExpiresAt.IsZero() {
+		parts = append(parts, "expires "+p.ExpiresAt.Format("2006-01-02"))
+	}
+	if len(parts) == 0 {
+		return "no expiry"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Replacement is a new password from StartRotation that has not been
+// confirmed yet: the site may not have accepted it, so the current
+// password stays in place until ConfirmRotation.
+type Replacement struct {
+	Password  string    `json:"password"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PasswordAge is when the password last changed: ChangedAt, or
+// ModifiedAt for entries written before it was tracked.
+func (p *PlainEntry) PasswordAge() time.Time {
+	if !p.ChangedAt.IsZero() {
+		return p.ChangedAt
+	}
+	return p.ModifiedAt
+}
+
+// touch marks an entry as modified at now. An entry written before
+// ChangedAt was tracked first takes its old ModifiedAt as ChangedAt, so an
+// edit that leaves the password alone does not restart its age.
+func touch(plain *PlainEntry, meta *CipherEntry, now time.Time) {
+	if plain.ChangedAt.IsZero() {
+		plain.ChangedAt = plain.ModifiedAt
+	}
+	plain.ModifiedAt = now
+	meta.ModifiedAt = now
+}
+
+// EffectivePolicy returns the policy that governs p and where it comes from:
+// the entry's own policy wins; otherwise the strictest rules among the
+// policies of its tags are combined. source is "entry", "tag NAME[, NAME]" or "".
+func EffectivePolicy(p *PlainEntry, tags map[string]ExpiryPolicy) (policy ExpiryPolicy, source string) {
+	if p.Expiry != nil && !p.Expiry.IsZero() {
+		return *p.Expiry, "entry"
+	}
+	var from []string
+	for _, t := range p.Tags {
+		tp, ok := tags[strings.ToLower(t)]
+		if !ok || tp.IsZero() {
+			continue
+		}
+		from = append(from, t)
+		if tp.RotateDays > 0 && (policy.RotateDays == 0 || tp.RotateDays < policy.RotateDays) {
+			policy.RotateDays = tp.RotateDays
+		}
+		if !tp.ExpiresAt.IsZero() && (policy.ExpiresAt.IsZero() || tp.ExpiresAt.Before(policy.ExpiresAt)) {
+			policy.ExpiresAt = tp.ExpiresAt
+		}
+	}
+	if len(from) == 0 {
+		return ExpiryPolicy{}, ""
+	}
+	return policy, "tag " + strings.Join(from, ", ")
+}
+
+// DueAt is when the password of p must next be rotated under policy, and
+// which rule sets that date; ok is false when the policy sets no rule.
+func (p *PlainEntry) DueAt(policy ExpiryPolicy) (due time.Time, rule string, ok bool) {
+	if policy.RotateDays > 0 && !p.PasswordAge().IsZero() {
+		due = p.PasswordAge().AddDate(0, 0, policy.RotateDays)
+		rule = fmt.Sprintf("rotate every %d days", policy.RotateDays)
+	}
+	if !policy.ExpiresAt.IsZero() && (due.IsZero() || policy.ExpiresAt.Before(due)) {
+		due, rule = policy.ExpiresAt, "hard expiry"
+	}
+	return due, rule, !due.IsZero()
+}
+
+// Rotation is an entry whose password is due, or will be soon.
+type Rotation struct {
+	ID      string
+	Title   string
+	DueAt   time.Time
+	Rule    string // "rotate every N days" or "hard expiry"
+	Source  string // see EffectivePolicy
+	Pending bool   // a replacement is waiting for ConfirmRotation
+}
+
+// Overdue reports whether r was due before now.
+func (r Rotation) Overdue(now time.Time) bool {
+	return r.DueAt.Before(now)
+}
+
+// DueRotations lists the logins in recs that are due before now+within, most
+// urgent (longest overdue) first.
+func DueRotations(recs []Record, tags map[string]ExpiryPolicy, now time.Time, within time.Duration) []Rotation {
+	var out []Rotation
+	for _, r := range recs {
+		if r.Kind != KindLogin {
+			continue
+		}
+		policy, source := EffectivePolicy(&r.PlainEntry, tags)
+		due, rule, ok := r.DueAt(policy)
+		if !ok || due.After(now.Add(within)) {
+			continue
+		}
+		out = append(out, Rotation{ID: r.ID, Title: r.Title, DueAt: due, Rule: rule, Source: source, Pending: r.Pending != nil})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DueAt.Equal(out[j].DueAt) {
+			return out[i].DueAt.Before(out[j].DueAt)
+		}
+		return out[i].Title < out[j].Title
+	})
+	return out
+}
+
+// SetExpiry sets or, with nil, removes the entry's own expiry policy.
+func (v *Vault) SetExpiry(key *secret.Buffer, id string, policy *ExpiryPolicy) error {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return err
+	}
+	if policy != nil && policy.IsZero() {
+		policy = nil
+	}
+	plain.Expiry = policy
+	now := time.Now().UTC()
+	touch(plain, meta, now)
+	return v.sealEntry(key, *meta, plain)
+}
+
+// StartRotation stores password as the entry's pending replacement, leaving
+// the current password in place. A second call replaces the pending one.
+func (v *Vault) StartRotation(key *secret.Buffer, id, password string) error {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return err
+	}
+	if plain.IsDerived() {
+		return conflictf("entry %q is derived; rotate it by bumping its counter", meta.Title)
+	}
+	if password == "" {
+		return errors.New("empty replacement password")
+	}
+	plain.Pending = &Replacement{Password: password, CreatedAt: time.Now().UTC()}
+	return v.sealEntry(key, *meta, plain)
+}
+
+// ConfirmRotation makes the pending password current and moves the old one
+// to the entry's history.
+func (v *Vault) ConfirmRotation(key *secret.Buffer, id string) error {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return err
+	}
+	if plain.Pending == nil {
+		return conflictf("entry %q has no pending rotation", meta.Title)
+	}
+	if plain.Password != "" {
+		plain.History = append(plain.History, HistoryEntry{
+			Username: plain.Username, Password: plain.Password, ModifiedAt: plain.PasswordAge(),
+		})
+	}
+	now := time.Now().UTC()
+	plain.Password = plain.Pending.Password
+	plain.Pending = nil
+	plain.ChangedAt = now
+	touch(plain, meta, now)
+	if err := v.sealEntry(key, *meta, plain); err != nil {
+		return err
+	}
+	v.warnIfBreached(meta.Title, plain.Password)
+	return nil
+}
+
+// CancelRotation discards the pending password.
+func (v *Vault) CancelRotation(key *secret.Buffer, id string) error {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return err
+	}
+	if plain.Pending == nil {
+		return conflictf("entry %q has no pending rotation", meta.Title)
+	}
+	plain.Pending = nil
+	return v.sealEntry(key, *meta, plain)
+}
+
+// policiesID is the associated data and entry-key label of the sealed tag
+// policies. Entry IDs are 22 base64url characters, so it cannot collide.
+const policiesID = "vault:tag-policies"
+
+// TagPolicies decrypts the per-tag expiry policies, keyed by lowercase tag.
+func (v *Vault) TagPolicies(key *secret.Buffer) (map[string]ExpiryPolicy, error) {
+	out := map[string]ExpiryPolicy{}
+	if v.PolicyCt == "" {
+		return out, nil
+	}
+	nonce, err := base64.StdEncoding.DecodeString(v.PolicyNonce)
+	if err != nil {
+		return nil, fmt.Errorf("bad policy nonce: %w", err)
+	}
+	ct, err := base64.StdEncoding.DecodeString(v.PolicyCt)
+	if err != nil {
+		return nil, fmt.Errorf("bad policy ciphertext: %w", err)
+	}
+	k, err := deriveEntryKey(key, policiesID)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Destroy()
+	pt, err := encrypt.DecryptAESGCM(k.Bytes(), nonce, ct, []byte(policiesID))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt tag policies: %w", err)
+	}
+	if err := json.Unmarshal(pt, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SetTagPolicy sets the expiry policy of every entry tagged tag; a nil or
+// empty policy removes it.
+func (v *Vault) SetTagPolicy(key *secret.Buffer, tag string, policy *ExpiryPolicy) error {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return errors.New("empty tag")
+	}
+	all, err := v.TagPolicies(key)
+	if err != nil {
+		return err
+	}
+	if policy == nil || policy.IsZero() {
+		delete(all, tag)
+	} else {
+		all[tag] = *policy
+	}
+	if len(all) == 0 {
+		v.PolicyNonce, v.PolicyCt = "", ""
+		return nil
+	}
+
+	blob, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	nonce, err := encrypt.GenerateNonce(12)
+	if err != nil {
+		return err
+	}
+	k, err := deriveEntryKey(key, policiesID)
+	if err != nil {
+		return err
+	}
+	defer k.Destroy()
+	ct, err := encrypt.EncryptAESGCM(k.Bytes(), nonce, blob, []byte(policiesID))
+	if err != nil {
+		return err
+	}
+	v.PolicyNonce = base64.StdEncoding.EncodeToString(nonce)
+	v.PolicyCt = base64.StdEncoding.EncodeToString(ct)
+	return nil
+}
@@ -0,0 +1,38 @@
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/dh"
+	"appliedcryptography-starter-kit/internal/hash"
+	"appliedcryptography-starter-kit/internal/secret"
+	"appliedcryptography-starter-kit/internal/sign"
+	"fmt"
+)
+
+// The vault owner's long-term keys are derived from the master key rather than
+// stored, so they survive password changes and need no extra secret on disk.
+
+// OwnerSigningKey returns the Ed25519 key pair the owner signs exports with.
+func OwnerSigningKey(masterKey *secret.Buffer) (*sign.KeyPair, error) {
+	if !masterKey.Alive() {
+		return nil, errLocked
+	}
+	seed, err := hash.HKDF(masterKey.Bytes(), nil, []byte("owner-signing-key"), sign.SeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive signing key: %w", err)
+	}
+	defer secret.Wipe(seed)
+	return sign.GenerateKeyPairFromSeed(seed)
+}
+
+// OwnerExchangeKey returns the X25519 key pair others encrypt bundles to.
+func OwnerExchangeKey(masterKey *secret.Buffer) (*dh.KeyPair, error) {
+	if !masterKey.Alive() {
+		return nil, errLocked
+	}
+	seed, err := hash.HKDF(masterKey.Bytes(), nil, []byte("owner-exchange-key"), dh.PrivateKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive exchange key: %w", err)
+	}
+	defer secret.Wipe(seed)
+	return dh.GenerateKeyPairFromSeed(seed)
+}
@@ -0,0 +1,237 @@
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/passwordrules"
+	"bufio"
+	"crypto/rand"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EFF's diceware lists (CC BY 3.0 US, Electronic Frontier Foundation), in the
+// original "DICE<TAB>word" format: 7776 words for five dice and 1296 for four.
+//
+//go:embed wordlists/*.txt
+var wordlistFS embed.FS
+
+// Built-in word list names for PasswordOptions.WordList.
+const (
+	WordListEFFLarge = "eff-large"
+	WordListEFFShort = "eff-short"
+)
+
+var builtinWordLists = map[string]string{
+	WordListEFFLarge: "wordlists/eff_large_wordlist.txt",
+	WordListEFFShort: "wordlists/eff_short_wordlist_2_0.txt",
+}
+
+// Capitalization modes for passphrases.
+const (
+	CapNone   = ""       // words as listed (lowercase for the EFF lists)
+	CapFirst  = "first"  // first letter of every word
+	CapAll    = "all"    // every word in uppercase
+	CapRandom = "random" // first letter of each word with probability 1/2 (adds a bit per word)
+)
+
+var (
+	wordListMu    sync.Mutex
+	wordListCache = map[string][]string{}
+)
+
+// WordList returns the words of a built-in list (WordListEFFLarge,
+// WordListEFFShort) or of a custom list file at the given path.
+func WordList(name string) ([]string, error) {
+	if name == "" {
+		name = WordListEFFLarge
+	}
+	wordListMu.Lock()
+	defer wordListMu.Unlock()
+	if words, ok := wordListCache[name]; ok {
+		return words, nil
+	}
+
+	var (
+		words []string
+		err   error
+	)
+	if path, ok := builtinWordLists[name]; ok {
+		f, ferr := wordlistFS.Open(path)
+		if ferr != nil {
+			return nil, ferr
+		}
+		defer f.Close()
+		words, err = ParseWordList(f)
+	} else {
+		f, ferr := os.Open(name)
+		if ferr != nil {
+			return nil, fmt.Errorf("word list: %w", ferr)
+		}
+		defer f.Close()
+		words, err = ParseWordList(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("word list %s: %w", name, err)
+	}
+	wordListCache[name] = words
+	return words, nil
+}
+
+// ParseWordList reads one word per line. Diceware-style lines ("16655 clause")
+// are accepted too: only the last field is kept. Blank lines and lines starting
+// with '#' are skipped, and duplicates are dropped since they would only make
+// the entropy estimate lie.
+func ParseWordList(r io.Reader) ([]string, error) {
+	var words []string
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		w := fields[len(fields)-1]
+		if !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(words) < 2 {
+		return nil, errors.New("needs at least two distinct words")
+	}
+	return words, nil
+}
+
+// randomIndex returns a uniform random integer in [0, n).
+func randomIndex(n int) (int, error) {
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// GeneratePassphrase picks opts.Words words uniformly from opts.WordList and
+// joins them with opts.Separator, then applies the capitalization and the
+// optional digit and symbol, each appended to a randomly chosen word.
+func GeneratePassphrase(opts PasswordOptions) (string, error) {
+	if opts.Words < 1 {
+		return "", errors.New("passphrase needs at least one word")
+	}
+	list, err := WordList(opts.WordList)
+	if err != nil {
+		return "", err
+	}
+
+	words := make([]string, opts.Words)
+	for i := range words {
+		n, err := randomIndex(len(list))
+		if err != nil {
+			return "", err
+		}
+		words[i] = list[n]
+		switch opts.Capitalize {
+		case CapFirst:
+			words[i] = capitalize(words[i])
+		case CapAll:
+			words[i] = strings.ToUpper(words[i])
+		case CapRandom:
+			coin, err := randomIndex(2)
+			if err != nil {
+				return "", err
+			}
+			if coin == 1 {
+				words[i] = capitalize(words[i])
+			}
+		case CapNone:
+		default:
+			return "", fmt.Errorf("unknown capitalization %q", opts.Capitalize)
+		}
+	}
+
+	inject := func(chars string) error {
+		w, err := randomIndex(len(words))
+		if err != nil {
+			return err
+		}
+		c, err := randomIndex(len(chars))
+		if err != nil {
+			return err
+		}
+		words[w] += chars[c : c+1]
+		return nil
+	}
+	if opts.InjectDigit {
+		if err := inject(numberChars); err != nil {
+			return "", err
+		}
+	}
+	if opts.InjectSymbol {
+		if err := inject(symbolChars); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(words, opts.Separator), nil
+}
+
+func capitalize(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	return string(unicode.ToUpper(r)) + w[size:]
+}
+
+// EntropyBits returns the entropy of a password generated with opts, assuming
+// the attacker knows the options. In passphrase mode that is the word choice,
+// random capitalization and the position and value of injected characters;
+// with opts.Rules see passwordrules.EntropyBits; otherwise it is the length
+// times log2 of the character set, a slight overestimate since one character
+// of each selected class is guaranteed.
+func EntropyBits(opts PasswordOptions) (float64, error) {
+	if opts.Words > 0 {
+		list, err := WordList(opts.WordList)
+		if err != nil {
+			return 0, err
+		}
+		n := float64(opts.Words)
+		bits := n * math.Log2(float64(len(list)))
+		if opts.Capitalize == CapRandom {
+			bits += n
+		}
+		if opts.InjectDigit {
+			bits += math.Log2(float64(len(numberChars))) + math.Log2(n)
+		}
+		if opts.InjectSymbol {
+			bits += math.Log2(float64(len(symbolChars))) + math.Log2(n)
+		}
+		return bits, nil
+	}
+
+	if opts.Rules != "" {
+		rules, err := passwordrules.Parse(opts.Rules)
+		if err != nil {
+			return 0, err
+		}
+		return passwordrules.EntropyBits(rules, opts.Length)
+	}
+
+	charSet := charSetFor(opts)
+	if charSet == "" {
+		return 0, nil
+	}
+	length := opts.Length
+	if length < 4 {
+		length = 12
+	}
+	return float64(length) * math.Log2(float64(len(charSet))), nil
+}
@@ -1,410 +1,494 @@
-// appliedcryptography/internal/pwmanager/pwmanager.go
-package pwmanager
-
-import (
-	"appliedcryptography-starter-kit/internal/encrypt"
-	"appliedcryptography-starter-kit/internal/hash"
-	"crypto/rand"
-	"encoding/base64"
-	"encoding/json"
-	"errors"
-	"fmt"
-	"os"
-	"sort"
-	"strings"
-	"time"
-)
-
-const (
-	kdfN      = 32768 // scrypt N
-	kdfr      = 8
-	kdfp      = 1
-	keyLen    = 32 // AES-256
-	verifyMsg = "vault-check"
-)
-
-// ---------- types saved to disk (JSON) ----------
-
-type Vault struct {
-	// KDF params are included so you can change them in future versions without breaking old vaults.
-	KDF struct {
-		N int `json:"N"`
-		R int `json:"r"`
-		P int `json:"p"`
-		L int `json:"keyLen"`
-	} `json:"kdf"`
-
-	SaltB64   string                 `json:"salt"`         // base64(salt)
-	KeyMgr    keyManager             `json:"keyManager"`   // Encrypted master key
-	VerifyNnc string                 `json:"verify_nonce"` // base64(nonce)
-	VerifyCt  string                 `json:"verify_ct"`    // base64(AES-GCM(verifyMsg))
-	Entries   map[string]CipherEntry `json:"entries"`      // id -> encrypted blob
-}
-
-type CipherEntry struct {
-	ID         string    `json:"id"`
-	Title      string    `json:"title"`      // kept in clear so you can list/search
-	NonceB64   string    `json:"nonce"`      // base64(12B nonce)
-	CipherB64  string    `json:"ciphertext"` // base64(GCM(PlainEntry JSON))
-	CreatedAt  time.Time `json:"createdAt"`
-	ModifiedAt time.Time `json:"modifiedAt"`
-}
-
-// This is never written as a top-level record; it’s encrypted as JSON into CipherEntry.CipherB64
-type PlainEntry struct {
-	Username   string    `json:"username"`
-	Password   string    `json:"password"`
-	URL        string    `json:"url,omitempty"`
-	Notes      string    `json:"notes,omitempty"`
-	CreatedAt  time.Time `json:"createdAt"`
-	ModifiedAt time.Time `json:"modifiedAt"`
-}
-
-// ---------- helpers ----------
-
-func randomBytes(n int) ([]byte, error) {
-	b := make([]byte, n)
-	_, err := rand.Read(b)
-	return b, err
-}
-
-func deriveKey(masterPassword string, salt []byte) ([]byte, error) {
-	return hash.Scrypt([]byte(masterPassword), salt, kdfN, kdfr, kdfp, keyLen)
-}
-
-// ---------- Vault lifecycle ----------
-
-// Create a brand new empty vault and return it + the master key.
-func Create(masterPassword string) (*Vault, []byte, error) {
-	// Generate random salt and derive key for password verification
-	salt, err := randomBytes(32)
-	if err != nil {
-		return nil, nil, err
-	}
-	key, err := deriveKey(masterPassword, salt)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// Create empty vault
-	v := &Vault{
-		Entries: make(map[string]CipherEntry),
-	}
-	v.KDF.N, v.KDF.R, v.KDF.P, v.KDF.L = kdfN, kdfr, kdfp, keyLen
-	v.SaltB64 = base64.StdEncoding.EncodeToString(salt)
-
-	// Generate and wrap master key
-	masterKey, err := generateMasterKey()
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate master key: %w", err)
-	}
-	km, err := wrapMasterKey(masterKey, masterPassword, salt)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to wrap master key: %w", err)
-	}
-	v.KeyMgr = *km
-
-	// Create verification block
-	nonce, err := encrypt.GenerateNonce(12)
-	if err != nil {
-		return nil, nil, err
-	}
-	ct, err := encrypt.EncryptAESGCM(key, nonce, []byte(verifyMsg), nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	v.VerifyNnc = base64.StdEncoding.EncodeToString(nonce)
-	v.VerifyCt = base64.StdEncoding.EncodeToString(ct)
-
-	return v, masterKey, nil
-}
-
-// CreateWithExistingKey creates a new vault using an existing master key.
-// This is used for changing the password without re-encrypting all entries.
-func CreateWithExistingKey(masterPassword string, masterKey []byte) (*Vault, error) {
-	// Generate random salt and derive key for password verification
-	salt, err := randomBytes(32)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate salt: %w", err)
-	}
-	key, err := deriveKey(masterPassword, salt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to derive key: %w", err)
-	}
-
-	// Create empty vault
-	v := &Vault{
-		Entries: make(map[string]CipherEntry),
-	}
-	v.KDF.N, v.KDF.R, v.KDF.P, v.KDF.L = kdfN, kdfr, kdfp, keyLen
-	v.SaltB64 = base64.StdEncoding.EncodeToString(salt)
-
-	// Wrap the existing master key with the new password
-	km, err := wrapMasterKey(masterKey, masterPassword, salt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to wrap master key: %w", err)
-	}
-	v.KeyMgr = *km
-
-	// Create verification block
-	nonce, err := encrypt.GenerateNonce(12)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate nonce: %w", err)
-	}
-	ct, err := encrypt.EncryptAESGCM(key, nonce, []byte(verifyMsg), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create verification block: %w", err)
-	}
-	v.VerifyNnc = base64.StdEncoding.EncodeToString(nonce)
-	v.VerifyCt = base64.StdEncoding.EncodeToString(ct)
-
-	return v, nil
-}
-
-// Unlock derives the key from the provided password and verifies it against the stored check.
-// Returns the unwrapped master key if successful.
-func (v *Vault) Unlock(masterPassword string) ([]byte, error) {
-	if v == nil {
-		return nil, errors.New("nil vault")
-	}
-	salt, err := base64.StdEncoding.DecodeString(v.SaltB64)
-	if err != nil {
-		return nil, fmt.Errorf("bad salt: %w", err)
-	}
-	key, err := deriveKey(masterPassword, salt)
-	if err != nil {
-		return nil, err
-	}
-	nonce, err := base64.StdEncoding.DecodeString(v.VerifyNnc)
-	if err != nil {
-		return nil, fmt.Errorf("bad verify nonce: %w", err)
-	}
-	ct, err := base64.StdEncoding.DecodeString(v.VerifyCt)
-	if err != nil {
-		return nil, fmt.Errorf("bad verify ct: %w", err)
-	}
-	pt, err := encrypt.DecryptAESGCM(key, nonce, ct, nil)
-	if err != nil || string(pt) != verifyMsg {
-		return nil, errors.New("wrong master password")
-	}
-
-	// Unwrap the master key
-	masterKey, err := v.KeyMgr.unwrapMasterKey(masterPassword, salt)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unwrap master key: %w", err)
-	}
-
-	return masterKey, nil
-}
-
-// Save to path
-func (v *Vault) Save(path string) error {
-	data, err := json.MarshalIndent(v, "", "  ")
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, data, 0600)
-}
-
-// Load from path
-func Load(path string) (*Vault, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	var v Vault
-	if err := json.Unmarshal(data, &v); err != nil {
-		return nil, err
-	}
-	if v.Entries == nil {
-		v.Entries = make(map[string]CipherEntry)
-	}
-	return &v, nil
-}
-
-// ---------- CRUD operations ----------
-
-func (v *Vault) AddEntry(key []byte, title, username, password, url, notes string) (string, error) {
-	if v == nil {
-		return "", errors.New("nil vault")
-	}
-	idBytes, err := randomBytes(16)
-	if err != nil {
-		return "", err
-	}
-	id := base64.RawURLEncoding.EncodeToString(idBytes)
-
-	now := time.Now().UTC()
-	plain := PlainEntry{
-		Username:   username,
-		Password:   password,
-		URL:        url,
-		Notes:      notes,
-		CreatedAt:  now,
-		ModifiedAt: now,
-	}
-	blob, err := json.Marshal(plain)
-	if err != nil {
-		return "", err
-	}
-
-	nonce, err := encrypt.GenerateNonce(12)
-	if err != nil {
-		return "", err
-	}
-
-	// Derive a unique key for this entry
-	entryKey, err := deriveEntryKey(key, id)
-	if err != nil {
-		return "", fmt.Errorf("failed to derive entry key: %w", err)
-	}
-
-	aad := []byte(id) // bind ct to entry id
-	ct, err := encrypt.EncryptAESGCM(entryKey, nonce, blob, aad)
-	if err != nil {
-		return "", err
-	}
-
-	v.Entries[id] = CipherEntry{
-		ID:         id,
-		Title:      title,
-		NonceB64:   base64.StdEncoding.EncodeToString(nonce),
-		CipherB64:  base64.StdEncoding.EncodeToString(ct),
-		CreatedAt:  now,
-		ModifiedAt: now,
-	}
-	return id, nil
-}
-
-func (v *Vault) List() []CipherEntry {
-	out := make([]CipherEntry, 0, len(v.Entries))
-	for _, e := range v.Entries {
-		out = append(out, e)
-	}
-	return out
-}
-
-func (v *Vault) GetDecrypted(key []byte, id string) (*PlainEntry, *CipherEntry, error) {
-	e, ok := v.Entries[id]
-	if !ok {
-		return nil, nil, errors.New("no such id")
-	}
-	nonce, err := base64.StdEncoding.DecodeString(e.NonceB64)
-	if err != nil {
-		return nil, nil, fmt.Errorf("bad nonce: %w", err)
-	}
-	ct, err := base64.StdEncoding.DecodeString(e.CipherB64)
-	if err != nil {
-		return nil, nil, fmt.Errorf("bad ciphertext: %w", err)
-	}
-
-	// Derive the unique key for this entry
-	entryKey, err := deriveEntryKey(key, id)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to derive entry key: %w", err)
-	}
-
-	pt, err := encrypt.DecryptAESGCM(entryKey, nonce, ct, []byte(id))
-	if err != nil {
-		return nil, nil, err
-	}
-	var plain PlainEntry
-	if err := json.Unmarshal(pt, &plain); err != nil {
-		return nil, nil, err
-	}
-	return &plain, &e, nil
-}
-
-func (v *Vault) UpdateEntry(key []byte, id string, title, username, password, url, notes *string) error {
-	plain, meta, err := v.GetDecrypted(key, id)
-	if err != nil {
-		return err
-	}
-
-	// apply changes if non-nil
-	if title != nil {
-		meta.Title = *title
-	}
-	if username != nil {
-		plain.Username = *username
-	}
-	if password != nil {
-		plain.Password = *password
-	}
-	if url != nil {
-		plain.URL = *url
-	}
-	if notes != nil {
-		plain.Notes = *notes
-	}
-	now := time.Now().UTC()
-	plain.ModifiedAt = now
-	meta.ModifiedAt = now
-
-	blob, err := json.Marshal(plain)
-	if err != nil {
-		return err
-	}
-	nonce, err := encrypt.GenerateNonce(12)
-	if err != nil {
-		return err
-	}
-
-	// Derive the unique key for this entry
-	entryKey, err := deriveEntryKey(key, id)
-	if err != nil {
-		return fmt.Errorf("failed to derive entry key: %w", err)
-	}
-
-	ct, err := encrypt.EncryptAESGCM(entryKey, nonce, blob, []byte(id))
-	if err != nil {
-		return err
-	}
-
-	meta.NonceB64 = base64.StdEncoding.EncodeToString(nonce)
-	meta.CipherB64 = base64.StdEncoding.EncodeToString(ct)
-	v.Entries[id] = *meta
-	return nil
-}
-
-func (v *Vault) Delete(id string) bool {
-	if _, ok := v.Entries[id]; !ok {
-		return false
-	}
-	delete(v.Entries, id)
-	return true
-}
-
-// SearchTitles returns entries whose Title contains query (case-insensitive).
-func (v *Vault) SearchTitles(query string) []CipherEntry {
-	q := strings.ToLower(strings.TrimSpace(query))
-	if q == "" {
-		return nil
-	}
-	out := make([]CipherEntry, 0, len(v.Entries))
-	for _, e := range v.Entries {
-		if strings.Contains(strings.ToLower(e.Title), q) {
-			out = append(out, e)
-		}
-	}
-	// sort by ModifiedAt desc for nicer UX
-	sort.Slice(out, func(i, j int) bool { return out[i].ModifiedAt.After(out[j].ModifiedAt) })
-	return out
-}
-
-// FindByExactTitle returns entries whose Title equals the provided title (case-insensitive).
-func (v *Vault) FindByExactTitle(title string) []CipherEntry {
-	t := strings.ToLower(strings.TrimSpace(title))
-	if t == "" {
-		return nil
-	}
-	out := make([]CipherEntry, 0, 1)
-	for _, e := range v.Entries {
-		if strings.ToLower(e.Title) == t {
-			out = append(out, e)
-		}
-	}
-	// deterministic order
-	sort.Slice(out, func(i, j int) bool { return out[i].ModifiedAt.After(out[j].ModifiedAt) })
-	return out
-}
+// appliedcryptography/internal/pwmanager/pwmanager.go
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/encrypt"
+	"appliedcryptography-starter-kit/internal/hash"
+	"appliedcryptography-starter-kit/internal/secret"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	kdfN      = 32768 // scrypt N
+	kdfr      = 8
+	kdfp      = 1
+	keyLen    = 32 // AES-256
+	verifyMsg = "vault-check"
+)
+
+// ---------- types saved to disk (JSON) ----------
+
+type Vault struct {
+	// KDF params are included so you can change them in future versions without breaking old vaults.
+	KDF struct {
+		N int `json:"N"`
+		R int `json:"r"`
+		P int `json:"p"`
+		L int `json:"keyLen"`
+	} `json:"kdf"`
+
+	SaltB64   string                 `json:"salt"`         // base64(salt)
+	KeyMgr    keyManager             `json:"keyManager"`   // Encrypted master key
+	VerifyNnc string                 `json:"verify_nonce"` // base64(nonce)
+	VerifyCt  string                 `json:"verify_ct"`    // base64(AES-GCM(verifyMsg))
+	Entries   map[string]CipherEntry `json:"entries"`      // id -> encrypted blob
+
+	// Per-tag expiry policies, sealed like an entry (see TagPolicies).
+	PolicyNonce string `json:"policy_nonce,omitempty"`
+	PolicyCt    string `json:"policy_ct,omitempty"`
+
+	// Deleted entries, still sealed, until restored or purged (see MoveToTrash).
+	Trash map[string]CipherEntry `json:"trash,omitempty"`
+
+	// Optional breach check run by AddEntry and UpdateEntry; findings go to Warn.
+	Breaches BreachChecker    `json:"-"`
+	Warn     func(msg string) `json:"-"`
+}
+
+type CipherEntry struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`      // kept in clear so you can list/search
+	NonceB64   string    `json:"nonce"`      // base64(12B nonce)
+	CipherB64  string    `json:"ciphertext"` // base64(GCM(PlainEntry JSON))
+	CreatedAt  time.Time `json:"createdAt"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+	DeletedAt  time.Time `json:"deletedAt,omitzero"` // set while the entry is in the trash
+}
+
+// This is never written as a top-level record; it’s encrypted as JSON into CipherEntry.CipherB64
+type PlainEntry struct {
+	Username    string         `json:"username"`
+	Password    string         `json:"password"`
+	URL         string         `json:"url,omitempty"`
+	URLMatch    string         `json:"urlMatch,omitempty"` // autofill match mode (see package urlmatch); "" is by domain
+	Notes       string         `json:"notes,omitempty"`
+	Kind        string         `json:"kind,omitempty"`        // "" (login), "note", "card" or "identity"
+	Folder      string         `json:"folder,omitempty"`      // slash-separated folder path
+	TOTP        string         `json:"totp,omitempty"`        // otpauth:// URI or bare base32 secret
+	Fields      []CustomField  `json:"fields,omitempty"`      // extra fields without a dedicated slot
+	Tags        []string       `json:"tags,omitempty"`        // free-form labels
+	History     []HistoryEntry `json:"history,omitempty"`     // previous credentials, oldest first
+	Attachments []Attachment   `json:"attachments,omitempty"` // small files stored inside the entry
+	Derived     *DerivedParams `json:"derived,omitempty"`     // set when Password is derived, not stored
+	Expiry      *ExpiryPolicy  `json:"expiry,omitempty"`      // rotation rules of this entry; overrides tag policies
+	Pending     *Replacement   `json:"pending,omitempty"`     // new password awaiting ConfirmRotation
+	ChangedAt   time.Time      `json:"changedAt,omitzero"`    // when Password last changed; zero on older entries
+	CreatedAt   time.Time      `json:"createdAt"`
+	ModifiedAt  time.Time      `json:"modifiedAt"`
+}
+
+// HistoryEntry is a previous version of an entry's credentials.
+type HistoryEntry struct {
+	Username   string    `json:"username,omitempty"`
+	Password   string    `json:"password"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+}
+
+// Attachment is a named binary blob; it is encrypted together with the rest of the entry.
+type Attachment struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"` // base64 in JSON
+}
+
+// CustomField is a named extra value attached to an entry (security questions, PINs, ...).
+type CustomField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Hidden bool   `json:"hidden,omitempty"` // mask in UIs like a password
+}
+
+// Entry kinds beyond the default login.
+const (
+	KindLogin    = ""
+	KindNote     = "note"
+	KindCard     = "card"
+	KindIdentity = "identity"
+)
+
+// ---------- helpers ----------
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// errLocked is returned when a key has already been destroyed by locking.
+var errLocked = errors.New("vault is locked")
+
+// Errors callers can tell apart with errors.Is, e.g. to choose an exit code.
+var (
+	ErrWrongPassword = errors.New("wrong master password")
+	ErrNotFound      = errors.New("no such id")
+	ErrConflict      = errors.New("conflicts with the vault's state")
+	ErrCorrupt       = errors.New("corrupt vault")
+)
+
+// conflictError is a clash with the vault's state, such as confirming a
+// rotation that is not pending; it matches ErrConflict but keeps its message.
+type conflictError struct{ msg string }
+
+func (e *conflictError) Error() string        { return e.msg }
+func (e *conflictError) Is(target error) bool { return target == ErrConflict }
+
+func conflictf(format string, args ...any) error {
+	return &conflictError{fmt.Sprintf(format, args...)}
+}
+
+// corrupt wraps a failure to read what the vault file holds in ErrCorrupt.
+func corrupt(what string, err error) error {
+	if errors.Is(err, ErrCorrupt) {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	return fmt.Errorf("%w: %s: %v", ErrCorrupt, what, err)
+}
+
+func deriveKey(masterPassword string, salt []byte) (*secret.Buffer, error) {
+	pw := []byte(masterPassword)
+	defer secret.Wipe(pw)
+	k, err := hash.Scrypt(pw, salt, kdfN, kdfr, kdfp, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	return secret.FromBytes(k)
+}
+
+// ---------- Vault lifecycle ----------
+
+// Create a brand new empty vault and return it + the master key. The caller
+// owns the key and destroys it when the vault is locked.
+func Create(masterPassword string) (*Vault, *secret.Buffer, error) {
+	// Generate random salt and derive key for password verification
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := deriveKey(masterPassword, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer key.Destroy()
+
+	// Create empty vault
+	v := &Vault{
+		Entries: make(map[string]CipherEntry),
+	}
+	v.KDF.N, v.KDF.R, v.KDF.P, v.KDF.L = kdfN, kdfr, kdfp, keyLen
+	v.SaltB64 = base64.StdEncoding.EncodeToString(salt)
+
+	// Generate and wrap master key
+	masterKey, err := generateMasterKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate master key: %w", err)
+	}
+	km, err := wrapMasterKey(masterKey, masterPassword, salt)
+	if err != nil {
+		masterKey.Destroy()
+		return nil, nil, fmt.Errorf("failed to wrap master key: %w", err)
+	}
+	v.KeyMgr = *km
+
+	// Create verification block
+	nonce, err := encrypt.GenerateNonce(12)
+	if err != nil {
+		masterKey.Destroy()
+		return nil, nil, err
+	}
+	ct, err := encrypt.EncryptAESGCM(key.Bytes(), nonce, []byte(verifyMsg), nil)
+	if err != nil {
+		masterKey.Destroy()
+		return nil, nil, err
+	}
+	v.VerifyNnc = base64.StdEncoding.EncodeToString(nonce)
+	v.VerifyCt = base64.StdEncoding.EncodeToString(ct)
+
+	return v, masterKey, nil
+}
+
+// CreateWithExistingKey creates a new vault using an existing master key.
+// This is used for changing the password without re-encrypting all entries.
+func CreateWithExistingKey(masterPassword string, masterKey *secret.Buffer) (*Vault, error) {
+	// Generate random salt and derive key for password verification
+	salt, err := randomBytes(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := deriveKey(masterPassword, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	defer key.Destroy()
+
+	// Create empty vault
+	v := &Vault{
+		Entries: make(map[string]CipherEntry),
+	}
+	v.KDF.N, v.KDF.R, v.KDF.P, v.KDF.L = kdfN, kdfr, kdfp, keyLen
+	v.SaltB64 = base64.StdEncoding.EncodeToString(salt)
+
+	// Wrap the existing master key with the new password
+	km, err := wrapMasterKey(masterKey, masterPassword, salt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap master key: %w", err)
+	}
+	v.KeyMgr = *km
+
+	// Create verification block
+	nonce, err := encrypt.GenerateNonce(12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	ct, err := encrypt.EncryptAESGCM(key.Bytes(), nonce, []byte(verifyMsg), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create verification block: %w", err)
+	}
+	v.VerifyNnc = base64.StdEncoding.EncodeToString(nonce)
+	v.VerifyCt = base64.StdEncoding.EncodeToString(ct)
+
+	return v, nil
+}
+
+// ChangePassword re-wraps the master key under newPassword with a fresh salt
+// and verification block. Entries are left alone: their keys come from the
+// master key, which does not change.
+func (v *Vault) ChangePassword(masterKey *secret.Buffer, newPassword string) error {
+	if newPassword == "" {
+		return errors.New("empty master password")
+	}
+	nv, err := CreateWithExistingKey(newPassword, masterKey)
+	if err != nil {
+		return err
+	}
+	v.KDF, v.SaltB64, v.KeyMgr = nv.KDF, nv.SaltB64, nv.KeyMgr
+	v.VerifyNnc, v.VerifyCt = nv.VerifyNnc, nv.VerifyCt
+	return nil
+}
+
+// Unlock derives the key from the provided password and verifies it against the stored check.
+// Returns the unwrapped master key if successful, in a locked buffer the
+// caller destroys when the vault is locked again.
+func (v *Vault) Unlock(masterPassword string) (*secret.Buffer, error) {
+	if v == nil {
+		return nil, errors.New("nil vault")
+	}
+	salt, err := base64.StdEncoding.DecodeString(v.SaltB64)
+	if err != nil {
+		return nil, corrupt("bad salt", err)
+	}
+	key, err := deriveKey(masterPassword, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Destroy()
+	nonce, err := base64.StdEncoding.DecodeString(v.VerifyNnc)
+	if err != nil {
+		return nil, corrupt("bad verify nonce", err)
+	}
+	ct, err := base64.StdEncoding.DecodeString(v.VerifyCt)
+	if err != nil {
+		return nil, corrupt("bad verify ct", err)
+	}
+	pt, err := encrypt.DecryptAESGCM(key.Bytes(), nonce, ct, nil)
+	if err != nil || string(pt) != verifyMsg {
+		return nil, ErrWrongPassword
+	}
+
+	// Unwrap the master key
+	masterKey, err := v.KeyMgr.unwrapMasterKey(masterPassword, salt)
+	if err != nil {
+		return nil, corrupt("failed to unwrap master key", err)
+	}
+
+	return masterKey, nil
+}
+
+// Save to path
+func (v *Vault) Save(path string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Load from path
+func Load(path string) (*Vault, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var v Vault
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, corrupt(path, err)
+	}
+	if v.SaltB64 == "" || v.VerifyCt == "" {
+		return nil, fmt.Errorf("%w: %s has no key material", ErrCorrupt, path)
+	}
+	if v.Entries == nil {
+		v.Entries = make(map[string]CipherEntry)
+	}
+	return &v, nil
+}
+
+// ---------- CRUD operations ----------
+
+func (v *Vault) AddEntry(key *secret.Buffer, title, username, password, url, notes string) (string, error) {
+	id, err := v.AddRecord(key, title, PlainEntry{
+		Username: username,
+		Password: password,
+		URL:      url,
+		Notes:    notes,
+	})
+	if err == nil {
+		v.warnIfBreached(title, password)
+	}
+	return id, err
+}
+
+func (v *Vault) List() []CipherEntry {
+	out := make([]CipherEntry, 0, len(v.Entries))
+	for _, e := range v.Entries {
+		out = append(out, e)
+	}
+	return out
+}
+
+func (v *Vault) GetDecrypted(key *secret.Buffer, id string) (*PlainEntry, *CipherEntry, error) {
+	e, ok := v.Entries[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	nonce, err := base64.StdEncoding.DecodeString(e.NonceB64)
+	if err != nil {
+		return nil, nil, corrupt("bad nonce", err)
+	}
+	ct, err := base64.StdEncoding.DecodeString(e.CipherB64)
+	if err != nil {
+		return nil, nil, corrupt("bad ciphertext", err)
+	}
+
+	// Derive the unique key for this entry
+	entryKey, err := deriveEntryKey(key, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive entry key: %w", err)
+	}
+	defer entryKey.Destroy()
+
+	pt, err := encrypt.DecryptAESGCM(entryKey.Bytes(), nonce, ct, []byte(id))
+	if err != nil {
+		return nil, nil, corrupt("entry "+id, err)
+	}
+	defer secret.Wipe(pt)
+	var plain PlainEntry
+	if err := json.Unmarshal(pt, &plain); err != nil {
+		return nil, nil, corrupt("entry "+id, err)
+	}
+	return &plain, &e, nil
+}
+
+func (v *Vault) UpdateEntry(key *secret.Buffer, id string, title, username, password, url, notes *string) error {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return err
+	}
+
+	// apply changes if non-nil
+	if title != nil {
+		meta.Title = *title
+	}
+	if username != nil {
+		plain.Username = *username
+	}
+	if password != nil && *password != plain.Password {
+		plain.Password = *password
+		plain.ChangedAt = time.Now().UTC()
+	}
+	if url != nil {
+		plain.URL = *url
+	}
+	if notes != nil {
+		plain.Notes = *notes
+	}
+	now := time.Now().UTC()
+	touch(plain, meta, now)
+
+	blob, err := json.Marshal(plain)
+	if err != nil {
+		return err
+	}
+	defer secret.Wipe(blob)
+	nonce, err := encrypt.GenerateNonce(12)
+	if err != nil {
+		return err
+	}
+
+	// Derive the unique key for this entry
+	entryKey, err := deriveEntryKey(key, id)
+	if err != nil {
+		return fmt.Errorf("failed to derive entry key: %w", err)
+	}
+	defer entryKey.Destroy()
+
+	ct, err := encrypt.EncryptAESGCM(entryKey.Bytes(), nonce, blob, []byte(id))
+	if err != nil {
+		return err
+	}
+
+	meta.NonceB64 = base64.StdEncoding.EncodeToString(nonce)
+	meta.CipherB64 = base64.StdEncoding.EncodeToString(ct)
+	v.Entries[id] = *meta
+	if password != nil {
+		v.warnIfBreached(meta.Title, *password)
+	}
+	return nil
+}
+
+func (v *Vault) Delete(id string) bool {
+	if _, ok := v.Entries[id]; !ok {
+		return false
+	}
+	delete(v.Entries, id)
+	return true
+}
+
+// SearchTitles returns entries whose Title contains query (case-insensitive).
+func (v *Vault) SearchTitles(query string) []CipherEntry {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return nil
+	}
+	out := make([]CipherEntry, 0, len(v.Entries))
+	for _, e := range v.Entries {
+		if strings.Contains(strings.ToLower(e.Title), q) {
+			out = append(out, e)
+		}
+	}
+	// sort by ModifiedAt desc for nicer UX
+	sort.Slice(out, func(i, j int) bool { return out[i].ModifiedAt.After(out[j].ModifiedAt) })
+	return out
+}
+
+// FindByExactTitle returns entries whose Title equals the provided title (case-insensitive).
+func (v *Vault) FindByExactTitle(title string) []CipherEntry {
+	t := strings.ToLower(strings.TrimSpace(title))
+	if t == "" {
+		return nil
+	}
+	out := make([]CipherEntry, 0, 1)
+	for _, e := range v.Entries {
+		if strings.ToLower(e.Title) == t {
+			out = append(out, e)
+		}
+	}
+	// deterministic order
+	sort.Slice(out, func(i, j int) bool { return out[i].ModifiedAt.After(out[j].ModifiedAt) })
+	return out
+}
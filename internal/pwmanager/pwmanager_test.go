@@ -1,90 +1,620 @@
-package pwmanager
-
-import (
-	"os"
-	"testing"
-)
-
-func TestVaultLifecycle(t *testing.T) {
-	const testMaster = "testPassword123!"
-	
-	// Create new vault
-	v, key, err := Create(testMaster)
-	if err != nil {
-		t.Fatalf("Create() error = %v", err)
-	}
-	if v == nil || key == nil {
-		t.Fatal("Create() returned nil vault or key")
-	}
-
-	// Save to temporary file
-	tmpFile := "test_vault.json"
-	if err := v.Save(tmpFile); err != nil {
-		t.Fatalf("Save() error = %v", err)
-	}
-	defer os.Remove(tmpFile)
-
-	// Load vault
-	v2, err := Load(tmpFile)
-	if err != nil {
-		t.Fatalf("Load() error = %v", err)
-	}
-
-	// Try to unlock with wrong password
-	_, err = v2.Unlock("wrongpassword")
-	if err == nil {
-		t.Error("Unlock() with wrong password should fail")
-	}
-
-	// Unlock with correct password
-	key2, err := v2.Unlock(testMaster)
-	if err != nil {
-		t.Fatalf("Unlock() error = %v", err)
-	}
-
-	// Add an entry
-	id, err := v2.AddEntry(key2, "Test Site", "testuser", "testpass", "https://test.com", "test notes")
-	if err != nil {
-		t.Fatalf("AddEntry() error = %v", err)
-	}
-
-	// List entries
-	entries := v2.List()
-	if len(entries) != 1 {
-		t.Errorf("List() got %d entries, want 1", len(entries))
-	}
-	if entries[0].Title != "Test Site" {
-		t.Errorf("List() entry title = %s, want Test Site", entries[0].Title)
-	}
-
-	// Search by title
-	found := v2.SearchTitles("Test")
-	if len(found) != 1 {
-		t.Errorf("SearchTitles() got %d entries, want 1", len(found))
-	}
-
-	// Get decrypted entry
-	plain, meta, err := v2.GetDecrypted(key2, id)
-	if err != nil {
-		t.Fatalf("GetDecrypted() error = %v", err)
-	}
-	if plain.Username != "testuser" || plain.Password != "testpass" {
-		t.Error("GetDecrypted() returned wrong username/password")
-	}
-	if meta.Title != "Test Site" {
-		t.Error("GetDecrypted() returned wrong title")
-	}
-
-	// Delete entry
-	if !v2.Delete(id) {
-		t.Error("Delete() failed")
-	}
-	if len(v2.List()) != 0 {
-		t.Error("Delete() did not remove entry")
-	}
-
-	// Save changes
-	if err := v2.Save(tmpFile); err != nil {
-		t.Fatalf("Save() error after changes = %v", err)
-	}
-}
\ No newline at end of file
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/secret"
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVaultLifecycle(t *testing.T) {
+	const testMaster = "testPassword123!"
+
+	// Create new vault
+	v, key, err := Create(testMaster)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if v == nil || key == nil {
+		t.Fatal("Create() returned nil vault or key")
+	}
+
+	// Save to temporary file
+	tmpFile := "test_vault.json"
+	if err := v.Save(tmpFile); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	defer os.Remove(tmpFile)
+
+	// Load vault
+	v2, err := Load(tmpFile)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Try to unlock with wrong password
+	_, err = v2.Unlock("wrongpassword")
+	if err == nil {
+		t.Error("Unlock() with wrong password should fail")
+	}
+
+	// Unlock with correct password
+	key2, err := v2.Unlock(testMaster)
+	if err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	// Add an entry
+	id, err := v2.AddEntry(key2, "Test Site", "testuser", "testpass", "https://test.com", "test notes")
+	if err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+
+	// List entries
+	entries := v2.List()
+	if len(entries) != 1 {
+		t.Errorf("List() got %d entries, want 1", len(entries))
+	}
+	if entries[0].Title != "Test Site" {
+		t.Errorf("List() entry title = %s, want Test Site", entries[0].Title)
+	}
+
+	// Search by title
+	found := v2.SearchTitles("Test")
+	if len(found) != 1 {
+		t.Errorf("SearchTitles() got %d entries, want 1", len(found))
+	}
+
+	// Get decrypted entry
+	plain, meta, err := v2.GetDecrypted(key2, id)
+	if err != nil {
+		t.Fatalf("GetDecrypted() error = %v", err)
+	}
+	if plain.Username != "testuser" || plain.Password != "testpass" {
+		t.Error("GetDecrypted() returned wrong username/password")
+	}
+	if meta.Title != "Test Site" {
+		t.Error("GetDecrypted() returned wrong title")
+	}
+	if !key.Equal(key2) {
+		t.Error("Unlock() returned a different master key than Create()")
+	}
+
+	// A destroyed key locks the vault
+	key2.Destroy()
+	if _, _, err := v2.GetDecrypted(key2, id); err == nil {
+		t.Error("GetDecrypted() with a destroyed key should fail")
+	}
+	key2, _ = v2.Unlock(testMaster)
+	defer key2.Destroy()
+
+	// Delete entry
+	if !v2.Delete(id) {
+		t.Error("Delete() failed")
+	}
+	if len(v2.List()) != 0 {
+		t.Error("Delete() did not remove entry")
+	}
+
+	// Save changes
+	if err := v2.Save(tmpFile); err != nil {
+		t.Fatalf("Save() error after changes = %v", err)
+	}
+}
+
+type fakeBreaches map[string]int
+
+func (f fakeBreaches) Count(pw string) (int, error) { return f[pw], nil }
+
+func TestBreachWarning(t *testing.T) {
+	v, key, err := Create("m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	v.Breaches = fakeBreaches{"hunter2": 32}
+	v.Warn = func(msg string) { warnings = append(warnings, msg) }
+
+	id, err := v.AddEntry(key, "Forum", "alice", "hunter2", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Fatalf("AddEntry warnings = %q", warnings)
+	}
+	strong := "x9$Lq!r2Vb#8mZt"
+	if err := v.UpdateEntry(key, id, nil, nil, &strong, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("clean password warned: %q", warnings)
+	}
+}
+
+func TestAnalyzePasswordStrength(t *testing.T) {
+	weak, feedback := AnalyzePasswordStrength("Password1!")
+	if weak >= 60 || len(feedback) < 2 {
+		t.Errorf("Password1!: %d %q", weak, feedback)
+	}
+	strong, _ := AnalyzePasswordStrength("correct horse battery staple")
+	if strong < 80 {
+		t.Errorf("passphrase: %d", strong)
+	}
+	if own, _ := AnalyzePasswordStrength("acmecorp2019", "Acme Corp"); own >= weak {
+		t.Errorf("title-derived password scored %d", own)
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	large, err := WordList(WordListEFFLarge)
+	if err != nil || len(large) != 7776 {
+		t.Fatalf("eff-large: %d words, %v", len(large), err)
+	}
+	short, err := WordList(WordListEFFShort)
+	if err != nil || len(short) != 1296 {
+		t.Fatalf("eff-short: %d words, %v", len(short), err)
+	}
+
+	opts := PasswordOptions{Words: 6, Separator: " ", Capitalize: CapFirst, InjectDigit: true}
+	pw, err := GeneratePassword(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Split(pw, " ")
+	if len(words) != 6 {
+		t.Fatalf("passphrase %q has %d words", pw, len(words))
+	}
+	digits := 0
+	for _, w := range words {
+		if w[0] < 'A' || w[0] > 'Z' {
+			t.Errorf("word %q not capitalized", w)
+		}
+		digits += strings.IndexAny(w, numberChars) + 1
+	}
+	if digits == 0 {
+		t.Errorf("no digit injected in %q", pw)
+	}
+
+	bits, err := EntropyBits(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 6*math.Log2(7776) + math.Log2(10) + math.Log2(6); math.Abs(bits-want) > 1e-9 {
+		t.Errorf("entropy = %.2f, want %.2f", bits, want)
+	}
+
+	custom := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(custom, []byte("# mine\n11\talpha\n12\tbravo\n\ncharlie\nalpha\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	list, err := WordList(custom)
+	if err != nil || strings.Join(list, ",") != "alpha,bravo,charlie" {
+		t.Fatalf("custom list = %q, %v", list, err)
+	}
+	pw, err = GeneratePassword(PasswordOptions{Words: 4, WordList: custom, Separator: "."})
+	if err != nil || len(strings.Split(pw, ".")) != 4 {
+		t.Errorf("custom passphrase = %q, %v", pw, err)
+	}
+	if _, err := GeneratePassword(PasswordOptions{Words: 3, Capitalize: "sideways"}); err == nil {
+		t.Error("unknown capitalization accepted")
+	}
+}
+
+func TestGeneratePasswordSiteRules(t *testing.T) {
+	rules, domain, ok := SiteRules("https://www.bankofamerica.com/login")
+	if !ok || domain != "bankofamerica.com" {
+		t.Fatalf("SiteRules = %q, %q, %v", rules, domain, ok)
+	}
+	pw, err := GeneratePassword(PasswordOptions{Length: 0, IncludeSymbols: true, Rules: rules})
+	if err != nil || len(pw) != 20 || strings.ContainsAny(pw, "!$%^&") {
+		t.Errorf("site password = %q, %v", pw, err)
+	}
+	if _, err := GeneratePassword(PasswordOptions{Rules: "required: nothing"}); err == nil {
+		t.Error("invalid rules accepted")
+	}
+}
+
+func TestDerivedPasswords(t *testing.T) {
+	// Known answers pin version 1: any change here breaks users' passwords.
+	dk, err := secret.FromBytes(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dk.Destroy()
+	p := DefaultDerivedParams("https://www.GitHub.com/login", "alice")
+	if p.Site != "github.com" {
+		t.Fatalf("site = %q", p.Site)
+	}
+	for _, tt := range []struct {
+		counter, length int
+		symbols         bool
+		want            string
+	}{
+		{1, 16, true, "N@OP%Iz$c01%F=zd"},
+		{2, 16, true, "7W!&1oJ+RrwP8iPd"},
+		{2, 8, false, "wwmY7okA"},
+	} {
+		q := p
+		q.Counter, q.Length, q.Symbols = tt.counter, tt.length, tt.symbols
+		if got, err := DerivePassword(dk, q); err != nil || got != tt.want {
+			t.Errorf("counter %d, length %d: %q, %v; want %q", tt.counter, tt.length, got, err, tt.want)
+		}
+	}
+	for i := range 200 {
+		q := DefaultDerivedParams("example.com", "bob")
+		q.Counter, q.Length = i+1, 4
+		pw, _ := DerivePassword(dk, q)
+		if !strings.ContainsAny(pw, derivedLower) || !strings.ContainsAny(pw, derivedUpper) ||
+			!strings.ContainsAny(pw, derivedDigits) || !strings.ContainsAny(pw, derivedSymbols) {
+			t.Fatalf("%q misses a character class", pw)
+		}
+	}
+	if _, err := DerivePassword(dk, DerivedParams{Version: 1, Site: "a.com", Counter: 1, Length: 8}); err == nil {
+		t.Error("no character classes accepted")
+	}
+
+	// the master password alone gives the same password on any machine
+	fromMaster, err := DerivationKey("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fromMaster.Destroy()
+	if got, err := DerivePassword(fromMaster, DefaultDerivedParams("github.com", "alice")); err != nil || got != "-IRig61xNlY4Cm#C" {
+		t.Errorf("from the master password: %q, %v", got, err)
+	}
+
+	v, key, err := Create("derive-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := v.AddDerivedEntry(key, "GitHub", DefaultDerivedParams("github.com", "alice"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _, _ := v.GetDecrypted(key, id)
+	if plain.Password != "" || !plain.IsDerived() || plain.URL != "https://github.com" {
+		t.Fatalf("stored entry = %+v", plain)
+	}
+	root, err := DerivationKey("derive-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plain.ResolveDerived(root); err != nil || len(plain.Password) != 16 {
+		t.Fatalf("resolved %q, %v", plain.Password, err)
+	}
+
+	next, err := v.RotateDerived(key, id)
+	if err != nil || next.Counter != 2 {
+		t.Fatalf("rotate = %+v, %v", next, err)
+	}
+	recs, _ := v.Records(key)
+	if !HasDerived(recs) {
+		t.Fatal("derived record not reported")
+	}
+	if err := ResolveDerivedRecords(recs, root); err != nil || recs[0].Derived != nil ||
+		recs[0].Password == "" || recs[0].Password == plain.Password {
+		t.Errorf("after rotation: %+v, %v", recs[0], err)
+	}
+}
+
+func TestExpiryAndRotation(t *testing.T) {
+	v, key, err := Create("expiry-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := v.AddEntry(key, "Payroll", "alice", "0ld-Passw0rd!", "https://payroll.example", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _ := v.AddRecord(key, "Tagged", PlainEntry{Username: "bob", Password: "x", Tags: []string{"PCI"}})
+
+	if err := v.SetTagPolicy(key, "pci", &ExpiryPolicy{RotateDays: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.SetExpiry(key, id, &ExpiryPolicy{RotateDays: 90}); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "v.json")
+	if err := v.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	v, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := v.TagPolicies(key)
+	if err != nil || tags["pci"].RotateDays != 30 {
+		t.Fatalf("tag policies = %v, %v", tags, err)
+	}
+
+	recs, _ := v.Records(key)
+	now := time.Now()
+	if due := DueRotations(recs, tags, now, 7*24*time.Hour); len(due) != 0 {
+		t.Errorf("fresh entries due: %+v", due)
+	}
+	due := DueRotations(recs, tags, now.AddDate(0, 0, 100), 0)
+	if len(due) != 2 || due[0].ID != other || due[0].Source != "tag PCI" || due[1].Source != "entry" {
+		t.Fatalf("due = %+v", due)
+	}
+	if !due[0].Overdue(now.AddDate(0, 0, 100)) {
+		t.Error("not overdue")
+	}
+
+	hard := now.AddDate(0, 0, 10)
+	if err := v.SetExpiry(key, id, &ExpiryPolicy{RotateDays: 90, ExpiresAt: hard}); err != nil {
+		t.Fatal(err)
+	}
+	plain, _, _ := v.GetDecrypted(key, id)
+	if at, rule, _ := plain.DueAt(*plain.Expiry); !at.Equal(hard) || rule != "hard expiry" {
+		t.Errorf("DueAt = %v %q", at, rule)
+	}
+
+	if err := v.ConfirmRotation(key, id); err == nil {
+		t.Error("confirmed without a pending password")
+	}
+	if err := v.StartRotation(key, id, "N3w-Passw0rd!"); err != nil {
+		t.Fatal(err)
+	}
+	plain, _, _ = v.GetDecrypted(key, id)
+	if plain.Password != "0ld-Passw0rd!" || plain.Pending == nil {
+		t.Fatalf("pending rotation changed the password: %+v", plain)
+	}
+	if err := v.ConfirmRotation(key, id); err != nil {
+		t.Fatal(err)
+	}
+	plain, _, _ = v.GetDecrypted(key, id)
+	if plain.Password != "N3w-Passw0rd!" || plain.Pending != nil || plain.ChangedAt.IsZero() ||
+		len(plain.History) != 1 || plain.History[0].Password != "0ld-Passw0rd!" {
+		t.Errorf("after confirm: %+v", plain)
+	}
+
+	if err := v.SetTagPolicy(key, "PCI", nil); err != nil || v.PolicyCt != "" {
+		t.Errorf("removing the last tag policy left %q, %v", v.PolicyCt, err)
+	}
+	if _, err := v.TagPolicies(nil); err != nil {
+		t.Errorf("no policies should need no decryption: %v", err)
+	}
+}
+
+// TestPasswordAgeSurvivesEdits checks that only a new password restarts the
+// rotation clock: a 100-day-old password under a 90-day policy stays due
+// after edits to its policy, notes and URL match.
+func TestPasswordAgeSurvivesEdits(t *testing.T) {
+	v, key, err := Create("age-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Destroy()
+	old := time.Now().UTC().AddDate(0, 0, -100)
+	id, err := v.AddRecord(key, "Payroll", PlainEntry{Username: "alice", Password: "pw", CreatedAt: old})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an entry written before ChangedAt was tracked
+	legacy, err := v.AddRecord(key, "Legacy", PlainEntry{Username: "bob", Password: "pw", CreatedAt: old})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, meta, _ := v.GetDecrypted(key, legacy)
+	plain.ChangedAt = time.Time{}
+	if err := v.sealEntry(key, *meta, plain); err != nil {
+		t.Fatal(err)
+	}
+	derivedID, err := v.AddDerivedEntry(key, "Derived", DefaultDerivedParams("example.com", "carol"), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notes := "moved to the new payroll provider"
+	for _, id := range []string{id, legacy, derivedID} {
+		if err := v.SetExpiry(key, id, &ExpiryPolicy{RotateDays: 90}); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.UpdateEntry(key, id, nil, nil, nil, nil, &notes); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.SetURLMatch(key, id, "host"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	recs, _ := v.Records(key)
+	due := DueRotations(recs, nil, time.Now(), 0)
+	if len(due) != 2 || due[0].ID == derivedID || due[1].ID == derivedID {
+		t.Fatalf("due after metadata edits = %+v, want Payroll and Legacy", due)
+	}
+
+	// a new password does restart it
+	pw := "new-pw"
+	if err := v.UpdateEntry(key, id, nil, nil, &pw, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	recs, _ = v.Records(key)
+	if due := DueRotations(recs, nil, time.Now().AddDate(0, 0, 100), 0); len(due) != 3 {
+		t.Errorf("due in 100 days = %+v, want all three", due)
+	}
+	if due := DueRotations(recs, nil, time.Now(), 0); len(due) != 1 || due[0].ID != legacy {
+		t.Errorf("due after a new password = %+v, want Legacy", due)
+	}
+	before, _, _ := v.GetDecrypted(key, derivedID)
+	if _, err := v.RotateDerived(key, derivedID); err != nil {
+		t.Fatal(err)
+	}
+	after, _, _ := v.GetDecrypted(key, derivedID)
+	if !after.ChangedAt.After(before.ChangedAt) {
+		t.Errorf("RotateDerived left ChangedAt at %v", after.ChangedAt)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	v, key, err := Create("trash-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Destroy()
+	id, err := v.AddEntry(key, "GitHub", "alice", "gh-pass", "https://github.com", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.MoveToTrash(id); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v.Entries[id]; ok || len(v.TrashList()) != 1 || v.Trash[id].DeletedAt.IsZero() {
+		t.Fatal("entry not moved to the trash")
+	}
+	if err := v.Restore(id); err != nil {
+		t.Fatal(err)
+	}
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil || plain.Password != "gh-pass" || !meta.DeletedAt.IsZero() {
+		t.Fatalf("restored entry = %+v, %v", plain, err)
+	}
+
+	v.MoveToTrash(id)
+	if n := v.EmptyTrash(time.Hour); n != 0 {
+		t.Errorf("EmptyTrash(1h) purged %d fresh entries", n)
+	}
+	if n := v.EmptyTrash(0); n != 1 || len(v.Trash) != 0 {
+		t.Errorf("EmptyTrash(0) = %d", n)
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	v, key, err := Create("old-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Destroy()
+	id, err := v.AddEntry(key, "GitHub", "alice", "gh-pass", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := v.ChangePassword(key, "new-master"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Unlock("old-master"); err == nil {
+		t.Error("the old master password still unlocks")
+	}
+	k2, err := v.Unlock("new-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k2.Destroy()
+	if !bytes.Equal(k2.Bytes(), key.Bytes()) {
+		t.Error("the master key changed")
+	}
+	if plain, _, err := v.GetDecrypted(k2, id); err != nil || plain.Password != "gh-pass" {
+		t.Errorf("entry after passwd = %+v, %v", plain, err)
+	}
+}
+
+func TestMasterKeyPersisted(t *testing.T) {
+	v, key, err := Create("persist-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Destroy()
+	id, err := v.AddEntry(key, "GitHub", "alice", "gh-pass", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if err := v.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries are sealed under the random master key, so it has to
+	// survive a save and load wrapped under the password
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k2, err := loaded.Unlock("persist-master")
+	if err != nil {
+		t.Fatalf("Unlock() after Load error = %v", err)
+	}
+	defer k2.Destroy()
+	if plain, _, err := loaded.GetDecrypted(k2, id); err != nil || plain.Password != "gh-pass" {
+		t.Errorf("entry after reload = %+v, %v", plain, err)
+	}
+
+	loaded.KeyMgr = keyManager{}
+	if err := loaded.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	stripped, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stripped.Unlock("persist-master"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Unlock() without the wrapped key = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	v, key, err := Create("kinds-master")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer key.Destroy()
+	id, err := v.AddEntry(key, "GitHub", "alice", "gh-pass", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := v.Unlock("wrong"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Unlock(wrong) = %v", err)
+	}
+	if _, _, err := v.GetDecrypted(key, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetDecrypted(missing) = %v", err)
+	}
+	if err := v.ConfirmRotation(key, id); !errors.Is(err, ErrConflict) || !strings.Contains(err.Error(), "no pending rotation") {
+		t.Errorf("ConfirmRotation without pending = %v", err)
+	}
+
+	e := v.Entries[id]
+	e.CipherB64 = e.NonceB64
+	v.Entries[id] = e
+	if _, _, err := v.GetDecrypted(key, id); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("GetDecrypted(tampered) = %v", err)
+	}
+
+	dir := t.TempDir()
+	for name, data := range map[string]string{"garbage.json": "{not json", "empty.json": "{}"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Load(%s) = %v", name, err)
+		}
+	}
+}
+
+func TestVaultFiles(t *testing.T) {
+	home := t.TempDir()
+	for _, env := range []string{"HOME", "XDG_CONFIG_HOME", "AppData", "home"} {
+		t.Setenv(env, home)
+	}
+	if files, err := VaultFiles(); err != nil || len(files) != 0 {
+		t.Fatalf("VaultFiles without a directory = %v, %v", files, err)
+	}
+	dir, err := VaultDir()
+	if err != nil || !strings.HasPrefix(dir, home) {
+		t.Fatalf("VaultDir = %q, %v", dir, err)
+	}
+	os.MkdirAll(filepath.Join(dir, "sub.json"), 0700)
+	for _, name := range []string{"work.json", "home.json", "notes.txt"} {
+		os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600)
+	}
+	files, err := VaultFiles()
+	want := []string{filepath.Join(dir, "home.json"), filepath.Join(dir, "work.json")}
+	if err != nil || strings.Join(files, ",") != strings.Join(want, ",") {
+		t.Errorf("VaultFiles = %v, %v; want %v", files, err, want)
+	}
+}
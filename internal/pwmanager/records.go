@@ -0,0 +1,230 @@
+package pwmanager
+
+import (
+	"appliedcryptography-starter-kit/internal/encrypt"
+	"appliedcryptography-starter-kit/internal/secret"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Record is a fully decrypted entry together with its cleartext metadata.
+// It is the unit exchanged with importers, exporters and reports.
+type Record struct {
+	ID    string
+	Title string
+	PlainEntry
+}
+
+// sealEntry encrypts plain under the entry key for meta.ID and stores it in the vault.
+func (v *Vault) sealEntry(key *secret.Buffer, meta CipherEntry, plain *PlainEntry) error {
+	blob, err := json.Marshal(plain)
+	if err != nil {
+		return err
+	}
+	defer secret.Wipe(blob)
+	nonce, err := encrypt.GenerateNonce(12)
+	if err != nil {
+		return err
+	}
+
+	// Derive the unique key for this entry
+	entryKey, err := deriveEntryKey(key, meta.ID)
+	if err != nil {
+		return fmt.Errorf("failed to derive entry key: %w", err)
+	}
+	defer entryKey.Destroy()
+
+	ct, err := encrypt.EncryptAESGCM(entryKey.Bytes(), nonce, blob, []byte(meta.ID))
+	if err != nil {
+		return err
+	}
+
+	meta.NonceB64 = base64.StdEncoding.EncodeToString(nonce)
+	meta.CipherB64 = base64.StdEncoding.EncodeToString(ct)
+	v.Entries[meta.ID] = meta
+	return nil
+}
+
+// AddRecord stores a new entry built from plain. Timestamps already set on plain
+// (e.g. by an importer) are preserved; missing ones default to now.
+func (v *Vault) AddRecord(key *secret.Buffer, title string, plain PlainEntry) (string, error) {
+	if v == nil {
+		return "", errors.New("nil vault")
+	}
+	idBytes, err := randomBytes(16)
+	if err != nil {
+		return "", err
+	}
+	id := base64.RawURLEncoding.EncodeToString(idBytes)
+
+	now := time.Now().UTC()
+	if plain.CreatedAt.IsZero() {
+		plain.CreatedAt = now
+	}
+	if plain.ModifiedAt.IsZero() {
+		plain.ModifiedAt = plain.CreatedAt
+	}
+	if plain.ChangedAt.IsZero() {
+		plain.ChangedAt = plain.ModifiedAt
+	}
+
+	meta := CipherEntry{
+		ID:         id,
+		Title:      title,
+		CreatedAt:  plain.CreatedAt,
+		ModifiedAt: plain.ModifiedAt,
+	}
+	if err := v.sealEntry(key, meta, &plain); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// ImportRecords adds every record as a new entry and returns the new IDs in order.
+// Record IDs are ignored: imported entries always get fresh vault IDs.
+func (v *Vault) ImportRecords(key *secret.Buffer, recs []Record) ([]string, error) {
+	ids := make([]string, 0, len(recs))
+	for _, r := range recs {
+		id, err := v.AddRecord(key, r.Title, r.PlainEntry)
+		if err != nil {
+			return ids, fmt.Errorf("import %q: %w", r.Title, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Records decrypts every entry in the vault, sorted by title then ID.
+func (v *Vault) Records(key *secret.Buffer) ([]Record, error) {
+	out := make([]Record, 0, len(v.Entries))
+	for id := range v.Entries {
+		plain, meta, err := v.GetDecrypted(key, id)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt %s: %w", id, err)
+		}
+		out = append(out, Record{ID: meta.ID, Title: meta.Title, PlainEntry: *plain})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ti, tj := strings.ToLower(out[i].Title), strings.ToLower(out[j].Title)
+		if ti != tj {
+			return ti < tj
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// Field returns the value of the first custom field with the given name (case-insensitive).
+func (p *PlainEntry) Field(name string) (string, bool) {
+	for _, f := range p.Fields {
+		if strings.EqualFold(f.Name, name) {
+			return f.Value, true
+		}
+	}
+	return "", false
+}
+
+// ImportSummary describes what an importer produced, for display after an import.
+type ImportSummary struct {
+	Source      string
+	Total       int
+	ByKind      map[string]int // keyed by Kind; "" counts logins
+	Folders     int            // distinct folder paths
+	Fields      int            // custom fields carried over
+	Attachments int
+	Skipped     []string // one human-readable reason per item that was not imported
+}
+
+// Summarize counts what recs contain; skipped lists the items an importer dropped.
+func Summarize(source string, recs []Record, skipped []string) ImportSummary {
+	s := ImportSummary{Source: source, Total: len(recs), ByKind: map[string]int{}, Skipped: skipped}
+	folders := map[string]bool{}
+	for _, r := range recs {
+		s.ByKind[r.Kind]++
+		s.Fields += len(r.Fields)
+		s.Attachments += len(r.Attachments)
+		if r.Folder != "" {
+			folders[r.Folder] = true
+		}
+	}
+	s.Folders = len(folders)
+	return s
+}
+
+// String renders the summary as a short multi-line report.
+func (s ImportSummary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "imported %d entries from %s\n", s.Total, s.Source)
+	fmt.Fprintf(&b, "  logins: %d, notes: %d, cards: %d, identities: %d\n",
+		s.ByKind[KindLogin], s.ByKind[KindNote], s.ByKind[KindCard], s.ByKind[KindIdentity])
+	fmt.Fprintf(&b, "  folders: %d, custom fields: %d, attachments: %d\n", s.Folders, s.Fields, s.Attachments)
+	if len(s.Skipped) > 0 {
+		fmt.Fprintf(&b, "  skipped: %d\n", len(s.Skipped))
+		for _, reason := range s.Skipped {
+			fmt.Fprintf(&b, "    - %s\n", reason)
+		}
+	}
+	return b.String()
+}
+
+// Selector picks records by ID, title substring or tag. A record matches when it
+// satisfies any of the criteria that are set; an empty selector matches nothing.
+type Selector struct {
+	IDs   []string
+	Title string // case-insensitive substring
+	Tags  []string
+}
+
+// Empty reports whether no criteria are set.
+func (s Selector) Empty() bool {
+	return len(s.IDs) == 0 && s.Title == "" && len(s.Tags) == 0
+}
+
+// Match reports whether r satisfies the selector.
+func (s Selector) Match(r Record) bool {
+	for _, id := range s.IDs {
+		if r.ID == id {
+			return true
+		}
+	}
+	if s.Title != "" && strings.Contains(strings.ToLower(r.Title), strings.ToLower(s.Title)) {
+		return true
+	}
+	for _, want := range s.Tags {
+		for _, tag := range r.Tags {
+			if strings.EqualFold(tag, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Select returns the records matching s, in order.
+func Select(recs []Record, s Selector) []Record {
+	var out []Record
+	for _, r := range recs {
+		if s.Match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// SetURLMatch changes how an entry's URL is matched for autofill. The mode
+// is stored as given; package urlmatch defines and validates the names.
+func (v *Vault) SetURLMatch(key *secret.Buffer, id, mode string) error {
+	plain, meta, err := v.GetDecrypted(key, id)
+	if err != nil {
+		return err
+	}
+	plain.URLMatch = mode
+	now := time.Now().UTC()
+	touch(plain, meta, now)
+	return v.sealEntry(key, *meta, plain)
+}
@@ -0,0 +1,71 @@
+package pwmanager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MoveToTrash moves an entry to the trash. It stays sealed under its own
+// entry key, so Restore brings it back unchanged.
+func (v *Vault) MoveToTrash(id string) error {
+	e, ok := v.Entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if v.Trash == nil {
+		v.Trash = make(map[string]CipherEntry)
+	}
+	e.DeletedAt = time.Now().UTC()
+	v.Trash[id] = e
+	delete(v.Entries, id)
+	return nil
+}
+
+// Restore moves an entry from the trash back into the vault.
+func (v *Vault) Restore(id string) error {
+	e, ok := v.Trash[id]
+	if !ok {
+		return fmt.Errorf("%w in the trash", ErrNotFound)
+	}
+	if _, taken := v.Entries[id]; taken {
+		return conflictf("an entry with id %s already exists", id)
+	}
+	e.DeletedAt = time.Time{}
+	v.Entries[id] = e
+	delete(v.Trash, id)
+	return nil
+}
+
+// Purge deletes an entry from the trash for good.
+func (v *Vault) Purge(id string) bool {
+	if _, ok := v.Trash[id]; !ok {
+		return false
+	}
+	delete(v.Trash, id)
+	return true
+}
+
+// TrashList returns the trashed entries, most recently deleted first.
+func (v *Vault) TrashList() []CipherEntry {
+	out := make([]CipherEntry, 0, len(v.Trash))
+	for _, e := range v.Trash {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt.After(out[j].DeletedAt) })
+	return out
+}
+
+// EmptyTrash purges the entries deleted more than olderThan ago (all of them
+// when olderThan is 0) and returns how many it removed.
+func (v *Vault) EmptyTrash(olderThan time.Duration) int {
+	cutoff := time.Now().Add(-olderThan)
+	n := 0
+	for id, e := range v.Trash {
+		if olderThan <= 0 || e.DeletedAt.Before(cutoff) {
+			delete(v.Trash, id)
+			n++
+		}
+	}
+	return n
+}
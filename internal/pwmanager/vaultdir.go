@@ -0,0 +1,50 @@
+package pwmanager
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VaultDir returns the directory the GUI keeps its vaults in, one
+// NAME.json per vault: SecurePasswordManager in the user's config
+// directory, or in ~/.config when there is none. It may not exist yet.
+func VaultDir() (string, error) {
+	cfgDir, err := os.UserConfigDir()
+	if err != nil || strings.TrimSpace(cfgDir) == "" {
+		home, herr := os.UserHomeDir()
+		if herr != nil {
+			return "", fmt.Errorf("cannot determine a config/home directory: %v / %v", err, herr)
+		}
+		cfgDir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(cfgDir, "SecurePasswordManager"), nil
+}
+
+// VaultFiles returns the paths of the vaults in VaultDir, sorted; none if
+// the directory does not exist.
+func VaultFiles() ([]string, error) {
+	dir, err := VaultDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,93 @@
+// Package redact masks secrets in a stream of output, such as a child
+// process's stdout, before it reaches a terminal or a log.
+package redact
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Mask replaces each secret in the output.
+const Mask = "*****"
+
+// Writer copies to an underlying writer with every occurrence of a secret
+// replaced by Mask. A secret split across two writes is still found: the
+// tail of a write that could be the start of one is held back until the
+// next write, or Close, shows whether it is. Writer is safe for concurrent use.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets [][]byte // longest first, so a secret containing another is masked whole
+	pending []byte
+}
+
+// NewWriter returns a Writer that masks secrets on their way to w. Empty
+// secrets are ignored.
+func NewWriter(w io.Writer, secrets []string) *Writer {
+	rw := &Writer{w: w}
+	for _, s := range secrets {
+		if s != "" {
+			rw.secrets = append(rw.secrets, []byte(s))
+		}
+	}
+	sort.Slice(rw.secrets, func(i, j int) bool { return len(rw.secrets[i]) > len(rw.secrets[j]) })
+	return rw
+}
+
+// Write masks and writes p, less any tail that may begin a secret. It
+// reports len(p) when everything up to that tail was written.
+func (rw *Writer) Write(p []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	buf := append(rw.pending, p...)
+	var out bytes.Buffer
+	for len(buf) > 0 {
+		if s := rw.secretAt(buf); s != nil {
+			out.WriteString(Mask)
+			buf = buf[len(s):]
+			continue
+		}
+		if rw.mayStart(buf) {
+			break // wait for more
+		}
+		out.WriteByte(buf[0])
+		buf = buf[1:]
+	}
+	rw.pending = append([]byte(nil), buf...)
+	if _, err := rw.w.Write(out.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes what was held back; it does not close the underlying writer.
+func (rw *Writer) Close() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	_, err := rw.w.Write(rw.pending)
+	rw.pending = nil
+	return err
+}
+
+// secretAt returns the secret buf starts with, if any.
+func (rw *Writer) secretAt(buf []byte) []byte {
+	for _, s := range rw.secrets {
+		if bytes.HasPrefix(buf, s) {
+			return s
+		}
+	}
+	return nil
+}
+
+// mayStart reports whether all of buf is the start of a secret, so that
+// the next write could complete it.
+func (rw *Writer) mayStart(buf []byte) bool {
+	for _, s := range rw.secrets {
+		if len(buf) < len(s) && bytes.HasPrefix(s, buf) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		secrets []string
+		writes  []string
+		want    string
+	}{
+		{"whole", []string{"hunter2"}, []string{"pw=hunter2\n"}, "pw=*****\n"},
+		{"split", []string{"hunter2"}, []string{"pw=hun", "ter2 ok"}, "pw=***** ok"},
+		{"false start", []string{"hunter2"}, []string{"hunt", "ing hunter2"}, "hunting *****"},
+		{"held at close", []string{"hunter2"}, []string{"ends with hunt"}, "ends with hunt"},
+		{"longest first", []string{"abc", "abcdef"}, []string{"abcdef abc"}, "***** *****"},
+		{"overlap", []string{"aab"}, []string{"aa", "aab"}, "aa*****"},
+		{"none", nil, []string{"plain"}, "plain"},
+	} {
+		var out strings.Builder
+		w := NewWriter(&out, tc.secrets)
+		for _, s := range tc.writes {
+			if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+				t.Fatalf("%s: Write = %d, %v", tc.name, n, err)
+			}
+		}
+		w.Close()
+		if out.String() != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, out.String(), tc.want)
+		}
+	}
+}
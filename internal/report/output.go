@@ -0,0 +1,96 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteTable prints the report as an aligned text table.
+func (r *Report) WriteTable(w io.Writer) error {
+	fmt.Fprintf(w, "Vault health: %d/100 (%d logins checked, %d with issues)\n", r.Score, r.Checked, r.withIssues())
+	for _, issue := range []Issue{IssueEmpty, IssueBreached, IssueWeak, IssueReused, IssueOld, IssueInsecure, IssueDuplicate} {
+		if n := r.Counts[issue]; n > 0 {
+			fmt.Fprintf(w, "  %-16s %d\n", issue, n)
+		}
+	}
+	if len(r.Entries) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SCORE\tTITLE\tUSERNAME\tCRACK TIME\tISSUES")
+	for _, e := range r.Entries {
+		issues := make([]string, len(e.Findings))
+		for i, f := range e.Findings {
+			issues[i] = string(f.Issue)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", e.Score, e.Title, e.Username, e.CrackTime, strings.Join(issues, ", "))
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteHTML writes a standalone HTML page.
+func (r *Report) WriteHTML(w io.Writer) error {
+	return htmlTemplate.Execute(w, r)
+}
+
+func (r *Report) withIssues() int {
+	n := 0
+	for _, e := range r.Entries {
+		if len(e.Findings) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"grade": func(score int) string {
+		switch {
+		case score >= 80:
+			return "good"
+		case score >= 50:
+			return "fair"
+		}
+		return "poor"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Vault health report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.good { color: #2e7d32; } .fair { color: #ef6c00; } .poor { color: #c62828; }
+</style>
+</head>
+<body>
+<h1>Vault health: <span class="{{grade .Score}}">{{.Score}}/100</span></h1>
+<p>Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}} &middot; {{.Checked}} logins checked</p>
+{{if .Counts}}<ul>{{range $issue, $n := .Counts}}<li>{{$issue}}: {{$n}}</li>{{end}}</ul>{{end}}
+{{if .Entries}}
+<table>
+<tr><th>Score</th><th>Title</th><th>Username</th><th>URL</th><th>Crack time</th><th>Findings</th></tr>
+{{range .Entries}}<tr>
+<td class="{{grade .Score}}">{{.Score}}</td><td>{{.Title}}</td><td>{{.Username}}</td><td>{{.URL}}</td><td>{{.CrackTime}}</td>
+<td>{{range .Findings}}<div><b>{{.Issue}}</b>: {{.Detail}}</div>{{end}}</td>
+</tr>
+{{end}}</table>
+{{else}}<p>No issues found.</p>{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,260 @@
+// Package report builds a health report over decrypted vault records: weak,
+// reused, old, empty and breached passwords, overdue rotations, plain-http URLs
+// and duplicate logins, with a 0-100 health score per entry and for the whole
+// vault.
+package report
+
+import (
+	"appliedcryptography-starter-kit/internal/hash"
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"appliedcryptography-starter-kit/internal/secret"
+	"appliedcryptography-starter-kit/internal/strength"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Issue identifies a kind of problem found on an entry.
+type Issue string
+
+const (
+	IssueEmpty     Issue = "empty"
+	IssueBreached  Issue = "breached"
+	IssueWeak      Issue = "weak"
+	IssueReused    Issue = "reused"
+	IssueOld       Issue = "old"
+	IssueInsecure  Issue = "insecure-url"
+	IssueDuplicate Issue = "duplicate-login"
+	IssueOverdue   Issue = "rotation-overdue"
+)
+
+// penalties are subtracted from an entry's score of 100 for each issue found.
+var penalties = map[Issue]int{
+	IssueEmpty:     100,
+	IssueBreached:  50,
+	IssueWeak:      40,
+	IssueReused:    30,
+	IssueOld:       15,
+	IssueInsecure:  15,
+	IssueDuplicate: 10,
+	IssueOverdue:   20,
+}
+
+// Options tune the checks. Zero values select the defaults.
+type Options struct {
+	MaxAge       time.Duration // passwords older than this are flagged (default 365 days)
+	WeakBelow    int           // strength scores below this are weak (default 60, i.e. under 10^8 guesses)
+	Now          time.Time     // reference time for ages (default time.Now)
+	IncludeClean bool          // list entries without issues too
+
+	// Breaches, when set, flags passwords found in a breach corpus (see internal/hibp).
+	Breaches pwmanager.BreachChecker
+
+	// DerivationKey, when set, lets Build recompute derived passwords (see
+	// pwmanager.DerivationKey) so they are checked like stored ones.
+	DerivationKey *secret.Buffer
+
+	// TagPolicies are the vault's per-tag expiry policies; Build reads them
+	// from the vault when nil. Entries past their rotation date are flagged.
+	TagPolicies map[string]pwmanager.ExpiryPolicy
+}
+
+func (o *Options) defaults() {
+	if o.MaxAge <= 0 {
+		o.MaxAge = 365 * 24 * time.Hour
+	}
+	if o.WeakBelow <= 0 {
+		o.WeakBelow = 60
+	}
+	if o.Now.IsZero() {
+		o.Now = time.Now()
+	}
+}
+
+// Finding is one issue on one entry. Detail never contains a password.
+type Finding struct {
+	Issue  Issue  `json:"issue"`
+	Detail string `json:"detail"`
+}
+
+// Entry is the report for a single vault entry.
+type Entry struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Username  string    `json:"username,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Strength  int       `json:"strength"`            // 0-100, see strength.Result.Percent
+	CrackTime string    `json:"crackTime,omitempty"` // offline slow-hash estimate
+	AgeDays   int       `json:"ageDays"`
+	Findings  []Finding `json:"findings"`
+	Score     int       `json:"score"`
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Checked     int           `json:"checked"` // entries with a password slot (logins)
+	Score       int           `json:"score"`   // mean entry score, 100 for an empty vault
+	Counts      map[Issue]int `json:"counts"`
+	Entries     []Entry       `json:"entries"` // worst first
+}
+
+// Build decrypts every entry in the vault and analyzes it.
+func Build(v *pwmanager.Vault, key *secret.Buffer, opts Options) (*Report, error) {
+	recs, err := v.Records(key)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DerivationKey != nil {
+		if err := pwmanager.ResolveDerivedRecords(recs, opts.DerivationKey); err != nil {
+			return nil, err
+		}
+	}
+	if opts.TagPolicies == nil {
+		if opts.TagPolicies, err = v.TagPolicies(key); err != nil {
+			return nil, err
+		}
+	}
+	return Analyze(recs, opts)
+}
+
+// Analyze checks the login records in recs. Notes, cards and identities are
+// skipped, and so are the password checks of derived entries left unresolved.
+func Analyze(recs []pwmanager.Record, opts Options) (*Report, error) {
+	opts.defaults()
+
+	// Reuse is detected by comparing HMACs under a throwaway key, so the report
+	// never holds or prints passwords and the digests are useless afterwards.
+	rk, err := secret.Random(32)
+	if err != nil {
+		return nil, err
+	}
+	defer rk.Destroy()
+	reuseKey := rk.Bytes()
+
+	var logins []pwmanager.Record
+	byDigest := map[string][]int{}
+	byLogin := map[string][]int{}
+	for _, r := range recs {
+		if r.Kind != pwmanager.KindLogin {
+			continue
+		}
+		i := len(logins)
+		logins = append(logins, r)
+		if r.Password != "" {
+			d := string(hash.HMACSHA256(reuseKey, []byte(r.Password)))
+			byDigest[d] = append(byDigest[d], i)
+		}
+		if k := loginKey(r); k != "" {
+			byLogin[k] = append(byLogin[k], i)
+		}
+	}
+
+	rep := &Report{GeneratedAt: opts.Now.UTC(), Checked: len(logins), Counts: map[Issue]int{}}
+	total := 0
+	for i, r := range logins {
+		e := Entry{ID: r.ID, Title: r.Title, Username: r.Username, URL: r.URL}
+		if !r.ModifiedAt.IsZero() {
+			e.AgeDays = int(opts.Now.Sub(r.ModifiedAt).Hours() / 24)
+		}
+		add := func(issue Issue, detail string) {
+			e.Findings = append(e.Findings, Finding{issue, detail})
+			rep.Counts[issue]++
+		}
+
+		switch {
+		case r.Password == "" && r.IsDerived():
+			// recomputed only when Options.DerivationKey is set
+		case r.Password == "":
+			add(IssueEmpty, "no password set")
+		default:
+			est := strength.Estimate(r.Password, r.Title, r.Username, r.URL)
+			e.Strength = est.Percent()
+			e.CrackTime = est.CrackTimes.OfflineSlowHash.Display
+			if e.Strength < opts.WeakBelow {
+				add(IssueWeak, weakDetail(e.Strength, est))
+			}
+			if opts.Breaches != nil {
+				n, err := opts.Breaches.Count(r.Password)
+				if err != nil {
+					return nil, fmt.Errorf("breach check: %w", err)
+				}
+				if n > 0 {
+					add(IssueBreached, fmt.Sprintf("seen %d times in known data breaches", n))
+				}
+			}
+			d := string(hash.HMACSHA256(reuseKey, []byte(r.Password)))
+			if others := titlesExcept(logins, byDigest[d], i); len(others) > 0 {
+				add(IssueReused, "same password as "+strings.Join(others, ", "))
+			}
+		}
+		if !r.ModifiedAt.IsZero() && opts.Now.Sub(r.ModifiedAt) > opts.MaxAge {
+			add(IssueOld, fmt.Sprintf("last changed %d days ago", e.AgeDays))
+		}
+		policy, _ := pwmanager.EffectivePolicy(&r.PlainEntry, opts.TagPolicies)
+		if due, rule, ok := r.DueAt(policy); ok && due.Before(opts.Now) {
+			add(IssueOverdue, fmt.Sprintf("%s: due %s", rule, due.Format("2006-01-02")))
+		}
+		if strings.HasPrefix(strings.ToLower(r.URL), "http://") {
+			add(IssueInsecure, "URL uses unencrypted http://")
+		}
+		if others := titlesExcept(logins, byLogin[loginKey(r)], i); len(others) > 0 {
+			add(IssueDuplicate, "same username and site as "+strings.Join(others, ", "))
+		}
+
+		e.Score = 100
+		for _, f := range e.Findings {
+			e.Score -= penalties[f.Issue]
+		}
+		e.Score = max(e.Score, 0)
+		total += e.Score
+		if len(e.Findings) > 0 || opts.IncludeClean {
+			rep.Entries = append(rep.Entries, e)
+		}
+	}
+
+	rep.Score = 100
+	if len(logins) > 0 {
+		rep.Score = total / len(logins)
+	}
+	sort.SliceStable(rep.Entries, func(i, j int) bool { return rep.Entries[i].Score < rep.Entries[j].Score })
+	return rep, nil
+}
+
+// weakDetail describes a weak password by its score, crack time and the
+// estimator's feedback, which never quotes the password itself.
+func weakDetail(score int, est strength.Result) string {
+	detail := fmt.Sprintf("strength %d/100, cracked offline in %s", score, est.CrackTimes.OfflineSlowHash.Display)
+	notes := est.Feedback.Suggestions
+	if est.Feedback.Warning != "" {
+		notes = append([]string{est.Feedback.Warning}, notes...)
+	}
+	if len(notes) > 0 {
+		detail += ": " + strings.Join(notes, "; ")
+	}
+	return detail
+}
+
+// loginKey identifies a login by username and host; empty when either is missing.
+func loginKey(r pwmanager.Record) string {
+	if r.Username == "" || r.URL == "" {
+		return ""
+	}
+	host := r.URL
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	return strings.ToLower(r.Username) + "\x00" + strings.ToLower(host)
+}
+
+func titlesExcept(recs []pwmanager.Record, idx []int, self int) []string {
+	var out []string
+	for _, j := range idx {
+		if j != self {
+			out = append(out, fmt.Sprintf("%q", recs[j].Title))
+		}
+	}
+	return out
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func login(id, title, user, pw, url string, modified time.Time) pwmanager.Record {
+	return pwmanager.Record{ID: id, Title: title, PlainEntry: pwmanager.PlainEntry{
+		Username: user, Password: pw, URL: url, ModifiedAt: modified,
+	}}
+}
+
+func findings(e Entry) map[Issue]bool {
+	m := map[Issue]bool{}
+	for _, f := range e.Findings {
+		m[f.Issue] = true
+	}
+	return m
+}
+
+func TestAnalyze(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	strong := "x9$Lq!r2Vb#8mZt"
+	recs := []pwmanager.Record{
+		login("1", "Good", "alice", strong, "https://good.example", now),
+		login("2", "Weak", "bob", "abc", "https://weak.example", now),
+		login("3", "Shared A", "carol", "R3used!Passw0rd#", "https://a.example", now),
+		login("4", "Shared B", "carol", "R3used!Passw0rd#", "https://b.example", now),
+		login("5", "Ancient", "dave", "Anc1ent!Passw0rd#", "http://old.example", now.AddDate(-2, 0, 0)),
+		login("6", "Empty", "erin", "", "", now),
+		login("7", "Dup 1", "frank", "Dup1!Passw0rd#xyz", "https://dup.example/login", now),
+		login("8", "Dup 2", "Frank", "Dup2!Passw0rd#xyz", "https://dup.example/other", now),
+		{ID: "9", Title: "Note", PlainEntry: pwmanager.PlainEntry{Kind: pwmanager.KindNote, Notes: "no password"}},
+	}
+
+	rep, err := Analyze(recs, Options{Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Checked != 8 {
+		t.Errorf("Checked = %d, want 8 (notes skipped)", rep.Checked)
+	}
+	byID := map[string]Entry{}
+	for _, e := range rep.Entries {
+		byID[e.ID] = e
+	}
+
+	if _, ok := byID["1"]; ok {
+		t.Error("clean entry listed without IncludeClean")
+	}
+	want := map[string]Issue{"2": IssueWeak, "3": IssueReused, "4": IssueReused, "5": IssueOld, "6": IssueEmpty, "7": IssueDuplicate, "8": IssueDuplicate}
+	for id, issue := range want {
+		if !findings(byID[id])[issue] {
+			t.Errorf("entry %s: missing %s in %+v", id, issue, byID[id].Findings)
+		}
+	}
+	if !findings(byID["5"])[IssueInsecure] {
+		t.Error("http:// URL not flagged")
+	}
+	if byID["6"].Score != 0 {
+		t.Errorf("empty password score = %d, want 0", byID["6"].Score)
+	}
+	if rep.Entries[0].ID != "6" {
+		t.Errorf("worst entry first: got %s", rep.Entries[0].ID)
+	}
+	if rep.Score <= 0 || rep.Score >= 100 {
+		t.Errorf("overall score = %d", rep.Score)
+	}
+
+	// Passwords must never reach any output format
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"table": func(b *bytes.Buffer) error { return rep.WriteTable(b) },
+		"json":  func(b *bytes.Buffer) error { return rep.WriteJSON(b) },
+		"html":  func(b *bytes.Buffer) error { return rep.WriteHTML(b) },
+	} {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, r := range recs {
+			if r.Password != "" && strings.Contains(buf.String(), r.Password) {
+				t.Errorf("%s output contains the password of %q", name, r.Title)
+			}
+		}
+	}
+}
+
+type fakeBreaches map[string]int
+
+func (f fakeBreaches) Count(pw string) (int, error) { return f[pw], nil }
+
+func TestAnalyzeBreached(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recs := []pwmanager.Record{
+		login("1", "Pwned", "alice", "Tr0ub4dor&3xyz!", "https://a.example", now),
+		login("2", "Fine", "bob", "x9$Lq!r2Vb#8mZt", "https://b.example", now),
+	}
+	rep, err := Analyze(recs, Options{Now: now, Breaches: fakeBreaches{"Tr0ub4dor&3xyz!": 7}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Entries) != 1 || !findings(rep.Entries[0])[IssueBreached] {
+		t.Fatalf("entries = %+v", rep.Entries)
+	}
+	if rep.Counts[IssueBreached] != 1 {
+		t.Errorf("counts = %v", rep.Counts)
+	}
+}
+
+func TestAnalyzeOverdue(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recs := []pwmanager.Record{
+		login("1", "Tagged", "alice", "x9$Lq!r2Vb#8mZt", "https://a.example", now.AddDate(0, 0, -100)),
+		login("2", "Own", "bob", "Qw7&zP!m3Lk#9vR", "https://b.example", now.AddDate(0, 0, -100)),
+	}
+	recs[0].Tags = []string{"PCI"}
+	recs[1].Tags = []string{"PCI"}
+	recs[1].Expiry = &pwmanager.ExpiryPolicy{RotateDays: 365} // the entry's own policy wins
+	rep, err := Analyze(recs, Options{Now: now, TagPolicies: map[string]pwmanager.ExpiryPolicy{"pci": {RotateDays: 90}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Entries) != 1 || rep.Entries[0].ID != "1" || !findings(rep.Entries[0])[IssueOverdue] {
+		t.Fatalf("entries = %+v", rep.Entries)
+	}
+}
+
+func TestAnalyzeEmptyVault(t *testing.T) {
+	rep, err := Analyze(nil, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Score != 100 || len(rep.Entries) != 0 {
+		t.Errorf("empty vault report = %+v", rep)
+	}
+}
+
+func TestAnalyzeEntryDerived(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	recs := []pwmanager.Record{
+		login("1", "Wombat VPN", "jdoe", "WombatVPNjdoe", "https://vpn.wombat.example", now),
+		login("2", "Other", "jdoe", "WombatVPNjdoe", "https://other.example", now.Add(time.Hour)),
+	}
+	rep, err := Analyze(recs, Options{Now: now, IncludeClean: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]Entry{}
+	for _, e := range rep.Entries {
+		byID[e.ID] = e
+	}
+	// The same password is weaker on the entry it was built from.
+	if own, other := byID["1"], byID["2"]; own.Strength >= other.Strength || !findings(own)[IssueWeak] {
+		t.Errorf("entry-derived strength %d vs %d, findings %+v", own.Strength, other.Strength, own.Findings)
+	}
+	if byID["1"].CrackTime == "" {
+		t.Error("crack time not reported")
+	}
+}
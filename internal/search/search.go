@@ -0,0 +1,374 @@
+// Package search is an in-memory full-text index over decrypted vault records.
+//
+// The index is meant to live only while the vault is unlocked: build it right
+// after Unlock and call Wipe on lock. Passwords and hidden custom fields are
+// never indexed, but titles, usernames, URLs, notes, tags, folders and visible
+// custom fields are, so the index is as sensitive as the decrypted entries.
+//
+// Queries are lists of words, each of which must match the entry. A word may
+// be qualified by a field ("user:alice url:github"); unqualified words match
+// any field. Matching tolerates typos and prefers exact words, then prefixes,
+// then near-misses; titles weigh more than notes, and recently changed entries
+// get a small boost.
+package search
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Field names a part of an entry that can be searched.
+type Field string
+
+const (
+	FieldTitle  Field = "title"
+	FieldUser   Field = "user"
+	FieldURL    Field = "url"
+	FieldNotes  Field = "notes"
+	FieldTag    Field = "tag"
+	FieldFolder Field = "folder"
+	FieldCustom Field = "field" // custom field names and visible values
+)
+
+// fieldAliases maps query qualifiers to fields.
+var fieldAliases = map[string]Field{
+	"title": FieldTitle, "t": FieldTitle, "name": FieldTitle,
+	"user": FieldUser, "username": FieldUser, "login": FieldUser, "u": FieldUser,
+	"url": FieldURL, "site": FieldURL, "domain": FieldURL, "host": FieldURL,
+	"notes": FieldNotes, "note": FieldNotes,
+	"tag": FieldTag, "tags": FieldTag,
+	"folder": FieldFolder, "dir": FieldFolder,
+	"field": FieldCustom, "fields": FieldCustom,
+}
+
+// fieldWeights scale a match by where it was found.
+var fieldWeights = map[Field]float64{
+	FieldTitle:  3,
+	FieldUser:   2,
+	FieldURL:    2,
+	FieldTag:    2,
+	FieldFolder: 1,
+	FieldNotes:  1,
+	FieldCustom: 1,
+}
+
+// Match quality of a query word against an indexed word, before field weight.
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.75 // plus up to 0.25 as the prefix nears the whole word
+	scoreTypo      = 0.6  // one edit; each further edit costs typoStep
+	typoStep       = 0.15
+	scoreSubstring = 0.45
+	scoreTypoPre   = 0.35 // a prefix with a typo in it
+
+	// recencyBoost multiplies the score of an entry changed just now; it
+	// decays with recencyHalfLife.
+	recencyBoost    = 0.25
+	recencyHalfLife = 90 * 24 * time.Hour
+)
+
+// Result is one matching entry.
+type Result struct {
+	ID       string
+	Title    string
+	Score    float64
+	Modified time.Time
+	Fields   []Field // where the query matched, in field order
+}
+
+type posting struct {
+	doc   int
+	field Field
+}
+
+type document struct {
+	id, title string
+	modified  time.Time
+}
+
+// Index is safe for concurrent use.
+type Index struct {
+	mu    sync.RWMutex
+	docs  []document
+	terms map[string][]posting
+	now   func() time.Time
+}
+
+// Build indexes recs.
+func Build(recs []pwmanager.Record) *Index {
+	ix := &Index{terms: map[string][]posting{}, now: time.Now}
+	for _, r := range recs {
+		ix.add(r)
+	}
+	return ix
+}
+
+func (ix *Index) add(r pwmanager.Record) {
+	d := len(ix.docs)
+	ix.docs = append(ix.docs, document{id: r.ID, title: r.Title, modified: r.ModifiedAt})
+	seen := map[posting]map[string]bool{}
+	put := func(f Field, text string) {
+		p := posting{doc: d, field: f}
+		if seen[p] == nil {
+			seen[p] = map[string]bool{}
+		}
+		for _, t := range tokenize(text) {
+			if !seen[p][t] {
+				seen[p][t] = true
+				ix.terms[t] = append(ix.terms[t], p)
+			}
+		}
+	}
+
+	put(FieldTitle, r.Title)
+	put(FieldUser, r.Username)
+	put(FieldURL, r.URL)
+	put(FieldNotes, r.Notes)
+	put(FieldFolder, r.Folder)
+	for _, tag := range r.Tags {
+		put(FieldTag, tag)
+	}
+	for _, f := range r.Fields {
+		put(FieldCustom, f.Name)
+		if !f.Hidden {
+			put(FieldCustom, f.Value)
+		}
+	}
+}
+
+// Len returns the number of indexed entries.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Wipe drops everything the index holds. Go strings cannot be overwritten, so
+// this releases the decrypted text to the garbage collector rather than
+// zeroing it; what matters is that no reference outlives the session.
+func (ix *Index) Wipe() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.docs = nil
+	ix.terms = map[string][]posting{}
+}
+
+// tokenize lowercases s and splits it into words of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// term is one word of a parsed query; fields is nil when unqualified.
+type term struct {
+	word   string
+	fields map[Field]bool
+}
+
+// parseQuery splits q into words. "field:value" restricts the words of value
+// to that field; value may be quoted to hold spaces. Unknown qualifiers are
+// searched as ordinary text, so "https://github.com" still works.
+func parseQuery(q string) []term {
+	var out []term
+	for _, tok := range splitQuery(q) {
+		var fields map[Field]bool
+		if name, value, ok := strings.Cut(tok, ":"); ok {
+			if f, known := fieldAliases[strings.ToLower(name)]; known {
+				fields = map[Field]bool{f: true}
+				tok = value
+			}
+		}
+		for _, w := range tokenize(strings.Trim(tok, `"`)) {
+			out = append(out, term{word: w, fields: fields})
+		}
+	}
+	return out
+}
+
+// splitQuery splits on spaces outside double quotes.
+func splitQuery(q string) []string {
+	var out []string
+	var cur strings.Builder
+	quoted := false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// Search returns the entries matching every word of query, best first.
+func (ix *Index) Search(query string) []Result {
+	terms := parseQuery(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	total := make([]float64, len(ix.docs))
+	matched := make([]map[Field]bool, len(ix.docs))
+	for i, t := range terms {
+		best := make(map[int]float64)
+		bestFields := make(map[int][]Field)
+		for word, postings := range ix.terms {
+			s := wordScore(t.word, word)
+			if s == 0 {
+				continue
+			}
+			for _, p := range postings {
+				if t.fields != nil && !t.fields[p.field] {
+					continue
+				}
+				ws := s * fieldWeights[p.field]
+				if ws > best[p.doc] {
+					best[p.doc] = ws
+				}
+				bestFields[p.doc] = append(bestFields[p.doc], p.field)
+			}
+		}
+		// every word must match: drop documents this word missed
+		for d := range total {
+			if i > 0 && total[d] == 0 {
+				continue
+			}
+			if best[d] == 0 {
+				total[d] = 0
+				continue
+			}
+			total[d] += best[d]
+			if matched[d] == nil {
+				matched[d] = map[Field]bool{}
+			}
+			for _, f := range bestFields[d] {
+				matched[d][f] = true
+			}
+		}
+	}
+
+	now := ix.now()
+	var out []Result
+	for d, score := range total {
+		if score == 0 {
+			continue
+		}
+		doc := ix.docs[d]
+		if !doc.modified.IsZero() {
+			age := max(now.Sub(doc.modified), 0)
+			score *= 1 + recencyBoost*math.Exp2(-float64(age)/float64(recencyHalfLife))
+		}
+		r := Result{ID: doc.id, Title: doc.title, Score: score, Modified: doc.modified}
+		for _, f := range []Field{FieldTitle, FieldUser, FieldURL, FieldTag, FieldFolder, FieldNotes, FieldCustom} {
+			if matched[d][f] {
+				r.Fields = append(r.Fields, f)
+			}
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if !out[i].Modified.Equal(out[j].Modified) {
+			return out[i].Modified.After(out[j].Modified)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// wordScore rates how well query word q matches indexed word w, 0 for no match.
+func wordScore(q, w string) float64 {
+	if q == w {
+		return scoreExact
+	}
+	qr, wr := []rune(q), []rune(w)
+	if strings.HasPrefix(w, q) {
+		return scorePrefix + (1-scorePrefix)*float64(len(qr))/float64(len(wr))
+	}
+	allowed := typoBudget(len(qr))
+	if allowed > 0 {
+		if d := editDistance(qr, wr, allowed); d <= allowed {
+			return scoreTypo - typoStep*float64(d-1)
+		}
+	}
+	if len(qr) >= 3 && strings.Contains(w, q) {
+		return scoreSubstring
+	}
+	if allowed > 0 && len(wr) > len(qr) && editDistance(qr, wr[:len(qr)], allowed) <= allowed {
+		return scoreTypoPre
+	}
+	return 0
+}
+
+// typoBudget is the number of edits tolerated in a word of n runes.
+func typoBudget(n int) int {
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// editDistance is the optimal-string-alignment distance between a and b
+// (insertions, deletions, substitutions and adjacent transpositions). It
+// returns limit+1 as soon as the distance is known to exceed limit.
+func editDistance(a, b []rune, limit int) int {
+	if abs(len(a)-len(b)) > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
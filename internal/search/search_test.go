@@ -0,0 +1,113 @@
+package search
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func record(id, title, user, url, notes string, age time.Duration) pwmanager.Record {
+	return pwmanager.Record{ID: id, Title: title, PlainEntry: pwmanager.PlainEntry{
+		Username: user, URL: url, Notes: notes, ModifiedAt: now.Add(-age),
+	}}
+}
+
+func testIndex() *Index {
+	day := 24 * time.Hour
+	recs := []pwmanager.Record{
+		record("gh", "GitHub", "alice", "https://github.com/login", "", 400*day),
+		record("gl", "GitLab", "bob", "https://gitlab.example.org", "", 2*day),
+		record("wifi", "Home router", "admin", "http://192.168.1.1", "WiFi passphrase on the fridge", 30*day),
+		record("bank", "Bank", "alice@example.com", "https://bank.example", "", 10*day),
+	}
+	recs[3].Tags = []string{"finance"}
+	recs[3].Fields = []pwmanager.CustomField{
+		{Name: "Security answer", Value: "Rexford", Hidden: true},
+		{Name: "Branch", Value: "Springfield"},
+	}
+	ix := Build(recs)
+	ix.now = func() time.Time { return now }
+	return ix
+}
+
+func ids(rs []Result) []string {
+	var out []string
+	for _, r := range rs {
+		out = append(out, r.ID)
+	}
+	return out
+}
+
+func TestSearch(t *testing.T) {
+	ix := testIndex()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"github", []string{"gh"}},
+		{"githbu", []string{"gh"}},        // transposition
+		{"gti", nil},                      // too short for typos
+		{"git", []string{"gl", "gh"}},     // prefix; the recent entry first
+		{"fridge", []string{"wifi"}},      // notes
+		{"alice", []string{"bank", "gh"}}, // equal matches: the recent entry first
+		{"user:alice url:github", []string{"gh"}},
+		{"url:alice", nil},
+		{"tag:finance", []string{"bank"}},
+		{"springfield", []string{"bank"}},
+		{"rexford", nil}, // hidden field values stay out of the index
+		{"https://github.com", []string{"gh"}},
+		{`notes:"wifi fridge"`, []string{"wifi"}},
+		{"alice nothing", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got := ids(ix.Search(tt.query))
+		if len(got) != len(tt.want) {
+			t.Errorf("%q = %v, want %v", tt.query, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q = %v, want %v", tt.query, got, tt.want)
+				break
+			}
+		}
+	}
+
+	r := ix.Search("user:alice github")[0]
+	if len(r.Fields) != 3 || r.Fields[0] != FieldTitle || r.Fields[1] != FieldUser || r.Fields[2] != FieldURL {
+		t.Errorf("matched fields = %v", r.Fields)
+	}
+}
+
+func TestRanking(t *testing.T) {
+	ix := testIndex()
+	exact := ix.Search("gitlab")
+	if len(exact) != 1 || exact[0].ID != "gl" {
+		t.Fatalf("gitlab = %v", ids(exact))
+	}
+	// exact beats prefix beats typo, at equal field and age
+	if !(wordScore("github", "github") > wordScore("gith", "github") &&
+		wordScore("gith", "github") > wordScore("githbu", "github")) {
+		t.Error("exact > prefix > typo ordering broken")
+	}
+	if wordScore("hub", "github") == 0 || wordScore("xyz", "github") != 0 {
+		t.Error("substring matching")
+	}
+	if d := editDistance([]rune("kitten"), []rune("sitting"), 5); d != 3 {
+		t.Errorf("editDistance = %d", d)
+	}
+}
+
+func TestWipe(t *testing.T) {
+	ix := testIndex()
+	if ix.Len() != 4 {
+		t.Fatalf("Len = %d", ix.Len())
+	}
+	ix.Wipe()
+	if ix.Len() != 0 || len(ix.Search("github")) != 0 {
+		t.Error("index still answers after Wipe")
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly || windows)
+
+package secret
+
+// alloc falls back to the Go heap where there is no portable way to lock
+// memory; the buffer is still wiped on Destroy.
+func alloc(n int) (region, data []byte, locked bool, err error) {
+	return nil, make([]byte, n), false, nil
+}
+
+func free(region []byte) {}
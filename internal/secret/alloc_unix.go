@@ -0,0 +1,38 @@
+//go:build linux || darwin || freebsd || openbsd || netbsd || dragonfly
+
+package secret
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// alloc maps n bytes between two inaccessible guard pages and locks them.
+// The secret ends exactly at the trailing guard page, so an overrun faults
+// instead of reading neighbouring memory.
+func alloc(n int) (region, data []byte, locked bool, err error) {
+	page := os.Getpagesize()
+	size := roundUp(n, page)
+	region, err = unix.Mmap(-1, 0, size+2*page, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if err := unix.Mprotect(region[:page], unix.PROT_NONE); err != nil {
+		unix.Munmap(region)
+		return nil, nil, false, err
+	}
+	if err := unix.Mprotect(region[page+size:], unix.PROT_NONE); err != nil {
+		unix.Munmap(region)
+		return nil, nil, false, err
+	}
+	inner := region[page : page+size]
+	locked = unix.Mlock(inner) == nil
+	dontDump(inner)
+	return region, inner[size-n:], locked, nil
+}
+
+// free unmaps region, which also unlocks it.
+func free(region []byte) {
+	unix.Munmap(region)
+}
@@ -0,0 +1,39 @@
+package secret
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// alloc reserves n bytes between two PAGE_NOACCESS guard pages and locks
+// them into the working set. The secret ends exactly at the trailing guard
+// page, so an overrun faults instead of reading neighbouring memory.
+func alloc(n int) (region, data []byte, locked bool, err error) {
+	page := os.Getpagesize()
+	size := roundUp(n, page)
+	total := size + 2*page
+	addr, err := windows.VirtualAlloc(0, uintptr(total), windows.MEM_RESERVE|windows.MEM_COMMIT, windows.PAGE_READWRITE)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	region = unsafe.Slice((*byte)(*(*unsafe.Pointer)(unsafe.Pointer(&addr))), total)
+	var old uint32
+	if err := windows.VirtualProtect(addr, uintptr(page), windows.PAGE_NOACCESS, &old); err != nil {
+		free(region)
+		return nil, nil, false, err
+	}
+	if err := windows.VirtualProtect(addr+uintptr(page+size), uintptr(page), windows.PAGE_NOACCESS, &old); err != nil {
+		free(region)
+		return nil, nil, false, err
+	}
+	locked = windows.VirtualLock(addr+uintptr(page), uintptr(size)) == nil
+	inner := region[page : page+size]
+	return region, inner[size-n:], locked, nil
+}
+
+// free releases region, which also unlocks it.
+func free(region []byte) {
+	windows.VirtualFree(uintptr(unsafe.Pointer(&region[0])), 0, windows.MEM_RELEASE)
+}
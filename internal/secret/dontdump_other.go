@@ -0,0 +1,6 @@
+//go:build darwin || freebsd || openbsd || netbsd || dragonfly
+
+package secret
+
+// dontDump is a no-op where madvise has no MADV_DONTDUMP.
+func dontDump(b []byte) {}
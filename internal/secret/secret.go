@@ -0,0 +1,167 @@
+// Package secret keeps key material in memory that is locked against
+// swapping, fenced by guard pages where the platform allows, and wiped when
+// the secret is destroyed.
+//
+// A Buffer never turns into a string by itself: fmt prints it redacted and
+// encoding/json refuses it, so the secret only leaves the buffer through an
+// explicit Bytes call. Anything derived from those bytes (a cipher's key
+// schedule, a decrypted plaintext) is ordinary Go memory again; keep it
+// short-lived and Wipe it when done.
+package secret
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+// ErrDestroyed is returned when copying a buffer that was already destroyed.
+var ErrDestroyed = errors.New("secret: buffer destroyed")
+
+// Buffer is a fixed-size secret. Its methods are safe for concurrent use,
+// but a slice returned by Bytes must not be used after Destroy.
+//
+// Nothing destroys a buffer but Destroy: there is no finalizer, because the
+// Go runtime may collect a buffer while a slice from Bytes is still in use,
+// and unmapping the pages under it would crash the program. A buffer that is
+// dropped without Destroy keeps its locked pages until the process exits.
+type Buffer struct {
+	mu     sync.Mutex
+	data   []byte // the secret itself, inside region
+	region []byte // the whole allocation with guard pages; nil on the heap fallback
+	locked bool   // data is locked in RAM
+}
+
+// New allocates a zeroed n-byte buffer. When the memory cannot be locked (a
+// low RLIMIT_MEMLOCK, say) the buffer still works and Locked reports false.
+func New(n int) (*Buffer, error) {
+	if n < 0 {
+		return nil, errors.New("secret: negative size")
+	}
+	b := &Buffer{}
+	if n > 0 {
+		region, data, locked, err := alloc(n)
+		if err != nil {
+			return nil, err
+		}
+		b.region, b.data, b.locked = region, data, locked
+	}
+	return b, nil
+}
+
+// FromBytes moves src into a new buffer and wipes src.
+func FromBytes(src []byte) (*Buffer, error) {
+	defer Wipe(src)
+	b, err := New(len(src))
+	if err != nil {
+		return nil, err
+	}
+	copy(b.data, src)
+	return b, nil
+}
+
+// Random returns a buffer of n random bytes.
+func Random(n int) (*Buffer, error) {
+	b, err := New(n)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(b.data); err != nil {
+		b.Destroy()
+		return nil, err
+	}
+	return b, nil
+}
+
+// Bytes returns the secret in place; nil once the buffer is destroyed. The
+// slice is valid only until Destroy, which wipes and unmaps it.
+func (b *Buffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.data
+}
+
+// Len returns the size of the secret, 0 once destroyed.
+func (b *Buffer) Len() int {
+	return len(b.Bytes())
+}
+
+// Alive reports whether b holds a secret that has not been destroyed.
+func (b *Buffer) Alive() bool {
+	return b.Len() > 0
+}
+
+// Locked reports whether the secret is locked in RAM.
+func (b *Buffer) Locked() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.locked
+}
+
+// Clone copies the secret into a new buffer.
+func (b *Buffer) Clone() (*Buffer, error) {
+	data := b.Bytes()
+	if data == nil {
+		return nil, ErrDestroyed
+	}
+	c, err := New(len(data))
+	if err != nil {
+		return nil, err
+	}
+	copy(c.data, data)
+	return c, nil
+}
+
+// Equal compares two secrets in constant time.
+func (b *Buffer) Equal(o *Buffer) bool {
+	x, y := b.Bytes(), o.Bytes()
+	return x != nil && y != nil && subtle.ConstantTimeCompare(x, y) == 1
+}
+
+// Destroy wipes the secret and releases its memory. It is safe to call more
+// than once and on a nil buffer.
+func (b *Buffer) Destroy() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.data == nil {
+		return
+	}
+	Wipe(b.data)
+	if b.region != nil {
+		free(b.region)
+	}
+	b.data, b.region, b.locked = nil, nil, false
+}
+
+// String keeps the secret out of logs and error messages.
+func (b *Buffer) String() string { return "[secret]" }
+
+// GoString keeps the secret out of %#v.
+func (b *Buffer) GoString() string { return "secret.Buffer{[secret]}" }
+
+// MarshalJSON refuses to serialize the secret.
+func (b *Buffer) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("secret: refusing to marshal a secret buffer")
+}
+
+// Wipe zeroes b; use it on temporary copies of secret data.
+func Wipe(b []byte) {
+	clear(b)
+	runtime.KeepAlive(b)
+}
+
+// roundUp rounds n up to a multiple of page.
+func roundUp(n, page int) int {
+	return (n + page - 1) / page * page
+}
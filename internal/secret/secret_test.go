@@ -0,0 +1,105 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+func TestBuffer(t *testing.T) {
+	src := []byte("correct horse battery staple")
+	want := bytes.Clone(src)
+	b, err := FromBytes(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) || b.Len() != len(want) || !b.Alive() {
+		t.Fatalf("Bytes = %q", b.Bytes())
+	}
+	if !bytes.Equal(src, make([]byte, len(src))) {
+		t.Error("FromBytes left the source unwiped")
+	}
+	t.Logf("locked in RAM: %v", b.Locked())
+
+	c, err := b.Clone()
+	if err != nil || !c.Equal(b) {
+		t.Fatalf("Clone = %v, %v", c, err)
+	}
+	c.Bytes()[0] ^= 1
+	if c.Equal(b) {
+		t.Error("Equal ignores content")
+	}
+
+	view := b.Bytes()
+	b.Destroy()
+	b.Destroy() // idempotent
+	if b.Bytes() != nil || b.Alive() || b.Equal(b) {
+		t.Error("destroyed buffer still answers")
+	}
+	if _, err := b.Clone(); err != ErrDestroyed {
+		t.Errorf("Clone after Destroy = %v", err)
+	}
+	_ = view // the mapping is gone; touching view now would fault, which is the point
+
+	var nilBuf *Buffer
+	nilBuf.Destroy()
+	if nilBuf.Bytes() != nil || nilBuf.Locked() {
+		t.Error("nil buffer")
+	}
+}
+
+func TestBytesOutliveBuffer(t *testing.T) {
+	// the buffer is unreachable once Bytes returns, but its pages must stay
+	// mapped: only Destroy releases them
+	b, err := Random(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view := b.Bytes()
+	want := bytes.Clone(view)
+	for range 3 {
+		runtime.GC()
+	}
+	if !bytes.Equal(view, want) {
+		t.Error("secret changed after the buffer was collected")
+	}
+}
+
+func TestNoImplicitString(t *testing.T) {
+	b, err := FromBytes([]byte("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Destroy()
+	for _, format := range []string{"%v", "%s", "%+v", "%#v", "%x", "%q"} {
+		if out := fmt.Sprintf(format, b); bytes.Contains([]byte(out), []byte("hunter2")) ||
+			bytes.Contains([]byte(out), []byte("68756e74657232")) {
+			t.Errorf("%s leaks the secret: %s", format, out)
+		}
+	}
+	if _, err := json.Marshal(struct{ Key *Buffer }{b}); err == nil {
+		t.Error("json.Marshal accepted a secret")
+	}
+}
+
+func TestSizes(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 4096, 4097} {
+		b, err := Random(n)
+		if err != nil {
+			t.Fatalf("Random(%d): %v", n, err)
+		}
+		if b.Len() != n {
+			t.Errorf("Random(%d).Len() = %d", n, b.Len())
+		}
+		// the whole secret is writable up to its last byte
+		for i := range b.Bytes() {
+			b.Bytes()[i] = 0xAA
+		}
+		b.Destroy()
+	}
+	if _, err := New(-1); err == nil {
+		t.Error("negative size accepted")
+	}
+}
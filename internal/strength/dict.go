@@ -0,0 +1,101 @@
+package strength
+
+import (
+	"embed"
+	"net/url"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// The frequency lists come from zxcvbn (MIT licence, Dropbox Inc.): one
+// lowercase word per line, most frequent first. english.txt and surnames.txt
+// are truncated to their most frequent entries to keep the binary small.
+//
+//go:embed data/*.txt
+var dataFS embed.FS
+
+// Dictionary names as reported in Match.Dictionary.
+const (
+	DictPasswords   = "passwords"
+	DictEnglish     = "english"
+	DictFemaleNames = "female_names"
+	DictMaleNames   = "male_names"
+	DictSurnames    = "surnames"
+	DictUserInputs  = "user_inputs"
+)
+
+// dictOrder fixes the order dictionaries are searched in, so that equal-cost
+// matches are always resolved the same way.
+var dictOrder = []string{DictUserInputs, DictPasswords, DictEnglish, DictFemaleNames, DictMaleNames, DictSurnames}
+
+// rankedDict maps a lowercase word to its 1-based frequency rank.
+type rankedDict map[string]int
+
+var (
+	dictsOnce  sync.Once
+	dicts      map[string]rankedDict
+	maxWordLen int // longest word in any built-in dictionary, in runes
+)
+
+func loadDicts() {
+	dicts = map[string]rankedDict{}
+	for _, name := range dictOrder[1:] {
+		data, err := dataFS.ReadFile("data/" + name + ".txt")
+		if err != nil {
+			panic("strength: missing embedded list " + name)
+		}
+		d := rankedDict{}
+		for _, w := range strings.Fields(string(data)) {
+			if _, dup := d[w]; !dup {
+				d[w] = len(d) + 1
+			}
+			maxWordLen = max(maxWordLen, len([]rune(w)))
+		}
+		dicts[name] = d
+	}
+}
+
+// builtinDicts returns the embedded dictionaries, parsing them on first use.
+func builtinDicts() map[string]rankedDict {
+	dictsOnce.Do(loadDicts)
+	return dicts
+}
+
+// skipInputs are fragments of user inputs that carry no information about the
+// entry (URL schemes, common hosts and TLDs) and would only add noise.
+var skipInputs = map[string]bool{
+	"http": true, "https": true, "www": true, "com": true, "org": true, "net": true,
+	"io": true, "co": true, "uk": true, "de": true, "login": true, "signin": true,
+}
+
+// userInputDict builds the user_inputs dictionary. Each input is ranked in
+// order, followed by its alphanumeric parts run together and then its parts of
+// three or more characters on their own, so "Acme Corp" also yields "acmecorp",
+// "acme" and "corp". URLs contribute their host name rather than the full string.
+func userInputDict(inputs []string) rankedDict {
+	d := rankedDict{}
+	add := func(w string) {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if len([]rune(w)) < 3 || skipInputs[w] {
+			return
+		}
+		if _, dup := d[w]; !dup {
+			d[w] = len(d) + 1
+		}
+	}
+	for _, in := range inputs {
+		if u, err := url.Parse(in); err == nil && u.Host != "" {
+			in = u.Hostname()
+		}
+		add(in)
+		parts := strings.FieldsFunc(in, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		add(strings.Join(parts, ""))
+		for _, part := range parts {
+			add(part)
+		}
+	}
+	return d
+}
@@ -0,0 +1,101 @@
+package strength
+
+import (
+	"strings"
+	"unicode"
+)
+
+const extraWords = "Add another word or two. Uncommon words are better."
+
+// feedback explains the weakest part of a password scoring 2 or less.
+func feedback(score int, seq []*Match) Feedback {
+	if len(seq) == 0 {
+		return Feedback{Suggestions: []string{
+			"Use a few words, avoid common phrases",
+			"No need for symbols, digits, or uppercase letters",
+		}}
+	}
+	if score > 2 {
+		return Feedback{}
+	}
+
+	longest := seq[0]
+	for _, m := range seq[1:] {
+		if len([]rune(m.Token)) > len([]rune(longest.Token)) {
+			longest = m
+		}
+	}
+	f := matchFeedback(longest, len(seq) == 1)
+	f.Suggestions = append([]string{extraWords}, f.Suggestions...)
+	return f
+}
+
+func matchFeedback(m *Match, sole bool) Feedback {
+	switch m.Pattern {
+	case PatternDictionary:
+		return dictionaryFeedback(m, sole)
+	case PatternSpatial:
+		w := "Short keyboard patterns are easy to guess"
+		if m.Turns == 1 {
+			w = "Straight rows of keys are easy to guess"
+		}
+		return Feedback{w, []string{"Use a longer keyboard pattern with more turns"}}
+	case PatternRepeat:
+		w := "Repeating a pattern is only slightly harder to guess than using it once"
+		if len([]rune(m.BaseToken)) == 1 {
+			w = "Repeated characters are easy to guess"
+		}
+		return Feedback{w, []string{"Avoid repeated words and characters"}}
+	case PatternSequence:
+		return Feedback{"Alphabet and number sequences are easy to guess", []string{"Avoid sequences"}}
+	case PatternYear:
+		return Feedback{"Recent years are easy to guess", []string{"Avoid recent years", "Avoid years that are associated with you"}}
+	case PatternDate:
+		return Feedback{"Dates are often easy to guess", []string{"Avoid dates and years that are associated with you"}}
+	}
+	return Feedback{}
+}
+
+func dictionaryFeedback(m *Match, sole bool) Feedback {
+	var f Feedback
+	switch m.Dictionary {
+	case DictUserInputs:
+		f.Warning = "This is based on the entry's own title, username or site"
+		f.Suggestions = append(f.Suggestions, "Don't build the password from the site name or your username")
+	case DictPasswords:
+		switch {
+		case sole && !m.L33t && !m.Reversed && m.Rank <= 10:
+			f.Warning = "This is a top-10 common password"
+		case sole && !m.L33t && !m.Reversed && m.Rank <= 100:
+			f.Warning = "This is a top-100 common password"
+		case sole && !m.L33t && !m.Reversed:
+			f.Warning = "This is a very common password"
+		case m.Guesses <= 1e4:
+			f.Warning = "This is similar to a commonly used password"
+		}
+	case DictEnglish:
+		if sole {
+			f.Warning = "A word by itself is easy to guess"
+		}
+	case DictFemaleNames, DictMaleNames, DictSurnames:
+		f.Warning = "Common names and surnames are easy to guess"
+		if sole {
+			f.Warning = "Names and surnames by themselves are easy to guess"
+		}
+	}
+
+	r := []rune(m.Token)
+	switch {
+	case unicode.IsUpper(r[0]) && strings.ToLower(string(r[1:])) == string(r[1:]):
+		f.Suggestions = append(f.Suggestions, "Capitalization doesn't help very much")
+	case strings.ToUpper(m.Token) == m.Token && strings.ToLower(m.Token) != m.Token:
+		f.Suggestions = append(f.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+	}
+	if m.Reversed && len(r) >= 4 {
+		f.Suggestions = append(f.Suggestions, "Reversed words aren't much harder to guess")
+	}
+	if m.L33t {
+		f.Suggestions = append(f.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+	return f
+}
@@ -0,0 +1,101 @@
+package strength
+
+import "strings"
+
+// Keyboard layouts, one row per line. Keys are one or two characters (unshifted
+// then shifted) separated by single spaces; on the slanted layouts each row is
+// indented one column further than a straight grid would place it.
+var (
+	qwertyRows = []string{
+		"`~ 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+",
+		"    qQ wW eE rR tT yY uU iI oO pP [{ ]} \\|",
+		"     aA sS dD fF gG hH jJ kK lL ;: '\"",
+		"      zZ xX cC vV bB nN mM ,< .> /?",
+	}
+	dvorakRows = []string{
+		"`~ 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}",
+		"    '\" ,< .> pP yY fF gG cC rR lL /? =+ \\|",
+		"     aA oO eE uU iI dD hH tT nN sS -_",
+		"      ;: qQ jJ kK xX bB mM wW vV zZ",
+	}
+	keypadRows = []string{
+		"  / * -",
+		"7 8 9 +",
+		"4 5 6",
+		"1 2 3",
+		"  0 .",
+	}
+	macKeypadRows = []string{
+		"  = / *",
+		"7 8 9 -",
+		"4 5 6 +",
+		"1 2 3",
+		"  0 .",
+	}
+)
+
+// adjacencyGraph maps a character to its neighbouring keys, one slot per
+// direction (nil where there is no key). Slot order is fixed per layout so a
+// change of slot between two steps counts as a turn.
+type adjacencyGraph map[rune][]string
+
+// keyboard is a layout together with the statistics spatial scoring needs.
+type keyboard struct {
+	name      string
+	graph     adjacencyGraph
+	shifted   bool    // layout has shifted characters (counted by ShiftedCount)
+	starts    float64 // number of keys
+	avgDegree float64 // mean number of neighbours per key
+}
+
+var keyboards = []*keyboard{
+	newKeyboard("qwerty", qwertyRows, true),
+	newKeyboard("dvorak", dvorakRows, true),
+	newKeyboard("keypad", keypadRows, false),
+	newKeyboard("mac_keypad", macKeypadRows, false),
+}
+
+// newKeyboard builds the adjacency graph for rows. Slanted layouts (full
+// keyboards) have six neighbours per key, aligned ones (keypads) eight.
+func newKeyboard(name string, rows []string, slanted bool) *keyboard {
+	type pos struct{ x, y int }
+	unit := len(strings.Fields(rows[0])[0]) + 1
+	keys := map[pos]string{}
+	for y, row := range rows {
+		slant := 0
+		if slanted {
+			slant = y
+		}
+		col := 0
+		for _, tok := range strings.Fields(row) {
+			idx := strings.Index(row[col:], tok) + col
+			keys[pos{(idx - slant) / unit, y}] = tok
+			col = idx + len(tok)
+		}
+	}
+
+	neighbours := func(x, y int) []pos {
+		if slanted {
+			return []pos{{x - 1, y}, {x, y - 1}, {x + 1, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y + 1}}
+		}
+		return []pos{{x - 1, y}, {x - 1, y - 1}, {x, y - 1}, {x + 1, y - 1}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}, {x - 1, y + 1}}
+	}
+
+	kb := &keyboard{name: name, graph: adjacencyGraph{}, shifted: slanted}
+	degree := 0
+	for p, tok := range keys {
+		adj := make([]string, 0, 8)
+		for _, n := range neighbours(p.x, p.y) {
+			adj = append(adj, keys[n])
+			if keys[n] != "" {
+				degree++
+			}
+		}
+		for _, c := range tok {
+			kb.graph[c] = adj
+		}
+	}
+	kb.starts = float64(len(kb.graph))
+	kb.avgDegree = float64(degree) / float64(len(keys))
+	return kb
+}
@@ -0,0 +1,609 @@
+package strength
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Pattern names as reported in Match.Pattern.
+const (
+	PatternDictionary = "dictionary"
+	PatternSpatial    = "spatial"
+	PatternRepeat     = "repeat"
+	PatternSequence   = "sequence"
+	PatternYear       = "year"
+	PatternDate       = "date"
+	PatternBruteforce = "bruteforce"
+)
+
+// Match is one recognised piece of a password. I and J are inclusive rune
+// offsets. Only the fields relevant to Pattern are set.
+type Match struct {
+	Pattern string
+	I, J    int
+	Token   string
+	Guesses float64
+
+	// dictionary
+	Word       string        // matched dictionary word (lowercase, unsubstituted)
+	Rank       int           // 1-based frequency rank
+	Dictionary string        // Dict* name
+	Reversed   bool          // token is the word backwards
+	L33t       bool          // token uses substitutions such as 4 for a
+	Subs       map[rune]rune // substituted character -> original letter
+
+	// spatial
+	Keyboard     string
+	Turns        int
+	ShiftedCount int
+
+	// repeat
+	BaseToken   string
+	BaseGuesses float64
+	RepeatCount int
+
+	// sequence
+	SequenceSpace int
+	Ascending     bool
+
+	// date (Year is also set for PatternYear)
+	Separator        string
+	Year, Month, Day int
+}
+
+// matcher finds every pattern in one password.
+type matcher struct {
+	runes []rune
+	lower []rune
+	dicts map[string]rankedDict
+	year  int // reference year for dates
+}
+
+func newMatcher(password string, userInputs []string, year int) *matcher {
+	m := &matcher{runes: []rune(password), lower: []rune(strings.ToLower(password)), year: year}
+	m.dicts = builtinDicts()
+	if ui := userInputDict(userInputs); len(ui) > 0 {
+		all := make(map[string]rankedDict, len(m.dicts)+1)
+		for k, v := range m.dicts {
+			all[k] = v
+		}
+		all[DictUserInputs] = ui
+		m.dicts = all
+	}
+	return m
+}
+
+// omnimatch returns all matches, sorted by position.
+func (m *matcher) omnimatch() []*Match {
+	var out []*Match
+	out = append(out, m.dictionaryMatch(m.lower, m.runes)...)
+	out = append(out, m.reverseDictionaryMatch()...)
+	out = append(out, m.l33tMatch()...)
+	out = append(out, m.spatialMatch()...)
+	out = append(out, m.repeatMatch()...)
+	out = append(out, m.sequenceMatch()...)
+	out = append(out, m.yearMatch()...)
+	out = append(out, m.dateMatch()...)
+	sortMatches(out)
+	return out
+}
+
+func sortMatches(ms []*Match) {
+	sort.SliceStable(ms, func(a, b int) bool {
+		if ms[a].I != ms[b].I {
+			return ms[a].I < ms[b].I
+		}
+		return ms[a].J < ms[b].J
+	})
+}
+
+// dictionaryMatch finds every substring of lower that is a dictionary word.
+// token supplies the original characters at the same offsets.
+func (m *matcher) dictionaryMatch(lower, token []rune) []*Match {
+	var out []*Match
+	longest := maxWordLen
+	if ui, ok := m.dicts[DictUserInputs]; ok {
+		for w := range ui {
+			longest = max(longest, len([]rune(w)))
+		}
+	}
+	for _, name := range dictOrder {
+		dict, ok := m.dicts[name]
+		if !ok {
+			continue
+		}
+		for i := range lower {
+			for j := i; j < len(lower) && j-i < longest; j++ {
+				word := string(lower[i : j+1])
+				if rank, ok := dict[word]; ok {
+					out = append(out, &Match{
+						Pattern: PatternDictionary, I: i, J: j, Token: string(token[i : j+1]),
+						Word: word, Rank: rank, Dictionary: name,
+					})
+				}
+			}
+		}
+	}
+	return out
+}
+
+func reverseRunes(r []rune) []rune {
+	out := make([]rune, len(r))
+	for i, c := range r {
+		out[len(r)-1-i] = c
+	}
+	return out
+}
+
+// reverseDictionaryMatch finds dictionary words spelled backwards.
+func (m *matcher) reverseDictionaryMatch() []*Match {
+	n := len(m.runes)
+	out := m.dictionaryMatch(reverseRunes(m.lower), reverseRunes(m.runes))
+	for _, mt := range out {
+		mt.Token = string(reverseRunes([]rune(mt.Token)))
+		mt.Reversed = true
+		mt.I, mt.J = n-1-mt.J, n-1-mt.I
+	}
+	return out
+}
+
+// l33tTable lists the common substitutions for each letter.
+var l33tTable = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
+
+// l33tSubs enumerates the ways the substitution characters present in the
+// password can be read back as letters ('1' may stand for i or l, for example).
+func (m *matcher) l33tSubs() []map[rune]rune {
+	present := map[rune]bool{}
+	for _, c := range m.lower {
+		present[c] = true
+	}
+	candidates := map[rune][]rune{} // substitution char -> letters it may stand for
+	var chars []rune
+	for letter, subs := range l33tTable {
+		for _, s := range subs {
+			if present[s] {
+				if candidates[s] == nil {
+					chars = append(chars, s)
+				}
+				candidates[s] = append(candidates[s], letter)
+			}
+		}
+	}
+	sort.Slice(chars, func(a, b int) bool { return chars[a] < chars[b] })
+
+	out := []map[rune]rune{{}}
+	for _, s := range chars {
+		letters := candidates[s]
+		sort.Slice(letters, func(a, b int) bool { return letters[a] < letters[b] })
+		var next []map[rune]rune
+		for _, sub := range out {
+			for _, l := range letters {
+				cp := make(map[rune]rune, len(sub)+1)
+				for k, v := range sub {
+					cp[k] = v
+				}
+				cp[s] = l
+				next = append(next, cp)
+			}
+		}
+		out = next
+	}
+	return out
+}
+
+// l33tMatch finds dictionary words written with substitutions, such as p4ssw0rd.
+func (m *matcher) l33tMatch() []*Match {
+	var out []*Match
+	seen := map[string]bool{}
+	for _, sub := range m.l33tSubs() {
+		if len(sub) == 0 {
+			break
+		}
+		subbed := make([]rune, len(m.lower))
+		for i, c := range m.lower {
+			if l, ok := sub[c]; ok {
+				c = l
+			}
+			subbed[i] = c
+		}
+		for _, mt := range m.dictionaryMatch(subbed, m.runes) {
+			if mt.J == mt.I || strings.ToLower(mt.Token) == mt.Word {
+				continue // single characters, or no substitution actually used
+			}
+			key := mt.Dictionary + "\x00" + mt.Word + "\x00" + strconv.Itoa(mt.I) + "\x00" + strconv.Itoa(mt.J)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			mt.L33t = true
+			mt.Subs = map[rune]rune{}
+			for _, c := range strings.ToLower(mt.Token) {
+				if l, ok := sub[c]; ok {
+					mt.Subs[c] = l
+				}
+			}
+			out = append(out, mt)
+		}
+	}
+	return out
+}
+
+// spatialMatch finds runs of three or more adjacent keys on each keyboard.
+func (m *matcher) spatialMatch() []*Match {
+	var out []*Match
+	for _, kb := range keyboards {
+		out = append(out, m.spatialMatchOn(kb)...)
+	}
+	return out
+}
+
+func isShifted(c rune) bool {
+	return unicode.IsUpper(c) || strings.ContainsRune("~!@#$%^&*()_+{}|:\"<>?", c)
+}
+
+func (m *matcher) spatialMatchOn(kb *keyboard) []*Match {
+	var out []*Match
+	pw := m.runes
+	for i := 0; i < len(pw)-1; {
+		j := i + 1
+		lastDir, turns, shifted := -1, 0, 0
+		if kb.shifted && isShifted(pw[i]) {
+			shifted = 1
+		}
+		for {
+			found := false
+			if j < len(pw) {
+				for dir, adj := range kb.graph[pw[j-1]] {
+					idx := strings.IndexRune(adj, pw[j])
+					if adj == "" || idx < 0 {
+						continue
+					}
+					found = true
+					if idx > 0 {
+						shifted++
+					}
+					if dir != lastDir {
+						turns++
+						lastDir = dir
+					}
+					break
+				}
+			}
+			if found {
+				j++
+				continue
+			}
+			if j-i > 2 {
+				out = append(out, &Match{
+					Pattern: PatternSpatial, I: i, J: j - 1, Token: string(pw[i:j]),
+					Keyboard: kb.name, Turns: turns, ShiftedCount: shifted,
+				})
+			}
+			i = j
+			break
+		}
+	}
+	return out
+}
+
+// period returns the length of the shortest unit that s[i:] repeats at least
+// twice from i, searching unit lengths in ascending (lazy) or descending
+// (greedy) order, together with how many copies follow each other.
+func period(s []rune, i int, greedy bool) (unit, count int) {
+	maxUnit := (len(s) - i) / 2
+	for k := 1; k <= maxUnit; k++ {
+		p := k
+		if greedy {
+			p = maxUnit + 1 - k
+		}
+		n := 1
+		for i+(n+1)*p <= len(s) && equalRunes(s[i:i+p], s[i+n*p:i+(n+1)*p]) {
+			n++
+		}
+		if n > 1 {
+			return p, n
+		}
+	}
+	return 0, 0
+}
+
+// smallestTile returns the shortest prefix of s that s is made of repeats of;
+// the greedy run "abababab" is four copies of "ab", not two of "abab".
+func smallestTile(s []rune) []rune {
+	for u := 1; u <= len(s)/2; u++ {
+		if len(s)%u != 0 {
+			continue
+		}
+		tiled := true
+		for k := u; k < len(s) && tiled; k += u {
+			tiled = equalRunes(s[:u], s[k:k+u])
+		}
+		if tiled {
+			return s[:u]
+		}
+	}
+	return s
+}
+
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// repeatMatch finds runs of a repeated unit, such as "aaa" or "abcabcabc".
+// The repeated unit is itself estimated, so "passwordpassword" costs little
+// more than "password".
+func (m *matcher) repeatMatch() []*Match {
+	var out []*Match
+	pw := m.runes
+	for i := 0; i < len(pw); {
+		lazyUnit, lazyCount := period(pw, i, false)
+		if lazyUnit == 0 {
+			i++
+			continue
+		}
+		greedyUnit, greedyCount := period(pw, i, true)
+		span, base := lazyUnit*lazyCount, pw[i:i+lazyUnit]
+		if g := greedyUnit * greedyCount; g > span {
+			span = g
+			base = smallestTile(pw[i : i+span])
+		}
+		baseToken := string(base)
+		sub := newMatcher(baseToken, nil, m.year)
+		sub.dicts = m.dicts
+		baseGuesses, _ := mostGuessable(len(base), sub.omnimatch(), m.year)
+		out = append(out, &Match{
+			Pattern: PatternRepeat, I: i, J: i + span - 1, Token: string(pw[i : i+span]),
+			BaseToken: baseToken, BaseGuesses: baseGuesses, RepeatCount: span / len(base),
+		})
+		i += span
+	}
+	return out
+}
+
+// maxSequenceDelta is the largest step between characters that still counts
+// as a sequence ("aceg" steps by 2, "zyx" by -1).
+const maxSequenceDelta = 5
+
+// sequenceMatch finds runs with a constant step between code points, such as
+// "abcd", "9753" or "ZYX".
+func (m *matcher) sequenceMatch() []*Match {
+	pw := m.runes
+	if len(pw) < 2 {
+		return nil
+	}
+	var out []*Match
+	add := func(i, j, delta int) {
+		if j-i <= 1 && abs(delta) != 1 {
+			return
+		}
+		if abs(delta) == 0 || abs(delta) > maxSequenceDelta {
+			return
+		}
+		tok := string(pw[i : j+1])
+		space := 26
+		switch {
+		case allIn(tok, unicode.IsLower):
+		case allIn(tok, unicode.IsUpper):
+		case allIn(tok, unicode.IsDigit):
+			space = 10
+		}
+		out = append(out, &Match{
+			Pattern: PatternSequence, I: i, J: j, Token: tok,
+			SequenceSpace: space, Ascending: delta > 0,
+		})
+	}
+	i, last := 0, int(pw[1]-pw[0])
+	for k := 1; k < len(pw); k++ {
+		delta := int(pw[k] - pw[k-1])
+		if delta == last {
+			continue
+		}
+		add(i, k-1, last)
+		i, last = k-1, delta
+	}
+	add(i, len(pw)-1, last)
+	return out
+}
+
+func allIn(s string, f func(rune) bool) bool {
+	for _, c := range s {
+		if !f(c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+var yearRE = regexp.MustCompile(`19\d\d|20\d\d`)
+
+// yearMatch finds four-digit years from 1900 to 2099.
+func (m *matcher) yearMatch() []*Match {
+	var out []*Match
+	s := string(m.runes)
+	for _, loc := range yearRE.FindAllStringIndex(s, -1) {
+		i := len([]rune(s[:loc[0]]))
+		tok := s[loc[0]:loc[1]]
+		y, _ := strconv.Atoi(tok)
+		out = append(out, &Match{Pattern: PatternYear, I: i, J: i + 3, Token: tok, Year: y})
+	}
+	return out
+}
+
+const (
+	dateMinYear = 1000
+	dateMaxYear = 2050
+)
+
+// dateSplits lists, per length of a separator-free digit run, where to cut it
+// into three numbers: "1191" may be 1/1/91 or 11/9/1.
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},
+	5: {{1, 3}, {2, 3}},
+	6: {{1, 2}, {2, 4}, {4, 5}},
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}},
+}
+
+var dateSepRE = regexp.MustCompile(`^(\d{1,4})([\s/\\_.-])(\d{1,2})([\s/\\_.-])(\d{1,4})$`)
+
+type dmy struct{ day, month, year int }
+
+// dateMatch finds dates with or without separators, in any day/month/year order.
+func (m *matcher) dateMatch() []*Match {
+	var out []*Match
+	pw := m.runes
+
+	// without separators: 4 ("1191") to 8 ("11111991") digits
+	for i := 0; i+4 <= len(pw); i++ {
+		for j := i + 3; j <= i+7 && j < len(pw); j++ {
+			tok := string(pw[i : j+1])
+			if !allIn(tok, isASCIIDigit) {
+				continue
+			}
+			var best *dmy
+			for _, split := range dateSplits[len(tok)] {
+				a, _ := strconv.Atoi(tok[:split[0]])
+				b, _ := strconv.Atoi(tok[split[0]:split[1]])
+				c, _ := strconv.Atoi(tok[split[1]:])
+				if d := mapToDate(a, b, c); d != nil {
+					if best == nil || abs(d.year-m.year) < abs(best.year-m.year) {
+						best = d
+					}
+				}
+			}
+			if best != nil {
+				out = append(out, &Match{Pattern: PatternDate, I: i, J: j, Token: tok,
+					Year: best.year, Month: best.month, Day: best.day})
+			}
+		}
+	}
+
+	// with separators: 6 ("1/1/91") to 10 ("11/11/1991") characters
+	for i := 0; i+6 <= len(pw); i++ {
+		for j := i + 5; j <= i+9 && j < len(pw); j++ {
+			tok := string(pw[i : j+1])
+			g := dateSepRE.FindStringSubmatch(tok)
+			if g == nil || g[2] != g[4] {
+				continue
+			}
+			a, _ := strconv.Atoi(g[1])
+			b, _ := strconv.Atoi(g[3])
+			c, _ := strconv.Atoi(g[5])
+			if d := mapToDate(a, b, c); d != nil {
+				out = append(out, &Match{Pattern: PatternDate, I: i, J: j, Token: tok,
+					Separator: g[2], Year: d.year, Month: d.month, Day: d.day})
+			}
+		}
+	}
+
+	// drop dates contained in a longer date
+	var kept []*Match
+	for _, a := range out {
+		sub := false
+		for _, b := range out {
+			if a != b && b.I <= a.I && b.J >= a.J {
+				sub = true
+				break
+			}
+		}
+		if !sub {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+func isASCIIDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+// mapToDate interprets three numbers as a date, or returns nil when no
+// reading is plausible. The middle number is always the day or the month.
+func mapToDate(a, b, c int) *dmy {
+	if b > 31 || b <= 0 {
+		return nil
+	}
+	over12, over31, under1 := 0, 0, 0
+	for _, n := range []int{a, b, c} {
+		if (n > 99 && n < dateMinYear) || n > dateMaxYear {
+			return nil
+		}
+		if n > 31 {
+			over31++
+		}
+		if n > 12 {
+			over12++
+		}
+		if n <= 0 {
+			under1++
+		}
+	}
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return nil
+	}
+
+	splits := [][3]int{{c, a, b}, {a, b, c}} // year, then the other two
+	for _, s := range splits {
+		if s[0] >= dateMinYear && s[0] <= dateMaxYear {
+			if day, month, ok := mapToDayMonth(s[1], s[2]); ok {
+				return &dmy{day, month, s[0]}
+			}
+			return nil // a four-digit year with no valid day and month is not a date
+		}
+	}
+	for _, s := range splits {
+		if day, month, ok := mapToDayMonth(s[1], s[2]); ok {
+			return &dmy{day, month, twoToFourDigitYear(s[0])}
+		}
+	}
+	return nil
+}
+
+func mapToDayMonth(a, b int) (day, month int, ok bool) {
+	for _, p := range [][2]int{{a, b}, {b, a}} {
+		if p[0] >= 1 && p[0] <= 31 && p[1] >= 1 && p[1] <= 12 {
+			return p[0], p[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+func twoToFourDigitYear(y int) int {
+	switch {
+	case y > 99:
+		return y
+	case y > 50:
+		return y + 1900
+	default:
+		return y + 2000
+	}
+}
@@ -0,0 +1,293 @@
+package strength
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	bruteforceCardinality    = 10
+	minGuessesBeforeGrowing  = 10000 // cost of each additional match in a sequence
+	minSubmatchGuessesSingle = 10
+	minSubmatchGuessesMulti  = 50
+	minYearSpace             = 20
+)
+
+// estimateGuesses fills in m.Guesses: the number of guesses an attacker who
+// knows the pattern needs to hit this token. passwordLen is in runes.
+func (m *Match) estimateGuesses(passwordLen, refYear int) float64 {
+	if m.Guesses != 0 {
+		return m.Guesses
+	}
+	tokenLen := m.J - m.I + 1
+	minGuesses := 1.0
+	if tokenLen < passwordLen {
+		// a match inside a longer password must not be cheaper than bruteforcing it
+		minGuesses = minSubmatchGuessesMulti
+		if tokenLen == 1 {
+			minGuesses = minSubmatchGuessesSingle
+		}
+	}
+	var g float64
+	switch m.Pattern {
+	case PatternBruteforce:
+		g = bruteforceGuesses(tokenLen)
+	case PatternDictionary:
+		g = dictionaryGuesses(m)
+	case PatternSpatial:
+		g = spatialGuesses(m)
+	case PatternRepeat:
+		g = m.BaseGuesses * float64(m.RepeatCount)
+	case PatternSequence:
+		g = sequenceGuesses(m)
+	case PatternYear:
+		g = max(float64(abs(m.Year-refYear)), minYearSpace)
+	case PatternDate:
+		g = max(float64(abs(m.Year-refYear)), minYearSpace) * 365
+		if m.Separator != "" {
+			g *= 4
+		}
+	}
+	m.Guesses = max(g, minGuesses)
+	return m.Guesses
+}
+
+func bruteforceGuesses(n int) float64 {
+	g := math.Pow(bruteforceCardinality, float64(n))
+	if math.IsInf(g, 1) {
+		g = math.MaxFloat64
+	}
+	minGuesses := float64(minSubmatchGuessesMulti + 1)
+	if n == 1 {
+		minGuesses = minSubmatchGuessesSingle + 1
+	}
+	return max(g, minGuesses)
+}
+
+func dictionaryGuesses(m *Match) float64 {
+	g := float64(m.Rank) * uppercaseVariations(m.Token) * l33tVariations(m)
+	if m.Reversed {
+		g *= 2
+	}
+	return g
+}
+
+// uppercaseVariations counts the capitalisations of token an attacker would
+// try before this one: first or last letter and all-caps are cheap, anything
+// else costs the number of ways to place that many capitals.
+func uppercaseVariations(token string) float64 {
+	r := []rune(token)
+	upper, lower := 0, 0
+	for _, c := range r {
+		switch {
+		case unicode.IsUpper(c):
+			upper++
+		case unicode.IsLower(c):
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+	if lower == 0 {
+		return 2
+	}
+	if upper == 1 && (unicode.IsUpper(r[0]) || unicode.IsUpper(r[len(r)-1])) {
+		return 2
+	}
+	v := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		v += nCk(upper+lower, i)
+	}
+	return v
+}
+
+// l33tVariations counts the ways the substitutions in m could have been
+// applied to the word: all or none are cheap, a mix costs more.
+func l33tVariations(m *Match) float64 {
+	if !m.L33t {
+		return 1
+	}
+	v := 1.0
+	lower := strings.ToLower(m.Token)
+	for subbed, orig := range m.Subs {
+		s := strings.Count(lower, string(subbed))
+		u := strings.Count(lower, string(orig))
+		if s == 0 || u == 0 {
+			v *= 2
+			continue
+		}
+		p := 0.0
+		for i := 1; i <= min(u, s); i++ {
+			p += nCk(u+s, i)
+		}
+		v *= p
+	}
+	return v
+}
+
+func spatialGuesses(m *Match) float64 {
+	var kb *keyboard
+	for _, k := range keyboards {
+		if k.name == m.Keyboard {
+			kb = k
+		}
+	}
+	length := m.J - m.I + 1
+	g := 0.0
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= min(m.Turns, i-1); j++ {
+			g += nCk(i-1, j-1) * kb.starts * math.Pow(kb.avgDegree, float64(j))
+		}
+	}
+	if s := m.ShiftedCount; s > 0 {
+		u := length - s
+		if u == 0 {
+			g *= 2
+		} else {
+			v := 0.0
+			for i := 1; i <= min(s, u); i++ {
+				v += nCk(s+u, i)
+			}
+			g *= v
+		}
+	}
+	return g
+}
+
+// sequenceGuesses rates obvious starting points ("abc", "123", "zyx") lower
+// than sequences starting elsewhere, and descending ones a little higher.
+func sequenceGuesses(m *Match) float64 {
+	first := []rune(m.Token)[0]
+	var base float64
+	switch {
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if !m.Ascending {
+		base *= 2
+	}
+	return base * float64(len([]rune(m.Token)))
+}
+
+func nCk(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	if k == 0 {
+		return 1
+	}
+	r := 1.0
+	for d := 1; d <= k; d++ {
+		r *= float64(n)
+		r /= float64(d)
+		n--
+	}
+	return r
+}
+
+func factorial(n int) float64 {
+	f := 1.0
+	for i := 2; i <= n; i++ {
+		f *= float64(i)
+	}
+	return f
+}
+
+// mostGuessable finds the cover of an n-rune password by non-overlapping
+// matches (with bruteforce filling the gaps) that needs the fewest guesses,
+// and returns that guess count and the cover. A sequence of l matches costs
+// l! times the product of their guesses, plus a penalty that grows with l so
+// that splitting a password into many small pieces is not free.
+func mostGuessable(n int, matches []*Match, year int) (float64, []*Match) {
+	if n == 0 {
+		return 1, nil
+	}
+	byJ := make([][]*Match, n)
+	for _, m := range matches {
+		byJ[m.J] = append(byJ[m.J], m)
+	}
+
+	// For each end position k and sequence length l: the best last match,
+	// the product of guesses along it (pi) and the overall cost (g).
+	type step struct {
+		m  *Match
+		pi float64
+		g  float64
+	}
+	best := make([]map[int]step, n)
+	for k := range best {
+		best[k] = map[int]step{}
+	}
+
+	update := func(m *Match, l int) {
+		k := m.J
+		pi := m.estimateGuesses(n, year)
+		if l > 1 {
+			pi *= best[m.I-1][l-1].pi
+		}
+		g := factorial(l)*pi + math.Pow(minGuessesBeforeGrowing, float64(l-1))
+		// keep this candidate only if no shorter-or-equal sequence is already cheaper
+		for cl, c := range best[k] {
+			if cl <= l && c.g <= g {
+				return
+			}
+		}
+		best[k][l] = step{m, pi, g}
+	}
+
+	bruteforce := func(i, j int) *Match {
+		return &Match{Pattern: PatternBruteforce, I: i, J: j}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, m := range byJ[k] {
+			if m.I > 0 {
+				for _, l := range sortedKeys(best[m.I-1]) {
+					update(m, l+1)
+				}
+			} else {
+				update(m, 1)
+			}
+		}
+		// bruteforce from the start, or after any non-bruteforce match
+		update(bruteforce(0, k), 1)
+		for i := 1; i <= k; i++ {
+			for _, l := range sortedKeys(best[i-1]) {
+				if best[i-1][l].m.Pattern != PatternBruteforce {
+					update(bruteforce(i, k), l+1)
+				}
+			}
+		}
+	}
+
+	// unwind from the cheapest sequence ending at the last position
+	bestL, bestG := 0, math.Inf(1)
+	for l, s := range best[n-1] {
+		if s.g < bestG || (s.g == bestG && l < bestL) {
+			bestL, bestG = l, s.g
+		}
+	}
+	seq := make([]*Match, bestL)
+	for k, l := n-1, bestL; k >= 0 && l > 0; l-- {
+		m := best[k][l].m
+		seq[l-1] = m
+		k = m.I - 1
+	}
+	return bestG, seq
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
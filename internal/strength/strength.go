@@ -0,0 +1,181 @@
+// Package strength estimates how many guesses an attacker needs to find a
+// password, in the manner of Dropbox's zxcvbn.
+//
+// Rather than counting character classes, it looks for the patterns people
+// actually use: common passwords, English words and names (optionally l33t,
+// reversed or capitalised), keyboard walks, repeats, sequences, years and
+// dates, and words taken from the entry itself (title, username, URL). The
+// cheapest way to cover the whole password with those patterns, with
+// bruteforce for the gaps, gives the guess estimate, from which the crack
+// times, a 0-4 score and feedback are derived.
+package strength
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxLength caps how much of a password is analyzed; matching cost grows
+// quickly with length and anything longer is far out of reach anyway.
+const maxLength = 100
+
+// Result is the estimate for one password.
+type Result struct {
+	Guesses      float64
+	GuessesLog10 float64
+	Score        int // 0 (too guessable) to 4 (very unguessable), see ScoreLabel
+	CrackTimes   CrackTimes
+	Feedback     Feedback
+	Sequence     []*Match // the patterns the estimate is built from, in order
+}
+
+// CrackTimes estimates the time to find the password under four attack models.
+type CrackTimes struct {
+	OnlineThrottled   CrackTime // 100 guesses per hour: a rate-limited login form
+	OnlineUnthrottled CrackTime // 10 guesses per second
+	OfflineSlowHash   CrackTime // 1e4 guesses per second: bcrypt, scrypt, Argon2
+	OfflineFastHash   CrackTime // 1e10 guesses per second: a GPU rig against unsalted SHA-1
+}
+
+// CrackTime is a duration in seconds together with a rounded human-readable form.
+type CrackTime struct {
+	Seconds float64
+	Display string // "less than a second", "3 hours", "centuries", ...
+}
+
+// Feedback explains a low score. Both fields are empty for strong passwords.
+type Feedback struct {
+	Warning     string
+	Suggestions []string
+}
+
+// Estimate analyzes password. userInputs are strings an attacker targeting
+// this account would try first, such as the entry title, username and URL;
+// passwords built from them score lower.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+	if len(runes) > maxLength {
+		runes = runes[:maxLength]
+		password = string(runes)
+	}
+	year := refYear()
+	m := newMatcher(password, userInputs, year)
+	guesses, seq := mostGuessable(len(runes), m.omnimatch(), year)
+	for _, mt := range seq {
+		if mt.Pattern == PatternBruteforce {
+			mt.Token = string(runes[mt.I : mt.J+1])
+		}
+	}
+
+	r := Result{
+		Guesses:      guesses,
+		GuessesLog10: math.Log10(guesses),
+		Score:        guessesToScore(guesses),
+		CrackTimes: CrackTimes{
+			OnlineThrottled:   crackTime(guesses / (100.0 / 3600)),
+			OnlineUnthrottled: crackTime(guesses / 10),
+			OfflineSlowHash:   crackTime(guesses / 1e4),
+			OfflineFastHash:   crackTime(guesses / 1e10),
+		},
+		Sequence: seq,
+	}
+	r.Feedback = feedback(r.Score, seq)
+	return r
+}
+
+// refYear is the year dates and years in passwords are measured against.
+var refYear = func() int { return time.Now().Year() }
+
+// Score thresholds, in guesses. The small delta keeps a password that sits
+// exactly on a boundary (e.g. a 1000-guess bruteforce) in the lower bucket.
+const scoreDelta = 5
+
+func guessesToScore(g float64) int {
+	switch {
+	case g < 1e3+scoreDelta:
+		return 0 // risky: guessable within a few hundred online attempts
+	case g < 1e6+scoreDelta:
+		return 1 // protects against throttled online attacks only
+	case g < 1e8+scoreDelta:
+		return 2 // protects against unthrottled online attacks
+	case g < 1e10+scoreDelta:
+		return 3 // moderate protection against offline slow-hash attacks
+	default:
+		return 4 // strong protection against offline slow-hash attacks
+	}
+}
+
+// ScoreLabel names a 0-4 score.
+func ScoreLabel(score int) string {
+	switch score {
+	case 0:
+		return "very weak"
+	case 1:
+		return "weak"
+	case 2:
+		return "fair"
+	case 3:
+		return "good"
+	default:
+		return "strong"
+	}
+}
+
+// Percent maps the estimate onto 0-100 for meters and reports. Each score
+// bucket spans 20 points and the position inside it follows log10(guesses),
+// so 60 is the boundary between fair (2) and good (3); 10^14 guesses and
+// more read as 100.
+func (r Result) Percent() int {
+	// log10 guesses at the lower edge of each bucket, plus the cap
+	edges := []float64{0, 3, 6, 8, 10, 14}
+	lg := max(r.GuessesLog10, 0)
+	for b := 0; b < 5; b++ {
+		lo, hi := edges[b], edges[b+1]
+		if lg < hi || b == 4 {
+			p := 20*float64(b) + 20*min((lg-lo)/(hi-lo), 1)
+			return int(math.Round(p))
+		}
+	}
+	return 100
+}
+
+func crackTime(seconds float64) CrackTime {
+	return CrackTime{Seconds: seconds, Display: displayTime(seconds)}
+}
+
+func displayTime(seconds float64) string {
+	const (
+		minute  = 60
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+	unit := func(n float64, name string) string {
+		v := math.Round(seconds / n)
+		if v == 1 {
+			return "1 " + name
+		}
+		return fmt.Sprintf("%.0f %ss", v, name)
+	}
+	switch {
+	case seconds < 1:
+		return "less than a second"
+	case seconds < minute:
+		return unit(1, "second")
+	case seconds < hour:
+		return unit(minute, "minute")
+	case seconds < day:
+		return unit(hour, "hour")
+	case seconds < month:
+		return unit(day, "day")
+	case seconds < year:
+		return unit(month, "month")
+	case seconds < century:
+		return unit(year, "year")
+	default:
+		return "centuries"
+	}
+}
@@ -0,0 +1,140 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+)
+
+func patterns(r Result) map[string]bool {
+	m := map[string]bool{}
+	for _, mt := range r.Sequence {
+		m[mt.Pattern] = true
+	}
+	return m
+}
+
+func TestEstimateOrdering(t *testing.T) {
+	// Each password should need clearly fewer guesses than the next.
+	order := []string{
+		"password",
+		"Password1!",
+		"Tr0ub4dor&3",
+		"correct horse battery staple",
+	}
+	prev := Estimate(order[0])
+	for _, pw := range order[1:] {
+		r := Estimate(pw)
+		if r.Guesses <= prev.Guesses {
+			t.Errorf("%q: %.3g guesses, not above the previous password's %.3g", pw, r.Guesses, prev.Guesses)
+		}
+		prev = r
+	}
+
+	if r := Estimate("Password1!"); r.Score > 1 {
+		t.Errorf("Password1! score = %d, want at most 1", r.Score)
+	}
+	if r := Estimate("correct horse battery staple"); r.Score != 4 || r.Percent() != 100 {
+		t.Errorf("passphrase score = %d (%d%%), want 4 (100%%)", r.Score, r.Percent())
+	}
+	if r := Estimate("x9$Lq!r2Vb#8mZt"); r.Score != 4 {
+		t.Errorf("random password score = %d", r.Score)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	tests := []struct {
+		password string
+		pattern  string
+	}{
+		{"qwertyuiop[]", PatternSpatial},
+		{"zxcvfdsa", PatternSpatial},
+		{"aaaaaaaaaa", PatternRepeat},
+		{"blahblahblah", PatternRepeat},
+		{"lmnopqrs", PatternSequence},
+		{"97531", PatternSequence},
+		{"13/05/1987", PatternDate},
+		{"19870513", PatternDate},
+		{"x9$L2017", PatternYear},
+		{"p@ssw0rd", PatternDictionary},
+		{"nogard", PatternDictionary},
+	}
+	for _, tt := range tests {
+		r := Estimate(tt.password)
+		if !patterns(r)[tt.pattern] {
+			t.Errorf("%q: no %s match in %+v", tt.password, tt.pattern, r.Sequence)
+		}
+		if r.Score > 2 {
+			t.Errorf("%q: score %d, want at most 2", tt.password, r.Score)
+		}
+	}
+
+	r := Estimate("p@ssw0rd")
+	if m := r.Sequence[0]; !m.L33t || m.Word != "password" || m.Subs['@'] != 'a' {
+		t.Errorf("l33t match = %+v", m)
+	}
+	if m := Estimate("nogard").Sequence[0]; !m.Reversed || m.Word != "dragon" {
+		t.Errorf("reversed match = %+v", m)
+	}
+}
+
+func TestUserInputs(t *testing.T) {
+	pw := "wombatcorp-jdoe"
+	plain := Estimate(pw)
+	own := Estimate(pw, "Wombat Corp VPN", "jdoe", "https://vpn.wombatcorp.example/login")
+	if own.Guesses >= plain.Guesses/100 {
+		t.Errorf("entry-derived password: %.3g guesses with inputs vs %.3g without", own.Guesses, plain.Guesses)
+	}
+	found := false
+	for _, m := range own.Sequence {
+		found = found || m.Dictionary == DictUserInputs
+	}
+	if !found || !strings.Contains(own.Feedback.Warning, "entry") {
+		t.Errorf("user input not reported: %+v, %+v", own.Sequence, own.Feedback)
+	}
+}
+
+func TestFeedback(t *testing.T) {
+	r := Estimate("password")
+	if r.Score != 0 || r.Feedback.Warning != "This is a top-10 common password" {
+		t.Errorf("password: score %d, feedback %+v", r.Score, r.Feedback)
+	}
+	if len(r.Feedback.Suggestions) == 0 {
+		t.Error("no suggestions for a weak password")
+	}
+	if f := Estimate("correct horse battery staple").Feedback; f.Warning != "" || len(f.Suggestions) != 0 {
+		t.Errorf("strong password got feedback %+v", f)
+	}
+	if f := Estimate("").Feedback; len(f.Suggestions) == 0 {
+		t.Error("empty password got no suggestions")
+	}
+}
+
+func TestCrackTimes(t *testing.T) {
+	r := Estimate("password")
+	if r.CrackTimes.OfflineFastHash.Display != "less than a second" {
+		t.Errorf("fast hash = %+v", r.CrackTimes.OfflineFastHash)
+	}
+	if got := Estimate("correct horse battery staple").CrackTimes.OfflineSlowHash.Display; got != "centuries" {
+		t.Errorf("slow hash = %q", got)
+	}
+	for secs, want := range map[float64]string{0.5: "less than a second", 1: "1 second", 150: "3 minutes", 7200: "2 hours", 86400 * 40: "1 month"} {
+		if got := displayTime(secs); got != want {
+			t.Errorf("displayTime(%v) = %q, want %q", secs, got, want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	for lg, want := range map[float64]int{0: 0, 3: 20, 6: 40, 7: 50, 8: 60, 10: 80, 12: 90, 14: 100, 30: 100} {
+		if got := (Result{GuessesLog10: lg}).Percent(); got != want {
+			t.Errorf("Percent(10^%v) = %d, want %d", lg, got, want)
+		}
+	}
+}
+
+func TestLongPassword(t *testing.T) {
+	r := Estimate(strings.Repeat("ab", 1000))
+	if r.Score != 0 {
+		t.Errorf("long repeat score = %d", r.Score)
+	}
+}
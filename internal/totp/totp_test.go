@@ -0,0 +1,67 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The test vectors of RFC 6238, appendix B, with its seeds for each hash.
+func TestRFC6238(t *testing.T) {
+	seed := func(n int) string {
+		return base32.StdEncoding.EncodeToString([]byte(strings.Repeat("1234567890", 7)[:n]))
+	}
+	seeds := map[string]string{"SHA1": seed(20), "SHA256": seed(32), "SHA512": seed(64)}
+	for _, tt := range []struct {
+		unix int64
+		alg  string
+		want string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111111, "SHA256", "67062674"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{20000000000, "SHA256", "77737706"},
+	} {
+		k, err := Parse("otpauth://totp/RFC?secret=" + seeds[tt.alg] + "&algorithm=" + tt.alg + "&digits=8")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := k.Code(time.Unix(tt.unix, 0)); got != tt.want {
+			t.Errorf("%s at %d = %s, want %s", tt.alg, tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	k, err := Parse("jbsw y3dp-ehpk 3pxp")
+	if err != nil || k.Algorithm != "SHA1" || k.Digits != 6 || k.Period != 30*time.Second || string(k.Secret) != "Hello!\xde\xad\xbe\xef" {
+		t.Fatalf("bare secret = %+v, %v", k, err)
+	}
+	code, left := k.Code(time.Unix(65, 0))
+	if len(code) != 6 || left != 25*time.Second {
+		t.Errorf("Code = %q, %v", code, left)
+	}
+
+	k, err = Parse("otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example&period=60&algorithm=sha256")
+	if err != nil || k.Algorithm != "SHA256" || k.Period != time.Minute {
+		t.Errorf("URI = %+v, %v", k, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"not base32!",
+		"otpauth://hotp/x?secret=JBSWY3DPEHPK3PXP&counter=1",
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&digits=4",
+		"otpauth://totp/x?secret=JBSWY3DPEHPK3PXP&period=0",
+	} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) accepted", bad)
+		}
+	}
+}
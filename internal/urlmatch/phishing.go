@@ -0,0 +1,248 @@
+package urlmatch
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+)
+
+// Risk grades how likely a page is to be imitating a site the vault knows.
+type Risk int
+
+const (
+	RiskNone Risk = iota
+	RiskLow       // worth a warning; credentials are still handed out
+	RiskHigh      // credentials are withheld unless the caller forces it
+)
+
+func (r Risk) String() string {
+	switch r {
+	case RiskLow:
+		return "low"
+	case RiskHigh:
+		return "high"
+	default:
+		return "none"
+	}
+}
+
+// Verdict is the phishing assessment of a page.
+type Verdict struct {
+	Risk   Risk
+	Reason string // empty for RiskNone
+	Target string // the known site the page resembles, if any
+}
+
+// ErrLookalike is wrapped by Verdict.Err for high-risk pages.
+var ErrLookalike = errors.New("page looks like an imitation of a known site")
+
+// Err returns an error wrapping ErrLookalike when the verdict is high risk.
+// Autofill integrations should refuse to release credentials when it is
+// non-nil, unless the user explicitly overrides it.
+func (v Verdict) Err() error {
+	if v.Risk < RiskHigh {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrLookalike, v.Reason)
+}
+
+// Assess compares page with the sites of the vault's entries (registrable
+// domains, as URL.Site returns them). It checks for homoglyphs and punycode
+// that spell a known site, typosquats within a small edit distance, and known
+// sites used as a subdomain of another one ("github.com.evil.io"). A page on a
+// known ASCII site is never risky; an internationalized one is still compared
+// with the other known sites, since the vault may hold a login saved on the
+// look-alike itself.
+func Assess(page *URL, known []string) Verdict {
+	host, err := unicodeHost(page.Host)
+	if err != nil {
+		return Verdict{Risk: RiskHigh, Reason: fmt.Sprintf("%s has malformed punycode", page.Host)}
+	}
+	uSite, _ := unicodeHost(page.Site())
+	// compare decoded forms, so "xn--bcher-kva.de" is the known "bücher.de"
+	decoded := make([]string, 0, len(known))
+	for _, k := range known {
+		if k, err := unicodeHost(k); err == nil {
+			if k == uSite {
+				if isASCII(uSite) {
+					return Verdict{}
+				}
+				continue
+			}
+			decoded = append(decoded, k)
+		}
+	}
+	shown := page.Host
+	if host != page.Host {
+		shown = fmt.Sprintf("%s (%s)", page.Host, host)
+	}
+
+	var low Verdict
+	pageName, pageSuffix := splitSite(uSite)
+	for _, k := range decoded {
+		name, suffix := splitSite(k)
+		switch {
+		case skeleton(uSite) == skeleton(k):
+			return Verdict{Risk: RiskHigh, Target: k,
+				Reason: fmt.Sprintf("%s uses look-alike characters to imitate %s", shown, k)}
+		case strings.HasPrefix(host, k+".") || strings.Contains(host, "."+k+"."):
+			return Verdict{Risk: RiskHigh, Target: k,
+				Reason: fmt.Sprintf("%s puts %s in front of the unrelated site %s", shown, k, uSite)}
+		case suffix == pageSuffix && isTypo(pageName, name):
+			return Verdict{Risk: RiskHigh, Target: k,
+				Reason: fmt.Sprintf("%s is a near-miss spelling of %s", shown, k)}
+		case low.Risk == RiskNone && name == pageName:
+			low = Verdict{Risk: RiskLow, Target: k,
+				Reason: fmt.Sprintf("%s has the same name as %s under a different suffix", shown, k)}
+		case low.Risk == RiskNone && slices.Contains(strings.Split(host, "."), name) && len(name) >= 4:
+			low = Verdict{Risk: RiskLow, Target: k,
+				Reason: fmt.Sprintf("%s uses the name of %s on the unrelated site %s", shown, k, uSite)}
+		}
+	}
+	for _, label := range strings.Split(host, ".") {
+		if mixedScripts(label) {
+			return Verdict{Risk: RiskHigh, Reason: fmt.Sprintf("%s mixes alphabets in %q", shown, label)}
+		}
+	}
+	return low
+}
+
+// isASCII reports whether s has no characters beyond ASCII.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// splitSite splits a registrable domain into its owner's label and the public suffix.
+func splitSite(site string) (name, suffix string) {
+	name, suffix, _ = strings.Cut(site, ".")
+	return name, suffix
+}
+
+// isTypo reports whether a is a small edit away from b: one edit for names of
+// four characters or more, two from eight.
+func isTypo(a, b string) bool {
+	ar, br := []rune(a), []rune(b)
+	limit := 0
+	switch n := min(len(ar), len(br)); {
+	case n >= 8:
+		limit = 2
+	case n >= 4:
+		limit = 1
+	}
+	return limit > 0 && a != b && editDistance(ar, br, limit) <= limit
+}
+
+// unicodeHost decodes the punycode ("xn--") labels of host.
+func unicodeHost(host string) (string, error) {
+	u, err := idna.Punycode.ToUnicode(strings.ToLower(host))
+	if err != nil {
+		return "", err
+	}
+	return u, nil
+}
+
+// confusables maps characters that render like ASCII letters to those letters.
+// It covers the Cyrillic, Greek and Latin look-alikes used in practice, not
+// the whole of Unicode's confusables table.
+var confusables = map[rune]string{
+	'а': "a", 'е': "e", 'о': "o", 'р': "p", 'с': "c", 'у': "y", 'х': "x", 'і': "i",
+	'ј': "j", 'ѕ': "s", 'һ': "h", 'ԁ': "d", 'ԛ': "q", 'ԝ': "w", 'ӏ': "l", 'к': "k",
+	'ԍ': "g", 'ց': "g", 'ո': "n", 'ս': "u",
+	'α': "a", 'ο': "o", 'ρ': "p", 'ν': "v", 'ι': "i", 'κ': "k", 'υ': "u", 'χ': "x", 'ε': "e",
+	'ı': "i", 'ɡ': "g", 'ℓ': "l", 'ɑ': "a", 'ɩ': "i",
+	'0': "o", '1': "l", '5': "s",
+}
+
+// skeleton maps s to a canonical form in which look-alike strings coincide,
+// in the spirit of Unicode TR 39: accents are dropped, confusable characters
+// are folded to ASCII and multi-letter shapes ("rn", "vv") to the letter they mimic.
+func skeleton(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if a, ok := confusables[r]; ok {
+			b.WriteString(a)
+			continue
+		}
+		b.WriteRune(stripAccent(r))
+	}
+	out := b.String()
+	for _, pair := range [][2]string{{"rn", "m"}, {"vv", "w"}, {"cl", "d"}} {
+		out = strings.ReplaceAll(out, pair[0], pair[1])
+	}
+	return out
+}
+
+// accents maps accented Latin letters to their base letter.
+var accents = map[rune]rune{
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ç': 'c',
+	'è': 'e', 'é': 'e', 'ê': 'e', 'ë': 'e', 'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i',
+	'ñ': 'n', 'ò': 'o', 'ó': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o', 'ø': 'o',
+	'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y',
+}
+
+func stripAccent(r rune) rune {
+	if a, ok := accents[r]; ok {
+		return a
+	}
+	return r
+}
+
+// mixedScripts reports whether label combines letters of Latin, Cyrillic,
+// Greek or Armenian, which legitimate names almost never do.
+func mixedScripts(label string) bool {
+	var seen *unicode.RangeTable
+	for _, r := range label {
+		for _, script := range []*unicode.RangeTable{unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian} {
+			if unicode.Is(script, r) {
+				if seen != nil && seen != script {
+					return true
+				}
+				seen = script
+			}
+		}
+	}
+	return false
+}
+
+// editDistance is the optimal-string-alignment distance between a and b,
+// or limit+1 once it is known to exceed limit.
+func editDistance(a, b []rune, limit int) int {
+	if len(a)-len(b) > limit || len(b)-len(a) > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,145 @@
+package urlmatch
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Public suffixes in the format of publicsuffix.org's public_suffix_list.dat.
+// The embedded file is a subset; LoadFile merges the upstream list over it.
+//
+//go:embed data/public_suffix_list.dat
+var builtinPSL string
+
+// List is a set of public suffix rules. It is safe for concurrent use.
+type List struct {
+	mu         sync.RWMutex
+	rules      map[string]bool // "co.uk"
+	wildcards  map[string]bool // "*.ck" is stored as "ck"
+	exceptions map[string]bool // "!www.ck" is stored as "www.ck"
+}
+
+var (
+	defaultOnce sync.Once
+	defaultList *List
+)
+
+// Default returns the shared list, initialised with the embedded suffixes.
+func Default() *List {
+	defaultOnce.Do(func() {
+		defaultList = NewList()
+		if err := defaultList.Update(strings.NewReader(builtinPSL)); err != nil {
+			panic("urlmatch: embedded public suffix list: " + err.Error())
+		}
+	})
+	return defaultList
+}
+
+// NewList returns an empty list; with no rules every TLD is a public suffix.
+func NewList() *List {
+	return &List{rules: map[string]bool{}, wildcards: map[string]bool{}, exceptions: map[string]bool{}}
+}
+
+// Update merges the rules of a public_suffix_list.dat file into l. Nothing is
+// merged if any line fails to parse.
+func (l *List) Update(r io.Reader) error {
+	var rules, wildcards, exceptions []string
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		// a rule is the first word of its line; the rest is ignored
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+		rule := strings.ToLower(strings.TrimSuffix(fields[0], "."))
+		switch {
+		case strings.HasPrefix(rule, "!"):
+			exceptions = append(exceptions, rule[1:])
+			rule = rule[1:]
+		case strings.HasPrefix(rule, "*."):
+			wildcards = append(wildcards, rule[2:])
+			rule = rule[2:]
+		default:
+			rules = append(rules, rule)
+		}
+		if rule == "" || strings.Contains(rule, "*") || strings.Contains(rule, "..") {
+			return fmt.Errorf("public suffix list line %d: bad rule %q", n, fields[0])
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("public suffix list: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range rules {
+		l.rules[s] = true
+	}
+	for _, s := range wildcards {
+		l.wildcards[s] = true
+	}
+	for _, s := range exceptions {
+		l.exceptions[s] = true
+	}
+	return nil
+}
+
+// LoadFile merges a public_suffix_list.dat file into the default list.
+func LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return Default().Update(f)
+}
+
+// PublicSuffix returns the public suffix of host following the publicsuffix.org
+// algorithm: exception rules win, then the longest matching rule, and a host
+// no rule covers falls back to its last label. IP addresses have no suffix.
+func (l *List) PublicSuffix(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return ""
+	}
+	labels := strings.Split(host, ".")
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for i := range labels {
+		if l.exceptions[strings.Join(labels[i:], ".")] {
+			return strings.Join(labels[i+1:], ".")
+		}
+	}
+	for i := range labels {
+		suffix := strings.Join(labels[i:], ".")
+		if l.rules[suffix] || (i+1 < len(labels) && l.wildcards[strings.Join(labels[i+1:], ".")]) {
+			return suffix
+		}
+	}
+	return labels[len(labels)-1]
+}
+
+// RegistrableDomain returns the public suffix of host plus one label, the
+// part of a name one owner controls: "eu.app.example.co.uk" gives
+// "example.co.uk". It returns false for IP addresses, single-label names
+// such as "localhost" and hosts that are themselves public suffixes.
+func (l *List) RegistrableDomain(host string) (string, bool) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	suffix := l.PublicSuffix(host)
+	if suffix == "" || len(host) <= len(suffix) {
+		return "", false
+	}
+	rest := host[:len(host)-len(suffix)-1]
+	return rest[strings.LastIndex(rest, ".")+1:] + "." + suffix, true
+}
+
+// RegistrableDomain looks host up in the default list.
+func RegistrableDomain(host string) (string, bool) {
+	return Default().RegistrableDomain(host)
+}
@@ -0,0 +1,319 @@
+// Package urlmatch decides which vault entries apply to a web page, for
+// autofill and browser integrations.
+//
+// URLs are normalised first (scheme and host lowercased, default ports and
+// fragments dropped), and host names are compared by registrable domain using
+// the public suffix list, so "eu.app.example.co.uk" and "example.co.uk" are the
+// same site while "alice.github.io" and "bob.github.io" are not.
+//
+// Each entry picks how strictly its URL must match through
+// PlainEntry.URLMatch; see Mode. An entry saved for HTTPS is never offered to
+// a plain-HTTP page on the same site, and Find rates every page for
+// lookalikes of the vault's sites so callers can refuse to fill phishing pages.
+package urlmatch
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Mode is how an entry's URL is compared with the page being filled.
+type Mode string
+
+const (
+	ModeDomain     Mode = "domain"     // same registrable domain; the default
+	ModeHost       Mode = "host"       // same host name and port
+	ModeStartsWith Mode = "startswith" // the page URL starts with the entry URL
+	ModeExact      Mode = "exact"      // the same URL, ignoring the fragment
+	ModeRegex      Mode = "regex"      // the entry URL is a regular expression over the page URL
+	ModeNever      Mode = "never"      // never offered for autofill
+)
+
+// Modes lists every match mode.
+var Modes = []Mode{ModeDomain, ModeHost, ModeStartsWith, ModeExact, ModeRegex, ModeNever}
+
+// ParseMode accepts a mode name; the empty string is ModeDomain.
+func ParseMode(s string) (Mode, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "", "base", "basedomain":
+		return ModeDomain, nil
+	case "starts-with", "prefix":
+		return ModeStartsWith, nil
+	case "regexp":
+		return ModeRegex, nil
+	}
+	for _, m := range Modes {
+		if s == string(m) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown match mode %q (want one of %s)", s, ModeNames())
+}
+
+// ModeNames lists the names of Modes, comma-separated, for usage and error messages.
+func ModeNames() string {
+	names := make([]string, len(Modes))
+	for i, m := range Modes {
+		names[i] = string(m)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Quality ranks how closely an entry matched; higher is better.
+type Quality int
+
+const (
+	NoMatch     Quality = iota
+	MatchDomain         // same registrable domain, different host
+	MatchHost           // same host, or a regular expression matched
+	MatchPrefix         // the page URL starts with the entry URL
+	MatchExact          // the same URL
+)
+
+func (q Quality) String() string {
+	switch q {
+	case MatchDomain:
+		return "domain"
+	case MatchHost:
+		return "host"
+	case MatchPrefix:
+		return "prefix"
+	case MatchExact:
+		return "exact"
+	default:
+		return "none"
+	}
+}
+
+// URL is a normalised web address.
+type URL struct {
+	Scheme   string
+	Host     string // lowercase, without trailing dot or port
+	Port     string // empty for the scheme's default port
+	Path     string // "/" when the URL has none
+	RawQuery string
+	Domain   string // registrable domain, empty for IPs, single labels and public suffixes
+}
+
+// ErrNoHost is returned for URLs without a host name.
+var ErrNoHost = errors.New("url has no host")
+
+// Parse normalises rawURL. A missing scheme means https, so a bare host name
+// such as "github.com" is accepted.
+func Parse(rawURL string) (*URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	out := &URL{
+		Scheme:   strings.ToLower(u.Scheme),
+		Host:     strings.TrimSuffix(strings.ToLower(u.Hostname()), "."),
+		Port:     u.Port(),
+		Path:     u.EscapedPath(),
+		RawQuery: u.RawQuery,
+	}
+	if out.Host == "" {
+		return nil, ErrNoHost
+	}
+	if (out.Scheme == "https" && out.Port == "443") || (out.Scheme == "http" && out.Port == "80") {
+		out.Port = ""
+	}
+	if out.Path == "" {
+		out.Path = "/"
+	}
+	out.Domain, _ = RegistrableDomain(out.Host)
+	return out, nil
+}
+
+// HostPort returns the host with its port, if not the default.
+func (u *URL) HostPort() string {
+	if u.Port == "" {
+		return u.Host
+	}
+	return u.Host + ":" + u.Port
+}
+
+// Site is the registrable domain, or the host when there is none.
+func (u *URL) Site() string {
+	if u.Domain == "" {
+		return u.Host
+	}
+	return u.Domain
+}
+
+func (u *URL) String() string {
+	s := u.Scheme + "://" + u.HostPort() + u.Path
+	if u.RawQuery != "" {
+		s += "?" + u.RawQuery
+	}
+	return s
+}
+
+// Match compares a page with one entry URL under mode. The int is a
+// tie-breaker within a quality: the length of the entry URL or path that
+// matched, so more specific entries rank first.
+func Match(page *URL, entryURL string, mode Mode) (Quality, int) {
+	entryURL = strings.TrimSpace(entryURL)
+	if entryURL == "" || mode == ModeNever {
+		return NoMatch, 0
+	}
+	if mode == ModeRegex {
+		re, err := regexp.Compile(entryURL)
+		if err != nil || !re.MatchString(page.String()) {
+			return NoMatch, 0
+		}
+		return MatchHost, len(entryURL)
+	}
+
+	e, err := Parse(entryURL)
+	if err != nil || !schemeAllowed(e.Scheme, page.Scheme) {
+		return NoMatch, 0
+	}
+	switch mode {
+	case ModeExact:
+		if e.String() == page.String() {
+			return MatchExact, len(e.String())
+		}
+	case ModeStartsWith:
+		if strings.HasPrefix(page.String(), e.String()) {
+			return MatchPrefix, len(e.String())
+		}
+	case ModeHost:
+		if e.HostPort() == page.HostPort() {
+			return MatchHost, pathOverlap(e, page)
+		}
+	default:
+		if e.Host == page.Host {
+			return MatchHost, pathOverlap(e, page)
+		}
+		// without a registrable domain only the host itself can match
+		if e.Domain != "" && e.Domain == page.Domain {
+			return MatchDomain, pathOverlap(e, page)
+		}
+	}
+	return NoMatch, 0
+}
+
+// schemeAllowed keeps credentials saved over HTTPS off plain-HTTP pages;
+// other schemes must agree exactly.
+func schemeAllowed(entry, page string) bool {
+	return entry == page || (entry == "http" && page == "https")
+}
+
+// pathOverlap is the length of the entry's path when the page is under it.
+func pathOverlap(e, page *URL) int {
+	if e.Path != "/" && strings.HasPrefix(page.Path, e.Path) {
+		return len(e.Path)
+	}
+	return 0
+}
+
+// Result is an entry that applies to a page.
+type Result struct {
+	ID       string
+	Title    string
+	Username string
+	URL      string // the entry URL that matched
+	Mode     Mode
+	Quality  Quality
+	score    int
+	modified int64
+}
+
+// extraURLPrefix names the custom fields holding a login's second and later
+// URLs, as written by the Bitwarden and 1Password importers.
+const extraURLPrefix = "URI "
+
+// URLs returns the primary URL of r followed by its "URI n" custom fields.
+func URLs(r pwmanager.Record) []string {
+	var out []string
+	if r.URL != "" {
+		out = append(out, r.URL)
+	}
+	for _, f := range r.Fields {
+		if strings.HasPrefix(f.Name, extraURLPrefix) && f.Value != "" {
+			out = append(out, f.Value)
+		}
+	}
+	return out
+}
+
+// Find returns the logins in recs that apply to rawURL, best first: exact
+// matches, then prefixes, then the same host, then the same site; within a
+// quality the more specific entry URL and then the most recently changed
+// entry win. An entry with an unknown mode is skipped.
+//
+// The verdict rates the page against every site in recs (see Assess); callers
+// must check it before releasing credentials, even when nothing matched.
+func Find(recs []pwmanager.Record, rawURL string) ([]Result, Verdict, error) {
+	page, err := Parse(rawURL)
+	if err != nil {
+		return nil, Verdict{}, fmt.Errorf("page url: %w", err)
+	}
+	verdict := Assess(page, KnownSites(recs))
+	var out []Result
+	for _, r := range recs {
+		if r.Kind != pwmanager.KindLogin {
+			continue
+		}
+		mode, err := ParseMode(r.URLMatch)
+		if err != nil {
+			continue
+		}
+		best := Result{ID: r.ID, Title: r.Title, Username: r.Username, Mode: mode, modified: r.ModifiedAt.UnixNano()}
+		for _, u := range URLs(r) {
+			q, score := Match(page, u, mode)
+			if q > best.Quality || (q == best.Quality && score > best.score) {
+				best.Quality, best.score, best.URL = q, score, u
+			}
+		}
+		if best.Quality != NoMatch {
+			out = append(out, best)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Quality != b.Quality {
+			return a.Quality > b.Quality
+		}
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.modified != b.modified {
+			return a.modified > b.modified
+		}
+		return a.ID < b.ID
+	})
+	return out, verdict, nil
+}
+
+// KnownSites returns the sites of the logins in recs, for Assess. Regular
+// expressions are patterns rather than sites and are left out.
+func KnownSites(recs []pwmanager.Record) []string {
+	var out []string
+	for _, r := range recs {
+		if r.Kind != pwmanager.KindLogin {
+			continue
+		}
+		if mode, _ := ParseMode(r.URLMatch); mode == ModeRegex {
+			continue
+		}
+		for _, u := range URLs(r) {
+			if p, err := Parse(u); err == nil && !slices.Contains(out, p.Site()) {
+				out = append(out, p.Site())
+			}
+		}
+	}
+	return out
+}
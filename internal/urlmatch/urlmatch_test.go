@@ -0,0 +1,245 @@
+package urlmatch
+
+import (
+	"appliedcryptography-starter-kit/internal/pwmanager"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistrableDomain(t *testing.T) {
+	tests := []struct {
+		host, want string
+	}{
+		{"example.com", "example.com"},
+		{"www.example.com", "example.com"},
+		{"eu.app.example.co.uk", "example.co.uk"},
+		{"Example.CO.UK.", "example.co.uk"},
+		{"co.uk", ""},
+		{"alice.github.io", "alice.github.io"},
+		{"github.io", ""},
+		{"example.unknowntld", "example.unknowntld"},
+		{"a.b.example.ck", "b.example.ck"}, // *.ck
+		{"www.ck", "www.ck"},               // !www.ck
+		{"test.ck", ""},
+		{"shop.city.kawasaki.jp", "city.kawasaki.jp"},
+		{"shop.foo.kawasaki.jp", "shop.foo.kawasaki.jp"},
+		{"localhost", ""},
+		{"192.168.1.1", ""},
+		{"::1", ""},
+	}
+	for _, tt := range tests {
+		got, ok := RegistrableDomain(tt.host)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("RegistrableDomain(%q) = %q, %v; want %q", tt.host, got, ok, tt.want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	l := NewList()
+	if got, _ := l.RegistrableDomain("app.example.co.uk"); got != "co.uk" {
+		t.Errorf("empty list: %q", got)
+	}
+	if err := l.Update(strings.NewReader("// comment\nuk\nco.uk extra words\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := l.RegistrableDomain("app.example.co.uk"); got != "example.co.uk" {
+		t.Errorf("after update: %q", got)
+	}
+	if err := l.Update(strings.NewReader("good.example\nfoo.*.bar\n")); err == nil {
+		t.Error("bad rule accepted")
+	}
+	if l.PublicSuffix("x.good.example") == "good.example" {
+		t.Error("failed update was partly applied")
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://Example.COM", "https://example.com/"},
+		{"example.com/login", "https://example.com/login"},
+		{"HTTPS://example.com:443/a?b=1#frag", "https://example.com/a?b=1"},
+		{"http://example.com:8080", "http://example.com:8080/"},
+	}
+	for _, tt := range tests {
+		u, err := Parse(tt.in)
+		if err != nil || u.String() != tt.want {
+			t.Errorf("Parse(%q) = %v, %v; want %q", tt.in, u, err, tt.want)
+		}
+	}
+	if _, err := Parse("mailto:someone"); err == nil {
+		t.Error("url without host accepted")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	page, err := Parse("https://eu.app.example.co.uk/login?next=/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		entry string
+		mode  Mode
+		want  Quality
+	}{
+		{"https://example.co.uk", ModeDomain, MatchDomain},
+		{"example.co.uk", ModeDomain, MatchDomain},
+		{"https://eu.app.example.co.uk", ModeDomain, MatchHost},
+		{"https://other.co.uk", ModeDomain, NoMatch},
+		{"https://example.co.uk", ModeHost, NoMatch},
+		{"https://eu.app.example.co.uk:8443", ModeHost, NoMatch},
+		{"https://eu.app.example.co.uk", ModeHost, MatchHost},
+		{"https://eu.app.example.co.uk/log", ModeStartsWith, MatchPrefix},
+		{"https://eu.app.example.co.uk/logout", ModeStartsWith, NoMatch},
+		{"https://eu.app.example.co.uk/login?next=/#top", ModeExact, MatchExact},
+		{"https://eu.app.example.co.uk/login", ModeExact, NoMatch},
+		{`^https://[a-z]+\.app\.example\.co\.uk/`, ModeRegex, MatchHost},
+		{`^https://www\.`, ModeRegex, NoMatch},
+		{`(`, ModeRegex, NoMatch},
+		{"https://eu.app.example.co.uk", ModeNever, NoMatch},
+		{"http://example.co.uk", ModeDomain, MatchDomain}, // http entry on an https page is fine
+	}
+	for _, tt := range tests {
+		if got, _ := Match(page, tt.entry, tt.mode); got != tt.want {
+			t.Errorf("Match(%q, %s) = %v, want %v", tt.entry, tt.mode, got, tt.want)
+		}
+	}
+
+	insecure, _ := Parse("http://example.co.uk/")
+	if q, _ := Match(insecure, "https://example.co.uk", ModeDomain); q != NoMatch {
+		t.Error("https entry offered to an http page")
+	}
+	pages, _ := Parse("https://bob.github.io/")
+	if q, _ := Match(pages, "https://alice.github.io", ModeDomain); q != NoMatch {
+		t.Error("different github.io sites matched")
+	}
+}
+
+func TestFind(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	login := func(id, url, mode string, age time.Duration) pwmanager.Record {
+		return pwmanager.Record{ID: id, Title: id, PlainEntry: pwmanager.PlainEntry{
+			Username: id + "-user", URL: url, URLMatch: mode, ModifiedAt: now.Add(-age),
+		}}
+	}
+	recs := []pwmanager.Record{
+		login("site", "https://example.co.uk", "", time.Hour),
+		login("old-site", "https://example.co.uk", "", 48*time.Hour),
+		login("host", "https://eu.app.example.co.uk", "host", time.Hour),
+		login("prefix", "https://eu.app.example.co.uk/login", "startswith", time.Hour),
+		login("never", "https://eu.app.example.co.uk", "never", time.Hour),
+		login("bad-mode", "https://eu.app.example.co.uk", "sometimes", time.Hour),
+		login("elsewhere", "https://example.com", "", time.Hour),
+		login("", "", "", time.Hour),
+	}
+	recs = append(recs, login("extra", "https://unrelated.org", "", time.Hour))
+	recs[len(recs)-1].Fields = []pwmanager.CustomField{{Name: "URI 2", Value: "https://app.example.co.uk/login"}}
+	note := login("note", "https://example.co.uk", "", 0)
+	note.Kind = pwmanager.KindNote
+	recs = append(recs, note)
+
+	got, verdict, err := Find(recs, "https://eu.app.example.co.uk/login?next=/")
+	if err != nil || verdict.Risk != RiskNone {
+		t.Fatal(err, verdict)
+	}
+	want := []string{"prefix", "host", "extra", "site", "old-site"}
+	if len(got) != len(want) {
+		t.Fatalf("Find = %+v, want %v", got, want)
+	}
+	for i, r := range got {
+		if r.ID != want[i] {
+			t.Fatalf("result %d = %s, want %v", i, r.ID, want)
+		}
+	}
+	if got[2].URL != "https://app.example.co.uk/login" || got[2].Quality != MatchDomain {
+		t.Errorf("extra URL result = %+v", got[2])
+	}
+
+	if _, _, err := Find(recs, "not a url"); err == nil {
+		t.Error("bad page url accepted")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for _, s := range []string{"", "domain", "Host", "starts-with", "exact", "regex", "never"} {
+		if _, err := ParseMode(s); err != nil {
+			t.Errorf("ParseMode(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseMode("sometimes"); err == nil {
+		t.Error("unknown mode accepted")
+	}
+}
+
+func TestAssess(t *testing.T) {
+	known := []string{"github.com", "paypal.com", "example.co.uk", "bücher.de", "xn--gthub-n2e.com"}
+	tests := []struct {
+		page   string
+		want   Risk
+		target string
+	}{
+		{"https://github.com/login", RiskNone, ""},
+		{"https://gist.github.com", RiskNone, ""},
+		{"https://xn--bcher-kva.de", RiskNone, ""}, // punycode of a known site
+		{"https://unrelated.org", RiskNone, ""},
+		{"https://gіthub.com", RiskHigh, "github.com"},               // Cyrillic і, even with a login saved on it
+		{"https://xn--gthub-n2e.com", RiskHigh, "github.com"},        // the same, as punycode
+		{"https://xn--l-7sba6dbr.com", RiskHigh, "paypal.com"},       // all-Cyrillic раураl
+		{"https://paypa1.com", RiskHigh, "paypal.com"},               // digit for letter
+		{"https://githbu.com", RiskHigh, "github.com"},               // transposition
+		{"https://gitbub.com", RiskHigh, "github.com"},               // substitution
+		{"https://github.com.evil.io/login", RiskHigh, "github.com"}, // subdomain trick
+		{"https://exarnple.co.uk", RiskHigh, "example.co.uk"},        // rn for m
+		{"https://xn--ab_c.com", RiskHigh, ""},                       // undecodable punycode
+		{"https://gіt.org", RiskHigh, ""},                            // mixed scripts, no known target
+		{"https://github.co", RiskLow, "github.com"},
+		{"https://github.evil.io", RiskLow, "github.com"},
+	}
+	for _, tt := range tests {
+		page, err := Parse(tt.page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v := Assess(page, known)
+		if v.Risk != tt.want || v.Target != tt.target {
+			t.Errorf("Assess(%s) = %v %q (%s), want %v %q", tt.page, v.Risk, v.Target, v.Reason, tt.want, tt.target)
+		}
+		if (v.Err() != nil) != (tt.want == RiskHigh) {
+			t.Errorf("Assess(%s).Err() = %v", tt.page, v.Err())
+		}
+	}
+
+	// a regex entry that matches a phishing page still gets a high-risk verdict
+	recs := []pwmanager.Record{
+		{ID: "gh", Title: "GitHub", PlainEntry: pwmanager.PlainEntry{URL: "https://github.com"}},
+		{ID: "re", Title: "GitHub (regex)", PlainEntry: pwmanager.PlainEntry{URL: `github\.com`, URLMatch: "regex"}},
+	}
+	got, verdict, err := Find(recs, "https://github.com.evil.io/")
+	if err != nil || len(got) != 1 || got[0].ID != "re" || verdict.Risk != RiskHigh {
+		t.Errorf("Find = %+v, %+v, %v", got, verdict, err)
+	}
+
+	// so does a look-alike host with a login of its own, saved there by mistake
+	recs = append(recs, pwmanager.Record{ID: "fake", Title: "GitHub?", PlainEntry: pwmanager.PlainEntry{URL: "https://xn--gthub-n2e.com/login"}})
+	got, verdict, err = Find(recs, "https://xn--gthub-n2e.com/login")
+	if err != nil || len(got) != 1 || got[0].ID != "fake" || !errors.Is(verdict.Err(), ErrLookalike) || verdict.Target != "github.com" {
+		t.Errorf("Find on a look-alike = %+v, %+v, %v", got, verdict, err)
+	}
+}
+
+func TestUnicodeHost(t *testing.T) {
+	for in, want := range map[string]string{
+		"xn--bcher-kva.de": "bücher.de", "www.XN--MAANA-PTA.com": "www.mañana.com", "xn--gthub-n2e.com": "gіthub.com", "github.com": "github.com",
+	} {
+		if got, err := unicodeHost(in); err != nil || got != want {
+			t.Errorf("unicodeHost(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := unicodeHost("xn--ab_c.com"); err == nil {
+		t.Error("undecodable punycode accepted")
+	}
+}